@@ -0,0 +1,97 @@
+package csvdb
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"io"
+	"time"
+)
+
+// ErrAsOfUnavailable is returned when the state of a key at a time cannot be reconstructed
+// from its recorded history
+var ErrAsOfUnavailable = errors.New("state of key is unavailable at the requested time")
+
+// GetAsOf will write a key as it existed at the provided time, on a best effort basis. The
+// key is reconstructed from its recorded history as the rows appended up to that time, so
+// Options.HistorySize must be set, and PersistHistory is needed for history to span restarts.
+// ErrAsOfUnavailable is returned when the time precedes the creation of the file of the key,
+// the history of the file has been trimmed, or its rows have since been rewritten by calls
+// such as Upsert, UpdateRows, DeleteRows, Truncate or Restore.
+func (d *DB[T]) GetAsOf(w io.Writer, key string, t time.Time) (err error) {
+	return d.GetAsOfContext(d.context(), w, key, t)
+}
+
+// GetAsOfContext is the context-aware variant of GetAsOf
+func (d *DB[T]) GetAsOfContext(ctx context.Context, w io.Writer, key string, t time.Time) (err error) {
+	w, flush := d.encodeOutput(w)
+	defer flush(&err)
+
+	return d.readKey(ctx, key, func(r *csv.Reader) (err error) {
+		// History is read while the key is locked, so it matches the file
+		var rows int
+		if rows, err = rowsAsOf(d.History(key), t); err != nil {
+			return
+		}
+
+		var header []string
+		if header, err = r.Read(); err == io.EOF {
+			return nil
+		} else if err != nil {
+			return
+		}
+
+		cw, bw := getCSVWriter(w, d.dialect)
+		defer putBufWriter(bw)
+		if err = cw.Write(header); err != nil {
+			return
+		}
+
+		for i := 0; i != rows; i++ {
+			var values []string
+			if values, err = r.Read(); err == io.EOF {
+				break
+			} else if err != nil {
+				return
+			}
+
+			if err = cw.Write(values); err != nil {
+				return
+			}
+		}
+
+		cw.Flush()
+		return cw.Error()
+	})
+}
+
+// rowsAsOf will return the number of rows a file held at a time given the history of its
+// key, -1 when the file is unchanged since
+func rowsAsOf(events []Event, t time.Time) (rows int, err error) {
+	if len(events) > 0 && !t.Before(events[len(events)-1].Time) {
+		// Unchanged since the time
+		return -1, nil
+	}
+
+	created := -1
+	for i, e := range events {
+		if e.Type == EventCreated {
+			created = i
+		}
+	}
+
+	if created == -1 || t.Before(events[created].Time) {
+		return 0, ErrAsOfUnavailable
+	}
+
+	for _, e := range events[created+1:] {
+		switch {
+		case e.Type == EventRewritten:
+			return 0, ErrAsOfUnavailable
+		case e.Type == EventAppended && !e.Time.After(t):
+			rows += e.Rows
+		}
+	}
+
+	return
+}
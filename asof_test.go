@@ -0,0 +1,97 @@
+package csvdb
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestDB_GetAsOf(t *testing.T) {
+	var opts Options
+	opts.Dir = fmt.Sprintf("test_%d", time.Now().UnixNano())
+	opts.Name = "foo"
+	opts.HistorySize = 100
+	d, err := makeDB[testentry](opts, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(d.o.Dir)
+
+	beforeCreated := time.Now()
+	if err = d.Append("a", testentry{Foo: "1", Bar: "1b"}); err != nil {
+		t.Fatal(err)
+	}
+
+	afterFirst := time.Now()
+	if err = d.Append("a", testentry{Foo: "2", Bar: "2b"}, testentry{Foo: "3", Bar: "3b"}); err != nil {
+		t.Fatal(err)
+	}
+
+	afterSecond := time.Now()
+	if err = d.Append("a", testentry{Foo: "4", Bar: "4b"}); err != nil {
+		t.Fatal(err)
+	}
+
+	type testcase struct {
+		name    string
+		t       time.Time
+		want    string
+		wantErr error
+	}
+
+	tests := []testcase{
+		{
+			name:    "before created",
+			t:       beforeCreated,
+			wantErr: ErrAsOfUnavailable,
+		},
+		{
+			name: "after first append",
+			t:    afterFirst,
+			want: "foo,bar\n1,1b\n",
+		},
+		{
+			name: "after second append",
+			t:    afterSecond,
+			want: "foo,bar\n1,1b\n2,2b\n3,3b\n",
+		},
+		{
+			name: "current",
+			t:    time.Now(),
+			want: "foo,bar\n1,1b\n2,2b\n3,3b\n4,4b\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := &bytes.Buffer{}
+			if err := d.GetAsOf(w, "a", tt.t); err != tt.wantErr {
+				t.Fatalf("DB.GetAsOf() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if w.String() != tt.want {
+				t.Errorf("DB.GetAsOf() = %q, want %q", w.String(), tt.want)
+			}
+		})
+	}
+
+	// Rewrites prevent earlier states from being reconstructed
+	if err = d.Upsert("a", "foo", testentry{Foo: "1", Bar: "1c"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err = d.GetAsOf(&bytes.Buffer{}, "a", afterFirst); err != ErrAsOfUnavailable {
+		t.Fatalf("DB.GetAsOf() error = %v, wantErr %v", err, ErrAsOfUnavailable)
+	}
+
+	w := &bytes.Buffer{}
+	if err = d.GetAsOf(w, "a", time.Now()); err != nil {
+		t.Fatal(err)
+	}
+
+	if want := "foo,bar\n1,1c\n2,2b\n3,3b\n4,4b\n"; w.String() != want {
+		t.Errorf("DB.GetAsOf() = %q, want %q", w.String(), want)
+	}
+}
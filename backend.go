@@ -3,9 +3,43 @@ package csvdb
 import (
 	"context"
 	"io"
+	"time"
 )
 
 type Backend interface {
 	Import(ctx context.Context, prefix, filename string, w io.Writer) (err error)
 	Export(ctx context.Context, prefix, filename string, r io.Reader) (newFilename string, err error)
 }
+
+// Deleter is an optional interface implemented by a Backend which can delete exported files
+type Deleter interface {
+	Delete(ctx context.Context, prefix, filename string) (err error)
+}
+
+// Renamer is an optional interface implemented by a Backend which can rename exported files
+type Renamer interface {
+	Rename(ctx context.Context, prefix, filename, newFilename string) (err error)
+}
+
+// Lister is an optional interface implemented by a Backend which can list exported files
+type Lister interface {
+	List(ctx context.Context, prefix string) (filenames []string, err error)
+}
+
+// Header is an optional interface implemented by a Backend which can check whether an exported file exists
+type Header interface {
+	Head(ctx context.Context, prefix, filename string) (exists bool, err error)
+}
+
+// Stater is an optional interface implemented by a Backend which can describe exported files,
+// allowing downloads of files which do not exist to be skipped
+type Stater interface {
+	Stat(ctx context.Context, prefix, filename string) (info RemoteInfo, err error)
+}
+
+// RemoteInfo describes an exported file
+type RemoteInfo struct {
+	Exists  bool
+	Size    int64
+	ModTime time.Time
+}
@@ -0,0 +1,150 @@
+package csvdb
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// Barrier will block until every write made to a key before the call is durably on disk,
+// flushing the entries enqueued by WriteBehind and the buffered entries of any open
+// EntryWriter of the key, then syncing its file.
+// When export is set, the key is also exported to the backend unless it has not changed
+// since it was last exported. Reads of the key made after Barrier returns, including reads
+// by other processes sharing the directory, will observe those writes.
+func (d *DB[T]) Barrier(key string, export bool) (err error) {
+	return d.BarrierContext(context.Background(), key, export)
+}
+
+// BarrierContext is the context-aware variant of Barrier
+func (d *DB[T]) BarrierContext(ctx context.Context, key string, export bool) (err error) {
+	if export {
+		if err = d.checkWritable(); err != nil {
+			return
+		}
+
+		if d.eb == nil {
+			return ErrBackendNotSet
+		}
+	}
+
+	if err = d.flushKey(ctx, key); err != nil {
+		return
+	}
+
+	if err = d.flushWriters(key); err != nil {
+		return
+	}
+
+	if err = d.syncKey(ctx, key); err != nil {
+		return
+	}
+
+	if !export {
+		return
+	}
+
+	return d.exportKey(ctx, key)
+}
+
+// flushWriters will flush the buffered entries of the open EntryWriters of a key
+func (d *DB[T]) flushWriters(key string) (err error) {
+	d.wmux.Lock()
+	writers := make([]*EntryWriter[T], 0, len(d.writers[key]))
+	for ew := range d.writers[key] {
+		writers = append(writers, ew)
+	}
+	d.wmux.Unlock()
+
+	for _, ew := range writers {
+		// Writers closed in the meantime have flushed on Close
+		if err = ew.Flush(); err != nil && err != ErrWriterClosed {
+			return fmt.Errorf("error flushing writer of <%s>: %w", key, err)
+		}
+	}
+
+	return nil
+}
+
+// syncKey will sync the file of a key, along with its directory
+func (d *DB[T]) syncKey(ctx context.Context, key string) (err error) {
+	var unlock func()
+	if unlock, err = d.rlockKey(ctx, key); err != nil {
+		return
+	}
+	defer unlock()
+
+	_, filename := d.getFilename(key)
+	var f file
+	switch f, err = d.fs.Open(filename); {
+	case os.IsNotExist(err):
+		// Nothing has been written locally
+		return nil
+	case err != nil:
+		return
+	}
+
+	err = f.Sync()
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+
+	if err != nil {
+		return
+	}
+
+	return d.fs.SyncDir(filepath.Dir(filename))
+}
+
+// exportKey will export the file of a key, when it has changed since it was last exported
+func (d *DB[T]) exportKey(ctx context.Context, key string) (err error) {
+	if err = lockContext(ctx, &d.emux); err != nil {
+		return
+	}
+	defer d.emux.Unlock()
+
+	name, filename := d.getFilename(key)
+	var info os.FileInfo
+	switch info, err = d.fs.Stat(filename); {
+	case os.IsNotExist(err):
+		return nil
+	case err != nil:
+		return
+	}
+
+	if d.isExported(name, info) {
+		// Exported since the last write
+		return
+	}
+
+	if err = d.export(ctx, name); err != nil {
+		return
+	}
+
+	return d.publishCatalog(ctx)
+}
+
+// addWriter will track an open EntryWriter, so its buffered entries are flushed by Barrier
+func (d *DB[T]) addWriter(ew *EntryWriter[T]) {
+	d.wmux.Lock()
+	defer d.wmux.Unlock()
+	if d.writers == nil {
+		d.writers = make(map[string]map[*EntryWriter[T]]struct{})
+	}
+
+	if d.writers[ew.key] == nil {
+		d.writers[ew.key] = make(map[*EntryWriter[T]]struct{})
+	}
+
+	d.writers[ew.key][ew] = struct{}{}
+}
+
+func (d *DB[T]) removeWriter(ew *EntryWriter[T]) {
+	d.wmux.Lock()
+	defer d.wmux.Unlock()
+	delete(d.writers[ew.key], ew)
+	if len(d.writers[ew.key]) == 0 {
+		delete(d.writers, ew.key)
+	}
+}
@@ -0,0 +1,101 @@
+package csvdb
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestDB_Barrier(t *testing.T) {
+	type testcase struct {
+		name      string
+		noBackend bool
+		export    bool
+
+		wantErr     error
+		wantW       string
+		wantUploads int
+	}
+
+	tests := []testcase{
+		{
+			name:  "flushes writers",
+			wantW: "foo,bar\n1,1b\n2,2b\n",
+		},
+		{
+			name:        "export",
+			export:      true,
+			wantW:       "foo,bar\n1,1b\n2,2b\n",
+			wantUploads: 1,
+		},
+		{
+			name:      "export without backend",
+			noBackend: true,
+			export:    true,
+			wantErr:   ErrBackendNotSet,
+			wantW:     "foo,bar\n1,1b\n2,2b\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := newMockContentBackend()
+			var b Backend = m
+			if tt.noBackend {
+				b = nil
+			}
+
+			var opts Options
+			opts.Dir = fmt.Sprintf("test_%d", time.Now().UnixNano())
+			opts.Name = "foo"
+			d, err := makeDB[testentry](opts, b)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer os.RemoveAll(d.o.Dir)
+
+			if err = d.Append("a", testentry{Foo: "1", Bar: "1b"}); err != nil {
+				t.Fatal(err)
+			}
+
+			ew, err := d.Writer("a")
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer ew.Close()
+
+			if err = ew.Write(testentry{Foo: "2", Bar: "2b"}); err != nil {
+				t.Fatal(err)
+			}
+
+			if err = d.Barrier("a", false); err != nil {
+				t.Fatal(err)
+			}
+
+			// Ensure the export marker is newer than the file, regardless of the timestamp granularity
+			time.Sleep(10 * time.Millisecond)
+
+			// Barriers are repeated to ensure unchanged keys are not exported again
+			for i := 0; i < 2; i++ {
+				if err = d.Barrier("a", tt.export); err != tt.wantErr {
+					t.Fatalf("DB.Barrier() error = %v, wantErr %v", err, tt.wantErr)
+				}
+			}
+
+			w := &bytes.Buffer{}
+			if err = d.Get(w, "a"); err != nil {
+				t.Fatal(err)
+			}
+
+			if w.String() != tt.wantW {
+				t.Errorf("DB.Get() = %v, want %v", w.String(), tt.wantW)
+			}
+
+			if got := m.uploads["foo.a.csv"]; got != tt.wantUploads {
+				t.Errorf("uploads = %d, want %d", got, tt.wantUploads)
+			}
+		})
+	}
+}
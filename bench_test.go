@@ -0,0 +1,170 @@
+package csvdb
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func newBenchmarkDB(b *testing.B, inMemory bool) (d DB[testentry]) {
+	var opts Options
+	opts.Dir = fmt.Sprintf("test_%d", time.Now().UnixNano())
+	opts.Name = "foo"
+	opts.InMemory = inMemory
+	d, err := makeDB[testentry](opts, nil)
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	b.Cleanup(func() { os.RemoveAll(opts.Dir) })
+	return
+}
+
+func BenchmarkDB_Append(b *testing.B) {
+	for _, inMemory := range []bool{false, true} {
+		b.Run(fmt.Sprintf("inMemory=%v", inMemory), func(b *testing.B) {
+			d := newBenchmarkDB(b, inMemory)
+			e := testentry{Foo: "1", Bar: "1b"}
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if err := d.Append("key_"+strconv.Itoa(i%100), e); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkDB_AppendMany(b *testing.B) {
+	d := newBenchmarkDB(b, false)
+	m := make(map[string][]testentry, 10)
+	for i := 0; i < 10; i++ {
+		m["key_"+strconv.Itoa(i)] = []testentry{{Foo: "1", Bar: "1b"}, {Foo: "2", Bar: "2b"}}
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if err := d.AppendMany(m); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkDB_Get(b *testing.B) {
+	for _, rows := range []int{10, 1000} {
+		b.Run(fmt.Sprintf("rows=%d", rows), func(b *testing.B) {
+			d := newBenchmarkDB(b, false)
+			es := make([]testentry, rows)
+			for i := range es {
+				es[i] = testentry{Foo: strconv.Itoa(i), Bar: "bar"}
+			}
+
+			if err := d.Append("foo", es...); err != nil {
+				b.Fatal(err)
+			}
+
+			var buf bytes.Buffer
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				buf.Reset()
+				if err := d.Get(&buf, "foo"); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkDB_GetParallel(b *testing.B) {
+	d := newBenchmarkDB(b, false)
+	if err := d.Append("foo", testentry{Foo: "1", Bar: "1b"}); err != nil {
+		b.Fatal(err)
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		var buf bytes.Buffer
+		for pb.Next() {
+			buf.Reset()
+			if err := d.Get(&buf, "foo"); err != nil {
+				b.Error(err)
+				return
+			}
+		}
+	})
+}
+
+func BenchmarkRows_ForEach(b *testing.B) {
+	for _, rows := range []int{10, 1000} {
+		b.Run(fmt.Sprintf("rows=%d", rows), func(b *testing.B) {
+			d := newBenchmarkDB(b, false)
+			es := make([]testentry, rows)
+			for i := range es {
+				es[i] = testentry{Foo: strconv.Itoa(i), Bar: "bar"}
+			}
+
+			if err := d.Append("foo", es...); err != nil {
+				b.Fatal(err)
+			}
+
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				var count int
+				if err := d.AppendWithFunc("foo", func(r *Rows) (es []testentry, err error) {
+					err = r.ForEach(func(values []string) (err error) {
+						count++
+						return
+					})
+					return
+				}); err != nil {
+					b.Fatal(err)
+				}
+
+				if count != rows {
+					b.Fatalf("Rows.ForEach() count = %d, want %d", count, rows)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkDB_getExportable(b *testing.B) {
+	for _, manifestCache := range []bool{false, true} {
+		for _, keys := range []int{100, 10000} {
+			b.Run(fmt.Sprintf("manifestCache=%v/keys=%d", manifestCache, keys), func(b *testing.B) {
+				var opts Options
+				opts.Dir = fmt.Sprintf("test_%d", time.Now().UnixNano())
+				opts.Name = "foo"
+				opts.ManifestCache = manifestCache
+				d, err := makeDB[testentry](opts, nil)
+				if err != nil {
+					b.Fatal(err)
+				}
+				b.Cleanup(func() { os.RemoveAll(opts.Dir) })
+
+				e := testentry{Foo: "1", Bar: "1b"}
+				for i := 0; i < keys; i++ {
+					if err = d.Append("key_"+strconv.Itoa(i), e); err != nil {
+						b.Fatal(err)
+					}
+				}
+
+				b.ReportAllocs()
+				b.ResetTimer()
+				for i := 0; i < b.N; i++ {
+					if _, err = d.getExportable(""); err != nil {
+						b.Fatal(err)
+					}
+				}
+			})
+		}
+	}
+}
@@ -0,0 +1,236 @@
+package csvdb
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"os"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Catalog describes the files a DB has exported to its backend, allowing downstream
+// consumers to discover the available data without listing the backend themselves
+type Catalog struct {
+	Name        string        `json:"name"`
+	GeneratedAt time.Time     `json:"generatedAt"`
+	Files       []CatalogFile `json:"files"`
+}
+
+// CatalogFile describes an exported file
+type CatalogFile struct {
+	Key      string    `json:"key"`
+	Filename string    `json:"filename"`
+	Schema   []string  `json:"schema"`
+	Rows     int64     `json:"rows"`
+	Size     int64     `json:"size"`
+	Exported time.Time `json:"exported"`
+
+	// Start and End are the range of the values of Options.CatalogTimeColumn, parsed as RFC 3339
+	Start *time.Time `json:"start,omitempty"`
+	End   *time.Time `json:"end,omitempty"`
+}
+
+// catalog is the in-memory state of the published catalog
+type catalog struct {
+	mux   sync.Mutex
+	files map[string]CatalogFile
+	// dirty is set when the files have changed since the catalog was last published
+	dirty bool
+}
+
+func (c *catalog) set(f CatalogFile) {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+	if c.files == nil {
+		c.files = make(map[string]CatalogFile)
+	}
+
+	c.files[f.Key] = f
+	c.dirty = true
+}
+
+func (c *catalog) remove(key string) {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+	if _, ok := c.files[key]; !ok {
+		return
+	}
+
+	delete(c.files, key)
+	c.dirty = true
+}
+
+// move will move the file of a key to a new key, once the exported file has been renamed
+func (c *catalog) move(key, newKey, filename string) {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+	f, ok := c.files[key]
+	if !ok {
+		return
+	}
+
+	delete(c.files, key)
+	f.Key = newKey
+	f.Filename = filename
+	c.files[newKey] = f
+	c.dirty = true
+}
+
+func (c *catalog) has(key string) (ok bool) {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+	_, ok = c.files[key]
+	return
+}
+
+// Catalog will return the catalog of the files the DB has exported
+func (d *DB[T]) Catalog() (c Catalog) {
+	d.catalog.mux.Lock()
+	defer d.catalog.mux.Unlock()
+	c.Name = d.o.Name
+	c.GeneratedAt = time.Now()
+	c.Files = make([]CatalogFile, 0, len(d.catalog.files))
+	for _, f := range d.catalog.files {
+		c.Files = append(c.Files, f)
+	}
+
+	sort.Slice(c.Files, func(i, j int) bool {
+		return c.Files[i].Key < c.Files[j].Key
+	})
+
+	return
+}
+
+// catalogName will return the filename the catalog is published as
+func (d *DB[T]) catalogName() string {
+	return d.o.Name + ".catalog.json"
+}
+
+// publishCatalog will upload the catalog to the backend when PublishCatalog is set and
+// the catalog has changed since it was last published. Must be called while the export lock is held.
+func (d *DB[T]) publishCatalog(ctx context.Context) (err error) {
+	if !d.o.PublishCatalog {
+		return
+	}
+
+	if err = d.seedCatalog(); err != nil {
+		return
+	}
+
+	d.catalog.mux.Lock()
+	dirty := d.catalog.dirty
+	d.catalog.mux.Unlock()
+	if !dirty {
+		return
+	}
+
+	var bs []byte
+	if bs, err = json.Marshal(d.Catalog()); err != nil {
+		return
+	}
+
+	if _, err = d.eb.Export(d.request(ctx, OpExport), d.o.Name, d.catalogName(), bytes.NewReader(bs)); err != nil {
+		return
+	}
+
+	d.catalog.mux.Lock()
+	d.catalog.dirty = false
+	d.catalog.mux.Unlock()
+	return
+}
+
+// seedCatalog will add the local files which have been exported prior to the DB being
+// opened, and are not yet within the catalog
+func (d *DB[T]) seedCatalog() (err error) {
+	d.mux.RLock()
+	defer d.mux.RUnlock()
+
+	return d.forEach(func(filename string, info os.FileInfo) (err error) {
+		key := d.getKey(filename)
+		if d.catalog.has(key) {
+			return
+		}
+
+		lastExported := d.getLastExported(filename)
+		if !lastExported.After(info.ModTime()) {
+			// Not exported since it was last modified, will be added once exported
+			return
+		}
+
+		var f file
+		if f, err = d.fs.Open(d.getPath(filename)); os.IsNotExist(err) {
+			return nil
+		} else if err != nil {
+			return
+		}
+		defer f.Close()
+
+		return d.updateCatalog(filename, f, lastExported)
+	})
+}
+
+// updateCatalog will describe an exported file within the catalog, when PublishCatalog is set
+func (d *DB[T]) updateCatalog(filename string, r io.Reader, exported time.Time) (err error) {
+	if !d.o.PublishCatalog {
+		return
+	}
+
+	cf := CatalogFile{Key: d.getKey(filename), Filename: d.exportName(filename), Exported: exported}
+	cr := newCSVReader(&countingReader{r: r, n: &cf.Size}, d.dialect)
+	cr.FieldsPerRecord = -1
+	cr.ReuseRecord = true
+
+	timeColumn := -1
+	for {
+		var values []string
+		switch values, err = cr.Read(); {
+		case err == io.EOF:
+			d.catalog.set(cf)
+			return nil
+		case err != nil:
+			return
+		case cf.Schema == nil:
+			cf.Schema = append([]string{}, values...)
+			if d.o.CatalogTimeColumn != "" {
+				timeColumn = indexOf(cf.Schema, d.o.CatalogTimeColumn)
+			}
+
+			continue
+		}
+
+		cf.Rows++
+		if timeColumn == -1 || timeColumn >= len(values) {
+			continue
+		}
+
+		t, perr := time.Parse(time.RFC3339Nano, values[timeColumn])
+		if perr != nil {
+			continue
+		}
+
+		if cf.Start == nil || t.Before(*cf.Start) {
+			start := t
+			cf.Start = &start
+		}
+
+		if cf.End == nil || t.After(*cf.End) {
+			end := t
+			cf.End = &end
+		}
+	}
+}
+
+// countingReader is an io.Reader which counts the bytes read
+type countingReader struct {
+	r io.Reader
+	n *int64
+}
+
+func (c *countingReader) Read(bs []byte) (n int, err error) {
+	n, err = c.r.Read(bs)
+	*c.n += int64(n)
+	return
+}
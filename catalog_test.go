@@ -0,0 +1,163 @@
+package csvdb
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestDB_publishCatalog(t *testing.T) {
+	type testcase struct {
+		name       string
+		publish    bool
+		timeColumn string
+
+		wantPublished bool
+		wantStart     string
+		wantEnd       string
+	}
+
+	tests := []testcase{
+		{
+			name: "disabled",
+		},
+		{
+			name:          "published",
+			publish:       true,
+			wantPublished: true,
+		},
+		{
+			name:          "with time column",
+			publish:       true,
+			timeColumn:    "foo",
+			wantPublished: true,
+			wantStart:     "2024-01-01T00:00:00Z",
+			wantEnd:       "2024-01-03T00:00:00Z",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			remote := map[string][]byte{}
+			uploads := map[string]int{}
+			b := &mockDeleterBackend{}
+			b.exportFn = func(ctx context.Context, prefix, filename string, r io.Reader) (newFilename string, err error) {
+				if remote[filename], err = io.ReadAll(r); err != nil {
+					return
+				}
+
+				uploads[filename]++
+				return filename, nil
+			}
+
+			var opts Options
+			opts.Dir = fmt.Sprintf("test_%d", time.Now().UnixNano())
+			opts.Name = "foo"
+			opts.PublishCatalog = tt.publish
+			opts.CatalogTimeColumn = tt.timeColumn
+			opts.DeleteFromBackend = true
+			defer os.RemoveAll(opts.Dir)
+
+			d, err := makeDB[testentry](opts, b)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if err = d.Append("a",
+				testentry{Foo: "2024-01-03T00:00:00Z", Bar: "1"},
+				testentry{Foo: "2024-01-01T00:00:00Z", Bar: "2"},
+				testentry{Foo: "invalid", Bar: "3"},
+			); err != nil {
+				t.Fatal(err)
+			}
+
+			if err = d.Append("b/1", testentry{Foo: "1", Bar: "1b"}); err != nil {
+				t.Fatal(err)
+			}
+
+			// Ensure the export markers are newer than the files
+			time.Sleep(10 * time.Millisecond)
+
+			if err = d.backup(context.Background(), "", false); err != nil {
+				t.Fatal(err)
+			}
+
+			bs, published := remote["foo.catalog.json"]
+			if published != tt.wantPublished {
+				t.Fatalf("DB.backup() published = %v, want %v", published, tt.wantPublished)
+			} else if !published {
+				return
+			}
+
+			var c Catalog
+			if err = json.Unmarshal(bs, &c); err != nil {
+				t.Fatal(err)
+			}
+
+			if len(c.Files) != 2 || c.Files[0].Key != "a" || c.Files[1].Key != "b/1" {
+				t.Fatalf("catalog files = %+v, want keys a and b/1", c.Files)
+			}
+
+			a := c.Files[0]
+			if a.Filename != "foo.a.csv" || a.Rows != 3 || !reflect.DeepEqual(a.Schema, []string{"foo", "bar"}) {
+				t.Errorf("catalog file = %+v", a)
+			}
+
+			if size := int64(len(remote["foo.a.csv"])); a.Size != size {
+				t.Errorf("catalog file size = %d, want %d", a.Size, size)
+			}
+
+			var start, end string
+			if a.Start != nil {
+				start, end = a.Start.Format(time.RFC3339), a.End.Format(time.RFC3339)
+			}
+
+			if start != tt.wantStart || end != tt.wantEnd {
+				t.Errorf("catalog time range = %v - %v, want %v - %v", start, end, tt.wantStart, tt.wantEnd)
+			}
+
+			// An unchanged catalog is not published again
+			if err = d.backup(context.Background(), "", false); err != nil {
+				t.Fatal(err)
+			}
+
+			if uploads["foo.catalog.json"] != 1 {
+				t.Fatalf("catalog uploads = %d, want 1", uploads["foo.catalog.json"])
+			}
+
+			if err = d.Delete("b/1"); err != nil {
+				t.Fatal(err)
+			}
+
+			if err = d.backup(context.Background(), "", false); err != nil {
+				t.Fatal(err)
+			}
+
+			if err = json.Unmarshal(remote["foo.catalog.json"], &c); err != nil {
+				t.Fatal(err)
+			}
+
+			if len(c.Files) != 1 {
+				t.Fatalf("catalog files after delete = %+v, want 1 file", c.Files)
+			}
+
+			// Files exported prior to the DB being opened are seeded into the catalog
+			if d, err = makeDB[testentry](opts, b); err != nil {
+				t.Fatal(err)
+			}
+
+			if err = d.publishCatalog(context.Background()); err != nil {
+				t.Fatal(err)
+			}
+
+			if c = d.Catalog(); len(c.Files) != 1 || c.Files[0].Rows != 3 {
+				t.Fatalf("seeded catalog files = %+v, want a with 3 rows", c.Files)
+			}
+		})
+	}
+}
@@ -0,0 +1,96 @@
+package csvdb
+
+import (
+	"errors"
+	"io"
+
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/unicode"
+	"golang.org/x/text/transform"
+)
+
+const (
+	// BOMKeep will leave byte order marks as they are
+	BOMKeep BOM = iota
+	// BOMStrip will strip the byte order mark of the CSV ingested by AppendRaw and ImportFile.
+	// Input marked as UTF-8 or UTF-16 by its byte order mark is decoded as such, regardless of
+	// Options.Encoding.
+	BOMStrip
+	// BOMEmit will strip byte order marks as BOMStrip does, and begin the CSV written by Get (and
+	// the other methods writing CSV) with a UTF-8 byte order mark, as expected by Excel
+	BOMEmit
+)
+
+// ErrInvalidBOM is returned when Options.BOM is unknown, or emits a UTF-8 byte order mark alongside Options.Encoding
+var ErrInvalidBOM = errors.New("invalid bom, unknown value or cannot be emitted alongside an encoding")
+
+// utf8BOM is the UTF-8 byte order mark
+var utf8BOM = []byte{0xEF, 0xBB, 0xBF}
+
+// BOM determines how byte order marks are handled
+type BOM uint8
+
+// decodeInput will return a reader of ingested CSV as UTF-8, stripping its byte order mark
+// and decoding it from Options.Encoding
+func (d *DB[T]) decodeInput(r io.Reader) io.Reader {
+	var t transform.Transformer
+	if d.o.Encoding != nil {
+		t = d.o.Encoding.NewDecoder()
+	}
+
+	if d.o.BOM != BOMKeep {
+		if t == nil {
+			t = encoding.Nop.NewDecoder()
+		}
+
+		t = unicode.BOMOverride(t)
+	}
+
+	if t == nil {
+		return r
+	}
+
+	return transform.NewReader(r, t)
+}
+
+// encodeOutput will return a writer to w encoding the written CSV to Options.Encoding, and
+// preceding it with a byte order mark when Options.BOM is BOMEmit. The returned func must be
+// called once the CSV has been written, and sets the provided error if flushing fails.
+func (d *DB[T]) encodeOutput(w io.Writer) (out io.Writer, flush func(err *error)) {
+	flush = func(*error) {}
+	if d.o.BOM == BOMEmit {
+		w = &bomWriter{w: w}
+	}
+
+	if d.o.Encoding == nil {
+		return w, flush
+	}
+
+	tw := transform.NewWriter(w, d.o.Encoding.NewEncoder())
+	flush = func(err *error) {
+		if cerr := tw.Close(); *err == nil {
+			*err = cerr
+		}
+	}
+
+	return tw, flush
+}
+
+// bomWriter is a writer which writes a UTF-8 byte order mark before the first bytes written,
+// so nothing is written when reading fails before any CSV is written
+type bomWriter struct {
+	w       io.Writer
+	written bool
+}
+
+func (b *bomWriter) Write(p []byte) (n int, err error) {
+	if !b.written && len(p) > 0 {
+		if _, err = b.w.Write(utf8BOM); err != nil {
+			return
+		}
+
+		b.written = true
+	}
+
+	return b.w.Write(p)
+}
@@ -0,0 +1,171 @@
+package csvdb
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/charmap"
+	"golang.org/x/text/encoding/unicode"
+)
+
+func TestDB_Charset(t *testing.T) {
+	utf16, err := unicode.UTF16(unicode.LittleEndian, unicode.UseBOM).NewEncoder().String("foo,bar\n1,café\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	type testcase struct {
+		name     string
+		encoding encoding.Encoding
+		bom      BOM
+		// input is appended by AppendRaw
+		input string
+
+		wantStored string
+		wantGet    string
+	}
+
+	tests := []testcase{
+		{
+			name:       "windows-1252",
+			encoding:   charmap.Windows1252,
+			input:      "foo,bar\n1,caf\xe9\n",
+			wantStored: "foo,bar\n1,café\n",
+			wantGet:    "foo,bar\n1,caf\xe9\n",
+		},
+		{
+			name:       "keep bom",
+			input:      "\xef\xbb\xbffoo,bar\n1,café\n",
+			wantStored: "foo,bar\n\ufefffoo,bar\n1,café\n",
+			wantGet:    "foo,bar\n\ufefffoo,bar\n1,café\n",
+		},
+		{
+			name:       "strip bom",
+			bom:        BOMStrip,
+			input:      "\xef\xbb\xbffoo,bar\n1,café\n",
+			wantStored: "foo,bar\n1,café\n",
+			wantGet:    "foo,bar\n1,café\n",
+		},
+		{
+			name:       "strip utf-16 bom",
+			bom:        BOMStrip,
+			encoding:   charmap.Windows1252,
+			input:      utf16,
+			wantStored: "foo,bar\n1,café\n",
+			wantGet:    "foo,bar\n1,caf\xe9\n",
+		},
+		{
+			name:       "emit bom",
+			bom:        BOMEmit,
+			input:      "\xef\xbb\xbffoo,bar\n1,café\n",
+			wantStored: "foo,bar\n1,café\n",
+			wantGet:    "\xef\xbb\xbffoo,bar\n1,café\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var opts Options
+			opts.Dir = fmt.Sprintf("test_%d", time.Now().UnixNano())
+			opts.Name = "foo"
+			opts.Encoding = tt.encoding
+			opts.BOM = tt.bom
+			d, err := makeDB[testentry](opts, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer os.RemoveAll(opts.Dir)
+
+			// The file of the key is created by an entry, so ingested headers are compared to it
+			if err = os.WriteFile(d.getPath("foo.a.csv"), []byte("foo,bar\n"), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			if err = d.AppendRaw("a", bytes.NewReader([]byte(tt.input))); err != nil {
+				t.Fatal(err)
+			}
+
+			stored, err := os.ReadFile(d.getPath("foo.a.csv"))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if string(stored) != tt.wantStored {
+				t.Errorf("stored = %q, want %q", stored, tt.wantStored)
+			}
+
+			w := &bytes.Buffer{}
+			if err = d.Get(w, "a"); err != nil {
+				t.Fatal(err)
+			}
+
+			if w.String() != tt.wantGet {
+				t.Errorf("DB.Get() = %q, want %q", w.String(), tt.wantGet)
+			}
+
+			// Nothing is written when reading fails
+			w.Reset()
+			if err = d.Get(w, "missing"); err == nil || w.Len() > 0 {
+				t.Errorf("DB.Get() = %q, error = %v, want nothing written", w.String(), err)
+			}
+		})
+	}
+}
+
+func TestDB_Charset_unencodable(t *testing.T) {
+	var opts Options
+	opts.Dir = fmt.Sprintf("test_%d", time.Now().UnixNano())
+	opts.Name = "foo"
+	opts.Encoding = charmap.Windows1252
+	d, err := makeDB[testentry](opts, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(opts.Dir)
+
+	if err = d.Append("a", testentry{Foo: "1", Bar: "日本"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err = d.Get(&bytes.Buffer{}, "a"); err == nil {
+		t.Error("DB.Get() error = nil, want an error encoding the row")
+	}
+}
+
+func TestOptions_Validate_bom(t *testing.T) {
+	type testcase struct {
+		name    string
+		opts    Options
+		wantErr error
+	}
+
+	tests := []testcase{
+		{
+			name: "strip with encoding",
+			opts: Options{Dir: "test", Name: "foo", BOM: BOMStrip, Encoding: charmap.Windows1252},
+		},
+		{
+			name:    "emit with encoding",
+			opts:    Options{Dir: "test", Name: "foo", BOM: BOMEmit, Encoding: charmap.Windows1252},
+			wantErr: ErrInvalidBOM,
+		},
+		{
+			name:    "unknown",
+			opts:    Options{Dir: "test", Name: "foo", BOM: BOMEmit + 1},
+			wantErr: ErrInvalidBOM,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.opts.Validate(); !errors.Is(err, tt.wantErr) {
+				t.Errorf("Options.Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
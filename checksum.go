@@ -0,0 +1,322 @@
+package csvdb
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// checksumExt is the extension of the checksum sidecar written alongside the file of a key,
+// and of the checksum exported alongside the exported file of a key
+const checksumExt = ".sum"
+
+const (
+	// ChecksumsOff will not checksum files
+	ChecksumsOff Checksums = iota
+	// ChecksumsDownload will keep a SHA-256 checksum of each file, which is updated as the
+	// file is appended to, and export a checksum of each exported file alongside it as
+	// <exported>.sum. Downloads are verified against the exported checksum.
+	ChecksumsDownload
+	// ChecksumsOpen will verify files against their checksum every time they are opened for
+	// reading, in addition to ChecksumsDownload
+	ChecksumsOpen
+)
+
+var (
+	// ErrInvalidChecksums is returned when Options.Checksums is unknown
+	ErrInvalidChecksums = errors.New("invalid checksums, unknown value")
+	// ErrChecksumMismatch is returned when the contents of a file do not match its checksum,
+	// local files may be recovered from the backend using Restore
+	ErrChecksumMismatch = errors.New("checksum mismatch")
+)
+
+// Checksums determines how files are checksummed and when checksums are verified
+type Checksums uint8
+
+// checksumState is the checksum sidecar of a file
+type checksumState struct {
+	// Size is the number of bytes checksummed
+	Size   int64  `json:"size"`
+	SHA256 string `json:"sha256"`
+	// State is the marshaled state of the hash, so appends are checksummed without
+	// reading the file again
+	State []byte `json:"state"`
+}
+
+// updateChecksum will catch the checksum of a file up with an append
+func (d *DB[T]) updateChecksum(filename string) {
+	if d.o.Checksums == ChecksumsOff {
+		return
+	}
+
+	if err := d.checksum(filename, false); err != nil {
+		d.o.Logger.Printf("csvdb.DB[%s].updateChecksum(): error checksumming <%s>: %v\n", d.o.Name, filename, err)
+	}
+}
+
+// verifyChecksum will verify a file against its checksum, returning ErrChecksumMismatch when
+// the checksummed bytes have changed. Files without a checksum are checksummed.
+func (d *DB[T]) verifyChecksum(filename string) (err error) {
+	if err = d.checksum(filename, true); errors.Is(err, ErrChecksumMismatch) {
+		return fmt.Errorf("error verifying <%s>: %w", d.getKey(filename), err)
+	}
+
+	return
+}
+
+// checksum will update the checksum sidecar of a file to cover its contents. When verify is
+// set, the previously checksummed bytes are read again and compared to the sidecar.
+func (d *DB[T]) checksum(filename string, verify bool) (err error) {
+	state, ok := d.loadChecksum(filename)
+	var f file
+	if f, err = d.fs.Open(filename); err != nil {
+		return
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	var size int64
+	switch {
+	case !ok:
+	case verify:
+		if size, err = io.CopyN(h, f, state.Size); err == io.EOF || (err == nil && hex.EncodeToString(h.Sum(nil)) != state.SHA256) {
+			return ErrChecksumMismatch
+		} else if err != nil {
+			return
+		}
+	case h.(encoding.BinaryUnmarshaler).UnmarshalBinary(state.State) == nil && skip(f, state.Size) == nil:
+		size = state.Size
+	default:
+		// The sidecar cannot be resumed, the file is checksummed again
+		h.Reset()
+		if _, err = f.Seek(0, io.SeekStart); err != nil {
+			return
+		}
+	}
+
+	var n int64
+	if n, err = io.Copy(h, f); err != nil {
+		return
+	}
+
+	if ok && n == 0 {
+		return
+	}
+
+	if d.o.ReadOnly {
+		// Sidecars are left to be written by the writer of the directory
+		return
+	}
+
+	state = checksumState{Size: size + n, SHA256: hex.EncodeToString(h.Sum(nil))}
+	if state.State, err = h.(encoding.BinaryMarshaler).MarshalBinary(); err != nil {
+		return
+	}
+
+	return d.saveChecksum(filename, state)
+}
+
+// skip will move past the first n bytes of a file, reading them when the file cannot seek
+func skip(f file, n int64) (err error) {
+	if _, err = f.Seek(n, io.SeekStart); err == nil {
+		return
+	}
+
+	if _, err = f.Seek(0, io.SeekStart); err != nil {
+		return
+	}
+
+	_, err = io.CopyN(io.Discard, f, n)
+	return
+}
+
+func (d *DB[T]) loadChecksum(filename string) (state checksumState, ok bool) {
+	bs, err := readFile(d.fs, filename+checksumExt)
+	if err != nil {
+		return
+	}
+
+	// Sidecars which cannot be read are rebuilt
+	return state, json.Unmarshal(bs, &state) == nil
+}
+
+func (d *DB[T]) saveChecksum(filename string, state checksumState) (err error) {
+	var tmp file
+	if tmp, err = createTemp(d.fs, d.o.TempDir, filename+checksumExt); err != nil {
+		return
+	}
+
+	err = json.NewEncoder(tmp).Encode(state)
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+
+	if err == nil {
+		err = d.fs.Rename(tmp.Name(), filename+checksumExt)
+	}
+
+	if err != nil {
+		d.fs.Remove(tmp.Name())
+	}
+
+	return
+}
+
+// checksumName will return the name the checksum of an exported file is exported as
+func (d *DB[T]) checksumName(filename string) string {
+	return d.dataName(filename) + checksumExt
+}
+
+// hashReader will return a reader of r which writes the bytes read to the returned hash,
+// the hash is nil when Options.Checksums is not set
+func (d *DB[T]) hashReader(r io.ReadCloser) (out io.ReadCloser, h hash.Hash) {
+	if d.o.Checksums == ChecksumsOff {
+		return r, nil
+	}
+
+	h = sha256.New()
+	return readCloser{Reader: io.TeeReader(r, h), Closer: r}, h
+}
+
+// readCloser is a reader closed by a separate closer
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
+
+// hashWriter will return a writer to w which also writes to h, when set
+func hashWriter(w io.Writer, h hash.Hash) io.Writer {
+	if h == nil {
+		return w
+	}
+
+	return io.MultiWriter(w, h)
+}
+
+// exportChecksum will export the checksum of an exported file alongside it
+func (d *DB[T]) exportChecksum(ctx context.Context, filename string, h hash.Hash) (err error) {
+	if h == nil {
+		return
+	}
+
+	sum := strings.NewReader(hex.EncodeToString(h.Sum(nil)))
+	_, err = d.eb.Export(d.request(ctx, OpExport), d.remotePrefixOf(filename), d.checksumName(filename), sum)
+	return
+}
+
+// deleteChecksum will delete the exported checksum of a file from the backend
+func (d *DB[T]) deleteChecksum(ctx context.Context, deleter Deleter, filename string) (err error) {
+	if d.o.Checksums == ChecksumsOff || d.o.ContentAddressed {
+		return
+	}
+
+	if err = deleter.Delete(d.request(withKey(ctx, d.getKey(filename)), OpDelete), d.remotePrefixOf(filename), d.checksumName(filename)); os.IsNotExist(err) {
+		err = nil
+	}
+
+	return
+}
+
+// renameChecksum will rename the exported checksum of a file within the backend
+func (d *DB[T]) renameChecksum(ctx context.Context, renamer Renamer, filename, newFilename string) (err error) {
+	if d.o.Checksums == ChecksumsOff || d.o.ContentAddressed {
+		return
+	}
+
+	if err = renamer.Rename(d.request(withKey(ctx, d.getKey(filename)), OpRename), d.remotePrefixOf(filename), d.checksumName(filename), d.checksumName(newFilename)); os.IsNotExist(err) {
+		err = nil
+	}
+
+	return
+}
+
+// verifyDownload will verify the downloaded bytes of a remote file against its exported
+// checksum. Content addressed files are verified against the hash they are named after, and
+// files exported without a checksum are not verified.
+func (d *DB[T]) verifyDownload(ctx context.Context, name, remote string, h hash.Hash) (err error) {
+	var want string
+	if d.o.ContentAddressed {
+		want, _, _ = strings.Cut(remote, ".")
+	} else {
+		buf := &bytes.Buffer{}
+		if err = d.ib.Import(d.request(ctx, OpImport), d.remotePrefixOf(name), d.checksumName(name), buf); os.IsNotExist(err) {
+			return nil
+		} else if err != nil {
+			return
+		}
+
+		want = strings.TrimSpace(buf.String())
+	}
+
+	if hex.EncodeToString(h.Sum(nil)) != want {
+		return fmt.Errorf("error verifying <%s>: %w", remote, ErrChecksumMismatch)
+	}
+
+	return
+}
+
+// checksumFS is a fileSystem which removes the checksum sidecar of files as they are
+// removed, replaced or truncated, so checksums only ever cover appended files
+type checksumFS struct {
+	fileSystem
+}
+
+func (fsys checksumFS) OpenFile(name string, flag int, perm os.FileMode) (f file, err error) {
+	if flag&os.O_TRUNC != 0 {
+		fsys.removeChecksum(name)
+	}
+
+	if f, err = fsys.fileSystem.OpenFile(name, flag, perm); err != nil || flag&(os.O_WRONLY|os.O_RDWR) == 0 {
+		return
+	}
+
+	return checksumFile{file: f, fsys: fsys}, nil
+}
+
+func (fsys checksumFS) Create(name string) (f file, err error) {
+	fsys.removeChecksum(name)
+	if f, err = fsys.fileSystem.Create(name); err != nil {
+		return
+	}
+
+	return checksumFile{file: f, fsys: fsys}, nil
+}
+
+func (fsys checksumFS) Remove(name string) error {
+	fsys.removeChecksum(name)
+	return fsys.fileSystem.Remove(name)
+}
+
+func (fsys checksumFS) Rename(oldpath, newpath string) error {
+	fsys.removeChecksum(oldpath)
+	fsys.removeChecksum(newpath)
+	return fsys.fileSystem.Rename(oldpath, newpath)
+}
+
+func (fsys checksumFS) removeChecksum(name string) {
+	if filepath.Ext(name) == ".csv" {
+		fsys.fileSystem.Remove(name + checksumExt)
+	}
+}
+
+// checksumFile is the handle of a file opened for writing through a checksumFS
+type checksumFile struct {
+	file
+
+	fsys checksumFS
+}
+
+func (f checksumFile) Truncate(size int64) error {
+	f.fsys.removeChecksum(f.Name())
+	return f.file.Truncate(size)
+}
@@ -0,0 +1,156 @@
+package csvdb
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestDB_Checksums(t *testing.T) {
+	type testcase struct {
+		name string
+		opts func(o *Options)
+	}
+
+	tests := []testcase{
+		{name: "basic", opts: func(o *Options) {}},
+		{name: "atomic append", opts: func(o *Options) { o.AtomicAppend = true }},
+		{name: "write ahead log", opts: func(o *Options) { o.WriteAheadLog = true }},
+		{name: "manifest cache", opts: func(o *Options) { o.ManifestCache = true }},
+		{name: "compress exports", opts: func(o *Options) { o.CompressExports = true }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var (
+				mux      sync.Mutex
+				exported = make(map[string][]byte)
+			)
+
+			b := &mockBackend{
+				exportFn: func(ctx context.Context, prefix, filename string, r io.Reader) (string, error) {
+					bs, err := io.ReadAll(r)
+					mux.Lock()
+					exported[filename] = bs
+					mux.Unlock()
+					return filename, err
+				},
+				importFn: func(ctx context.Context, prefix, filename string, w io.Writer) error {
+					mux.Lock()
+					bs, ok := exported[filename]
+					mux.Unlock()
+					if !ok {
+						return os.ErrNotExist
+					}
+
+					_, err := w.Write(bs)
+					return err
+				},
+			}
+
+			var opts Options
+			opts.Dir = fmt.Sprintf("test_%d", time.Now().UnixNano())
+			opts.Name = "foo"
+			opts.Checksums = ChecksumsOpen
+			tt.opts(&opts)
+			d, err := makeDB[testentry](opts, b)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer os.RemoveAll(opts.Dir)
+
+			if err = d.Append("a", testentry{Foo: "1", Bar: "1b"}); err != nil {
+				t.Fatal(err)
+			}
+
+			if err = d.Append("a", testentry{Foo: "2", Bar: "2b"}); err != nil {
+				t.Fatal(err)
+			}
+
+			want := "foo,bar\n1,1b\n2,2b\n"
+			assertGet := func(wantErr error) {
+				t.Helper()
+				w := &bytes.Buffer{}
+				if err := d.Get(w, "a"); !errors.Is(err, wantErr) {
+					t.Fatalf("DB.Get() error = %v, wantErr %v", err, wantErr)
+				} else if err == nil && w.String() != want {
+					t.Errorf("DB.Get() = %q, want %q", w.String(), want)
+				}
+			}
+
+			assertGet(nil)
+			filename := d.getPath("foo.a.csv")
+			if state, ok := d.loadChecksum(filename); !ok || state.Size != int64(len(want)) {
+				t.Fatalf("checksum sidecar = %+v, want size %d", state, len(want))
+			}
+
+			if err = d.ExportPrefix(""); err != nil {
+				t.Fatal(err)
+			}
+
+			if _, ok := exported[d.checksumName("foo.a.csv")]; !ok {
+				t.Fatalf("exported files = %v, want %s", exported, d.checksumName("foo.a.csv"))
+			}
+
+			// Local files are verified when opened, and may be restored from the backend
+			if err = os.WriteFile(filename, []byte("foo,bar\n1,1b\n2,2c\n"), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			assertGet(ErrChecksumMismatch)
+			if err = d.Restore("a"); err != nil {
+				t.Fatal(err)
+			}
+
+			assertGet(nil)
+
+			// Downloads are verified against the exported checksum
+			mux.Lock()
+			exported[d.dataName("foo.a.csv")] = append(exported[d.dataName("foo.a.csv")], '\n')
+			mux.Unlock()
+			if err = d.Delete("a"); err != nil {
+				t.Fatal(err)
+			}
+
+			assertGet(ErrChecksumMismatch)
+		})
+	}
+}
+
+func TestOptions_Validate_checksums(t *testing.T) {
+	type testcase struct {
+		name    string
+		opts    Options
+		wantErr error
+	}
+
+	tests := []testcase{
+		{
+			name: "download",
+			opts: Options{Dir: "test", Name: "foo", Checksums: ChecksumsDownload},
+		},
+		{
+			name: "open",
+			opts: Options{Dir: "test", Name: "foo", Checksums: ChecksumsOpen},
+		},
+		{
+			name:    "unknown",
+			opts:    Options{Dir: "test", Name: "foo", Checksums: ChecksumsOpen + 1},
+			wantErr: ErrInvalidChecksums,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.opts.Validate(); !errors.Is(err, tt.wantErr) {
+				t.Errorf("Options.Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
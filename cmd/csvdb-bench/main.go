@@ -0,0 +1,64 @@
+// csvdb-bench simulates configurable csvdb workloads and reports the throughput
+// and latency percentiles of reads and writes.
+//
+// Usage:
+//
+//	csvdb-bench -keys=1000 -ops=100000 -workers=8 -read-ratio=0.8 -backend-latency=20ms
+//
+// Running with -duration will repeat the workload until the duration has passed,
+// which is useful for soak testing.
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+	"os"
+	"time"
+)
+
+func main() {
+	var c config
+	flag.IntVar(&c.Keys, "keys", 1000, "number of keys written to and read from")
+	flag.IntVar(&c.Ops, "ops", 10000, "number of operations per run")
+	flag.IntVar(&c.Workers, "workers", 4, "number of concurrent workers")
+	flag.IntVar(&c.RowsPerWrite, "rows-per-write", 10, "number of rows appended per write")
+	flag.IntVar(&c.RowSize, "row-size", 64, "size of the payload column of each row in bytes")
+	flag.Float64Var(&c.ReadRatio, "read-ratio", 0.5, "ratio of operations which are reads, between 0 and 1")
+	flag.DurationVar(&c.BackendLatency, "backend-latency", 0, "simulated latency of backend imports and exports")
+	flag.DurationVar(&c.Duration, "duration", 0, "repeat runs until the duration has passed (soak mode)")
+	flag.BoolVar(&c.InMemory, "in-memory", false, "store data in memory rather than on disk")
+	flag.StringVar(&c.Dir, "dir", "", "directory to store data in, defaults to a temporary directory")
+	flag.Int64Var(&c.Seed, "seed", time.Now().UnixNano(), "seed of the workload")
+	flag.IntVar(&c.FaultBackendFailures, "fault-backend-failures", 0, "number of backend calls which fail at the start of each run")
+	flag.DurationVar(&c.FaultWriteDelay, "fault-write-delay", 0, "delay injected into every disk write")
+	flag.IntVar(&c.FaultPartialReads, "fault-partial-reads", 0, "maximum number of bytes returned by every disk read")
+	flag.Parse()
+
+	if err := c.validate(); err != nil {
+		flag.Usage()
+		log.Fatalf("csvdb-bench: %v", err)
+	}
+
+	if len(c.Dir) == 0 && !c.InMemory {
+		dir, err := os.MkdirTemp("", "csvdb-bench")
+		if err != nil {
+			log.Fatalf("csvdb-bench: error creating directory: %v", err)
+		}
+		defer os.RemoveAll(dir)
+		c.Dir = dir
+	}
+
+	start := time.Now()
+	for run := 1; ; run++ {
+		r, err := runWorkload(context.Background(), c)
+		if err != nil {
+			log.Fatalf("csvdb-bench: %v", err)
+		}
+
+		r.print(os.Stdout, run)
+		if time.Since(start) >= c.Duration {
+			return
+		}
+	}
+}
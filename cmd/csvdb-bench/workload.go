@@ -0,0 +1,285 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log"
+	"math/rand"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/itsmontoya/csvdb"
+)
+
+var (
+	errInvalidKeys      = errors.New("invalid keys, must be greater than 0")
+	errInvalidOps       = errors.New("invalid ops, must be greater than 0")
+	errInvalidWorkers   = errors.New("invalid workers, must be greater than 0")
+	errInvalidReadRatio = errors.New("invalid read-ratio, must be between 0 and 1")
+)
+
+// config is the configuration of a workload
+type config struct {
+	Keys           int
+	Ops            int
+	Workers        int
+	RowsPerWrite   int
+	RowSize        int
+	ReadRatio      float64
+	BackendLatency time.Duration
+	Duration       time.Duration
+	InMemory       bool
+	Dir            string
+	Seed           int64
+
+	FaultBackendFailures int
+	FaultWriteDelay      time.Duration
+	FaultPartialReads    int
+}
+
+func (c *config) validate() (err error) {
+	var errs []error
+	if c.Keys <= 0 {
+		errs = append(errs, errInvalidKeys)
+	}
+
+	if c.Ops <= 0 {
+		errs = append(errs, errInvalidOps)
+	}
+
+	if c.Workers <= 0 {
+		errs = append(errs, errInvalidWorkers)
+	}
+
+	if c.ReadRatio < 0 || c.ReadRatio > 1 {
+		errs = append(errs, errInvalidReadRatio)
+	}
+
+	return errors.Join(errs...)
+}
+
+// faults will return the faults injected into the DB, nil is returned when none are configured
+func (c *config) faults() (f *csvdb.Faults) {
+	if c.FaultBackendFailures == 0 && c.FaultWriteDelay == 0 && c.FaultPartialReads == 0 {
+		return
+	}
+
+	f = &csvdb.Faults{}
+	f.FailBackend(c.FaultBackendFailures, nil)
+	f.DelayWrites(c.FaultWriteDelay)
+	f.PartialReads(c.FaultPartialReads)
+	return
+}
+
+// result is the outcome of a workload run
+type result struct {
+	Elapsed time.Duration
+	Reads   latencies
+	Writes  latencies
+	Errors  int
+}
+
+func (r *result) print(w io.Writer, run int) {
+	total := len(r.Reads) + len(r.Writes)
+	fmt.Fprintf(w, "run %d: %d ops in %v (%.0f ops/s), %d errors\n", run, total, r.Elapsed, float64(total)/r.Elapsed.Seconds(), r.Errors)
+	fmt.Fprintf(w, "  reads:  %s\n", r.Reads)
+	fmt.Fprintf(w, "  writes: %s\n", r.Writes)
+}
+
+// latencies are the durations of a set of operations
+type latencies []time.Duration
+
+// percentile will return the nearest-rank percentile of sorted latencies
+func (l latencies) percentile(p float64) time.Duration {
+	if len(l) == 0 {
+		return 0
+	}
+
+	i := int(float64(len(l))*p+0.5) - 1
+	switch {
+	case i < 0:
+		i = 0
+	case i >= len(l):
+		i = len(l) - 1
+	}
+
+	return l[i]
+}
+
+func (l latencies) String() string {
+	if len(l) == 0 {
+		return "n/a"
+	}
+
+	return fmt.Sprintf("n=%d p50=%v p90=%v p99=%v max=%v", len(l), l.percentile(0.5), l.percentile(0.9), l.percentile(0.99), l[len(l)-1])
+}
+
+// benchEntry is the entry written by workloads
+type benchEntry struct {
+	ID      string
+	Payload string
+}
+
+func (b benchEntry) Keys() []string {
+	return []string{"id", "payload"}
+}
+
+func (b benchEntry) Values() []string {
+	return []string{b.ID, b.Payload}
+}
+
+// runWorkload will run the configured mix of reads and writes against a new DB
+func runWorkload(ctx context.Context, c config) (r result, err error) {
+	var o csvdb.Options
+	o.Name = "bench"
+	o.Dir = c.Dir
+	o.InMemory = c.InMemory
+	// Background jobs are disabled so only the workload is measured
+	o.ExportInterval = time.Hour
+	o.PurgeInterval = time.Hour
+	o.Logger = log.New(io.Discard, "", 0)
+	o.Faults = c.faults()
+
+	var db *csvdb.DB[benchEntry]
+	if db, err = csvdb.New[benchEntry](ctx, o, newLatencyBackend(c.BackendLatency)); err != nil {
+		return
+	}
+
+	defer func() {
+		if derr := db.DropDB(context.Background(), o.Name, false); err == nil {
+			err = derr
+		}
+
+		if cerr := db.Close(); err == nil {
+			err = cerr
+		}
+	}()
+
+	payload := strings.Repeat("x", c.RowSize)
+	var (
+		mux sync.Mutex
+		wg  sync.WaitGroup
+		ops = make(chan int)
+	)
+
+	start := time.Now()
+	for i := 0; i < c.Workers; i++ {
+		wg.Add(1)
+		rng := rand.New(rand.NewSource(c.Seed + int64(i)))
+		go func() {
+			defer wg.Done()
+			var (
+				reads, writes latencies
+				errs          int
+				buf           bytes.Buffer
+			)
+
+			for op := range ops {
+				key := "key_" + strconv.Itoa(rng.Intn(c.Keys))
+				opStart := time.Now()
+				if rng.Float64() < c.ReadRatio {
+					buf.Reset()
+					if err := db.Get(&buf, key); err != nil && err != csvdb.ErrEntryNotFound {
+						errs++
+					}
+
+					reads = append(reads, time.Since(opStart))
+					continue
+				}
+
+				es := make([]benchEntry, c.RowsPerWrite)
+				for j := range es {
+					es[j] = benchEntry{ID: strconv.Itoa(op), Payload: payload}
+				}
+
+				if err := db.Append(key, es...); err != nil {
+					errs++
+				}
+
+				writes = append(writes, time.Since(opStart))
+			}
+
+			mux.Lock()
+			defer mux.Unlock()
+			r.Reads = append(r.Reads, reads...)
+			r.Writes = append(r.Writes, writes...)
+			r.Errors += errs
+		}()
+	}
+
+	for i := 0; i < c.Ops; i++ {
+		ops <- i
+	}
+
+	close(ops)
+	wg.Wait()
+	r.Elapsed = time.Since(start)
+	sort.Slice(r.Reads, func(i, j int) bool { return r.Reads[i] < r.Reads[j] })
+	sort.Slice(r.Writes, func(i, j int) bool { return r.Writes[i] < r.Writes[j] })
+	return
+}
+
+// latencyBackend is an in-memory csvdb.Backend which sleeps before every call
+type latencyBackend struct {
+	mux     sync.Mutex
+	latency time.Duration
+	files   map[string][]byte
+}
+
+func newLatencyBackend(latency time.Duration) *latencyBackend {
+	return &latencyBackend{latency: latency, files: make(map[string][]byte)}
+}
+
+func (l *latencyBackend) Import(ctx context.Context, prefix, filename string, w io.Writer) (err error) {
+	if err = l.wait(ctx); err != nil {
+		return
+	}
+
+	l.mux.Lock()
+	bs, ok := l.files[prefix+"/"+filename]
+	l.mux.Unlock()
+	if !ok {
+		return fs.ErrNotExist
+	}
+
+	_, err = w.Write(bs)
+	return
+}
+
+func (l *latencyBackend) Export(ctx context.Context, prefix, filename string, r io.Reader) (newFilename string, err error) {
+	if err = l.wait(ctx); err != nil {
+		return
+	}
+
+	var bs []byte
+	if bs, err = io.ReadAll(r); err != nil {
+		return
+	}
+
+	l.mux.Lock()
+	defer l.mux.Unlock()
+	l.files[prefix+"/"+filename] = bs
+	return filename, nil
+}
+
+func (l *latencyBackend) wait(ctx context.Context) (err error) {
+	if l.latency == 0 {
+		return
+	}
+
+	t := time.NewTimer(l.latency)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
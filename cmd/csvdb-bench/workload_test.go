@@ -0,0 +1,100 @@
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func Test_latencies_percentile(t *testing.T) {
+	l := latencies{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}
+	tests := []struct {
+		name string
+		l    latencies
+		p    float64
+		want time.Duration
+	}{
+		{
+			name: "empty",
+			p:    0.5,
+			want: 0,
+		},
+		{
+			name: "p0",
+			l:    l,
+			p:    0,
+			want: 1,
+		},
+		{
+			name: "p50",
+			l:    l,
+			p:    0.5,
+			want: 5,
+		},
+		{
+			name: "p90",
+			l:    l,
+			p:    0.9,
+			want: 9,
+		},
+		{
+			name: "p100",
+			l:    l,
+			p:    1,
+			want: 10,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.l.percentile(tt.p); got != tt.want {
+				t.Errorf("latencies.percentile() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_runWorkload(t *testing.T) {
+	tests := []struct {
+		name string
+		c    config
+	}{
+		{
+			name: "in memory",
+			c:    config{Keys: 10, Ops: 200, Workers: 4, RowsPerWrite: 2, RowSize: 16, ReadRatio: 0.5, InMemory: true, Seed: 1},
+		},
+		{
+			name: "disk with backend latency",
+			c:    config{Keys: 5, Ops: 50, Workers: 2, RowsPerWrite: 1, RowSize: 8, ReadRatio: 0.8, BackendLatency: time.Millisecond, Seed: 1},
+		},
+		{
+			name: "disk faults",
+			c:    config{Keys: 5, Ops: 50, Workers: 2, RowsPerWrite: 3, RowSize: 8, ReadRatio: 0.5, FaultWriteDelay: time.Microsecond, FaultPartialReads: 7, Seed: 1},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if !tt.c.InMemory {
+				tt.c.Dir = t.TempDir()
+			}
+
+			if err := tt.c.validate(); err != nil {
+				t.Fatal(err)
+			}
+
+			r, err := runWorkload(context.Background(), tt.c)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if got := len(r.Reads) + len(r.Writes); got != tt.c.Ops {
+				t.Errorf("runWorkload() ops = %d, want %d", got, tt.c.Ops)
+			}
+
+			if r.Errors != 0 {
+				t.Errorf("runWorkload() errors = %d, want 0", r.Errors)
+			}
+		})
+	}
+}
@@ -0,0 +1,207 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+type field struct {
+	name   string
+	column string
+	kind   string
+}
+
+type structType struct {
+	name   string
+	fields []field
+}
+
+func generate(dir string, typeNames []string) (src []byte, err error) {
+	var entries []os.DirEntry
+	if entries, err = os.ReadDir(dir); err != nil {
+		return
+	}
+
+	var (
+		pkgName string
+		found   = map[string]*ast.StructType{}
+	)
+
+	fset := token.NewFileSet()
+	for _, entry := range entries {
+		name := entry.Name()
+		switch {
+		case entry.IsDir():
+			continue
+		case filepath.Ext(name) != ".go":
+			continue
+		case strings.HasSuffix(name, "_test.go"):
+			continue
+		case strings.HasSuffix(name, "_csvdb.go"):
+			continue
+		}
+
+		var file *ast.File
+		if file, err = parser.ParseFile(fset, filepath.Join(dir, name), nil, 0); err != nil {
+			return
+		}
+
+		pkgName = file.Name.Name
+		collectStructs(file, found)
+	}
+
+	if len(pkgName) == 0 {
+		err = fmt.Errorf("no package found within <%s>", dir)
+		return
+	}
+
+	var sts []structType
+	if sts, err = findStructs(found, typeNames); err != nil {
+		return
+	}
+
+	return render(pkgName, sts)
+}
+
+func collectStructs(file *ast.File, found map[string]*ast.StructType) {
+	ast.Inspect(file, func(n ast.Node) bool {
+		ts, ok := n.(*ast.TypeSpec)
+		if !ok {
+			return true
+		}
+
+		if st, ok := ts.Type.(*ast.StructType); ok {
+			found[ts.Name.Name] = st
+		}
+
+		return false
+	})
+}
+
+func findStructs(found map[string]*ast.StructType, typeNames []string) (sts []structType, err error) {
+	for _, typeName := range typeNames {
+		st, ok := found[typeName]
+		if !ok {
+			err = fmt.Errorf("struct type <%s> not found", typeName)
+			return
+		}
+
+		s := structType{name: typeName}
+		if s.fields, err = parseFields(st); err != nil {
+			err = fmt.Errorf("error parsing <%s>: %v", typeName, err)
+			return
+		}
+
+		sts = append(sts, s)
+	}
+
+	return
+}
+
+func parseFields(st *ast.StructType) (fields []field, err error) {
+	for _, f := range st.Fields.List {
+		var tag reflect.StructTag
+		if f.Tag != nil {
+			var unquoted string
+			if unquoted, err = strconv.Unquote(f.Tag.Value); err != nil {
+				return
+			}
+
+			tag = reflect.StructTag(unquoted)
+		}
+
+		column := strings.Split(tag.Get("csv"), ",")[0]
+		if column == "-" {
+			continue
+		}
+
+		kind := types.ExprString(f.Type)
+		if _, ok := kinds[kind]; !ok {
+			err = fmt.Errorf("unsupported field type <%s>", kind)
+			return
+		}
+
+		for _, name := range f.Names {
+			if !name.IsExported() {
+				continue
+			}
+
+			c := column
+			if len(c) == 0 {
+				c = name.Name
+			}
+
+			fields = append(fields, field{name: name.Name, column: c, kind: kind})
+		}
+	}
+
+	return
+}
+
+func render(pkgName string, sts []structType) (src []byte, err error) {
+	var (
+		body    bytes.Buffer
+		imports = map[string]struct{}{}
+	)
+
+	for _, st := range sts {
+		renderStruct(&body, st, imports)
+	}
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "// Code generated by csvdbgen. DO NOT EDIT.\n\npackage %s\n\n", pkgName)
+
+	if len(imports) > 0 {
+		pkgs := make([]string, 0, len(imports))
+		for pkg := range imports {
+			pkgs = append(pkgs, strconv.Quote(pkg))
+		}
+
+		sort.Strings(pkgs)
+		fmt.Fprintf(&buf, "import (\n%s\n)\n\n", strings.Join(pkgs, "\n"))
+	}
+
+	buf.Write(body.Bytes())
+	return format.Source(buf.Bytes())
+}
+
+func renderStruct(w *bytes.Buffer, st structType, imports map[string]struct{}) {
+	keysVar := "csvdbKeys" + st.name
+	columns := make([]string, len(st.fields))
+	values := make([]string, len(st.fields))
+	for i, f := range st.fields {
+		k := kinds[f.kind]
+		columns[i] = strconv.Quote(f.column)
+		values[i] = fmt.Sprintf(k.format, "e."+f.name)
+		for _, pkg := range k.imports {
+			imports[pkg] = struct{}{}
+		}
+	}
+
+	fmt.Fprintf(w, "var %s = []string{%s}\n\n", keysVar, strings.Join(columns, ", "))
+	fmt.Fprintf(w, "// Keys returns the column names of %s\n", st.name)
+	fmt.Fprintf(w, "func (e %s) Keys() []string {\nreturn %s\n}\n\n", st.name, keysVar)
+	fmt.Fprintf(w, "// Values returns the column values of %s\n", st.name)
+	fmt.Fprintf(w, "func (e %s) Values() []string {\nreturn []string{\n%s,\n}\n}\n\n", st.name, strings.Join(values, ",\n"))
+	fmt.Fprintf(w, "// UnmarshalCSV parses a row of values into %s\n", st.name)
+	fmt.Fprintf(w, "func (e *%s) UnmarshalCSV(keys, values []string) (err error) {\n", st.name)
+	fmt.Fprintf(w, "for i, key := range keys {\nif i >= len(values) {\nbreak\n}\n\nswitch key {\n")
+	for _, f := range st.fields {
+		fmt.Fprintf(w, "case %s:\n", strconv.Quote(f.column))
+		fmt.Fprintf(w, kinds[f.kind].parse, "e."+f.name, f.kind)
+		w.WriteString("\n")
+	}
+
+	w.WriteString("}\n}\n\nreturn\n}\n\n")
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func Test_generate(t *testing.T) {
+	type args struct {
+		src       string
+		typeNames []string
+	}
+
+	type testcase struct {
+		name         string
+		args         args
+		wantContains []string
+		wantErr      bool
+	}
+
+	tests := []testcase{
+		{
+			name: "basic",
+			args: args{
+				src: `package foo
+
+import "time"
+
+type Foo struct {
+	Name  string    ` + "`csv:\"name\"`" + `
+	Count int       ` + "`csv:\"count\"`" + `
+	At    time.Time
+	Skip  string    ` + "`csv:\"-\"`" + `
+}
+`,
+				typeNames: []string{"Foo"},
+			},
+			wantContains: []string{
+				"package foo",
+				`var csvdbKeysFoo = []string{"name", "count", "At"}`,
+				"func (e Foo) Keys() []string",
+				"strconv.FormatInt(int64(e.Count), 10)",
+				"e.At.Format(time.RFC3339Nano)",
+				"func (e *Foo) UnmarshalCSV(keys, values []string) (err error)",
+			},
+		},
+		{
+			name: "unsupported type",
+			args: args{
+				src: `package foo
+
+type Foo struct {
+	Names []string
+}
+`,
+				typeNames: []string{"Foo"},
+			},
+			wantErr: true,
+		},
+		{
+			name: "missing type",
+			args: args{
+				src: `package foo
+
+type Foo struct {
+	Name string
+}
+`,
+				typeNames: []string{"Bar"},
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			if err := os.WriteFile(filepath.Join(dir, "foo.go"), []byte(tt.args.src), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := generate(dir, tt.args.typeNames)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("generate() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+
+			for _, want := range tt.wantContains {
+				if !strings.Contains(string(got), want) {
+					t.Errorf("generate() = %s, want to contain %s", got, want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,69 @@
+package main
+
+import "strconv"
+
+// kind describes how a field type is formatted to and parsed from a CSV value.
+// Format receives the field selector, parse receives the field selector and the type name.
+type kind struct {
+	format  string
+	parse   string
+	imports []string
+}
+
+var kinds = map[string]kind{
+	"string": {
+		format: "%s",
+		parse:  "%[1]s = values[i]",
+	},
+	"bool": {
+		format:  "strconv.FormatBool(%s)",
+		parse:   "if %[1]s, err = strconv.ParseBool(values[i]); err != nil {\nreturn\n}",
+		imports: []string{"strconv"},
+	},
+	"int":     intKind(0),
+	"int8":    intKind(8),
+	"int16":   intKind(16),
+	"int32":   intKind(32),
+	"int64":   intKind(64),
+	"uint":    uintKind(0),
+	"uint8":   uintKind(8),
+	"uint16":  uintKind(16),
+	"uint32":  uintKind(32),
+	"uint64":  uintKind(64),
+	"float32": floatKind(32),
+	"float64": floatKind(64),
+	"time.Time": {
+		format:  "%s.Format(time.RFC3339Nano)",
+		parse:   "if %[1]s, err = time.Parse(time.RFC3339Nano, values[i]); err != nil {\nreturn\n}",
+		imports: []string{"time"},
+	},
+	"time.Duration": {
+		format:  "%s.String()",
+		parse:   "if %[1]s, err = time.ParseDuration(values[i]); err != nil {\nreturn\n}",
+		imports: []string{"time"},
+	},
+}
+
+func intKind(bits int) kind {
+	return kind{
+		format:  "strconv.FormatInt(int64(%s), 10)",
+		parse:   "var v int64\nif v, err = strconv.ParseInt(values[i], 10, " + strconv.Itoa(bits) + "); err != nil {\nreturn\n}\n\n%[1]s = %[2]s(v)",
+		imports: []string{"strconv"},
+	}
+}
+
+func uintKind(bits int) kind {
+	return kind{
+		format:  "strconv.FormatUint(uint64(%s), 10)",
+		parse:   "var v uint64\nif v, err = strconv.ParseUint(values[i], 10, " + strconv.Itoa(bits) + "); err != nil {\nreturn\n}\n\n%[1]s = %[2]s(v)",
+		imports: []string{"strconv"},
+	}
+}
+
+func floatKind(bits int) kind {
+	return kind{
+		format:  "strconv.FormatFloat(float64(%s), 'f', -1, " + strconv.Itoa(bits) + ")",
+		parse:   "var v float64\nif v, err = strconv.ParseFloat(values[i], " + strconv.Itoa(bits) + "); err != nil {\nreturn\n}\n\n%[1]s = %[2]s(v)",
+		imports: []string{"strconv"},
+	}
+}
@@ -0,0 +1,50 @@
+// csvdbgen generates reflection-free csvdb.Entry implementations (Keys, Values
+// and UnmarshalCSV) for annotated structs.
+//
+// Usage:
+//
+//	//go:generate csvdbgen -type=Foo,Bar
+//
+// Columns are named by the `csv` struct tag, falling back to the field name.
+// Fields tagged with `csv:"-"` are skipped.
+package main
+
+import (
+	"flag"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+func main() {
+	var (
+		types  string
+		dir    string
+		output string
+	)
+
+	flag.StringVar(&types, "type", "", "comma-separated list of struct type names")
+	flag.StringVar(&dir, "dir", ".", "directory of the package containing the types")
+	flag.StringVar(&output, "output", "", "output filename, defaults to <type>_csvdb.go")
+	flag.Parse()
+
+	if len(types) == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	typeNames := strings.Split(types, ",")
+	if len(output) == 0 {
+		output = strings.ToLower(typeNames[0]) + "_csvdb.go"
+	}
+
+	src, err := generate(dir, typeNames)
+	if err != nil {
+		log.Fatalf("csvdbgen: %v", err)
+	}
+
+	if err = os.WriteFile(filepath.Join(dir, output), src, 0644); err != nil {
+		log.Fatalf("csvdbgen: error writing output: %v", err)
+	}
+}
@@ -0,0 +1,150 @@
+package csvdb
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+type raggedentry struct {
+	testentry
+}
+
+func (r raggedentry) Values() []string {
+	if r.Bar == "" {
+		return []string{r.Foo}
+	}
+
+	return r.testentry.Values()
+}
+
+func TestDB_columnCount(t *testing.T) {
+	type testcase struct {
+		name string
+		// file is written as the file of the key before appending when set
+		file string
+		fn   func(d *DB[raggedentry]) error
+
+		wantMsg string
+		// wantFile is the file of the key after the failed write, defaults to file
+		wantFile string
+	}
+
+	valid := raggedentry{testentry{Foo: "1", Bar: "1b"}}
+	ragged := raggedentry{testentry{Foo: "2"}}
+	tests := []testcase{
+		{
+			name: "append",
+			fn: func(d *DB[raggedentry]) error {
+				return d.Append("a", valid, ragged)
+			},
+			wantMsg: "row #1 of <a> has 1 values, expected 2",
+		},
+		{
+			name: "append to existing header",
+			file: "foo,bar,baz\n1,1b,1c\n",
+			fn: func(d *DB[raggedentry]) error {
+				return d.Append("a", valid)
+			},
+			wantMsg: "row #0 of <a> has 2 values, expected 3",
+		},
+		{
+			name: "upsert to existing header",
+			file: "foo,bar,baz\n1,1b,1c\n",
+			fn: func(d *DB[raggedentry]) error {
+				return d.Upsert("a", "foo", valid)
+			},
+			wantMsg: "row #0 of <a> has 2 values, expected 3",
+		},
+		{
+			name: "update rows",
+			file: "foo,bar\n1,1b\n",
+			fn: func(d *DB[raggedentry]) error {
+				return d.UpdateRows("a", func(e raggedentry) (raggedentry, bool, error) {
+					e.Bar = ""
+					return e, true, nil
+				})
+			},
+			wantMsg: "row #0 of <a> has 1 values, expected 2",
+		},
+		{
+			name: "writer",
+			fn: func(d *DB[raggedentry]) (err error) {
+				var w *EntryWriter[raggedentry]
+				if w, err = d.Writer("a"); err != nil {
+					return
+				}
+				defer w.Close()
+
+				if err = w.Write(valid); err != nil {
+					return
+				}
+
+				return w.Write(ragged)
+			},
+			wantMsg: "row #1 of <a> has 1 values, expected 2",
+			// Valid entries are flushed when the writer is closed
+			wantFile: "foo,bar\n1,1b\n",
+		},
+		{
+			name: "writer to existing header",
+			file: "foo,bar,baz\n1,1b,1c\n",
+			fn: func(d *DB[raggedentry]) (err error) {
+				var w *EntryWriter[raggedentry]
+				if w, err = d.Writer("a"); err != nil {
+					return
+				}
+				defer w.Close()
+
+				if err = w.Write(valid); err != nil {
+					return
+				}
+
+				return w.Flush()
+			},
+			wantMsg: "row #0 of <a> has 2 values, expected 3",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var opts Options
+			opts.Dir = fmt.Sprintf("test_%d", time.Now().UnixNano())
+			opts.Name = "foo"
+			d, err := makeDB[raggedentry](opts, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer os.RemoveAll(opts.Dir)
+
+			if tt.file != "" {
+				if err = os.WriteFile(d.getPath("foo.a.csv"), []byte(tt.file), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			err = tt.fn(&d)
+			if !errors.Is(err, ErrInvalidColumnCount) || !strings.Contains(err.Error(), tt.wantMsg) {
+				t.Fatalf("error = %v, want %v containing %q", err, ErrInvalidColumnCount, tt.wantMsg)
+			}
+
+			want := tt.file
+			if tt.wantFile != "" {
+				want = tt.wantFile
+			}
+
+			// Nothing is written by the failed write
+			got, err := os.ReadFile(d.getPath("foo.a.csv"))
+			if err != nil && !os.IsNotExist(err) {
+				t.Fatal(err)
+			}
+
+			if string(got) != want {
+				t.Errorf("file = %q, want %q", got, want)
+			}
+		})
+	}
+}
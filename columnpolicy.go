@@ -0,0 +1,320 @@
+package csvdb
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"io"
+	"strings"
+	"sync"
+)
+
+const (
+	// ColumnNone will store values unchanged, which is useful alongside ColumnPolicy.Mask
+	ColumnNone ColumnAction = iota
+	// ColumnHash will store values as their hex-encoded SHA-256 hash, prefixed with "sha256:"
+	ColumnHash
+	// ColumnEncrypt will store values encrypted with AES-GCM using the keys of
+	// Options.ColumnKeys, base64-encoded and prefixed with "enc:". Values are decrypted by Get.
+	ColumnEncrypt
+)
+
+const (
+	// hashedPrefix prefixes the values of ColumnHash columns
+	hashedPrefix = "sha256:"
+	// encryptedPrefix prefixes the values of ColumnEncrypt columns
+	encryptedPrefix = "enc:"
+)
+
+var (
+	// ErrInvalidColumnAction is returned when the action of a column policy is unknown
+	ErrInvalidColumnAction = errors.New("invalid columnPolicies, unknown action")
+	// ErrInvalidColumnKeys is returned when a column policy has the ColumnEncrypt action
+	// while Options.ColumnKeys is not set
+	ErrInvalidColumnKeys = errors.New("invalid columnKeys, must be set when a column policy encrypts")
+)
+
+// ColumnAction represents how the values of a column are protected when written
+type ColumnAction uint8
+
+// ColumnPolicy protects the values of a column. Empty values and values which already
+// carry the prefix of the action are written unchanged, so rows may be rewritten as-is.
+type ColumnPolicy struct {
+	// Action is how values are protected when written
+	Action ColumnAction `json:"action" toml:"action"`
+	// Mask will replace the non-empty values of the column when read by Get or ExportSQLite,
+	// when set.
+	// Note: Exported files and UpdateRows are not masked
+	Mask string `json:"mask" toml:"mask"`
+}
+
+func (o *Options) validateColumnPolicies() (err error) {
+	for _, p := range o.ColumnPolicies {
+		switch {
+		case p.Action > ColumnEncrypt:
+			return ErrInvalidColumnAction
+		case p.Action == ColumnEncrypt && o.ColumnKeys == nil:
+			return ErrInvalidColumnKeys
+		}
+	}
+
+	return
+}
+
+// policyColumn is a column with a policy, by its index within a header
+type policyColumn struct {
+	index  int
+	policy ColumnPolicy
+}
+
+// columnProtector applies the column policies to the values of rows
+type columnProtector struct {
+	policies map[string]ColumnPolicy
+	c        aesCodec
+	dialect  csvDialect
+}
+
+func newColumnProtector(o Options) (cp columnProtector) {
+	cp.policies = o.ColumnPolicies
+	cp.dialect = o.dialect()
+	if o.ColumnKeys != nil {
+		cp.c = aesCodec{kp: &keyCache{KeyProvider: o.ColumnKeys, keys: make(map[KeyID][]byte)}}
+	}
+
+	return
+}
+
+// columns will return the columns of a header which have a policy
+func (cp columnProtector) columns(header []string) (cs []policyColumn) {
+	for name, p := range cp.policies {
+		if i := indexOf(header, name); i != -1 {
+			cs = append(cs, policyColumn{index: i, policy: p})
+		}
+	}
+
+	return
+}
+
+// hashed will return the provided columns whose values are hashed. Unlike encrypted values,
+// hashed values are deterministic, so they may be compared as they are stored.
+func hashed(cs []policyColumn) (out []policyColumn) {
+	for _, c := range cs {
+		if c.policy.Action == ColumnHash {
+			out = append(out, c)
+		}
+	}
+
+	return
+}
+
+// at will return the provided columns which are at the provided index
+func at(cs []policyColumn, index int) (out []policyColumn) {
+	for _, c := range cs {
+		if c.index == index {
+			out = append(out, c)
+		}
+	}
+
+	return
+}
+
+// protect will return the values with those of the provided columns hashed or encrypted.
+// Values are always protected, even when they resemble protected values, as they are
+// provided by callers. The provided values are copied before any is replaced.
+func (cp columnProtector) protect(cs []policyColumn, values []string) (out []string, err error) {
+	out = values
+	var copied bool
+	for _, c := range cs {
+		if c.index >= len(values) {
+			continue
+		}
+
+		value := values[c.index]
+		switch {
+		case value == "":
+			continue
+		case c.policy.Action == ColumnHash:
+			sum := sha256.Sum256([]byte(value))
+			value = hashedPrefix + hex.EncodeToString(sum[:])
+		case c.policy.Action == ColumnEncrypt:
+			if value, err = cp.encrypt(value); err != nil {
+				return
+			}
+		default:
+			continue
+		}
+
+		if !copied {
+			out = append([]string(nil), values...)
+			copied = true
+		}
+
+		out[c.index] = value
+	}
+
+	return
+}
+
+// reprotect will return the values of a rewritten row protected as protect does, except that
+// the values left as they were read are kept as they are stored. The stored values are those
+// of the row as read, and opened are the same values once opened. Stored values which were
+// never protected, such as those predating a policy, are protected.
+func (cp columnProtector) reprotect(cs []policyColumn, values, stored, opened []string) (out []string, err error) {
+	out = values
+	var copied bool
+	changed := make([]policyColumn, 0, len(cs))
+	for _, c := range cs {
+		if c.index >= len(values) || c.index >= len(stored) || c.index >= len(opened) ||
+			values[c.index] != opened[c.index] || !isProtected(c.policy, stored[c.index]) {
+			changed = append(changed, c)
+			continue
+		}
+
+		if out[c.index] != stored[c.index] {
+			if !copied {
+				out = append([]string(nil), values...)
+				copied = true
+			}
+
+			out[c.index] = stored[c.index]
+		}
+	}
+
+	return cp.protect(changed, out)
+}
+
+// isProtected will return whether or not a stored value has been protected by the provided policy
+func isProtected(p ColumnPolicy, value string) bool {
+	switch p.Action {
+	case ColumnHash:
+		return strings.HasPrefix(value, hashedPrefix)
+	case ColumnEncrypt:
+		return strings.HasPrefix(value, encryptedPrefix)
+	default:
+		return false
+	}
+}
+
+// open will decrypt the values of the provided columns in place, masking them first when mask is set
+func (cp columnProtector) open(cs []policyColumn, values []string, mask bool) (err error) {
+	for _, c := range cs {
+		switch {
+		case c.index >= len(values) || values[c.index] == "":
+		case mask && c.policy.Mask != "":
+			values[c.index] = c.policy.Mask
+		case c.policy.Action == ColumnEncrypt && strings.HasPrefix(values[c.index], encryptedPrefix):
+			if values[c.index], err = cp.decrypt(values[c.index]); err != nil {
+				return
+			}
+		}
+	}
+
+	return
+}
+
+// reveals will return whether or not reads are transformed by any column policy
+func (cp columnProtector) reveals() bool {
+	for _, p := range cp.policies {
+		if p.Action == ColumnEncrypt || p.Mask != "" {
+			return true
+		}
+	}
+
+	return false
+}
+
+// reveal will copy CSV rows from r to w, decrypting and masking the values of the columns
+// with a policy
+func (cp columnProtector) reveal(w io.Writer, r io.Reader) (err error) {
+	cr := newCSVReader(r, cp.dialect)
+	cr.FieldsPerRecord = -1
+	cw := newCSVWriter(w, cp.dialect)
+
+	var cs []policyColumn
+	for first := true; ; first = false {
+		var values []string
+		switch values, err = cr.Read(); err {
+		case nil:
+		case io.EOF:
+			cw.Flush()
+			return cw.Error()
+		default:
+			return
+		}
+
+		if first {
+			cs = cp.columns(values)
+		} else if err = cp.open(cs, values, true); err != nil {
+			return
+		}
+
+		if err = cw.Write(values); err != nil {
+			return
+		}
+	}
+}
+
+func (cp columnProtector) encrypt(value string) (out string, err error) {
+	var buf bytes.Buffer
+	var w io.WriteCloser
+	if w, err = cp.c.NewWriter(&buf); err != nil {
+		return
+	}
+
+	if _, err = io.WriteString(w, value); err != nil {
+		return
+	}
+
+	if err = w.Close(); err != nil {
+		return
+	}
+
+	return encryptedPrefix + base64.StdEncoding.EncodeToString(buf.Bytes()), nil
+}
+
+func (cp columnProtector) decrypt(value string) (out string, err error) {
+	var bs []byte
+	if bs, err = base64.StdEncoding.DecodeString(strings.TrimPrefix(value, encryptedPrefix)); err != nil {
+		return "", ErrInvalidEncryptedRecord
+	}
+
+	var r io.ReadCloser
+	if r, err = cp.c.NewReader(bytes.NewReader(bs)); err != nil {
+		return
+	}
+
+	if bs, err = io.ReadAll(r); err != nil {
+		return
+	}
+
+	return string(bs), nil
+}
+
+var _ KeyProvider = &keyCache{}
+
+// keyCache is a KeyProvider caching the keys of the KeyProvider it wraps, as column values
+// are each encrypted separately
+type keyCache struct {
+	KeyProvider
+
+	mux  sync.Mutex
+	keys map[KeyID][]byte
+}
+
+func (k *keyCache) Key(id KeyID) (key []byte, err error) {
+	k.mux.Lock()
+	defer k.mux.Unlock()
+	var ok bool
+	if key, ok = k.keys[id]; ok {
+		return
+	}
+
+	if key, err = k.KeyProvider.Key(id); err != nil {
+		return
+	}
+
+	k.keys[id] = key
+	return
+}
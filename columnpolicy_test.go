@@ -0,0 +1,376 @@
+package csvdb
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestDB_ColumnPolicies(t *testing.T) {
+	type testcase struct {
+		name   string
+		policy ColumnPolicy
+		opts   func(o *Options)
+		// want is the value of the secret column returned by Get
+		want string
+		// wantStored is the prefix of the value of the secret column when exported
+		wantStored string
+	}
+
+	tests := []testcase{
+		{
+			name:       "hash",
+			policy:     ColumnPolicy{Action: ColumnHash},
+			want:       "sha256:2bb80d537b1da3e38bd30361aa855686bde0eacd7162fef6a25fe97bf527a25b",
+			wantStored: "sha256:",
+		},
+		{
+			name:       "encrypt",
+			policy:     ColumnPolicy{Action: ColumnEncrypt},
+			want:       "secret",
+			wantStored: "enc:",
+		},
+		{
+			name:       "encrypt and mask",
+			policy:     ColumnPolicy{Action: ColumnEncrypt, Mask: "***"},
+			want:       "***",
+			wantStored: "enc:",
+		},
+		{
+			name:       "mask",
+			policy:     ColumnPolicy{Mask: "***"},
+			want:       "***",
+			wantStored: "secret",
+		},
+		{
+			name:       "encrypt in memory",
+			policy:     ColumnPolicy{Action: ColumnEncrypt},
+			opts:       func(o *Options) { o.InMemory = true },
+			want:       "secret",
+			wantStored: "enc:",
+		},
+		{
+			name:   "encrypt with context columns",
+			policy: ColumnPolicy{Action: ColumnEncrypt},
+			opts: func(o *Options) {
+				o.ContextColumns = []ContextColumn{{Name: "region", Value: func(context.Context) string { return "eu" }}}
+			},
+			want:       "secret",
+			wantStored: "enc:",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var (
+				mux      sync.Mutex
+				exported = make(map[string][]byte)
+			)
+
+			b := &mockBackend{
+				exportFn: func(ctx context.Context, prefix, filename string, r io.Reader) (string, error) {
+					bs, err := io.ReadAll(r)
+					mux.Lock()
+					exported[filename] = bs
+					mux.Unlock()
+					return filename, err
+				},
+			}
+
+			var opts Options
+			opts.Dir = fmt.Sprintf("test_%d", time.Now().UnixNano())
+			opts.Name = "foo"
+			opts.ColumnPolicies = map[string]ColumnPolicy{"bar": tt.policy}
+			opts.ColumnKeys = &testKeyProvider{
+				current: KeyID{Name: "columns", Version: 1},
+				keys:    map[KeyID][]byte{{Name: "columns", Version: 1}: bytes.Repeat([]byte{1}, 32)},
+			}
+
+			if tt.opts != nil {
+				tt.opts(&opts)
+			}
+
+			d, err := makeDB[testentry](opts, b)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer os.RemoveAll(opts.Dir)
+
+			if err = d.Append("a", testentry{Foo: "1", Bar: "secret"}, testentry{Foo: "2"}); err != nil {
+				t.Fatal(err)
+			}
+
+			header, suffix := "foo,bar\n", ""
+			if len(opts.ContextColumns) > 0 {
+				header, suffix = "foo,bar,region\n", ",eu"
+			}
+
+			if err = d.AppendRaw("a", strings.NewReader("3,secret"+suffix+"\n")); err != nil {
+				t.Fatal(err)
+			}
+
+			// UpdateRows provides encrypted values decrypted, and protects the updated rows
+			if err = d.UpdateRows("a", func(e testentry) (testentry, bool, error) {
+				if tt.policy.Action == ColumnEncrypt && e.Bar != "" && e.Bar != "secret" {
+					return e, false, fmt.Errorf("unexpected value %q", e.Bar)
+				}
+
+				return e, true, nil
+			}); err != nil {
+				t.Fatal(err)
+			}
+
+			if err = d.Upsert("a", "foo", testentry{Foo: "4", Bar: "secret"}); err != nil {
+				t.Fatal(err)
+			}
+
+			// Protected values are compared as they were provided, so the entry is not appended again
+			if err = d.AppendUniqueContext(context.Background(), "a", testentry{Foo: "4", Bar: "secret"}); err != nil {
+				t.Fatal(err)
+			}
+
+			w := &bytes.Buffer{}
+			if err = d.Get(w, "a"); err != nil {
+				t.Fatal(err)
+			}
+
+			want := header + "1," + tt.want + suffix + "\n2," + suffix + "\n3," + tt.want + suffix + "\n4," + tt.want + suffix + "\n"
+			if w.String() != want {
+				t.Errorf("DB.Get() = %q, want %q", w.String(), want)
+			}
+
+			if err = d.ExportPrefix(""); err != nil {
+				t.Fatal(err)
+			}
+
+			mux.Lock()
+			defer mux.Unlock()
+			bs, ok := exported[d.dataName("foo.a.csv")]
+			if !ok {
+				t.Fatalf("exported files = %v, want %s", exported, d.dataName("foo.a.csv"))
+			}
+
+			for i, line := range strings.Split(strings.TrimSpace(string(bs)), "\n")[1:] {
+				values := strings.Split(line, ",")
+				if i == 1 {
+					continue
+				}
+
+				if !strings.HasPrefix(values[1], tt.wantStored) {
+					t.Errorf("exported value = %q, want prefix %q", values[1], tt.wantStored)
+				}
+			}
+		})
+	}
+}
+
+func TestOptions_Validate_columnPolicies(t *testing.T) {
+	type testcase struct {
+		name    string
+		opts    Options
+		wantErr error
+	}
+
+	kp := &testKeyProvider{}
+	tests := []testcase{
+		{
+			name: "hash",
+			opts: Options{Dir: "test", Name: "foo", ColumnPolicies: map[string]ColumnPolicy{"email": {Action: ColumnHash}}},
+		},
+		{
+			name: "encrypt",
+			opts: Options{Dir: "test", Name: "foo", ColumnPolicies: map[string]ColumnPolicy{"email": {Action: ColumnEncrypt}}, ColumnKeys: kp},
+		},
+		{
+			name:    "encrypt without keys",
+			opts:    Options{Dir: "test", Name: "foo", ColumnPolicies: map[string]ColumnPolicy{"email": {Action: ColumnEncrypt}}},
+			wantErr: ErrInvalidColumnKeys,
+		},
+		{
+			name:    "unknown action",
+			opts:    Options{Dir: "test", Name: "foo", ColumnPolicies: map[string]ColumnPolicy{"email": {Action: ColumnEncrypt + 1}}},
+			wantErr: ErrInvalidColumnAction,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.opts.Validate(); !errors.Is(err, tt.wantErr) {
+				t.Errorf("Options.Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestDB_ColumnPolicies_prefixedValues(t *testing.T) {
+	type testcase struct {
+		name   string
+		policy ColumnPolicy
+		// value is the provided value of the secret column, which resembles a protected value
+		value string
+		// want is the value of the secret column returned by Get
+		want string
+	}
+
+	tests := []testcase{
+		{
+			name:   "hash",
+			policy: ColumnPolicy{Action: ColumnHash},
+			value:  "sha256:alice@example.com",
+			want:   "sha256:76e4ad23e1bf0f3865e0644d796a3655cebdeb3187c84085df6bc3a564842803",
+		},
+		{
+			name:   "encrypt",
+			policy: ColumnPolicy{Action: ColumnEncrypt},
+			value:  "enc:alice@example.com",
+			want:   "enc:alice@example.com",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var opts Options
+			opts.Dir = fmt.Sprintf("test_%d", time.Now().UnixNano())
+			opts.Name = "foo"
+			opts.ColumnPolicies = map[string]ColumnPolicy{"bar": tt.policy}
+			opts.ColumnKeys = &testKeyProvider{
+				current: KeyID{Name: "columns", Version: 1},
+				keys:    map[KeyID][]byte{{Name: "columns", Version: 1}: bytes.Repeat([]byte{1}, 32)},
+			}
+
+			d, err := makeDB[testentry](opts, &mockBackend{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer os.RemoveAll(opts.Dir)
+
+			// Values resembling protected values are still protected when they are provided
+			if err = d.Append("a", testentry{Foo: "1", Bar: tt.value}); err != nil {
+				t.Fatal(err)
+			}
+
+			if err = d.AppendRaw("a", strings.NewReader("2,"+tt.value+"\n")); err != nil {
+				t.Fatal(err)
+			}
+
+			// Unchanged values are kept as stored, so hashed values are not hashed again
+			if err = d.UpdateRows("a", func(e testentry) (testentry, bool, error) {
+				return e, true, nil
+			}); err != nil {
+				t.Fatal(err)
+			}
+
+			w := &bytes.Buffer{}
+			if err = d.Get(w, "a"); err != nil {
+				t.Fatal(err)
+			}
+
+			want := "foo,bar\n1," + tt.want + "\n2," + tt.want + "\n"
+			if w.String() != want {
+				t.Errorf("DB.Get() = %q, want %q", w.String(), want)
+			}
+
+			stored, err := os.ReadFile(d.getPath("foo.a.csv"))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if strings.Contains(string(stored), "alice@example.com") {
+				t.Errorf("stored = %q, want alice@example.com protected", stored)
+			}
+		})
+	}
+}
+
+func TestDB_Upsert_encryptedKey(t *testing.T) {
+	var opts Options
+	opts.Dir = fmt.Sprintf("test_%d", time.Now().UnixNano())
+	opts.Name = "foo"
+	opts.ColumnPolicies = map[string]ColumnPolicy{"foo": {Action: ColumnEncrypt}}
+	opts.ColumnKeys = &testKeyProvider{
+		current: KeyID{Name: "columns", Version: 1},
+		keys:    map[KeyID][]byte{{Name: "columns", Version: 1}: bytes.Repeat([]byte{1}, 32)},
+	}
+
+	d, err := makeDB[testentry](opts, &mockBackend{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(opts.Dir)
+
+	if err = d.Append("a", testentry{Foo: "1", Bar: "a"}, testentry{Foo: "2", Bar: "b"}); err != nil {
+		t.Fatal(err)
+	}
+
+	// Encrypted primary keys are compared by their decrypted values
+	if err = d.Upsert("a", "foo", testentry{Foo: "1", Bar: "c"}, testentry{Foo: "3", Bar: "d"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err = d.Upsert("a", "foo", testentry{Foo: "3", Bar: "e"}); err != nil {
+		t.Fatal(err)
+	}
+
+	w := &bytes.Buffer{}
+	if err = d.Get(w, "a"); err != nil {
+		t.Fatal(err)
+	}
+
+	if want := "foo,bar\n1,c\n2,b\n3,e\n"; w.String() != want {
+		t.Errorf("DB.Get() = %q, want %q", w.String(), want)
+	}
+}
+
+func TestDB_DeleteRows_encrypted(t *testing.T) {
+	var opts Options
+	opts.Dir = fmt.Sprintf("test_%d", time.Now().UnixNano())
+	opts.Name = "foo"
+	opts.ColumnPolicies = map[string]ColumnPolicy{"bar": {Action: ColumnEncrypt}}
+	opts.ColumnKeys = &testKeyProvider{
+		current: KeyID{Name: "columns", Version: 1},
+		keys:    map[KeyID][]byte{{Name: "columns", Version: 1}: bytes.Repeat([]byte{1}, 32)},
+	}
+
+	d, err := makeDB[testentry](opts, &mockBackend{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(opts.Dir)
+
+	if err = d.Append("a", testentry{Foo: "1", Bar: "alice"}, testentry{Foo: "2", Bar: "bob"}); err != nil {
+		t.Fatal(err)
+	}
+
+	// Encrypted values are provided to the func decrypted
+	if err = d.DeleteRows("a", func(values []string) bool {
+		return values[1] == "alice"
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	w := &bytes.Buffer{}
+	if err = d.Get(w, "a"); err != nil {
+		t.Fatal(err)
+	}
+
+	if want := "foo,bar\n2,bob\n"; w.String() != want {
+		t.Errorf("DB.Get() = %q, want %q", w.String(), want)
+	}
+
+	// The remaining rows are kept encrypted
+	stored, err := os.ReadFile(d.getPath("foo.a.csv"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if strings.Contains(string(stored), "bob") {
+		t.Errorf("stored = %q, want bob encrypted", stored)
+	}
+}
@@ -0,0 +1,71 @@
+package csvdb
+
+import "strings"
+
+// Key is a composite key made up of "/"-delimited parts, allowing keys with multiple
+// dimensions (e.g. tenant and date) to be composed and parsed consistently. Percent signs
+// and slashes within parts are escaped, so parts may contain any character. As parts are
+// "/"-delimited, a Key is also the prefix of the keys which extend it, see ExportPrefix.
+type Key struct {
+	parts []string
+}
+
+// K will return a Key made up of the provided parts
+func K(parts ...string) (k Key) {
+	return k.Part(parts...)
+}
+
+// ParseKey will parse a key created by Key.String into its parts
+func ParseKey(key string) (k Key) {
+	if key == "" {
+		return
+	}
+
+	k.parts = strings.Split(key, "/")
+	for i, part := range k.parts {
+		k.parts[i] = unescapeKey(part)
+	}
+
+	return
+}
+
+// Part will return a copy of the Key with the provided parts appended
+func (k Key) Part(parts ...string) (out Key) {
+	out.parts = make([]string, 0, len(k.parts)+len(parts))
+	out.parts = append(out.parts, k.parts...)
+	out.parts = append(out.parts, parts...)
+	return
+}
+
+// Parts will return the parts of the Key
+func (k Key) Parts() (parts []string) {
+	return append(parts, k.parts...)
+}
+
+// String will return the Key as it is provided to the DB
+func (k Key) String() string {
+	var sb strings.Builder
+	for i, part := range k.parts {
+		if i > 0 {
+			sb.WriteByte('/')
+		}
+
+		escapePart(&sb, part)
+	}
+
+	return sb.String()
+}
+
+// escapePart will write a part of a Key, escaping the characters which would make it ambiguous
+func escapePart(sb *strings.Builder, part string) {
+	for i := 0; i < len(part); i++ {
+		switch b := part[i]; b {
+		case '%', '/':
+			sb.WriteByte('%')
+			sb.WriteByte(hexDigits[b>>4])
+			sb.WriteByte(hexDigits[b&0x0F])
+		default:
+			sb.WriteByte(b)
+		}
+	}
+}
@@ -0,0 +1,66 @@
+package csvdb
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestKey(t *testing.T) {
+	type testcase struct {
+		name string
+		key  Key
+
+		wantString string
+		wantParts  []string
+	}
+
+	tests := []testcase{
+		{
+			name:       "basic",
+			key:        K("tenant", "acme").Part("2024-06-01"),
+			wantString: "tenant/acme/2024-06-01",
+			wantParts:  []string{"tenant", "acme", "2024-06-01"},
+		},
+		{
+			name:       "escaped",
+			key:        K("a/b", "100%", "c%2Fd"),
+			wantString: "a%2Fb/100%25/c%252Fd",
+			wantParts:  []string{"a/b", "100%", "c%2Fd"},
+		},
+		{
+			name:       "empty part",
+			key:        K("a", "", "b"),
+			wantString: "a//b",
+			wantParts:  []string{"a", "", "b"},
+		},
+		{
+			name: "empty",
+			key:  K(),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.key.String(); got != tt.wantString {
+				t.Fatalf("Key.String() = %v, want %v", got, tt.wantString)
+			}
+
+			if got := ParseKey(tt.wantString).Parts(); !reflect.DeepEqual(got, tt.wantParts) {
+				t.Fatalf("ParseKey().Parts() = %#v, want %#v", got, tt.wantParts)
+			}
+
+			if !hasKeyPrefix(tt.key.Part("child").String(), tt.wantString) {
+				t.Fatalf("hasKeyPrefix() = false, want true")
+			}
+		})
+	}
+}
+
+func TestKey_Part(t *testing.T) {
+	base := K("tenant", "acme")
+	a := base.Part("a")
+	b := base.Part("b")
+	if a.String() != "tenant/acme/a" || b.String() != "tenant/acme/b" {
+		t.Fatalf("Key.Part() = %v and %v, want independent keys", a, b)
+	}
+}
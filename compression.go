@@ -0,0 +1,524 @@
+package csvdb
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// CompressionNone will store files uncompressed
+	CompressionNone Compression = iota
+	// CompressionGzip will store files gzipped as <Name>.<key>.csv.gz, files are exported gzipped
+	CompressionGzip
+)
+
+const (
+	// segmentSize is the maximum number of uncompressed bytes of a segment, past which
+	// writes start a segment of their own
+	segmentSize = 32 * 1024
+	// maxSegments is the maximum number of segments held by a codecFS
+	maxSegments = 256
+)
+
+var (
+	// ErrInvalidCompression is returned when Options.Compression is unknown
+	ErrInvalidCompression = errors.New("invalid compression, unknown value")
+	// ErrCompressionIncompatible is returned when Options.Compression or Options.Codec is set
+	// alongside options which access files at byte offsets
+	ErrCompressionIncompatible = errors.New("invalid compression, cannot be set alongside rowIndexInterval or tailRepair")
+	// ErrInvalidCodec is returned when Options.Codec is set alongside Options.Compression, or
+	// its extension is invalid
+	ErrInvalidCodec = errors.New("invalid codec, cannot be set alongside compression and extension must be of the form \".ext\"")
+
+	errSeekEncoded = errors.New("cannot seek within an encoded file")
+)
+
+// Compression represents how files are stored on disk
+type Compression uint8
+
+// Codec compresses the files stored by the DB. Compressed streams must support being
+// concatenated, as each write is stored as a separate stream appended to the file.
+// Zstandard is provided by the Codec of github.com/itsmontoya/csvdb/zstd, which is a module
+// of its own so csvdb does not depend upon the zstd implementation.
+type Codec interface {
+	// Extension is the suffix of stored and exported files, e.g. ".zst"
+	Extension() string
+	// NewWriter will return a writer compressing to the provided writer, the stream is
+	// complete once it is closed
+	NewWriter(w io.Writer) (io.WriteCloser, error)
+	// NewReader will return a reader decompressing every stream of the provided reader
+	NewReader(r io.Reader) (io.ReadCloser, error)
+}
+
+var _ Codec = GzipCodec{}
+
+// GzipCodec is the Codec used by CompressionGzip
+type GzipCodec struct{}
+
+// Extension returns ".gz"
+func (GzipCodec) Extension() string { return ".gz" }
+
+// NewWriter will return a gzip.Writer
+func (GzipCodec) NewWriter(w io.Writer) (io.WriteCloser, error) { return gzip.NewWriter(w), nil }
+
+// NewReader will return a gzip.Reader
+func (GzipCodec) NewReader(r io.Reader) (io.ReadCloser, error) { return gzip.NewReader(r) }
+
+// codec will return the Codec files are stored with, nil when files are stored uncompressed
+func (o *Options) codec() Codec {
+	switch {
+	case o.Codec != nil:
+		return o.Codec
+	case o.Compression == CompressionGzip:
+		return GzipCodec{}
+	default:
+		return nil
+	}
+}
+
+// validCodecExtension matches the extensions Codecs may use
+var validCodecExtension = regexp.MustCompile(`^\.[A-Za-z0-9]+$`)
+
+// compressedTemp matches the temporary files of data files, such as those created by createTemp
+var compressedTemp = regexp.MustCompile(`\.csv\.\d+\.tmp$`)
+
+var _ fileSystem = codecFS{}
+
+// codecFS is a fileSystem storing data files compressed by a Codec. Data files are named as
+// they are uncompressed, and are stored suffixed with the extension of the Codec. Each write
+// is stored as a separate stream, and reads decompress every stream in turn. Sizes and
+// truncation apply to the stored bytes.
+//
+// When segs is set, the last stream of each file is held as a segment, and writes are
+// compressed alongside the bytes of the segment, which is rewritten, so small writes compress
+// as well as large ones.
+type codecFS struct {
+	fileSystem
+	c    Codec
+	ext  string
+	segs *segments
+}
+
+func newCodecFS(fsys fileSystem, c Codec, segmented bool) codecFS {
+	cfs := codecFS{fileSystem: fsys, c: c, ext: c.Extension()}
+	if segmented {
+		cfs.segs = &segments{m: make(map[string]*segment)}
+	}
+
+	return cfs
+}
+
+func (fsys codecFS) Open(name string) (f file, err error) {
+	if f, err = fsys.fileSystem.Open(fsys.diskName(name)); err != nil {
+		return
+	}
+
+	return fsys.wrap(name, f), nil
+}
+
+func (fsys codecFS) OpenFile(name string, flag int, perm os.FileMode) (f file, err error) {
+	if flag&os.O_TRUNC != 0 {
+		fsys.segs.take(name)
+	}
+
+	if f, err = fsys.fileSystem.OpenFile(fsys.diskName(name), flag, perm); err != nil {
+		return
+	}
+
+	return fsys.wrap(name, f), nil
+}
+
+func (fsys codecFS) Create(name string) (f file, err error) {
+	fsys.segs.take(name)
+	if f, err = fsys.fileSystem.Create(fsys.diskName(name)); err != nil {
+		return
+	}
+
+	return fsys.wrap(name, f), nil
+}
+
+func (fsys codecFS) CreateTemp(dir, pattern string) (f file, err error) {
+	if f, err = fsys.fileSystem.CreateTemp(dir, pattern); err != nil {
+		return
+	}
+
+	return fsys.wrap(f.Name(), f), nil
+}
+
+func (fsys codecFS) Remove(name string) error {
+	fsys.segs.take(name)
+	return fsys.fileSystem.Remove(fsys.diskName(name))
+}
+
+func (fsys codecFS) Rename(oldpath, newpath string) error {
+	fsys.segs.rename(oldpath, newpath)
+	return fsys.fileSystem.Rename(fsys.diskName(oldpath), fsys.diskName(newpath))
+}
+
+func (fsys codecFS) Stat(name string) (info os.FileInfo, err error) {
+	if info, err = fsys.fileSystem.Stat(fsys.diskName(name)); err != nil {
+		return
+	}
+
+	return codecInfo{FileInfo: info, ext: fsys.ext}, nil
+}
+
+func (fsys codecFS) Chtimes(name string, atime, mtime time.Time) error {
+	return fsys.fileSystem.Chtimes(fsys.diskName(name), atime, mtime)
+}
+
+func (fsys codecFS) ReadDir(name string) (entries []os.DirEntry, err error) {
+	if entries, err = fsys.fileSystem.ReadDir(name); err != nil {
+		return
+	}
+
+	return fsys.entries(entries), nil
+}
+
+// wrap will wrap the handle of a data file, or of a temporary file of one, so its contents
+// are compressed. Directories are wrapped so their entries are named as they are uncompressed.
+func (fsys codecFS) wrap(name string, f file) file {
+	if filepath.Ext(name) == ".csv" || compressedTemp.MatchString(name) {
+		return &codecFile{f: f, name: name, c: fsys.c, ext: fsys.ext, segs: fsys.segs}
+	}
+
+	dr, ok := f.(dirReader)
+	if !ok {
+		return f
+	}
+
+	if info, err := f.Stat(); err != nil || !info.IsDir() {
+		return f
+	}
+
+	return &codecDir{file: f, dr: dr, fsys: fsys}
+}
+
+// diskName will return the name a file is stored as
+func (fsys codecFS) diskName(name string) string {
+	if filepath.Ext(name) == ".csv" {
+		return name + fsys.ext
+	}
+
+	return name
+}
+
+func (fsys codecFS) entries(entries []os.DirEntry) []os.DirEntry {
+	for i, entry := range entries {
+		if strings.HasSuffix(entry.Name(), ".csv"+fsys.ext) {
+			entries[i] = codecEntry{DirEntry: entry, ext: fsys.ext}
+		}
+	}
+
+	return entries
+}
+
+// codecFile is the handle of a compressed file
+type codecFile struct {
+	f    file
+	name string
+	c    Codec
+	ext  string
+	segs *segments
+
+	br *bufio.Reader
+	r  io.ReadCloser
+	// eof is set once every stream has been read
+	eof bool
+}
+
+func (c *codecFile) Read(p []byte) (n int, err error) {
+	if c.eof {
+		return 0, io.EOF
+	}
+
+	if c.r == nil {
+		if c.br == nil {
+			c.br = bufio.NewReader(c.f)
+		}
+
+		if _, err = c.br.Peek(1); err == io.EOF {
+			// Empty files hold no streams
+			c.eof = true
+			return 0, io.EOF
+		} else if err != nil {
+			return
+		}
+
+		if c.r, err = c.c.NewReader(c.br); err != nil {
+			return
+		}
+	}
+
+	if n, err = c.r.Read(p); err == io.EOF {
+		c.eof = true
+	}
+
+	return
+}
+
+// Write will store the provided bytes as a stream at the end of the file. When segments are
+// held, the bytes are appended to the last segment of the file, which is rewritten.
+func (c *codecFile) Write(p []byte) (n int, err error) {
+	if len(p) == 0 {
+		return
+	}
+
+	c.reset()
+	var size int64
+	if size, err = c.f.Seek(0, io.SeekEnd); err != nil {
+		return
+	}
+
+	seg := c.segs.take(c.name)
+	if seg == nil || seg.end != size || len(seg.data)+len(p) > segmentSize {
+		// The file was written elsewhere, or the segment is full, so a segment is started
+		seg = &segment{start: size, end: size, checkpoints: []checkpoint{{size: size}}}
+	}
+
+	prev := seg.data
+	if err = c.rewrite(seg, append(prev[:len(prev):len(prev)], p...)); err != nil {
+		if len(prev) > 0 {
+			// The segment is written as it was, so only the failed write is lost
+			c.rewrite(seg, prev)
+		}
+
+		return
+	}
+
+	seg.checkpoints = append(seg.checkpoints, checkpoint{size: seg.end, n: len(seg.data)})
+	if size > 0 {
+		// The first segment of a file is never rewritten, so the start of the stored file,
+		// such as the key record of an encrypted file, is kept
+		c.segs.put(c.name, seg)
+	}
+
+	return len(p), nil
+}
+
+// rewrite will replace the stored segment with the provided bytes, compressed as a stream
+func (c *codecFile) rewrite(seg *segment, data []byte) (err error) {
+	var (
+		buf bytes.Buffer
+		w   io.WriteCloser
+	)
+
+	if w, err = c.c.NewWriter(&buf); err != nil {
+		return
+	}
+
+	if _, err = w.Write(data); err != nil {
+		w.Close()
+		return
+	}
+
+	if err = w.Close(); err != nil {
+		return
+	}
+
+	if seg.end != seg.start {
+		if err = c.f.Truncate(seg.start); err != nil {
+			return
+		}
+	}
+
+	if _, err = c.f.Seek(0, io.SeekEnd); err != nil {
+		return
+	}
+
+	if _, err = c.f.Write(buf.Bytes()); err != nil {
+		return
+	}
+
+	// Files may be stored larger than the written stream, such as when encrypted
+	if seg.end, err = c.f.Seek(0, io.SeekEnd); err != nil {
+		return
+	}
+
+	seg.data = data
+	return
+}
+
+// Seek supports seeking to the start of a file to read it again, or to its end to append
+func (c *codecFile) Seek(offset int64, whence int) (pos int64, err error) {
+	switch {
+	case offset == 0 && whence == io.SeekStart:
+		c.reset()
+		return c.f.Seek(0, io.SeekStart)
+	case offset == 0 && whence == io.SeekEnd:
+		c.reset()
+		return c.f.Seek(0, io.SeekEnd)
+	default:
+		return 0, errSeekEncoded
+	}
+}
+
+// Truncate will truncate the stored bytes. The file may be truncated to a size it had before
+// its last segment was rewritten, such as when an append is rolled back, in which case the
+// segment is rewritten with the bytes it held at that size.
+func (c *codecFile) Truncate(size int64) error {
+	c.reset()
+	seg := c.segs.take(c.name)
+	switch {
+	case seg == nil, size <= seg.start, size > seg.end:
+	case size == seg.end:
+		c.segs.put(c.name, seg)
+	default:
+		for _, cp := range seg.checkpoints {
+			if cp.size == size {
+				return c.rewrite(seg, seg.data[:cp.n])
+			}
+		}
+	}
+
+	return c.f.Truncate(size)
+}
+
+func (c *codecFile) Stat() (info os.FileInfo, err error) {
+	if info, err = c.f.Stat(); err != nil {
+		return
+	}
+
+	return codecInfo{FileInfo: info, ext: c.ext}, nil
+}
+
+func (c *codecFile) Name() string { return c.name }
+
+func (c *codecFile) Sync() error { return c.f.Sync() }
+
+func (c *codecFile) Close() error {
+	c.reset()
+	return c.f.Close()
+}
+
+// reset will discard the reader, so the next read starts from the start of the file
+func (c *codecFile) reset() {
+	if c.r != nil {
+		c.r.Close()
+		c.r = nil
+	}
+
+	if c.br != nil {
+		c.br.Reset(c.f)
+	}
+
+	c.eof = false
+	c.f.Seek(0, io.SeekStart)
+}
+
+// segments holds the last segment of the files written through a codecFS
+type segments struct {
+	mux sync.Mutex
+	m   map[string]*segment
+}
+
+// segment is the last stream of a file, held alongside its uncompressed bytes
+type segment struct {
+	start int64
+	end   int64
+	data  []byte
+	// checkpoints are the sizes the file had as the segment was written
+	checkpoints []checkpoint
+}
+
+// checkpoint is a size of a file, along with the number of bytes its segment held at that size
+type checkpoint struct {
+	size int64
+	n    int
+}
+
+// take will remove and return the segment of a file, segments are put back once written
+func (s *segments) take(name string) (seg *segment) {
+	if s == nil {
+		return
+	}
+
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	seg = s.m[name]
+	delete(s.m, name)
+	return
+}
+
+// put will hold the segment of a file, dropping another segment once maxSegments are held.
+// The next write of a file whose segment was dropped starts a segment of its own.
+func (s *segments) put(name string, seg *segment) {
+	if s == nil {
+		return
+	}
+
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	for other := range s.m {
+		if len(s.m) < maxSegments {
+			break
+		}
+
+		delete(s.m, other)
+	}
+
+	s.m[name] = seg
+}
+
+func (s *segments) rename(oldpath, newpath string) {
+	if s == nil {
+		return
+	}
+
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	seg, ok := s.m[oldpath]
+	delete(s.m, oldpath)
+	delete(s.m, newpath)
+	if ok {
+		s.m[newpath] = seg
+	}
+}
+
+// codecDir is the handle of a directory whose entries are named as they are uncompressed
+type codecDir struct {
+	file
+	dr   dirReader
+	fsys codecFS
+}
+
+func (c *codecDir) ReadDir(n int) (entries []os.DirEntry, err error) {
+	entries, err = c.dr.ReadDir(n)
+	return c.fsys.entries(entries), err
+}
+
+type codecEntry struct {
+	os.DirEntry
+	ext string
+}
+
+func (e codecEntry) Name() string {
+	return strings.TrimSuffix(e.DirEntry.Name(), e.ext)
+}
+
+func (e codecEntry) Info() (info os.FileInfo, err error) {
+	if info, err = e.DirEntry.Info(); err != nil {
+		return
+	}
+
+	return codecInfo{FileInfo: info, ext: e.ext}, nil
+}
+
+// codecInfo describes a stored file by the name it has uncompressed
+type codecInfo struct {
+	os.FileInfo
+	ext string
+}
+
+func (i codecInfo) Name() string {
+	return strings.TrimSuffix(i.FileInfo.Name(), i.ext)
+}
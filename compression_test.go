@@ -0,0 +1,404 @@
+package csvdb
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestDB_Compression(t *testing.T) {
+	type testcase struct {
+		name string
+		opts func(o *Options)
+	}
+
+	tests := []testcase{
+		{name: "basic", opts: func(o *Options) {}},
+		{name: "in memory", opts: func(o *Options) { o.InMemory = true }},
+		{name: "write ahead log", opts: func(o *Options) { o.WriteAheadLog = true }},
+		{name: "atomic append", opts: func(o *Options) { o.AtomicAppend = true }},
+		{name: "max open files", opts: func(o *Options) { o.MaxOpenFiles = 2 }},
+		{name: "manifest cache", opts: func(o *Options) { o.ManifestCache = true }},
+		{name: "codec", opts: func(o *Options) { o.Compression, o.Codec = CompressionNone, xorCodec{} }},
+		{name: "codec in memory", opts: func(o *Options) { o.Compression, o.Codec, o.InMemory = CompressionNone, xorCodec{}, true }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var (
+				mux      sync.Mutex
+				exported = make(map[string][]byte)
+			)
+
+			b := &mockBackend{
+				exportFn: func(ctx context.Context, prefix, filename string, r io.Reader) (string, error) {
+					bs, err := io.ReadAll(r)
+					mux.Lock()
+					exported[filename] = bs
+					mux.Unlock()
+					return filename, err
+				},
+				importFn: func(ctx context.Context, prefix, filename string, w io.Writer) error {
+					mux.Lock()
+					bs, ok := exported[filename]
+					mux.Unlock()
+					if !ok {
+						return os.ErrNotExist
+					}
+
+					_, err := w.Write(bs)
+					return err
+				},
+			}
+
+			var opts Options
+			opts.Dir = fmt.Sprintf("test_%d", time.Now().UnixNano())
+			opts.Name = "foo"
+			opts.Compression = CompressionGzip
+			tt.opts(&opts)
+			d, err := makeDB[testentry](opts, b)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer os.RemoveAll(opts.Dir)
+
+			if err = d.Append("a", testentry{Foo: "1", Bar: "1b"}); err != nil {
+				t.Fatal(err)
+			}
+
+			if err = d.Append("a", testentry{Foo: "2", Bar: "2b"}); err != nil {
+				t.Fatal(err)
+			}
+
+			if err = d.AppendUnique("a", testentry{Foo: "2", Bar: "2b"}, testentry{Foo: "3", Bar: "3b"}); err != nil {
+				t.Fatal(err)
+			}
+
+			if err = d.Upsert("a", "foo", testentry{Foo: "1", Bar: "1c"}); err != nil {
+				t.Fatal(err)
+			}
+
+			want := "foo,bar\n1,1c\n2,2b\n3,3b\n"
+			assertGet := func(key, want string) {
+				t.Helper()
+				w := &bytes.Buffer{}
+				if err := d.Get(w, key); err != nil {
+					t.Fatal(err)
+				}
+
+				if w.String() != want {
+					t.Errorf("DB.Get(%s) = %q, want %q", key, w.String(), want)
+				}
+			}
+
+			assertGet("a", want)
+			ext := opts.codec().Extension()
+			if !opts.InMemory {
+				filename := filepath.Join(opts.Dir, "foo", "foo.a.csv"+ext)
+				f, err := os.Open(filename)
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				r, err := opts.codec().NewReader(f)
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				bs, err := io.ReadAll(r)
+				f.Close()
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				if string(bs) != want {
+					t.Errorf("stored contents = %q, want %q", bs, want)
+				}
+			}
+
+			keys, _, err := d.ListKeys("", "", 10)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if len(keys) != 1 || keys[0] != "a" {
+				t.Errorf("DB.ListKeys() = %v, want [a]", keys)
+			}
+
+			if err = d.ExportPrefix(""); err != nil {
+				t.Fatal(err)
+			}
+
+			if _, ok := exported["foo.a.csv"+ext]; !ok {
+				t.Fatalf("exported files = %v, want foo.a.csv%s", exported, ext)
+			}
+
+			if err = d.Delete("a"); err != nil {
+				t.Fatal(err)
+			}
+
+			// Downloads are decompressed and stored compressed again
+			assertGet("a", want)
+			if err = d.Append("a", testentry{Foo: "4", Bar: "4b"}); err != nil {
+				t.Fatal(err)
+			}
+
+			assertGet("a", want+"4,4b\n")
+		})
+	}
+}
+
+func TestDB_Compression_smallAppends(t *testing.T) {
+	type testcase struct {
+		name string
+		opts func(o *Options)
+		// wantRatio is the maximum size of the stored file relative to its contents
+		wantRatio float64
+	}
+
+	kp := &testKeyProvider{
+		current: KeyID{Name: "primary", Version: 1},
+		keys:    map[KeyID][]byte{{Name: "primary", Version: 1}: bytes.Repeat([]byte{1}, 32)},
+	}
+
+	tests := []testcase{
+		{name: "basic", opts: func(o *Options) {}, wantRatio: 0.25},
+		{name: "max open files", opts: func(o *Options) { o.MaxOpenFiles = 2 }, wantRatio: 0.25},
+		{name: "atomic append", opts: func(o *Options) { o.AtomicAppend = true }, wantRatio: 0.25},
+		{name: "encryption", opts: func(o *Options) { o.Encryption = kp }, wantRatio: 0.25},
+		// Each append is stored as a stream of its own
+		{name: "sync writes", opts: func(o *Options) { o.SyncWrites = true }, wantRatio: 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var opts Options
+			opts.Dir = fmt.Sprintf("test_%d", time.Now().UnixNano())
+			opts.Name = "foo"
+			opts.Compression = CompressionGzip
+			tt.opts(&opts)
+			d, err := makeDB[testentry](opts, &mockBackend{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer os.RemoveAll(opts.Dir)
+
+			want := &bytes.Buffer{}
+			want.WriteString("foo,bar\n")
+			for i := 0; i < 300; i++ {
+				e := testentry{Foo: fmt.Sprint(i), Bar: "a highly compressible value"}
+				if err = d.Append("a", e); err != nil {
+					t.Fatal(err)
+				}
+
+				fmt.Fprintf(want, "%s,%s\n", e.Foo, e.Bar)
+			}
+
+			w := &bytes.Buffer{}
+			if err = d.Get(w, "a"); err != nil {
+				t.Fatal(err)
+			}
+
+			if w.String() != want.String() {
+				t.Fatalf("DB.Get() = %d bytes, want %d bytes", w.Len(), want.Len())
+			}
+
+			info, err := os.Stat(filepath.Join(opts.Dir, "foo", "foo.a.csv.gz"))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if ratio := float64(info.Size()) / float64(want.Len()); ratio > tt.wantRatio {
+				t.Errorf("stored size = %d bytes (%.2f of %d bytes), want at most %.2f", info.Size(), ratio, want.Len(), tt.wantRatio)
+			}
+		})
+	}
+}
+
+func TestCodecFile_Truncate(t *testing.T) {
+	type testcase struct {
+		name string
+		// writes are written to the file, the file is truncated to its size ahead of the last
+		writes []string
+	}
+
+	tests := []testcase{
+		{name: "single write", writes: []string{"foo,bar\n"}},
+		{name: "first segment", writes: []string{"foo,bar\n", "1,1b\n"}},
+		{name: "rewritten segment", writes: []string{"foo,bar\n", "1,1b\n", "2,2b\n", "3,3b\n"}},
+		{name: "new segment", writes: []string{"foo,bar\n", "1,1b\n", string(bytes.Repeat([]byte("2,2b\n"), segmentSize))}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fsys := newCodecFS(newMemFS(0), GzipCodec{}, true)
+			f, err := fsys.Create("foo.a.csv")
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer f.Close()
+
+			var size int64
+			for _, w := range tt.writes {
+				info, err := f.Stat()
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				size = info.Size()
+				if _, err = f.Write([]byte(w)); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			if err = f.Truncate(size); err != nil {
+				t.Fatal(err)
+			}
+
+			if _, err = f.Seek(0, io.SeekStart); err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := io.ReadAll(f)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			want := strings.Join(tt.writes[:len(tt.writes)-1], "")
+			if string(got) != want {
+				t.Errorf("contents = %q, want %q", got, want)
+			}
+
+			// Writes following the truncation are appended to what remains
+			if _, err = f.Write([]byte("4,4b\n")); err != nil {
+				t.Fatal(err)
+			}
+
+			if _, err = f.Seek(0, io.SeekStart); err != nil {
+				t.Fatal(err)
+			}
+
+			if got, err = io.ReadAll(f); err != nil {
+				t.Fatal(err)
+			}
+
+			if want += "4,4b\n"; string(got) != want {
+				t.Errorf("contents = %q, want %q", got, want)
+			}
+		})
+	}
+}
+
+func TestOptions_Validate_compression(t *testing.T) {
+	type testcase struct {
+		name    string
+		opts    Options
+		wantErr error
+	}
+
+	tests := []testcase{
+		{
+			name: "basic",
+			opts: Options{Dir: "test", Name: "foo", Compression: CompressionGzip},
+		},
+		{
+			name:    "unknown",
+			opts:    Options{Dir: "test", Name: "foo", Compression: CompressionGzip + 1},
+			wantErr: ErrInvalidCompression,
+		},
+		{
+			name:    "row index",
+			opts:    Options{Dir: "test", Name: "foo", Compression: CompressionGzip, RowIndexInterval: 10},
+			wantErr: ErrCompressionIncompatible,
+		},
+		{
+			name:    "tail repair",
+			opts:    Options{Dir: "test", Name: "foo", Compression: CompressionGzip, TailRepair: TailRepairTruncate},
+			wantErr: ErrCompressionIncompatible,
+		},
+		{
+			name: "codec",
+			opts: Options{Dir: "test", Name: "foo", Codec: xorCodec{}},
+		},
+		{
+			name:    "codec with compression",
+			opts:    Options{Dir: "test", Name: "foo", Compression: CompressionGzip, Codec: xorCodec{}},
+			wantErr: ErrInvalidCodec,
+		},
+		{
+			name:    "codec extension",
+			opts:    Options{Dir: "test", Name: "foo", Codec: xorCodec{ext: "xor"}},
+			wantErr: ErrInvalidCodec,
+		},
+		{
+			name:    "codec with row index",
+			opts:    Options{Dir: "test", Name: "foo", Codec: xorCodec{}, RowIndexInterval: 10},
+			wantErr: ErrCompressionIncompatible,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.opts.Validate(); !errors.Is(err, tt.wantErr) {
+				t.Errorf("Options.Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// xorCodec is a Codec inverting every byte, whose streams may be concatenated
+type xorCodec struct {
+	ext string
+}
+
+func (c xorCodec) Extension() string {
+	if c.ext == "" {
+		return ".xor"
+	}
+
+	return c.ext
+}
+
+func (xorCodec) NewWriter(w io.Writer) (io.WriteCloser, error) {
+	return xorWriter{w: w}, nil
+}
+
+func (xorCodec) NewReader(r io.Reader) (io.ReadCloser, error) {
+	return io.NopCloser(xorReader{r: r}), nil
+}
+
+type xorWriter struct {
+	w io.Writer
+}
+
+func (x xorWriter) Write(p []byte) (n int, err error) {
+	bs := make([]byte, len(p))
+	for i, b := range p {
+		bs[i] = ^b
+	}
+
+	return x.w.Write(bs)
+}
+
+func (x xorWriter) Close() error { return nil }
+
+type xorReader struct {
+	r io.Reader
+}
+
+func (x xorReader) Read(p []byte) (n int, err error) {
+	n, err = x.r.Read(p)
+	for i := range p[:n] {
+		p[i] = ^p[i]
+	}
+
+	return
+}
@@ -0,0 +1,125 @@
+package csvdb
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"time"
+)
+
+// consumedExt is the extension of the marker recording how far a key has been consumed
+const consumedExt = ".consumed"
+
+// ErrInvalidOffset is returned when a negative offset is marked as consumed
+var ErrInvalidOffset = errors.New("invalid offset, cannot be negative")
+
+// consumption is how far the file of a key has been acknowledged by downstream consumers
+type consumption struct {
+	// Time is the modification time of the file up to which it has been consumed
+	Time time.Time `json:"time"`
+	// Offset is the byte offset of the file up to which it has been consumed
+	Offset int64 `json:"offset"`
+}
+
+// covers will return whether the consumption covers the entire file
+func (c consumption) covers(info os.FileInfo) bool {
+	if !c.Time.IsZero() && !c.Time.Before(info.ModTime()) {
+		return true
+	}
+
+	return c.Offset > 0 && c.Offset >= info.Size()
+}
+
+// MarkConsumed will acknowledge that downstream consumers have consumed the rows of a key
+// written up to the provided time. When Options.RequireConsumed is set, keys are only purged
+// once the time has reached the last modification of their file. Marks never move backwards.
+func (d *DB[T]) MarkConsumed(key string, upTo time.Time) (err error) {
+	return d.MarkConsumedContext(context.Background(), key, upTo)
+}
+
+// MarkConsumedContext is the context-aware variant of MarkConsumed
+func (d *DB[T]) MarkConsumedContext(ctx context.Context, key string, upTo time.Time) (err error) {
+	return d.markConsumed(ctx, key, func(c *consumption) {
+		if upTo.After(c.Time) {
+			c.Time = upTo
+		}
+	})
+}
+
+// MarkConsumedOffset will acknowledge that downstream consumers have consumed the file of a
+// key up to the provided byte offset. When Options.RequireConsumed is set, keys are only
+// purged once the offset has reached the size of their file. Marks never move backwards.
+func (d *DB[T]) MarkConsumedOffset(key string, offset int64) (err error) {
+	return d.MarkConsumedOffsetContext(context.Background(), key, offset)
+}
+
+// MarkConsumedOffsetContext is the context-aware variant of MarkConsumedOffset
+func (d *DB[T]) MarkConsumedOffsetContext(ctx context.Context, key string, offset int64) (err error) {
+	if offset < 0 {
+		return ErrInvalidOffset
+	}
+
+	return d.markConsumed(ctx, key, func(c *consumption) {
+		if offset > c.Offset {
+			c.Offset = offset
+		}
+	})
+}
+
+func (d *DB[T]) markConsumed(ctx context.Context, key string, fn func(*consumption)) (err error) {
+	var unlock func()
+	if unlock, err = d.lockKeys(ctx, key); err != nil {
+		return
+	}
+	defer unlock()
+
+	_, filename := d.getFilename(key)
+	c := d.getConsumption(filename)
+	fn(&c)
+
+	var tmp file
+	if tmp, err = createTemp(d.fs, d.o.TempDir, filename+consumedExt); err != nil {
+		return
+	}
+
+	err = json.NewEncoder(tmp).Encode(c)
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+
+	if err == nil {
+		err = d.fs.Rename(tmp.Name(), filename+consumedExt)
+	}
+
+	if err != nil {
+		d.fs.Remove(tmp.Name())
+	}
+
+	return
+}
+
+func (d *DB[T]) getConsumption(filename string) (c consumption) {
+	f, err := d.fs.Open(filename + consumedExt)
+	if err != nil {
+		return
+	}
+	defer f.Close()
+
+	if err = json.NewDecoder(f).Decode(&c); err != nil {
+		// Unreadable marks are treated as unconsumed, so data is never purged early
+		return consumption{}
+	}
+
+	return
+}
+
+// isConsumed will return whether the file of a key may be purged, which is always the case
+// unless RequireConsumed is set
+func (d *DB[T]) isConsumed(name string, info os.FileInfo) bool {
+	if !d.o.RequireConsumed {
+		return true
+	}
+
+	return d.getConsumption(d.getPath(name)).covers(info)
+}
@@ -0,0 +1,116 @@
+package csvdb
+
+import (
+	"fmt"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestDB_RequireConsumed(t *testing.T) {
+	type testcase struct {
+		name            string
+		requireConsumed bool
+		// mark is called once the key "foo" holds "foo,bar\n1,1b\n"
+		mark       func(d *DB[testentry]) error
+		wantPurged bool
+	}
+
+	tests := []testcase{
+		{
+			name:       "not required",
+			wantPurged: true,
+		},
+		{
+			name:            "unconsumed",
+			requireConsumed: true,
+			wantPurged:      false,
+		},
+		{
+			name:            "consumed by time",
+			requireConsumed: true,
+			mark: func(d *DB[testentry]) error {
+				return d.MarkConsumed("foo", time.Now())
+			},
+			wantPurged: true,
+		},
+		{
+			name:            "consumed before the last append",
+			requireConsumed: true,
+			mark: func(d *DB[testentry]) error {
+				return d.MarkConsumed("foo", time.Now().Add(-time.Hour))
+			},
+			wantPurged: false,
+		},
+		{
+			name:            "consumed by offset",
+			requireConsumed: true,
+			mark: func(d *DB[testentry]) error {
+				return d.MarkConsumedOffset("foo", int64(len("foo,bar\n1,1b\n")))
+			},
+			wantPurged: true,
+		},
+		{
+			name:            "partially consumed by offset",
+			requireConsumed: true,
+			mark: func(d *DB[testentry]) error {
+				return d.MarkConsumedOffset("foo", int64(len("foo,bar\n")))
+			},
+			wantPurged: false,
+		},
+		{
+			name:            "marks do not move backwards",
+			requireConsumed: true,
+			mark: func(d *DB[testentry]) error {
+				if err := d.MarkConsumedOffset("foo", 1024); err != nil {
+					return err
+				}
+
+				return d.MarkConsumedOffset("foo", 1)
+			},
+			wantPurged: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := fmt.Sprintf("test_%d", time.Now().UnixNano())
+			defer os.RemoveAll(dir)
+
+			var opts Options
+			opts.Dir = dir
+			opts.Name = "foo"
+			opts.FileTTL = 50 * time.Millisecond
+			opts.RequireConsumed = tt.requireConsumed
+			d, err := makeDB[testentry](opts, &mockBackend{})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if err = d.Append("foo", testentry{Foo: "1", Bar: "1b"}); err != nil {
+				t.Fatal(err)
+			}
+
+			if tt.mark != nil {
+				if err = tt.mark(&d); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			time.Sleep(100 * time.Millisecond)
+			if err = d.purge(""); err != nil {
+				t.Fatal(err)
+			}
+
+			_, filename := d.getFilename("foo")
+			_, err = os.Stat(filename)
+			if purged := os.IsNotExist(err); purged != tt.wantPurged {
+				t.Errorf("purged = %v, want %v", purged, tt.wantPurged)
+			}
+
+			if _, err = os.Stat(filename + consumedExt); tt.wantPurged && !os.IsNotExist(err) {
+				t.Errorf("consumption mark was kept after the key was purged")
+			}
+		})
+	}
+}
@@ -0,0 +1,125 @@
+package csvdb
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+var (
+	// ErrHeaderNotImplemented is returned when an existence check is requested from a Backend which does not implement Header
+	ErrHeaderNotImplemented = errors.New("backend does not implement Header")
+	// ErrContentAddressingNotSupported is returned when ContentAddressed is set for a Backend which implements neither Header nor Lister
+	ErrContentAddressingNotSupported = errors.New("content addressing requires a backend which implements Header or Lister")
+	// ErrInvalidContentRef is returned when an imported reference file does not name any contents
+	ErrInvalidContentRef = errors.New("invalid content reference")
+)
+
+// exportContent will export the contents of a file under the hash of its contents, uploading
+// them only when they do not already exist within the backend. The reference of the key is
+// then updated to point to the contents.
+func (d *DB[T]) exportContent(ctx context.Context, filename string, f file) (err error) {
+	var object string
+	if object, err = d.contentName(filename, f); err != nil {
+		return
+	}
+
+	var exists bool
+	if exists, err = d.remoteExists(ctx, d.remotePrefixOf(filename), object); err != nil {
+		return
+	}
+
+	if !exists {
+		if _, err = f.Seek(0, io.SeekStart); err != nil {
+			return
+		}
+
+		r, _ := d.exportReader(filename, f)
+		_, err = d.eb.Export(d.request(ctx, OpExport), d.remotePrefixOf(filename), object, r)
+		r.Close()
+		if err != nil {
+			return
+		}
+	}
+
+	_, err = d.eb.Export(d.request(ctx, OpExport), d.remotePrefixOf(filename), d.exportName(filename), strings.NewReader(object))
+	return
+}
+
+// contentName will return the filename the contents of a file are exported as, which is
+// the hash of the exported bytes with the extension of the file
+func (d *DB[T]) contentName(filename string, f file) (object string, err error) {
+	r, _ := d.exportReader(filename, f)
+	defer r.Close()
+
+	h := sha256.New()
+	if _, err = io.Copy(h, r); err != nil {
+		return
+	}
+
+	object = hex.EncodeToString(h.Sum(nil)) + ".csv" + d.exportExt(filename)
+
+	return
+}
+
+// remoteExists will check whether a file exists under a prefix of the backend, using Header
+// when implemented and falling back to Lister
+func (d *DB[T]) remoteExists(ctx context.Context, prefix, filename string) (exists bool, err error) {
+	if header, ok := d.eb.(Header); ok {
+		switch exists, err = header.Head(d.request(ctx, OpHead), prefix, filename); err {
+		case ErrHeaderNotImplemented:
+		default:
+			return
+		}
+	}
+
+	lister, ok := d.eb.(Lister)
+	if !ok {
+		return false, ErrContentAddressingNotSupported
+	}
+
+	var filenames []string
+	switch filenames, err = lister.List(d.request(ctx, OpList), prefix); err {
+	case nil:
+	case ErrListerNotImplemented:
+		return false, ErrContentAddressingNotSupported
+	default:
+		return
+	}
+
+	for _, name := range filenames {
+		if name == filename {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// resolveRef will import the reference file of a local file and return the name of the contents it points to
+func (d *DB[T]) resolveRef(ctx context.Context, name string) (object string, err error) {
+	var buf bytes.Buffer
+	if err = d.ib.Import(d.request(ctx, OpImport), d.remotePrefixOf(name), d.exportName(name), &buf); err != nil {
+		return
+	}
+
+	if object = strings.TrimSpace(buf.String()); len(object) == 0 || strings.ContainsAny(object, "/\\") {
+		err = fmt.Errorf("%w: <%s>", ErrInvalidContentRef, d.exportName(name))
+	}
+
+	return
+}
+
+func canCheckExistence(b Backend) (ok bool) {
+	if _, ok = b.(Header); ok {
+		return
+	}
+
+	_, ok = b.(Lister)
+	return
+}
@@ -0,0 +1,183 @@
+package csvdb
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+type mockContentBackend struct {
+	mockBackend
+
+	mux     sync.Mutex
+	remote  map[string][]byte
+	uploads map[string]int
+}
+
+func newMockContentBackend() *mockContentBackend {
+	var m mockContentBackend
+	m.remote = map[string][]byte{}
+	m.uploads = map[string]int{}
+	m.importFn = func(ctx context.Context, prefix, filename string, w io.Writer) (err error) {
+		m.mux.Lock()
+		bs, ok := m.remote[filename]
+		m.mux.Unlock()
+		if !ok {
+			return os.ErrNotExist
+		}
+
+		_, err = w.Write(bs)
+		return
+	}
+
+	m.exportFn = func(ctx context.Context, prefix, filename string, r io.Reader) (newFilename string, err error) {
+		var bs []byte
+		if bs, err = io.ReadAll(r); err != nil {
+			return
+		}
+
+		m.mux.Lock()
+		defer m.mux.Unlock()
+		m.remote[filename] = bs
+		m.uploads[filename]++
+		return filename, nil
+	}
+
+	return &m
+}
+
+type mockHeaderBackend struct {
+	*mockContentBackend
+}
+
+func (m mockHeaderBackend) Head(ctx context.Context, prefix, filename string) (exists bool, err error) {
+	m.mux.Lock()
+	defer m.mux.Unlock()
+	_, exists = m.remote[filename]
+	return
+}
+
+type mockContentListerBackend struct {
+	*mockContentBackend
+}
+
+func (m mockContentListerBackend) List(ctx context.Context, prefix string) (filenames []string, err error) {
+	m.mux.Lock()
+	defer m.mux.Unlock()
+	for filename := range m.remote {
+		filenames = append(filenames, filename)
+	}
+
+	return
+}
+
+func TestDB_ContentAddressed(t *testing.T) {
+	type testcase struct {
+		name     string
+		backend  func(*mockContentBackend) Backend
+		policies []Policy
+
+		wantErr error
+		wantExt string
+	}
+
+	tests := []testcase{
+		{
+			name:    "header",
+			backend: func(m *mockContentBackend) Backend { return mockHeaderBackend{m} },
+			wantExt: ".csv",
+		},
+		{
+			name:    "lister",
+			backend: func(m *mockContentBackend) Backend { return mockContentListerBackend{m} },
+			wantExt: ".csv",
+		},
+		{
+			name:     "compressed",
+			backend:  func(m *mockContentBackend) Backend { return mockHeaderBackend{m} },
+			policies: []Policy{{Prefix: "a", Compress: true}},
+			wantExt:  ".csv.gz",
+		},
+		{
+			name:    "no existence check",
+			backend: func(m *mockContentBackend) Backend { return m },
+			wantErr: ErrContentAddressingNotSupported,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := newMockContentBackend()
+			b := tt.backend(m)
+			dir := fmt.Sprintf("test_%d", time.Now().UnixNano())
+			defer os.RemoveAll(dir)
+
+			newReplica := func(i int) (d DB[testentry], err error) {
+				var opts Options
+				opts.Dir = fmt.Sprintf("%s/%d", dir, i)
+				opts.Name = "foo"
+				opts.ContentAddressed = true
+				opts.Policies = tt.policies
+				return makeDB[testentry](opts, b)
+			}
+
+			for i := 0; i < 3; i++ {
+				d, err := newReplica(i)
+				if err != tt.wantErr {
+					t.Fatalf("makeDB() error = %v, wantErr %v", err, tt.wantErr)
+				} else if err != nil {
+					return
+				}
+
+				if err = d.Append("a", testentry{Foo: "1", Bar: "1b"}); err != nil {
+					t.Fatal(err)
+				}
+
+				if err = d.backup(context.Background(), "", false); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			var objects []string
+			for filename, count := range m.uploads {
+				if strings.HasPrefix(filename, "foo.") {
+					continue
+				}
+
+				if count != 1 {
+					t.Errorf("uploads of <%s> = %d, want 1", filename, count)
+				}
+
+				objects = append(objects, filename)
+			}
+
+			if len(objects) != 1 || !strings.HasSuffix(objects[0], tt.wantExt) {
+				t.Fatalf("exported objects = %v, want a single object with extension %s", objects, tt.wantExt)
+			}
+
+			if got := string(m.remote["foo.a.csv.ref"]); got != objects[0] {
+				t.Fatalf("reference = %v, want %v", got, objects[0])
+			}
+
+			d, err := newReplica(3)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			w := &bytes.Buffer{}
+			if err = d.Get(w, "a"); err != nil {
+				t.Fatal(err)
+			}
+
+			if want := "foo,bar\n1,1b\n"; w.String() != want {
+				t.Fatalf("DB.Get() = %v, want %v", w.String(), want)
+			}
+		})
+	}
+}
@@ -0,0 +1,109 @@
+package csvdb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// ErrInvalidContextColumns is returned when a context column is missing its name or value func,
+// or when context columns are set alongside WriteBehind
+var ErrInvalidContextColumns = errors.New("invalid contextColumns, each requires a name and value func, and cannot be set alongside writeBehind")
+
+// ContextColumn is an extra column written on every appended row, holding a value taken from
+// the context of the append. This allows provenance, such as a request ID, to travel with the
+// data without being a field of the Entry.
+type ContextColumn struct {
+	// Name is the header of the column
+	Name string `json:"name" toml:"name"`
+	// Value will return the value of the column for the context of an append
+	Value func(ctx context.Context) string `json:"-" toml:"-"`
+}
+
+// ContextValue will return a value func for a ContextColumn which formats the value held by
+// the context for the provided key, or an empty string when it is not set
+func ContextValue(key any) func(ctx context.Context) string {
+	return func(ctx context.Context) string {
+		v := ctx.Value(key)
+		if v == nil {
+			return ""
+		}
+
+		return fmt.Sprint(v)
+	}
+}
+
+func validateContextColumns(o *Options) (err error) {
+	if len(o.ContextColumns) == 0 {
+		return
+	}
+
+	if o.WriteBehind {
+		// Entries are written after the call which enqueued them has returned
+		return ErrInvalidContextColumns
+	}
+
+	for _, c := range o.ContextColumns {
+		if c.Name == "" || c.Value == nil {
+			return ErrInvalidContextColumns
+		}
+	}
+
+	return
+}
+
+// header will return the header of the files of a key, which is the entry columns of the key
+// transformed by Options.HeaderCase followed by the context columns and the row checksum
+func (d *DB[T]) header(key string, e Entry) (header []string) {
+	keys := d.columns(key, e)
+	if len(d.o.ContextColumns) == 0 && d.o.HeaderCase == HeaderCaseNone && !d.o.RowChecksums {
+		return keys
+	}
+
+	header = make([]string, 0, len(keys)+len(d.o.ContextColumns)+1)
+	for _, k := range keys {
+		header = append(header, d.o.HeaderCase.transform(k))
+	}
+
+	for _, c := range d.o.ContextColumns {
+		header = append(header, c.Name)
+	}
+
+	if d.o.RowChecksums {
+		header = append(header, RowChecksumColumn)
+	}
+
+	return
+}
+
+// contextValues will return the values of the context columns for the context of an append
+func (d *DB[T]) contextValues(ctx context.Context) (values []string) {
+	if len(d.o.ContextColumns) == 0 {
+		return
+	}
+
+	values = make([]string, 0, len(d.o.ContextColumns))
+	for _, c := range d.o.ContextColumns {
+		values = append(values, c.Value(ctx))
+	}
+
+	return
+}
+
+// row will return the values of the entry at index i for the files of a key followed by the provided
+// extra values. ErrInvalidColumnCount is returned when the entry has a different number of values than keys.
+func (d *DB[T]) row(key string, i int, e Entry, extra []string) (values []string, err error) {
+	keys := e.Keys()
+	ev := e.Values()
+	if err = checkColumnCount(key, i, ev, keys); err != nil {
+		return
+	}
+
+	if ev = d.values(key, keys, ev); len(extra) == 0 {
+		return ev, nil
+	}
+
+	values = make([]string, 0, len(ev)+len(extra))
+	values = append(values, ev...)
+	return append(values, extra...), nil
+}
@@ -0,0 +1,169 @@
+package csvdb
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+)
+
+type requestIDKey struct{}
+
+func TestDB_ContextColumns(t *testing.T) {
+	type testcase struct {
+		name  string
+		write func(d *DB[testentry]) error
+		want  string
+	}
+
+	withID := func(id string) context.Context {
+		return context.WithValue(context.Background(), requestIDKey{}, id)
+	}
+
+	tests := []testcase{
+		{
+			name: "append",
+			write: func(d *DB[testentry]) (err error) {
+				if err = d.AppendContext(withID("r1"), "a", testentry{Foo: "1", Bar: "1b"}); err != nil {
+					return
+				}
+
+				return d.AppendContext(withID("r2"), "a", testentry{Foo: "2", Bar: "2b"})
+			},
+			want: "foo,bar,request_id\n1,1b,r1\n2,2b,r2\n",
+		},
+		{
+			name: "append without value",
+			write: func(d *DB[testentry]) (err error) {
+				return d.Append("a", testentry{Foo: "1", Bar: "1b"})
+			},
+			want: "foo,bar,request_id\n1,1b,\n",
+		},
+		{
+			name: "writer",
+			write: func(d *DB[testentry]) (err error) {
+				var ew *EntryWriter[testentry]
+				if ew, err = d.WriterContext(withID("r1"), "a"); err != nil {
+					return
+				}
+
+				if err = ew.Write(testentry{Foo: "1", Bar: "1b"}); err != nil {
+					return
+				}
+
+				return ew.Close()
+			},
+			want: "foo,bar,request_id\n1,1b,r1\n",
+		},
+		{
+			name: "upsert",
+			write: func(d *DB[testentry]) (err error) {
+				if err = d.AppendContext(withID("r1"), "a", testentry{Foo: "1", Bar: "1b"}, testentry{Foo: "2", Bar: "2b"}); err != nil {
+					return
+				}
+
+				return d.UpsertContext(withID("r2"), "a", "foo", testentry{Foo: "2", Bar: "2c"})
+			},
+			want: "foo,bar,request_id\n1,1b,r1\n2,2c,r2\n",
+		},
+		{
+			name: "append unique",
+			write: func(d *DB[testentry]) (err error) {
+				for _, id := range []string{"r1", "r1", "r2"} {
+					if err = d.AppendUniqueContext(withID(id), "a", testentry{Foo: "1", Bar: "1b"}); err != nil {
+						return
+					}
+				}
+
+				return
+			},
+			want: "foo,bar,request_id\n1,1b,r1\n1,1b,r2\n",
+		},
+		{
+			name: "update rows preserves values",
+			write: func(d *DB[testentry]) (err error) {
+				if err = d.AppendContext(withID("r1"), "a", testentry{Foo: "1", Bar: "1b"}); err != nil {
+					return
+				}
+
+				return d.UpdateRows("a", func(e testentry) (testentry, bool, error) {
+					e.Bar = "updated"
+					return e, true, nil
+				})
+			},
+			want: "foo,bar,request_id\n1,updated,r1\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var opts Options
+			opts.Dir = fmt.Sprintf("test_%d", time.Now().UnixNano())
+			opts.Name = "foo"
+			opts.ContextColumns = []ContextColumn{{Name: "request_id", Value: ContextValue(requestIDKey{})}}
+			d, err := makeDB[testentry](opts, &mockBackend{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer os.RemoveAll(opts.Dir)
+
+			if err = tt.write(&d); err != nil {
+				t.Fatal(err)
+			}
+
+			w := &bytes.Buffer{}
+			if err = d.Get(w, "a"); err != nil {
+				t.Fatal(err)
+			}
+
+			if w.String() != tt.want {
+				t.Errorf("DB.Get() = %q, want %q", w.String(), tt.want)
+			}
+		})
+	}
+}
+
+func TestOptions_Validate_contextColumns(t *testing.T) {
+	type testcase struct {
+		name        string
+		columns     []ContextColumn
+		writeBehind bool
+		wantErr     error
+	}
+
+	value := ContextValue(requestIDKey{})
+	tests := []testcase{
+		{
+			name:    "basic",
+			columns: []ContextColumn{{Name: "request_id", Value: value}},
+		},
+		{
+			name:    "missing name",
+			columns: []ContextColumn{{Value: value}},
+			wantErr: ErrInvalidContextColumns,
+		},
+		{
+			name:    "missing value",
+			columns: []ContextColumn{{Name: "request_id"}},
+			wantErr: ErrInvalidContextColumns,
+		},
+		{
+			name:        "write behind",
+			columns:     []ContextColumn{{Name: "request_id", Value: value}},
+			writeBehind: true,
+			wantErr:     ErrInvalidContextColumns,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			o := Options{Dir: "test", Name: "foo", ContextColumns: tt.columns, WriteBehind: tt.writeBehind}
+			if err := o.Validate(); !errors.Is(err, tt.wantErr) {
+				t.Errorf("Options.Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
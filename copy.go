@@ -0,0 +1,73 @@
+package csvdb
+
+import (
+	"context"
+	"io"
+	"os"
+)
+
+// CopyKey will duplicate the file of a key to a new key. The copy is written atomically and
+// is exportable regardless of whether or not the source key has been exported.
+func (d *DB[T]) CopyKey(src, dst string) (err error) {
+	return d.CopyKeyContext(context.Background(), src, dst)
+}
+
+// CopyKeyContext is the context-aware variant of CopyKey
+func (d *DB[T]) CopyKeyContext(ctx context.Context, src, dst string) (err error) {
+	var unlock func()
+	if unlock, err = d.lockKeys(ctx, src, dst); err != nil {
+		return
+	}
+	defer unlock()
+
+	if err = d.prepareWrite(ctx, src); err != nil {
+		return
+	}
+
+	if err = d.prepareWrite(ctx, dst); err != nil {
+		return
+	}
+
+	_, srcFilename := d.getFilename(src)
+	_, dstFilename := d.getFilename(dst)
+	if _, err = d.fs.Stat(dstFilename); err == nil {
+		return ErrEntryExists
+	} else if !os.IsNotExist(err) {
+		return
+	}
+
+	var f file
+	if f, err = d.fs.Open(srcFilename); os.IsNotExist(err) {
+		return ErrEntryNotFound
+	} else if err != nil {
+		return
+	}
+	defer f.Close()
+
+	var tmp file
+	if tmp, err = createTemp(d.fs, d.o.TempDir, dstFilename); err != nil {
+		return
+	}
+
+	if _, err = io.Copy(tmp, newContextReader(ctx, f)); err == nil {
+		err = tmp.Close()
+	} else {
+		tmp.Close()
+	}
+
+	if err == nil {
+		err = d.fs.Rename(tmp.Name(), dstFilename)
+	}
+
+	if err != nil {
+		d.fs.Remove(tmp.Name())
+		return
+	}
+
+	// Ensure a stale marker does not prevent the copy from being exported
+	if err = d.fs.Remove(dstFilename + ".exported"); err != nil && !os.IsNotExist(err) {
+		return
+	}
+
+	return nil
+}
@@ -0,0 +1,109 @@
+package csvdb
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestDB_CopyKey(t *testing.T) {
+	type testcase struct {
+		name     string
+		inMemory bool
+		src      string
+		dst      string
+		wantErr  error
+	}
+
+	tests := []testcase{
+		{
+			name: "basic",
+			src:  "a",
+			dst:  "experiments/a",
+		},
+		{
+			name:     "in memory",
+			inMemory: true,
+			src:      "a",
+			dst:      "c",
+		},
+		{
+			name:    "not found",
+			src:     "missing",
+			dst:     "c",
+			wantErr: ErrEntryNotFound,
+		},
+		{
+			name:    "exists",
+			src:     "a",
+			dst:     "b",
+			wantErr: ErrEntryExists,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var opts Options
+			opts.Dir = fmt.Sprintf("test_%d", time.Now().UnixNano())
+			opts.Name = "foo"
+			opts.InMemory = tt.inMemory
+			d, err := makeDB[testentry](opts, &mockBackend{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer os.RemoveAll(d.o.Dir)
+
+			for _, key := range []string{"a", "b"} {
+				if err = d.Append(key, testentry{Foo: "1", Bar: key}); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			// Ensure the export markers are newer than the files
+			time.Sleep(10 * time.Millisecond)
+			for _, key := range []string{"a", "b"} {
+				name, _ := d.getFilename(key)
+				if err = d.setLastExported(name); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			if err = d.CopyKey(tt.src, tt.dst); err != tt.wantErr {
+				t.Fatalf("DB.CopyKey() error = %v, wantErr %v", err, tt.wantErr)
+			} else if err != nil {
+				return
+			}
+
+			// Ensure the source is unaffected by writes to the copy
+			if err = d.Append(tt.dst, testentry{Foo: "2", Bar: "2b"}); err != nil {
+				t.Fatal(err)
+			}
+
+			for key, want := range map[string]string{
+				tt.src: "foo,bar\n1,a\n",
+				tt.dst: "foo,bar\n1,a\n2,2b\n",
+			} {
+				w := &bytes.Buffer{}
+				if err = d.Get(w, key); err != nil {
+					t.Fatal(err)
+				}
+
+				if w.String() != want {
+					t.Errorf("DB.Get(%s) = %q, want %q", key, w.String(), want)
+				}
+			}
+
+			var exportable []string
+			if exportable, err = d.getExportable(""); err != nil {
+				t.Fatal(err)
+			}
+
+			dstName, _ := d.getFilename(tt.dst)
+			if len(exportable) != 1 || exportable[0] != dstName {
+				t.Errorf("DB.getExportable() = %v, want [%s]", exportable, dstName)
+			}
+		})
+	}
+}
@@ -0,0 +1,73 @@
+package csvdb
+
+import (
+	"encoding/csv"
+	"errors"
+	"io"
+)
+
+// ErrInvalidCSV is returned when Options.CSV has a comment rune which is invalid or matches the delimiter
+var ErrInvalidCSV = errors.New("invalid csv, comment must be a valid rune other than the delimiter")
+
+// CSVOptions tune the reading and writing of CSV, allowing slightly malformed CSV from legacy
+// producers to be ingested. They apply to every file read and written by the DB.
+type CSVOptions struct {
+	// UseCRLF will end written rows with \r\n rather than \n
+	UseCRLF bool `json:"useCRLF" toml:"use-crlf"`
+	// LazyQuotes will allow quotes within unquoted fields, and unescaped quotes within quoted fields
+	LazyQuotes bool `json:"lazyQuotes" toml:"lazy-quotes"`
+	// TrimLeadingSpace will ignore the leading white space of fields
+	TrimLeadingSpace bool `json:"trimLeadingSpace" toml:"trim-leading-space"`
+	// Comment will skip the rows beginning with the rune when read
+	// Note: The first value of written rows must not begin with the rune, as the row is skipped when read
+	Comment rune `json:"comment" toml:"comment"`
+	// FieldsPerRecord is the number of fields expected of each row. When 0, rows must have as many
+	// fields as the header, and when negative rows may have any number of fields.
+	FieldsPerRecord int `json:"fieldsPerRecord" toml:"fields-per-record"`
+}
+
+func (c *CSVOptions) validate(delimiter rune) (err error) {
+	if c.Comment == 0 {
+		return
+	}
+
+	if delimiter == 0 {
+		delimiter = ','
+	}
+
+	if !validDelimiter(c.Comment) || c.Comment == delimiter {
+		return ErrInvalidCSV
+	}
+
+	return
+}
+
+// csvDialect is the dialect of the CSV read and written by the DB
+type csvDialect struct {
+	comma rune
+	CSVOptions
+}
+
+// dialect will return the dialect of the CSV of the DB
+func (o *Options) dialect() csvDialect {
+	return csvDialect{comma: o.Delimiter, CSVOptions: o.CSV}
+}
+
+// newCSVReader will return a csv.Reader of r using the provided dialect
+func newCSVReader(r io.Reader, dialect csvDialect) (cr *csv.Reader) {
+	cr = csv.NewReader(r)
+	cr.Comma = dialect.comma
+	cr.Comment = dialect.Comment
+	cr.LazyQuotes = dialect.LazyQuotes
+	cr.TrimLeadingSpace = dialect.TrimLeadingSpace
+	cr.FieldsPerRecord = dialect.FieldsPerRecord
+	return
+}
+
+// newCSVWriter will return a csv.Writer to w using the provided dialect
+func newCSVWriter(w io.Writer, dialect csvDialect) (cw *csv.Writer) {
+	cw = csv.NewWriter(w)
+	cw.Comma = dialect.comma
+	cw.UseCRLF = dialect.UseCRLF
+	return
+}
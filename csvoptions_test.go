@@ -0,0 +1,286 @@
+package csvdb
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDB_CSV(t *testing.T) {
+	type testcase struct {
+		name string
+		csv  CSVOptions
+		// raw is appended with AppendRaw following an appended entry
+		raw string
+		// file is written over the file of the key when set, before the rows are read
+		file string
+
+		want       string
+		wantRows   [][]string
+		wantErr    bool
+		wantRowErr bool
+	}
+
+	tests := []testcase{
+		{
+			name:     "crlf",
+			csv:      CSVOptions{UseCRLF: true},
+			raw:      "2,2b\r\n",
+			want:     "foo,bar\r\n1,1b\r\n2,2b\r\n",
+			wantRows: [][]string{{"1", "1b"}, {"2", "2b"}},
+		},
+		{
+			name:     "dirty",
+			csv:      CSVOptions{LazyQuotes: true, TrimLeadingSpace: true, Comment: '#'},
+			raw:      "# exported by a legacy producer\n2, \"2b\"\n3,3\"b\n",
+			want:     "foo,bar\n1,1b\n2,2b\n3,\"3\"\"b\"\n",
+			wantRows: [][]string{{"1", "1b"}, {"2", "2b"}, {"3", `3"b`}},
+		},
+		{
+			name:    "dirty without options",
+			raw:     "# exported by a legacy producer\n2, \"2b\"\n3,3\"b\n",
+			wantErr: true,
+		},
+		{
+			name:     "variable fields",
+			csv:      CSVOptions{FieldsPerRecord: -1},
+			file:     "foo,bar\n1,1b\n2\n",
+			want:     "foo,bar\n1,1b\n2\n",
+			wantRows: [][]string{{"1", "1b"}, {"2"}},
+		},
+		{
+			name:       "fixed fields",
+			file:       "foo,bar\n1,1b\n2\n",
+			want:       "foo,bar\n1,1b\n2\n",
+			wantRowErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var opts Options
+			opts.Dir = fmt.Sprintf("test_%d", time.Now().UnixNano())
+			opts.Name = "foo"
+			opts.CSV = tt.csv
+			d, err := makeDB[testentry](opts, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer os.RemoveAll(opts.Dir)
+
+			if err = d.Append("a", testentry{Foo: "1", Bar: "1b"}); err != nil {
+				t.Fatal(err)
+			}
+
+			if tt.raw != "" {
+				if err = d.AppendRaw("a", strings.NewReader(tt.raw)); (err != nil) != tt.wantErr {
+					t.Fatalf("DB.AppendRaw() error = %v, wantErr %v", err, tt.wantErr)
+				} else if err != nil {
+					return
+				}
+			}
+
+			if tt.file != "" {
+				if err = os.WriteFile(d.getPath("foo.a.csv"), []byte(tt.file), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			w := &bytes.Buffer{}
+			if err = d.Get(w, "a"); err != nil {
+				t.Fatal(err)
+			}
+
+			if w.String() != tt.want {
+				t.Errorf("DB.Get() = %q, want %q", w.String(), tt.want)
+			}
+
+			var rows [][]string
+			err = d.AppendWithFunc("a", func(r *Rows) (es []testentry, err error) {
+				err = r.ForEach(func(values []string) error {
+					rows = append(rows, append([]string(nil), values...))
+					return nil
+				})
+
+				return
+			})
+
+			if (err != nil) != tt.wantRowErr {
+				t.Fatalf("Rows.ForEach() error = %v, wantErr %v", err, tt.wantRowErr)
+			} else if err != nil {
+				return
+			}
+
+			if fmt.Sprint(rows) != fmt.Sprint(tt.wantRows) {
+				t.Errorf("Rows.ForEach() = %v, want %v", rows, tt.wantRows)
+			}
+		})
+	}
+}
+
+func TestOptions_Validate_csv(t *testing.T) {
+	type testcase struct {
+		name    string
+		opts    Options
+		wantErr error
+	}
+
+	tests := []testcase{
+		{
+			name: "comment",
+			opts: Options{Dir: "test", Name: "foo", CSV: CSVOptions{Comment: '#'}},
+		},
+		{
+			name:    "comment matching default delimiter",
+			opts:    Options{Dir: "test", Name: "foo", CSV: CSVOptions{Comment: ','}},
+			wantErr: ErrInvalidCSV,
+		},
+		{
+			name:    "comment matching delimiter",
+			opts:    Options{Dir: "test", Name: "foo", Delimiter: '\t', CSV: CSVOptions{Comment: '\t'}},
+			wantErr: ErrInvalidCSV,
+		},
+		{
+			name:    "newline comment",
+			opts:    Options{Dir: "test", Name: "foo", CSV: CSVOptions{Comment: '\n'}},
+			wantErr: ErrInvalidCSV,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.opts.Validate(); !errors.Is(err, tt.wantErr) {
+				t.Errorf("Options.Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestDB_CSV_raggedRows(t *testing.T) {
+	type testcase struct {
+		name string
+		// file is written as the file of key "a", key "b" holds "foo,bar\n1,1b\n"
+		file string
+		fn   func(d *DB[testentry]) error
+
+		wantMsg string
+	}
+
+	diff := func(d *DB[testentry]) error {
+		return d.Diff(io.Discard, "a", "b", "bar")
+	}
+
+	sqlite := func(d *DB[testentry]) (err error) {
+		_, err = d.ExportSQLite(context.Background(), "snapshot.sqlite", "a")
+		return
+	}
+
+	sql := func(d *DB[testentry]) error {
+		return d.GetSQL(io.Discard, "a", SQLOptions{Table: "a"})
+	}
+
+	tests := []testcase{
+		{
+			name: "upsert short row",
+			file: "foo,bar\n1,1b\n2\n",
+			fn: func(d *DB[testentry]) error {
+				return d.Upsert("a", "bar", testentry{Foo: "3", Bar: "3b"})
+			},
+			wantMsg: "row #1 of <a> has 1 values, expected 2",
+		},
+		{
+			name: "upsert long row",
+			file: "foo,bar\n1,1b,1c\n",
+			fn: func(d *DB[testentry]) error {
+				return d.Upsert("a", "bar", testentry{Foo: "3", Bar: "3b"})
+			},
+			wantMsg: "row #0 of <a> has 3 values, expected 2",
+		},
+		{
+			name:    "diff short row",
+			file:    "foo,bar\n1,1b\n2\n",
+			fn:      diff,
+			wantMsg: "row #1 of <a> has 1 values, expected 2",
+		},
+		{
+			name:    "diff long row",
+			file:    "foo,bar\n1,1b,1c\n",
+			fn:      diff,
+			wantMsg: "row #0 of <a> has 3 values, expected 2",
+		},
+		{
+			name:    "sqlite short row",
+			file:    "foo,bar\n1,1b\n2\n",
+			fn:      sqlite,
+			wantMsg: "row #1 of <a> has 1 values, expected 2",
+		},
+		{
+			name:    "sqlite long row",
+			file:    "foo,bar\n1,1b,1c\n",
+			fn:      sqlite,
+			wantMsg: "row #0 of <a> has 3 values, expected 2",
+		},
+		{
+			name:    "sql short row",
+			file:    "foo,bar\n1,1b\n2\n",
+			fn:      sql,
+			wantMsg: "row #1 has 1 values, expected 2",
+		},
+		{
+			name:    "sql long row",
+			file:    "foo,bar\n1,1b,1c\n",
+			fn:      sql,
+			wantMsg: "row #0 has 3 values, expected 2",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := &mockBackend{
+				importFn: func(ctx context.Context, prefix, filename string, w io.Writer) (err error) {
+					return os.ErrNotExist
+				},
+			}
+
+			var opts Options
+			opts.Dir = fmt.Sprintf("test_%d", time.Now().UnixNano())
+			opts.Name = "foo"
+			opts.CSV = CSVOptions{FieldsPerRecord: -1}
+			opts.SQLiteDriver = "csvdb_mock_sqlite"
+			d, err := makeDB[testentry](opts, b)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer os.RemoveAll(opts.Dir)
+
+			if err = d.Append("b", testentry{Foo: "1", Bar: "1b"}); err != nil {
+				t.Fatal(err)
+			}
+
+			if err = os.WriteFile(d.getPath("foo.a.csv"), []byte(tt.file), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			err = tt.fn(&d)
+			if !errors.Is(err, ErrInvalidColumnCount) || !strings.Contains(err.Error(), tt.wantMsg) {
+				t.Fatalf("error = %v, want %v containing %q", err, ErrInvalidColumnCount, tt.wantMsg)
+			}
+
+			// The file is left as it was
+			got, err := os.ReadFile(d.getPath("foo.a.csv"))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if string(got) != tt.file {
+				t.Errorf("file = %q, want %q", got, tt.file)
+			}
+		})
+	}
+}
@@ -2,6 +2,7 @@ package csvdb
 
 import (
 	"bufio"
+	"bytes"
 	"context"
 	"encoding/csv"
 	"errors"
@@ -11,19 +12,32 @@ import (
 	"os"
 	"path"
 	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
 var (
 	// ErrEntryNotFound is returned when a requested key does not exist
 	ErrEntryNotFound = errors.New("entry not found")
+	// ErrReadOnly is returned when a DB opened with Options.ReadOnly is modified
+	ErrReadOnly = errors.New("cannot modify a read-only DB")
 	// ErrBackendNotSet is returned when the backend is unset
 	ErrBackendNotSet = errors.New("backend not set")
 	// ErrExportIsActive is returned when a export is attempted to start while one is still running
 	ErrExportIsActive = errors.New("cannot start export as export is still active. If this error is frequent, consider increasing your ExportInterval values")
 	// ErrPurgeIsActive is returned when a purge is attempted to start while one is still running
 	ErrPurgeIsActive = errors.New("cannot start purge as purge is still active. If this error is frequent, consider increasing your PurgeInterval values")
+	// ErrBusy is returned when a lock cannot be acquired before the context is done or the
+	// LockTimeout has passed. The error also wraps the context error.
+	ErrBusy = errors.New("lock could not be acquired in time")
+	// ErrClosed is returned when the DB is used after it has been closed
+	ErrClosed = errors.New("db is closed")
+	// ErrColumnNotFound is returned when a referenced column does not exist within the header
+	ErrColumnNotFound = errors.New("column not found")
 )
 
 func New[T Entry](ctx context.Context, o Options, b Backend) (db *DB[T], err error) {
@@ -33,8 +47,36 @@ func New[T Entry](ctx context.Context, o Options, b Backend) (db *DB[T], err err
 	}
 
 	d.ctx, d.cancel = context.WithCancel(ctx)
-	go scan(d.ctx, d.asyncBackup, d.o.ExportInterval)
-	go scan(d.ctx, d.asyncPurge, d.o.PurgeInterval)
+	if d.o.WarmupOnStart {
+		if err = d.warmup(d.ctx); err != nil {
+			d.cancel()
+			return
+		}
+	}
+
+	if d.o.Registry != nil {
+		d.o.Registry.register(&d)
+	}
+
+	if d.o.ReplicaInterval > 0 {
+		d.jobs.Add(1)
+		go scan(d.ctx, &d.jobs, d.guard("refresh", d.asyncRefresh), d.o.ReplicaInterval)
+	}
+
+	if d.o.ReadOnly {
+		// Read-only DBs never export or purge
+		db = &d
+		return
+	}
+
+	d.jobs.Add(2)
+	go scan(d.ctx, &d.jobs, d.guard("export", d.asyncBackup), d.o.ExportInterval)
+	go scan(d.ctx, &d.jobs, d.guard("purge", d.asyncPurge), d.o.PurgeInterval)
+	if d.o.WriteBehind {
+		d.jobs.Add(1)
+		go scan(d.ctx, &d.jobs, d.guard("flush", d.asyncFlush), d.o.WriteBehindInterval)
+	}
+
 	db = &d
 	return
 }
@@ -48,163 +90,1099 @@ func makeDB[T Entry](o Options, b Backend) (d DB[T], err error) {
 	o.fill()
 
 	fullDir := path.Join(o.Dir, o.Name)
-	if err = os.MkdirAll(fullDir, 0744); err != nil {
+	d.fs = osFS{}
+	if o.InMemory {
+		d.fs = newMemFS(o.MaxMemory)
+	}
+
+	ib, eb := b, b
+	if o.ImportBackend != nil {
+		ib = o.ImportBackend
+	}
+
+	if o.ExportBackend != nil {
+		eb = o.ExportBackend
+	}
+
+	if o.SpillToBackend && (ib == nil || eb == nil) {
+		err = ErrBackendNotSet
+		return
+	}
+
+	if _, ok := eb.(Deleter); (o.DeleteFromBackend || o.PurgeFromBackend) && !ok {
+		err = ErrDeleterNotImplemented
 		return
 	}
 
+	if _, ok := ib.(Stater); (o.MergeStrategy == MergePreferNewer || o.ReplicaInterval > 0) && !ok {
+		err = ErrStaterNotImplemented
+		return
+	}
+
+	if o.ContentAddressed && !canCheckExistence(eb) {
+		err = ErrContentAddressingNotSupported
+		return
+	}
+
+	if err = d.fs.MkdirAll(fullDir, 0744); err != nil {
+		return
+	}
+
+	if err = checkTempDir(d.fs, o.TempDir, fullDir); err != nil {
+		return
+	}
+
+	if c, ok := o.encryption(); ok {
+		d.fs = encryptFS{fileSystem: d.fs, c: c}
+	}
+
+	if c := o.codec(); c != nil {
+		// Rewriting segments could lose synced rows on a crash, or leave journals of rows
+		// whose recorded sizes no longer match their files
+		d.fs = newCodecFS(d.fs, c, !o.SyncWrites && !o.WriteAheadLog)
+	}
+
+	if f := o.Storage.format(); f != nil {
+		d.fs = storageFS{fileSystem: d.fs, f: f, dialect: o.dialect()}
+	}
+
+	if o.ManifestCache {
+		// The manifest sees the names of files as the DB does, regardless of their encoding
+		d.manifest = newManifestFS(d.fs)
+		d.fs = d.manifest
+	}
+
+	if o.Checksums != ChecksumsOff {
+		d.fs = checksumFS{fileSystem: d.fs}
+	}
+
+	if o.Faults != nil {
+		d.fs = &faultFS{fileSystem: d.fs, f: o.Faults}
+		ib, eb = wrapFaults(ib, o.Faults), wrapFaults(eb, o.Faults)
+	}
+
+	if o.RowIndexInterval > 0 {
+		d.fs = indexFS{fileSystem: d.fs}
+	}
+
+	if o.MaxOpenFiles > 0 {
+		d.handles = newHandleCache(o.MaxOpenFiles)
+		d.fs = &handleFS{fileSystem: d.fs, c: d.handles}
+	}
+
 	d.o = o
-	d.b = b
+	d.ib = ib
+	d.eb = eb
+	d.ioOps = newThrottle(float64(o.BackgroundIOOpsPerSecond))
+	d.ioBytes = newThrottle(float64(o.BackgroundIOBytesPerSecond))
+	d.exportHolds = make(map[string]struct{})
+	d.eq.failures = make(map[string]exportFailure)
+	d.eq.skips = make(map[string]time.Time)
+	d.cp = newColumnProtector(o)
+	d.dialect = o.dialect()
+	if err = d.checkHeaderCase(); err != nil {
+		return
+	}
+
+	if err = d.makeShards(); err != nil {
+		return
+	}
+
+	if err = d.recoverJournals(); err != nil {
+		return
+	}
+
+	if err = d.loadHistory(); err != nil {
+		return
+	}
+
+	if err = d.loadQuarantined(); err != nil {
+		return
+	}
+
+	if err = d.repairTails(); err != nil {
+		return
+	}
+
+	err = d.checkIntegrity()
 	return
 }
 
 type DB[T Entry] struct {
-	mux  sync.RWMutex
-	emux sync.Mutex
-	pmux sync.Mutex
+	mux  timedMutex
+	emux timedMutex
+	pmux timedMutex
 
 	o Options
 
-	b Backend
+	// ib is the Backend files are imported from and eb the Backend they are exported to,
+	// both are the Backend provided to New unless overridden by the Options
+	ib Backend
+	eb Backend
+
+	fs fileSystem
+
+	shadow *DB[T]
+
+	// ioOps and ioBytes throttle the disk IO of background jobs, nil is unlimited
+	ioOps   *throttle
+	ioBytes *throttle
+
+	// keyLocks are held while individual keys are read or written, mux is held shared alongside them
+	keyLocks keyLocks
+
+	// flights de-duplicates the downloads of concurrent readers
+	flights flightGroup
+
+	catalog catalog
+
+	exportHolds map[string]struct{}
+	eq          exportQueue
+	// manifest is set when Options.ManifestCache is set
+	manifest *manifestFS
+	// cp applies Options.ColumnPolicies to written and read rows
+	cp columnProtector
+	// dialect is the dialect of the CSV read and written, see Options.CSV
+	dialect     csvDialect
+	quarantined map[string]struct{}
+
+	integrityIssues []IntegrityIssue
+
+	hmux    sync.Mutex
+	history map[string][]Event
+
+	// handles caches the file handles of appends, nil when MaxOpenFiles is unset
+	handles *handleCache
+
+	// recovery is set once while the DB is made
+	recovery RecoveryStats
+
+	// scheduled holds the cancel funcs of the jobs registered by Schedule
+	smux      sync.Mutex
+	scheduled map[string]func()
+
+	// queue buffers appended entries while WriteBehind is set
+	queue writeQueue[T]
+
+	// writers are the open EntryWriters of each key
+	wmux    sync.Mutex
+	writers map[string]map[*EntryWriter[T]]struct{}
+
+	closed atomic.Bool
+
+	downloads    atomic.Uint64
+	downloadTime atomic.Int64
+
+	// jobs tracks the background scanners and the jobs they have started
+	jobs sync.WaitGroup
 
 	ctx    context.Context
 	cancel func()
 }
 
 func (d *DB[T]) Get(w io.Writer, key string) (err error) {
-	// TODO: Uncomment this when we implement a thread-safe downloader.
-	// Currently, multiple readers can download the same file and cause
-	// race conditions.
-	// d.mux.RLock()
-	// defer d.mux.RUnlock()
+	return d.GetContext(d.context(), w, key)
+}
 
-	d.mux.Lock()
-	defer d.mux.Unlock()
+// GetContext is the context-aware variant of Get
+func (d *DB[T]) GetContext(ctx context.Context, w io.Writer, key string) (err error) {
+	w, flush := d.encodeOutput(w)
+	defer flush(&err)
+
+	var unlock func()
+	if unlock, err = d.rlockKey(ctx, key); err != nil {
+		return
+	}
+	defer unlock()
+	defer d.trackHydration(key)()
 
 	var f fs.File
-	if f, err = d.getOrDownload(key); err != nil {
+	if f, err = d.getOrDownload(ctx, key); err != nil {
 		return
 	}
 	defer f.Close()
-	_, err = io.Copy(w, f)
+	var r io.Reader = newContextReader(ctx, f)
+	if d.cp.reveals() {
+		rc := pipe(io.NopCloser(r), d.cp.reveal)
+		defer rc.Close()
+		r = rc
+	}
+
+	_, err = io.Copy(w, r)
 	return
 }
 
+// GetMerged will write the keys as a single file, with the header written once. Keys whose
+// header differs are handled as determined by Options.MergedHeaders.
 func (d *DB[T]) GetMerged(w io.Writer, keys ...string) (err error) {
-	// TODO: Uncomment this when we implement a thread-safe downloader.
-	// Currently, multiple readers can download the same file and cause
-	// race conditions.
-	// d.mux.RLock()
-	// defer d.mux.RUnlock()
+	return d.GetMergedContext(d.context(), w, keys...)
+}
 
-	d.mux.Lock()
-	defer d.mux.Unlock()
+// GetMergedContext is the context-aware variant of GetMerged
+func (d *DB[T]) GetMergedContext(ctx context.Context, w io.Writer, keys ...string) (err error) {
+	w, flush := d.encodeOutput(w)
+	defer flush(&err)
 
-	return d.getMergedFile(w, keys)
+	return d.getMergedFile(ctx, w, keys)
 }
 
 func (d *DB[T]) Append(key string, es ...T) (err error) {
+	return d.AppendContext(context.Background(), key, es...)
+}
+
+// AppendContext is the context-aware variant of Append
+func (d *DB[T]) AppendContext(ctx context.Context, key string, es ...T) (err error) {
 	if len(es) == 0 {
 		return
 	}
 
-	d.mux.Lock()
-	defer d.mux.Unlock()
+	if d.o.WriteBehind {
+		return d.enqueue(ctx, key, es)
+	}
+
+	var unlock func()
+	if unlock, err = d.lockKeys(ctx, key); err != nil {
+		return
+	}
+	defer unlock()
+	return d.append(ctx, key, es)
+}
+
+// AppendMany will append entries to multiple keys within a single locked pass.
+// Keys are written in lexicographical order, each file is opened once.
+func (d *DB[T]) AppendMany(m map[string][]T) (err error) {
+	return d.AppendManyContext(context.Background(), m)
+}
+
+// AppendManyContext is the context-aware variant of AppendMany
+func (d *DB[T]) AppendManyContext(ctx context.Context, m map[string][]T) (err error) {
+	if len(m) == 0 {
+		return
+	}
+
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+
+	var unlock func()
+	if unlock, err = d.lockKeys(ctx, keys...); err != nil {
+		return
+	}
+	defer unlock()
+
+	for _, key := range keys {
+		if err = d.append(ctx, key, m[key]); err != nil {
+			err = fmt.Errorf("error appending <%s>: %w", key, err)
+			return
+		}
+	}
+
+	return
+}
+
+func (d *DB[T]) AppendWithFunc(key string, fn func(*Rows) ([]T, error)) (err error) {
+	return d.AppendWithFuncContext(context.Background(), key, fn)
+}
+
+// AppendWithFuncContext is the context-aware variant of AppendWithFunc
+func (d *DB[T]) AppendWithFuncContext(ctx context.Context, key string, fn func(*Rows) ([]T, error)) (err error) {
+	var unlock func()
+	if unlock, err = d.lockKeys(ctx, key); err != nil {
+		return
+	}
+	defer unlock()
+
+	if err = d.prepareWrite(ctx, key); err != nil {
+		return
+	}
+
+	if err = d.checkQuota(key); err != nil {
+		return
+	}
 
 	var (
-		f        *os.File
+		f        file
 		filename string
 	)
 
 	_, filename = d.getFilename(key)
-	if f, err = getOrCreate(filename); err != nil {
+	created := d.isNewFile(filename)
+	if f, err = getOrCreate(d.fs, filename); err != nil {
 		return
 	}
 	defer f.Close()
-	return d.writeEntries(f, es)
+
+	var es []T
+	r := makeRows(f, d.dialect)
+	if es, err = fn(&r); err != nil {
+		return
+	}
+
+	if d.o.AtomicAppend {
+		err = d.writeEntriesAtomic(key, filename, es, d.contextValues(ctx))
+	} else {
+		err = d.writeEntries(key, f, es, d.contextValues(ctx))
+	}
+
+	if err != nil {
+		return
+	}
+
+	d.updateRowIndex(filename)
+	d.updateChecksum(filename)
+	d.shadowAppend(key, es)
+	d.recordAppend(key, created, len(es))
+	return
 }
 
-func (d *DB[T]) AppendWithFunc(key string, fn func(*Rows) ([]T, error)) (err error) {
-	d.mux.Lock()
-	defer d.mux.Unlock()
+// AppendUnique will append the entries whose values are not already present within the key.
+// Duplicate entries within the provided entries are also only appended once. Entries are
+// compared as the rows they are written as, in the columns of the header override of the key
+// and including the values of any context columns. Hashed columns are compared by their hash,
+// while encrypted columns are compared by their decrypted values.
+func (d *DB[T]) AppendUnique(key string, es ...T) (err error) {
+	return d.AppendUniqueContext(context.Background(), key, es...)
+}
+
+// AppendUniqueContext is the context-aware variant of AppendUnique
+func (d *DB[T]) AppendUniqueContext(ctx context.Context, key string, es ...T) (err error) {
+	if len(es) == 0 {
+		return
+	}
+
+	extra := d.contextValues(ctx)
+	cs := d.cp.columns(d.header(key, es[0]))
+	return d.AppendWithFuncContext(ctx, key, func(r *Rows) (unique []T, err error) {
+		seen := make(map[string]struct{})
+		if err = r.ForEach(func(values []string) (err error) {
+			if err = d.cp.open(cs, values, false); err != nil {
+				return
+			}
+
+			seen[rowKey(values)] = struct{}{}
+			return
+		}); err != nil {
+			return
+		}
+
+		for i, e := range es {
+			var values []string
+			if values, err = d.row(key, i, e, extra); err != nil {
+				return
+			}
+
+			if values, err = d.cp.protect(hashed(cs), values); err != nil {
+				return
+			}
+
+			k := rowKey(values)
+			if _, ok := seen[k]; ok {
+				continue
+			}
+
+			seen[k] = struct{}{}
+			unique = append(unique, e)
+		}
+
+		return
+	})
+}
+
+// Upsert will replace the existing rows whose primary key column value matches one of
+// the provided entries, and will append the entries which do not match any existing rows.
+// When several existing rows share a matching primary key, the first is replaced and the
+// others are removed, so the entry is held once. When several entries share a primary key,
+// the last is used. The file is rewritten atomically.
+func (d *DB[T]) Upsert(key, pkColumn string, es ...T) (err error) {
+	return d.UpsertContext(context.Background(), key, pkColumn, es...)
+}
+
+// UpsertContext is the context-aware variant of Upsert
+func (d *DB[T]) UpsertContext(ctx context.Context, key, pkColumn string, es ...T) (err error) {
+	if len(es) == 0 {
+		return
+	}
+
+	var unlock func()
+	if unlock, err = d.lockKeys(ctx, key); err != nil {
+		return
+	}
+	defer unlock()
+
+	if err = d.prepareWrite(ctx, key); err != nil {
+		return
+	}
+
+	_, filename := d.getFilename(key)
+	err = rewriteFile(ctx, d.fs, d.o.TempDir, d.dialect, filename, func(header []string, r *csv.Reader, w *csv.Writer) (err error) {
+		if header == nil {
+			header = d.header(key, es[0])
+		}
+
+		pkIndex := indexOf(header, pkColumn)
+		if pkIndex == -1 {
+			return fmt.Errorf("error upserting <%s>: %w <%s>", key, ErrColumnNotFound, pkColumn)
+		}
+
+		// Primary keys are compared as AppendUnique compares values: hashed primary keys are
+		// compared by their hash, while encrypted primary keys are compared by their decrypted
+		// values, as their encryption is not deterministic
+		extra := d.contextValues(ctx)
+		cs := d.cp.columns(header)
+		pkcs := at(cs, pkIndex)
+		pending := make(map[string][]string, len(es))
+		order := make([]string, 0, len(es))
+		for i, e := range es {
+			var values, compared []string
+			if values, err = d.row(key, i, e, extra); err != nil {
+				return
+			}
+
+			if compared, err = d.cp.protect(hashed(pkcs), values); err != nil {
+				return
+			}
+
+			if values, err = d.cp.protect(cs, values); err != nil {
+				return
+			}
+
+			values = d.seal(values)
+			if err = checkColumnCount(key, i, values, header); err != nil {
+				return
+			}
+
+			pk := compared[pkIndex]
+			if _, ok := pending[pk]; !ok {
+				order = append(order, pk)
+			}
+
+			pending[pk] = values
+		}
+
+		if err = w.Write(header); err != nil {
+			return
+		}
+
+		var values []string
+		replaced := make(map[string]struct{}, len(pending))
+		for i := 0; ; i++ {
+			if values, err = r.Read(); err == io.EOF {
+				break
+			} else if err != nil {
+				return
+			}
+
+			// Rows may be ragged when CSV.FieldsPerRecord is negative
+			if err = checkColumnCount(key, i, values, header); err != nil {
+				return
+			}
+
+			pk := values[pkIndex]
+			if len(pkcs) > 0 {
+				opened := append([]string(nil), values...)
+				if err = d.cp.open(pkcs, opened, false); err != nil {
+					return
+				}
+
+				pk = opened[pkIndex]
+			}
+
+			if _, ok := replaced[pk]; ok {
+				// Only the first row of the primary key holds the replacement
+				continue
+			}
+
+			if replacement, ok := pending[pk]; ok {
+				values = replacement
+				delete(pending, pk)
+				replaced[pk] = struct{}{}
+			}
+
+			if err = w.Write(values); err != nil {
+				return
+			}
+		}
+
+		for _, pk := range order {
+			values, ok := pending[pk]
+			if !ok {
+				continue
+			}
+
+			if err = w.Write(values); err != nil {
+				return
+			}
+		}
+
+		return nil
+	})
+
+	if err == nil {
+		d.record(key, Event{Type: EventRewritten})
+	}
+
+	return
+}
+
+// UpdateRows will stream the rows of a key through the provided func and atomically
+// rewrite the file with the results. Returning false from the func will remove the row.
+// Note: T must implement Unmarshaler
+func (d *DB[T]) UpdateRows(key string, fn func(T) (T, bool, error)) (err error) {
+	return d.UpdateRowsContext(context.Background(), key, fn)
+}
+
+// UpdateRowsContext is the context-aware variant of UpdateRows
+func (d *DB[T]) UpdateRowsContext(ctx context.Context, key string, fn func(T) (T, bool, error)) (err error) {
+	var unlock func()
+	if unlock, err = d.lockKeys(ctx, key); err != nil {
+		return
+	}
+	defer unlock()
+
+	if err = d.prepareWrite(ctx, key); err != nil {
+		return
+	}
+
+	_, filename := d.getFilename(key)
+	err = rewriteFile(ctx, d.fs, d.o.TempDir, d.dialect, filename, func(header []string, r *csv.Reader, w *csv.Writer) (err error) {
+		if header == nil {
+			return ErrEntryNotFound
+		}
+
+		if err = w.Write(header); err != nil {
+			return
+		}
+
+		cs := d.cp.columns(header)
+		keys := d.entryKeys(header)
+		checksummed := rowChecksummed(header)
+		if checksummed {
+			keys = keys[:len(keys)-1]
+		}
+
+		var values []string
+		for i := 0; ; i++ {
+			if values, err = r.Read(); err == io.EOF {
+				return nil
+			} else if err != nil {
+				return
+			}
+
+			var (
+				e    T
+				keep bool
+			)
+
+			if err = checkColumnCount(key, i, values, header); err != nil {
+				return
+			}
+
+			if checksummed {
+				if values, err = openRow(values); err != nil {
+					return fmt.Errorf("error reading row #%d of <%s>: %w", i, key, err)
+				}
+			}
+
+			// Encrypted values are provided to the func decrypted
+			stored := append([]string(nil), values...)
+			if err = d.cp.open(cs, values, false); err != nil {
+				return
+			}
+
+			if e, err = unmarshalEntry[T](keys, values); err != nil {
+				return
+			}
+
+			if e, keep, err = fn(e); err != nil {
+				return
+			} else if !keep {
+				continue
+			}
+
+			// Values beyond those of the entry, such as context columns, are preserved
+			opened := values
+			if values, err = d.row(key, i, e, opened[min(len(d.columns(key, e)), len(opened)):]); err != nil {
+				return
+			}
+
+			// Values left unchanged are kept as stored, so hashed values are not hashed again
+			if values, err = d.cp.reprotect(cs, values, stored, opened); err != nil {
+				return
+			}
+
+			if checksummed {
+				values = append(values[:len(values):len(values)], rowChecksum(values))
+			}
+
+			if err = checkColumnCount(key, i, values, header); err != nil {
+				return
+			}
+
+			if err = w.Write(values); err != nil {
+				return
+			}
+		}
+	})
+
+	if err == nil {
+		d.record(key, Event{Type: EventRewritten})
+	}
+
+	return
+}
+
+// DeleteRows will atomically rewrite the file of a key without the rows matching the provided func.
+// The header is preserved. Encrypted values are provided to the func decrypted, and rows are
+// verified against their checksum when Options.RowChecksums is set, then provided without it.
+func (d *DB[T]) DeleteRows(key string, fn func(values []string) bool) (err error) {
+	return d.DeleteRowsContext(context.Background(), key, fn)
+}
+
+// DeleteRowsContext is the context-aware variant of DeleteRows
+func (d *DB[T]) DeleteRowsContext(ctx context.Context, key string, fn func(values []string) bool) (err error) {
+	var unlock func()
+	if unlock, err = d.lockKeys(ctx, key); err != nil {
+		return
+	}
+	defer unlock()
+
+	if err = d.prepareWrite(ctx, key); err != nil {
+		return
+	}
+
+	_, filename := d.getFilename(key)
+	err = rewriteFile(ctx, d.fs, d.o.TempDir, d.dialect, filename, func(header []string, r *csv.Reader, w *csv.Writer) (err error) {
+		if header == nil {
+			return ErrEntryNotFound
+		}
+
+		if err = w.Write(header); err != nil {
+			return
+		}
+
+		cs := d.cp.columns(header)
+		checksummed := rowChecksummed(header)
+		var values []string
+		for i := 0; ; i++ {
+			if values, err = r.Read(); err == io.EOF {
+				return nil
+			} else if err != nil {
+				return
+			}
+
+			// Rows may be ragged when CSV.FieldsPerRecord is negative
+			if err = checkColumnCount(key, i, values, header); err != nil {
+				return
+			}
+
+			// Rows are kept as they are stored, rather than as they are provided to the func
+			opened := values
+			if checksummed {
+				if opened, err = openRow(values); err != nil {
+					return fmt.Errorf("error reading row #%d of <%s>: %w", i, key, err)
+				}
+			}
+
+			if len(cs) > 0 {
+				opened = append([]string(nil), opened...)
+				if err = d.cp.open(cs, opened, false); err != nil {
+					return
+				}
+			}
+
+			if fn(opened) {
+				continue
+			}
+
+			if err = w.Write(values); err != nil {
+				return
+			}
+		}
+	})
+
+	if err == nil {
+		d.record(key, Event{Type: EventRewritten})
+	}
+
+	return
+}
+
+// Truncate will remove all the rows of a key while preserving the header row
+func (d *DB[T]) Truncate(key string) (err error) {
+	return d.TruncateContext(context.Background(), key)
+}
+
+// TruncateContext is the context-aware variant of Truncate
+func (d *DB[T]) TruncateContext(ctx context.Context, key string) (err error) {
+	var unlock func()
+	if unlock, err = d.lockKeys(ctx, key); err != nil {
+		return
+	}
+	defer unlock()
+
+	if err = d.prepareWrite(ctx, key); err != nil {
+		return
+	}
+
+	_, filename := d.getFilename(key)
+	err = rewriteFile(ctx, d.fs, d.o.TempDir, d.dialect, filename, func(header []string, r *csv.Reader, w *csv.Writer) (err error) {
+		if header == nil {
+			return ErrEntryNotFound
+		}
+
+		return w.Write(header)
+	})
+
+	if err == nil {
+		d.record(key, Event{Type: EventRewritten})
+	}
+
+	return
+}
+
+// Delete will remove the file of a key along with its export marker. When DeleteFromBackend
+// is set, the exported file of the key is also deleted from the Backend.
+func (d *DB[T]) Delete(key string) (err error) {
+	return d.DeleteContext(context.Background(), key)
+}
+
+// DeleteContext is the context-aware variant of Delete
+func (d *DB[T]) DeleteContext(ctx context.Context, key string) (err error) {
+	if err = ctx.Err(); err != nil {
+		return
+	}
+
+	// Prevent exports from reading the file while it is being removed
+	if err = lockContext(ctx, &d.emux); err != nil {
+		return
+	}
+	defer d.emux.Unlock()
+
+	var unlock func()
+	if unlock, err = d.lockKeys(ctx, key); err != nil {
+		return
+	}
+	defer unlock()
+
+	if err = d.checkHold(key); err != nil {
+		return
+	}
+
+	return d.deleteKey(ctx, key)
+}
+
+// deleteKey will remove the file of a key along with its side files, and its exported file
+// when DeleteFromBackend is set. Must be called while the export lock and the lock of the
+// key are held.
+func (d *DB[T]) deleteKey(ctx context.Context, key string) (err error) {
+	name, filename := d.getFilename(key)
+	if d.o.DeleteFromBackend {
+		// Backend is verified to implement Deleter when the DB is created
+		err = d.eb.(Deleter).Delete(d.request(withKey(ctx, key), OpDelete), d.remotePrefix(key), d.exportName(name))
+		if err != nil && !os.IsNotExist(err) {
+			return
+		}
+
+		if err = d.deleteChecksum(ctx, d.eb.(Deleter), name); err != nil {
+			return
+		}
+
+		d.catalog.remove(key)
+	}
+
+	if err = d.fs.Remove(filename); os.IsNotExist(err) && d.o.DeleteFromBackend {
+		// Keys which have been spilled are only held by the backend
+		err = nil
+	} else if err != nil {
+		return
+	}
+
+	if err = d.fs.Remove(filename + ".exported"); err != nil && !os.IsNotExist(err) {
+		return
+	}
+
+	if err = d.fs.Remove(filename + consumedExt); err != nil && !os.IsNotExist(err) {
+		return
+	}
+
+	d.record(key, Event{Type: EventDeleted})
+	return nil
+}
+
+func (d *DB[T]) Close() (err error) {
+	return d.CloseContext(context.Background())
+}
+
+// CloseContext will stop the background jobs, wait for any in-flight export or purge
+// to finish and perform a final export, returning the joined errors of every key which
+// failed to export. When the context is done before the background
+// jobs have drained, the final export is skipped and the context error is returned.
+// Entries enqueued while WriteBehind is set are flushed first, when the flush fails the
+// DB is left open so it may be retried by calling Flush.
+func (d *DB[T]) CloseContext(ctx context.Context) (err error) {
+	if err = d.closeQueue(ctx); err != nil {
+		return
+	}
+
+	if err = d.lock(ctx); err != nil {
+		return
+	}
+
+	d.closed.Store(true)
+	d.mux.Unlock()
+	if d.o.Registry != nil {
+		d.o.Registry.unregister(d)
+	}
+
+	if d.handles != nil {
+		// No appends are in-flight, as the lock was acquired exclusively
+		d.handles.closeAll()
+	}
+
+	if d.cancel != nil {
+		d.cancel()
+	}
+
+	drained := make(chan struct{})
+	go func() {
+		d.jobs.Wait()
+		close(drained)
+	}()
+
+	select {
+	case <-drained:
+	case <-ctx.Done():
+		return fmt.Errorf("error waiting for background jobs: %w", ctx.Err())
+	}
+
+	if d.o.ReadOnly {
+		return
+	}
+
+	d.emux.Lock()
+	defer d.emux.Unlock()
+
+	var exportable []string
+	if exportable, err = d.getExportable(""); err != nil {
+		return
+	}
+
+	// Unlike the periodic backup, the final export continues past failing keys
+	var errs []error
+	for _, name := range exportable {
+		if err = d.export(ctx, name); err != nil {
+			errs = append(errs, fmt.Errorf("error exporting <%s>: %w", name, err))
+		}
+	}
+
+	if err = d.publishCatalog(ctx); err != nil {
+		errs = append(errs, fmt.Errorf("error publishing catalog: %w", err))
+	}
+
+	return errors.Join(errs...)
+}
+
+func (d *DB[T]) append(ctx context.Context, key string, es []T) (err error) {
+	if len(es) == 0 {
+		return
+	}
+
+	if err = d.prepareWrite(ctx, key); err != nil {
+		return
+	}
+
+	if err = d.checkQuota(key); err != nil {
+		return
+	}
 
 	var (
-		f        *os.File
+		f        file
 		filename string
 	)
 
-	_, filename = d.getFilename(key)
-	if f, err = getOrCreate(filename); err != nil {
+	_, filename = d.getFilename(key)
+	created := d.isNewFile(filename)
+	if d.o.AtomicAppend {
+		err = d.writeEntriesAtomic(key, filename, es, d.contextValues(ctx))
+	} else if f, err = d.openAppend(filename); err == nil {
+		err = d.writeEntries(key, f, es, d.contextValues(ctx))
+		d.releaseAppend(filename, f, err)
+	}
+
+	if err != nil {
+		return
+	}
+
+	d.updateRowIndex(filename)
+	d.updateChecksum(filename)
+	d.shadowAppend(key, es)
+	d.recordAppend(key, created, len(es))
+	return
+}
+
+func (d *DB[T]) getOrDownload(ctx context.Context, key string) (f file, err error) {
+	if err = d.checkQuarantine(key); err != nil {
+		return
+	}
+
+	name, filename := d.getFilename(key)
+	// Concurrent readers of a file share a single download and header repair
+	if err = d.flights.do(ctx, filename, func() error {
+		return d.prepareRead(ctx, name, filename)
+	}); err != nil {
+		return
+	}
+
+	return d.fs.Open(filename)
+}
+
+// prepareRead will download a file when it is not held locally and repair its header when
+// RepairHeaders is set. Must be called through the flight group of the file.
+func (d *DB[T]) prepareRead(ctx context.Context, name, filename string) (err error) {
+	switch _, err = d.fs.Stat(filename); {
+	case err == nil:
+		if d.o.Checksums != ChecksumsOpen {
+			break
+		}
+
+		if err = d.verifyChecksum(filename); err != nil {
+			return
+		}
+	case os.IsNotExist(err):
+		if err = d.attemptDownload(ctx, name, filename); err != nil {
+			return
+		}
+
+		d.updateChecksum(filename)
+	default:
 		return
 	}
-	defer f.Close()
 
-	var es []T
-	r := makeRows(f)
-	if es, err = fn(&r); err != nil {
+	if !d.o.RepairHeaders {
 		return
 	}
 
-	return d.writeEntries(f, es)
+	_, err = d.repairHeader(d.getKey(name), filename)
+	return
 }
 
-func (d *DB[T]) Delete(key string) (err error) {
-	_, filename := d.getFilename(key)
-	return os.Remove(filename)
+// lock will acquire the lock of the DB, returning ErrClosed once the DB has been closed
+func (d *DB[T]) lock(ctx context.Context) (err error) {
+	return d.lockWith(ctx, &d.mux)
 }
 
-func (d *DB[T]) Close() (err error) {
-	d.cancel()
-	return d.backup()
+// lockWith will acquire the provided locks in order, releasing any acquired locks on failure
+func (d *DB[T]) lockWith(ctx context.Context, lockers ...tryLocker) (err error) {
+	if d.closed.Load() {
+		// Checked prior to the context, which is cancelled along with the DB
+		return ErrClosed
+	}
+
+	if d.o.LockTimeout > 0 {
+		var cancel func()
+		ctx, cancel = context.WithTimeout(ctx, d.o.LockTimeout)
+		defer cancel()
+	}
+
+	for i, mux := range lockers {
+		if err = lockContext(ctx, mux); err != nil {
+			unlockAll(lockers[:i])
+			return
+		}
+	}
+
+	if d.closed.Load() {
+		unlockAll(lockers)
+		return ErrClosed
+	}
+
+	return
 }
 
-func (d *DB[T]) getOrDownload(key string) (f fs.File, err error) {
-	name, filename := d.getFilename(key)
-	f, err = os.Open(filename)
-	switch {
-	case err == nil:
-		return
-	case os.IsNotExist(err):
-		return d.attemptDownload(name, filename)
-	default:
-		return
+// context will return the context the DB was created with, downloads made by
+// methods which do not accept a context are cancelled along with it
+func (d *DB[T]) context() context.Context {
+	if d.ctx == nil {
+		return context.Background()
 	}
+
+	return d.ctx
 }
 
 func (d *DB[T]) getFilename(key string) (name, filename string) {
-	name = fmt.Sprintf("%s.%s.csv", d.o.Name, key)
-	filename = path.Join(d.getFullPath(), name)
+	name = fmt.Sprintf("%s.%s.csv", d.o.Name, d.o.LayoutVersion.escape(key))
+	filename = d.getPath(name)
 	return
 }
 
+func (d *DB[T]) getKey(filename string) (key string) {
+	key = strings.TrimPrefix(filename, d.o.Name+".")
+	return d.o.LayoutVersion.unescape(strings.TrimSuffix(key, ".csv"))
+}
+
 func (d *DB[T]) getFullPath() (fullPath string) {
 	return path.Join(d.o.Dir, d.o.Name)
 }
 
-func (d *DB[T]) writeHeader(w *csv.Writer, created bool, e Entry) (err error) {
+func (d *DB[T]) writeHeader(w *csv.Writer, created bool, key string, e Entry) (err error) {
 	if !created {
 		return
 	}
 
-	return w.Write(e.Keys())
+	return w.Write(d.header(key, e))
 }
 
-func (d *DB[T]) getMergedFile(w io.Writer, keys []string) (err error) {
-	var headerWritten bool
-	for _, key := range keys {
-		var ok bool
-		if ok, err = d.appendFile(w, !headerWritten, key); err != nil {
+func (d *DB[T]) getMergedFile(ctx context.Context, w io.Writer, keys []string) (err error) {
+	var union []string
+	if d.o.MergedHeaders == MergedHeadersUnion {
+		if union, err = d.unionHeader(ctx, keys); err != nil {
+			return
+		}
+	}
+
+	p := d.prefetchAll(ctx, keys)
+	defer p.close()
+
+	// header is the header written, once the first key is appended
+	var header []string
+	for i, key := range keys {
+		var f fetched
+		if f, err = p.next(ctx, i); err != nil {
 			return
-		} else if ok {
-			headerWritten = true
+		} else if f.skip {
+			continue
+		}
+
+		var h []string
+		if h, err = d.appendFile(ctx, w, header, union, key, f.release); err != nil {
+			return
+		} else if h != nil && header == nil {
+			header = h
 		}
 	}
 
 	return
 }
 
-func (d *DB[T]) appendFile(w io.Writer, writeHeader bool, key string) (ok bool, err error) {
+// appendFile will acquire the lock of the key for reading while it is appended, returning
+// the header written. The header of the key must match the provided header unless union is
+// set, and is only written when the provided header is nil. The hydrated func releases a
+// download made by prefetch and is called while the lock is held.
+func (d *DB[T]) appendFile(ctx context.Context, w io.Writer, header, union []string, key string, hydrated func()) (written []string, err error) {
+	var unlock func()
+	if unlock, err = d.rlockKey(ctx, key); err != nil {
+		d.unhydrate(key, hydrated)
+		return
+	}
+	defer unlock()
+	defer hydrated()
+	defer d.trackHydration(key)()
+
 	var f fs.File
-	f, err = d.getOrDownload(key)
+	f, err = d.getOrDownload(ctx, key)
 	switch err {
 	case nil:
 	case ErrEntryNotFound:
@@ -213,60 +1191,117 @@ func (d *DB[T]) appendFile(w io.Writer, writeHeader bool, key string) (ok bool,
 	case ErrBackendNotSet:
 		err = nil
 		return
+	case ErrEntryQuarantined:
+		err = nil
+		return
 	default:
 		return
 	}
+	defer f.Close()
+
+	fbuf := bufio.NewReader(newContextReader(ctx, f))
+	var line []byte
+	if line, err = fbuf.ReadBytes('\n'); err == io.EOF && len(line) == 0 {
+		// Empty files have no header to merge
+		return nil, nil
+	} else if err != nil && err != io.EOF {
+		return
+	}
+
+	var h []string
+	if h, err = newCSVReader(bytes.NewReader(line), d.dialect).Read(); err != nil {
+		err = fmt.Errorf("error reading header of <%s>: %v", key, err)
+		return
+	}
+
+	if union != nil {
+		if header == nil {
+			cw := newCSVWriter(w, d.dialect)
+			if err = cw.Write(union); err != nil {
+				return
+			}
+
+			cw.Flush()
+			if err = cw.Error(); err != nil {
+				return
+			}
+		}
+
+		if err = copyUnion(w, fbuf, key, h, union, d.dialect); err != nil {
+			return
+		}
 
-	fbuf := bufio.NewReader(f)
-	if !writeHeader {
-		if _, _, err = fbuf.ReadLine(); err != nil {
+		return union, nil
+	}
+
+	switch {
+	case header == nil:
+		if _, err = w.Write(line); err != nil {
 			return
 		}
+	case !reflect.DeepEqual(h, header):
+		return nil, &HeaderMismatchError{Key: key, Header: h, Want: header}
 	}
 
 	if _, err = io.Copy(w, fbuf); err != nil {
 		return
 	}
 
-	ok = true
-	return
+	return h, nil
 }
 
-func (d *DB[T]) attemptDownload(name, filename string) (f *os.File, err error) {
-	if d.b == nil {
+// attemptDownload will download a file into a temporary file which is moved into place
+// once complete, so concurrent readers never observe a partial download
+func (d *DB[T]) attemptDownload(ctx context.Context, name, filename string) (err error) {
+	if d.ib == nil {
 		err = ErrBackendNotSet
 		return
 	}
 
-	if f, err = os.Create(filename); err != nil {
+	var info RemoteInfo
+	switch info, err = d.statRemote(ctx, name); {
+	case err == ErrStaterNotImplemented:
+	case err != nil:
 		return
+	case !info.Exists:
+		return ErrEntryNotFound
 	}
 
-	if err = d.b.Import(context.Background(), d.o.Name, name, f); err == nil {
-		_, err = f.Seek(0, 0)
+	var tmp file
+	if tmp, err = createTemp(d.fs, d.o.TempDir, filename); err != nil {
 		return
 	}
 
+	start := time.Now()
+	err = d.importFile(ctx, name, tmp)
+	d.downloads.Add(1)
+	d.downloadTime.Add(int64(time.Since(start)))
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+
+	if err == nil {
+		if err = d.fs.Rename(tmp.Name(), filename); err == nil {
+			return
+		}
+	}
+
 	d.o.Logger.Printf("error downloading <%s>: %v\n", filename, err)
 
 	if os.IsNotExist(err) {
 		err = ErrEntryNotFound
 	}
 
-	if err := f.Close(); err != nil {
-		fmt.Printf("csvdb.attemptDownload(): error closing empty file: %v\n", err)
-	}
-
-	if err := os.Remove(filename); err != nil {
-		fmt.Printf("csvdb.attemptDownload(): error purging empty file: %v\n", err)
+	if err := d.fs.Remove(tmp.Name()); err != nil && !os.IsNotExist(err) {
+		fmt.Printf("csvdb.attemptDownload(): error purging temporary file: %v\n", err)
 	}
 
 	return
 }
 
-func (d *DB[T]) exportAll(exportable []string) (err error) {
+func (d *DB[T]) exportAll(ctx context.Context, exportable []string) (err error) {
 	for _, name := range exportable {
-		if err = d.export(name); err != nil {
+		if err = d.export(ctx, name); err != nil {
 			err = fmt.Errorf("error exporting <%s>: %v", name, err)
 			return
 		}
@@ -275,28 +1310,73 @@ func (d *DB[T]) exportAll(exportable []string) (err error) {
 	return
 }
 
-func (d *DB[T]) export(filename string) (err error) {
-	if d.b == nil {
+func (d *DB[T]) export(ctx context.Context, filename string) (err error) {
+	defer func() { d.recordExport(filename, err) }()
+	ctx = withKey(ctx, d.getKey(filename))
+	if d.eb == nil {
 		err = ErrBackendNotSet
 		return
 	}
 
-	var f *os.File
-	filepath := path.Join(d.getFullPath(), filename)
-	if f, err = os.Open(filepath); err != nil {
+	if err = d.ioOps.wait(ctx, 1); err != nil {
+		return
+	}
+
+	// Modifications made after the sequence is read are exported by a later pass
+	seq := d.sequence(filename)
+	var f file
+	filepath := d.getPath(filename)
+	if f, err = d.fs.Open(filepath); err != nil {
 		err = fmt.Errorf("error opening <%s> for export: %v", filepath, err)
 		return
 	}
 	defer f.Close()
+	f = d.throttleFile(ctx, f)
+
+	var info os.FileInfo
+	if info, err = f.Stat(); err != nil {
+		return
+	}
 
-	if _, err = d.b.Export(context.Background(), d.o.Name, filename, f); err != nil {
+	if d.o.ContentAddressed {
+		err = d.exportContent(ctx, filename, f)
+	} else {
+		r, name := d.exportReader(filename, f)
+		r, h := d.hashReader(r)
+		_, err = d.eb.Export(d.request(ctx, OpExport), d.remotePrefixOf(filename), name, r)
+		r.Close()
+		if err == nil {
+			err = d.exportChecksum(ctx, filename, h)
+		}
+	}
+
+	if err != nil {
+		return
+	}
+
+	if err = d.markExported(filename, seq); err != nil {
+		return
+	}
+
+	d.record(d.getKey(filename), Event{Type: EventExported})
+	if _, err = f.Seek(0, io.SeekStart); err == nil {
+		err = d.updateCatalog(filename, f, time.Now())
+	}
+
+	if err != nil {
+		d.o.Logger.Printf("csvdb.DB[%s].export(): error updating catalog for <%s>: %v\n", d.o.Name, filename, err)
+		err = nil
+	}
+
+	if !d.o.SpillToBackend {
 		return
 	}
 
-	return d.setLastExported(filename)
+	return d.spill(filename, info)
 }
 
-func (d *DB[T]) writeEntries(f *os.File, es []T) (err error) {
+// writeEntries will append the entries to a file, each followed by the values of the context columns
+func (d *DB[T]) writeEntries(key string, f file, es []T, extra []string) (err error) {
 	if len(es) == 0 {
 		return
 	}
@@ -306,68 +1386,219 @@ func (d *DB[T]) writeEntries(f *os.File, es []T) (err error) {
 		return
 	}
 
+	// Rows are validated against the existing header of the file, or the header written with them
+	header := d.header(key, es[0])
+	cs := d.cp.columns(header)
+	isNew := info.Size() == 0
+	if !isNew {
+		if header, err = readHeader(f, info.Size(), d.dialect); err != nil {
+			return
+		}
+	}
+
 	if _, err = f.Seek(0, io.SeekEnd); err != nil {
 		return
 	}
 
-	w := csv.NewWriter(f)
-	isNew := info.Size() == 0
-	if err = d.writeHeader(w, isNew, es[0]); err != nil {
+	defer func() {
+		if err == nil {
+			return
+		}
+
+		// Rows flushed ahead of an invalid row are removed, so no partial batch is left behind
+		if terr := f.Truncate(info.Size()); terr != nil {
+			d.o.Logger.Printf("csvdb.DB[%s].writeEntries(): error restoring <%s>: %v\n", d.o.Name, f.Name(), terr)
+		}
+	}()
+
+	a := d.newAppender(f, info.Size())
+	w, bw := getCSVWriter(a, d.dialect)
+	defer putBufWriter(bw)
+	if err = d.writeHeader(w, isNew, key, es[0]); err != nil {
 		return
 	}
 
-	for _, e := range es {
-		if err = w.Write(e.Values()); err != nil {
+	for i, e := range es {
+		var values []string
+		if values, err = d.row(key, i, e, extra); err != nil {
+			return
+		}
+
+		if values, err = d.cp.protect(cs, values); err != nil {
+			return
+		}
+
+		values = d.seal(values)
+		if err = checkColumnCount(key, i, values, header); err != nil {
+			return
+		}
+
+		if err = w.Write(values); err != nil {
 			return
 		}
 	}
 
 	w.Flush()
-	return
+	if err = w.Error(); err != nil {
+		return
+	}
+
+	if err = d.commit(a); err != nil {
+		return
+	}
+
+	return d.syncWrite(f, isNew)
 }
 
-func (d *DB[T]) forEach(fn func(key string, info os.FileInfo) error) (err error) {
-	dir := filepath.Join(d.o.Dir, d.o.Name)
-	err = filepath.Walk(dir, func(path string, info fs.FileInfo, ierr error) (err error) {
-		if ierr != nil {
-			return ierr
-		}
+// syncWrite will sync a written file when SyncWrites is set, along with its directory
+// when the file is new
+func (d *DB[T]) syncWrite(f file, isNew bool) (err error) {
+	if !d.o.SyncWrites {
+		return
+	}
+
+	if err = f.Sync(); err != nil || !isNew {
+		return
+	}
 
-		if filepath.Dir(path) != dir {
+	return d.fs.SyncDir(filepath.Dir(f.Name()))
+}
+
+// writeEntriesAtomic will copy the current contents of a file into a temporary file,
+// write the entries to it and then atomically rename it over the original file
+func (d *DB[T]) writeEntriesAtomic(key, filename string, es []T, extra []string) (err error) {
+	if len(es) == 0 {
+		return
+	}
+
+	var tmp file
+	if tmp, err = createTemp(d.fs, d.o.TempDir, filename); err != nil {
+		return
+	}
+
+	defer func() {
+		if err == nil {
 			return
 		}
 
-		if filepath.Ext(path) != ".csv" {
+		tmp.Close()
+		d.fs.Remove(tmp.Name())
+	}()
+
+	var src file
+	src, err = d.fs.Open(filename)
+	switch {
+	case err == nil:
+		_, err = io.Copy(tmp, src)
+		src.Close()
+		if err != nil {
 			return
 		}
+	case os.IsNotExist(err):
+		err = nil
+	default:
+		return
+	}
+
+	if err = d.writeEntries(key, tmp, es, extra); err != nil {
+		return
+	}
+
+	if err = tmp.Sync(); err != nil {
+		return
+	}
+
+	if err = tmp.Close(); err != nil {
+		return
+	}
+
+	if err = d.fs.Rename(tmp.Name(), filename); err != nil || !d.o.SyncWrites {
+		return
+	}
+
+	// Sync the directory so the rename itself is durable
+	return d.fs.SyncDir(filepath.Dir(filename))
+}
+
+// entryInfo will return the file info of a directory entry, from the manifest when
+// Options.ManifestCache is set
+func (d *DB[T]) entryInfo(dir string, entry os.DirEntry) (info os.FileInfo, err error) {
+	if !d.o.ManifestCache {
+		return entry.Info()
+	}
+
+	return d.fs.Stat(filepath.Join(dir, entry.Name()))
+}
+
+func (d *DB[T]) forEach(fn func(key string, info os.FileInfo) error) (err error) {
+	type item struct {
+		name string
+		info os.FileInfo
+	}
+
+	var files []item
+	if err = d.readDataDirs(func(dir string, entries []os.DirEntry) (err error) {
+		for _, entry := range entries {
+			if entry.IsDir() || filepath.Ext(entry.Name()) != ".csv" {
+				continue
+			}
+
+			var info os.FileInfo
+			if info, err = d.entryInfo(dir, entry); os.IsNotExist(err) {
+				// File was removed after the directory was read
+				err = nil
+				continue
+			} else if err != nil {
+				return
+			}
+
+			files = append(files, item{name: entry.Name(), info: info})
+		}
 
-		base := filepath.Base(path)
+		return
+	}); err != nil {
+		return
+	}
 
-		return fn(base, info)
+	sort.SliceStable(files, func(i, j int) bool {
+		return d.o.Ordering.less(files[i].name, files[i].info, files[j].name, files[j].info)
 	})
 
+	for _, f := range files {
+		if err = fn(f.name, f.info); err != nil {
+			return
+		}
+	}
+
 	return
 }
 
-func (d *DB[T]) getExportable() (exportable []string, err error) {
-	// TODO: Uncomment this when we implement a thread-safe downloader.
-	// Currently, multiple readers can download the same file and cause
-	// race conditions.
-	// d.mux.RLock()
-	// defer d.mux.RUnlock()
-
-	d.mux.Lock()
-	defer d.mux.Unlock()
+func (d *DB[T]) getExportable(prefix string) (exportable []string, err error) {
+	d.mux.RLock()
+	defer d.mux.RUnlock()
 
 	exportable = make([]string, 0, 32)
-	err = d.forEach(func(key string, info fs.FileInfo) (err error) {
-		lastExported := d.getLastExported(key)
+	err = d.forEachWithin(prefix, func(key string, info fs.FileInfo) (err error) {
+		if d.isExportHeld(key) {
+			// Key is currently held, skip
+			return nil
+		}
+
+		if d.isExportSkipped(key, info) {
+			// Skipped until modified
+			return nil
+		}
 
-		if lastExported.After(info.ModTime()) {
+		if d.isExported(key, info) {
 			// We exported since our last update, return
 			return nil
 		}
 
+		if !d.isExportDue(key, d.getLastExported(key)) {
+			// Export interval of the policy has not passed, return
+			return nil
+		}
+
 		exportable = append(exportable, info.Name())
 		return
 	})
@@ -375,20 +1606,13 @@ func (d *DB[T]) getExportable() (exportable []string, err error) {
 	return
 }
 
-func (d *DB[T]) getExpired() (expired []string, err error) {
-	// TODO: Uncomment this when we implement a thread-safe downloader.
-	// Currently, multiple readers can download the same file and cause
-	// race conditions.
-	// d.mux.RLock()
-	// defer d.mux.RUnlock()
-
-	d.mux.Lock()
-	defer d.mux.Unlock()
+func (d *DB[T]) getExpired(prefix string) (expired []string, err error) {
+	d.mux.RLock()
+	defer d.mux.RUnlock()
 
 	expired = make([]string, 0, 32)
-	err = d.forEach(func(key string, info fs.FileInfo) (err error) {
-
-		if !d.o.ExpiryMonitor(key, info) {
+	err = d.forEachWithin(prefix, func(key string, info fs.FileInfo) (err error) {
+		if !d.isExpired(key, info) || !d.isConsumed(key, info) {
 			return
 		}
 
@@ -399,12 +1623,14 @@ func (d *DB[T]) getExpired() (expired []string, err error) {
 	return
 }
 
-func (d *DB[T]) removeAll(list []string) (err error) {
-	d.mux.Lock()
-	defer d.mux.Unlock()
+func (d *DB[T]) removeAll(ctx context.Context, list []string) (err error) {
 	for _, filename := range list {
-		filepath := path.Join(d.getFullPath(), filename)
-		if err = os.Remove(filepath); err != nil {
+		// Throttle outside of the lock so foreground calls are not held up
+		if err = d.ioOps.wait(ctx, 1); err != nil {
+			return
+		}
+
+		if err = d.remove(ctx, filename); err != nil {
 			return
 		}
 	}
@@ -412,59 +1638,188 @@ func (d *DB[T]) removeAll(list []string) (err error) {
 	return
 }
 
-func (d *DB[T]) purge() (err error) {
+func (d *DB[T]) remove(ctx context.Context, filename string) (err error) {
+	key := d.getKey(filename)
+	defer d.holdKeys(key)()
+
+	if d.isHeld(key) {
+		// Held keys are retained regardless of their TTL
+		return
+	}
+
+	filepath := d.getPath(filename)
+	if d.o.RequireConsumed {
+		// Rows may have been appended since the key was found to be expired
+		var info os.FileInfo
+		switch info, err = d.fs.Stat(filepath); {
+		case os.IsNotExist(err):
+			return nil
+		case err != nil:
+			return
+		case !d.isConsumed(filename, info):
+			return
+		}
+	}
+
+	if err = d.purgeRemote(ctx, filename); err != nil {
+		return
+	}
+
+	if err = d.fs.Remove(filepath); err != nil {
+		return
+	}
+
+	if err = d.fs.Remove(filepath + consumedExt); err != nil && !os.IsNotExist(err) {
+		return
+	}
+
+	d.record(key, Event{Type: EventPurged})
+	return nil
+}
+
+func (d *DB[T]) purge(prefix string) (err error) {
 	if !d.pmux.TryLock() {
 		return ErrPurgeIsActive
 	}
 	defer d.pmux.Unlock()
 
 	var expired []string
-	if expired, err = d.getExpired(); err != nil {
+	if expired, err = d.getExpired(prefix); err != nil {
+		return
+	}
+
+	return d.removeAll(d.context(), expired)
+}
+
+// purgeRemote will delete the exported file of a purged file from the backend, when
+// PurgeFromBackend is set and the policy of its key does not retain it
+func (d *DB[T]) purgeRemote(ctx context.Context, filename string) (err error) {
+	if !d.o.PurgeFromBackend {
+		return
+	}
+
+	if p, ok := d.o.policyFor(d.getKey(filename)); ok && p.RetainRemote {
+		return
+	}
+
+	// Backend is verified to implement Deleter when the DB is created
+	if err = d.eb.(Deleter).Delete(d.request(withKey(ctx, d.getKey(filename)), OpDelete), d.remotePrefixOf(filename), d.exportName(filename)); err != nil && !os.IsNotExist(err) {
+		return
+	}
+
+	if err = d.deleteChecksum(ctx, d.eb.(Deleter), filename); err != nil {
 		return
 	}
 
-	return d.removeAll(expired)
+	d.catalog.remove(d.getKey(filename))
+	return nil
 }
 
 func (d *DB[T]) asyncBackup() {
-	if err := d.backup(); err != nil {
+	if err := d.backup(context.Background(), "", true); err != nil {
 		d.o.Logger.Printf("csvdb.DB[%s].asyncBackup(): error exporting: %v\n", d.o.Name, err)
 	}
 }
 
 func (d *DB[T]) asyncPurge() {
-	if err := d.purge(); err != nil {
+	if err := d.purge(""); err != nil {
 		d.o.Logger.Printf("csvdb.DB[%s].asyncPurge(): error purging: %v\n", d.o.Name, err)
 	}
 }
 
-func (d *DB[T]) backup() (err error) {
+// backup will export the exportable files within a prefix, skipping the files whose failed
+// exports are backing off when backoff is set
+func (d *DB[T]) backup(ctx context.Context, prefix string, backoff bool) (err error) {
 	if !d.emux.TryLock() {
 		return ErrExportIsActive
 	}
 	defer d.emux.Unlock()
 
 	var exportable []string
-	if exportable, err = d.getExportable(); err != nil {
+	if exportable, err = d.getExportable(prefix); err != nil {
 		return
 	}
 
-	return d.exportAll(exportable)
+	if backoff {
+		exportable = d.dueForExport(exportable, time.Now())
+	}
+
+	if err = d.exportAll(ctx, exportable); err != nil {
+		return
+	}
+
+	return d.publishCatalog(ctx)
 }
 
 func (d *DB[T]) setLastExported(name string) (err error) {
-	var f *os.File
-	filename := path.Join(d.getFullPath(), name)
-	if f, err = os.Create(filename + ".exported"); err != nil {
+	return d.markExported(name, d.sequence(name))
+}
+
+// markExported will write the export marker of a file, recording the sequence of the
+// modification which was exported when Options.ManifestCache is set
+func (d *DB[T]) markExported(name string, seq uint64) (err error) {
+	var f file
+	filename := d.getPath(name)
+	if f, err = d.fs.Create(filename + ".exported"); err != nil {
+		return
+	}
+
+	if err = f.Close(); err != nil {
+		return
+	}
+
+	if d.manifest != nil {
+		d.manifest.setMark(filename+".exported", seq)
+	}
+
+	return
+}
+
+// sequence will return the sequence of the last modification of a file, see manifestFS
+func (d *DB[T]) sequence(name string) (seq uint64) {
+	if d.manifest == nil {
+		return
+	}
+
+	return d.manifest.sequence(d.getPath(name))
+}
+
+// isExported will return whether or not a file has been exported since it was last
+// modified. Sequences are compared when the manifest holds the sequence of the export,
+// otherwise modification times are compared. Markers dated in the future, such as after
+// the system clock jumped backwards, are not trusted.
+func (d *DB[T]) isExported(name string, info os.FileInfo) bool {
+	if d.manifest != nil {
+		filename := d.getPath(name)
+		if mark, ok := d.manifest.getMark(filename + ".exported"); ok {
+			return d.manifest.sequence(filename) <= mark
+		}
+	}
+
+	lastExported := d.getLastExported(name)
+	return lastExported.After(info.ModTime()) && !lastExported.After(time.Now())
+}
+
+// setSynced will mark a file which matches the backend as exported. The file is backdated
+// so it is older than its export marker, regardless of the timestamp granularity.
+func (d *DB[T]) setSynced(name string) (err error) {
+	if err = d.setLastExported(name); err != nil {
+		return
+	}
+
+	filename := d.getPath(name)
+	var info os.FileInfo
+	if info, err = d.fs.Stat(filename + ".exported"); err != nil {
 		return
 	}
 
-	return f.Close()
+	modTime := info.ModTime().Add(-time.Millisecond)
+	return d.fs.Chtimes(filename, modTime, modTime)
 }
 
 func (d *DB[T]) getLastExported(name string) (t time.Time) {
-	filename := path.Join(d.getFullPath(), name)
-	exported, err := os.Stat(filename + ".exported")
+	filename := d.getPath(name)
+	exported, err := d.fs.Stat(filename + ".exported")
 	switch {
 	case err == nil:
 		return exported.ModTime()
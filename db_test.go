@@ -3,9 +3,16 @@ package csvdb
 import (
 	"bytes"
 	"context"
+	"errors"
 	"fmt"
+	"io"
 	"io/fs"
 	"os"
+	"path"
+	"reflect"
+	"sort"
+	"strings"
+	"sync/atomic"
 	"testing"
 	"time"
 )
@@ -641,7 +648,7 @@ func TestDB_purge(t *testing.T) {
 				var opts Options
 				opts.Dir = fmt.Sprintf("test_%d", time.Now().UnixNano())
 				opts.Name = "foo"
-				opts.FileTTL = time.Millisecond
+				opts.FileTTL = time.Minute
 
 				b := &mockBackend{}
 				var d DB[testentry]
@@ -668,7 +675,12 @@ func TestDB_purge(t *testing.T) {
 					return
 				}
 
-				time.Sleep(time.Millisecond * 10)
+				// Files are aged rather than waited upon, so files appended afterwards
+				// cannot expire before they are purged
+				if err = backdate(&d, "foo", time.Hour); err != nil {
+					return
+				}
+
 				db = &d
 				return
 			},
@@ -681,7 +693,7 @@ func TestDB_purge(t *testing.T) {
 				var opts Options
 				opts.Dir = fmt.Sprintf("test_%d", time.Now().UnixNano())
 				opts.Name = "foo"
-				opts.FileTTL = time.Millisecond
+				opts.FileTTL = time.Minute
 
 				b := &mockBackend{}
 				var d DB[testentry]
@@ -708,7 +720,9 @@ func TestDB_purge(t *testing.T) {
 					return
 				}
 
-				time.Sleep(time.Millisecond * 10)
+				if err = backdate(&d, "foo", time.Hour); err != nil {
+					return
+				}
 
 				if err = d.Append("bar", tvs...); err != nil {
 					return
@@ -753,7 +767,9 @@ func TestDB_purge(t *testing.T) {
 					return
 				}
 
-				time.Sleep(time.Millisecond * 10)
+				if err = backdate(&d, "foo", time.Hour); err != nil {
+					return
+				}
 
 				if err = d.Append("bar", tvs...); err != nil {
 					return
@@ -775,7 +791,7 @@ func TestDB_purge(t *testing.T) {
 			}
 			defer os.RemoveAll(d.o.Dir)
 
-			err = d.purge()
+			err = d.purge("")
 			if (err != nil) != tt.wantErr {
 				t.Errorf("DB.purge() error = %v, wantErr %v", err, tt.wantErr)
 				return
@@ -798,6 +814,90 @@ func TestDB_purge(t *testing.T) {
 	}
 }
 
+// backdate will set the modification time of the file of a key to the provided age
+func backdate(d *DB[testentry], key string, age time.Duration) error {
+	_, filename := d.getFilename(key)
+	modified := time.Now().Add(-age)
+	return d.fs.Chtimes(filename, modified, modified)
+}
+
+func TestDB_PurgeFromBackend(t *testing.T) {
+	type testcase struct {
+		name             string
+		deleter          bool
+		purgeFromBackend bool
+		policies         []Policy
+
+		wantMakeErr error
+		wantDeleted []string
+	}
+
+	tests := []testcase{
+		{
+			name:    "disabled",
+			deleter: true,
+		},
+		{
+			name:             "enabled",
+			deleter:          true,
+			purgeFromBackend: true,
+			wantDeleted:      []string{"foo.a.csv", "foo.b%2F1.csv"},
+		},
+		{
+			name:             "retained by policy",
+			deleter:          true,
+			purgeFromBackend: true,
+			policies:         []Policy{{Prefix: "b", RetainRemote: true}},
+			wantDeleted:      []string{"foo.a.csv"},
+		},
+		{
+			name:             "backend without deleter",
+			purgeFromBackend: true,
+			wantMakeErr:      ErrDeleterNotImplemented,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var opts Options
+			opts.Dir = fmt.Sprintf("test_%d", time.Now().UnixNano())
+			opts.Name = "foo"
+			opts.FileTTL = time.Millisecond
+			opts.PurgeFromBackend = tt.purgeFromBackend
+			opts.Policies = tt.policies
+
+			var b Backend = &mockBackend{}
+			deleter := &mockDeleterBackend{}
+			if tt.deleter {
+				b = deleter
+			}
+
+			d, err := makeDB[testentry](opts, b)
+			if err != tt.wantMakeErr {
+				t.Fatalf("makeDB() error = %v, wantErr %v", err, tt.wantMakeErr)
+			} else if err != nil {
+				return
+			}
+			defer os.RemoveAll(d.o.Dir)
+
+			for _, key := range []string{"a", "b/1"} {
+				if err = d.Append(key, testentry{Foo: "1", Bar: "1b"}); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			time.Sleep(time.Millisecond * 10)
+			if err = d.purge(""); err != nil {
+				t.Fatal(err)
+			}
+
+			if !reflect.DeepEqual(deleter.deleted, tt.wantDeleted) {
+				t.Errorf("DB.purge() deleted = %v, want %v", deleter.deleted, tt.wantDeleted)
+			}
+		})
+	}
+}
+
 func TestDB_export(t *testing.T) {
 	type args struct {
 		filename string
@@ -863,7 +963,7 @@ func TestDB_export(t *testing.T) {
 			}
 			defer os.RemoveAll(d.o.Dir)
 
-			err = d.export(tt.args.filename)
+			err = d.export(context.Background(), tt.args.filename)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("DB.export() error = %v, wantErr %v", err, tt.wantErr)
 				return
@@ -980,7 +1080,7 @@ func TestDB_getExportable(t *testing.T) {
 			defer os.RemoveAll(d.o.Dir)
 
 			var exportable []string
-			exportable, err = d.getExportable()
+			exportable, err = d.getExportable("")
 			if (err != nil) != tt.wantErr {
 				t.Errorf("DB.getExportable() error = %v, wantErr %v", err, tt.wantErr)
 				return
@@ -993,3 +1093,1615 @@ func TestDB_getExportable(t *testing.T) {
 		})
 	}
 }
+
+func TestDB_Upsert(t *testing.T) {
+	type args struct {
+		key      string
+		pkColumn string
+		es       []testentry
+	}
+
+	type testcase struct {
+		name    string
+		init    func() (*DB[testentry], error)
+		args    args
+		wantW   string
+		wantErr bool
+	}
+
+	init := func() (db *DB[testentry], err error) {
+		var opts Options
+		opts.Dir = fmt.Sprintf("test_%d", time.Now().UnixNano())
+		opts.Name = "foo"
+
+		b := &mockBackend{}
+		var d DB[testentry]
+		if d, err = makeDB[testentry](opts, b); err != nil {
+			return
+		}
+
+		tvs := []testentry{
+			{
+				Foo: "1",
+				Bar: "1b",
+			},
+			{
+				Foo: "2",
+				Bar: "2b",
+			},
+		}
+
+		if err = d.Append("foo", tvs...); err != nil {
+			return
+		}
+
+		db = &d
+		return
+	}
+
+	initDuplicates := func() (db *DB[testentry], err error) {
+		if db, err = init(); err != nil {
+			return
+		}
+
+		err = db.Append("foo", testentry{Foo: "1", Bar: "1c"})
+		return
+	}
+
+	tests := []testcase{
+		{
+			name: "replace and append",
+			init: init,
+			args: args{
+				key:      "foo",
+				pkColumn: "foo",
+				es: []testentry{
+					{
+						Foo: "2",
+						Bar: "2c",
+					},
+					{
+						Foo: "3",
+						Bar: "3b",
+					},
+				},
+			},
+			wantW: `foo,bar
+1,1b
+2,2c
+3,3b
+`,
+		},
+		{
+			name: "new key",
+			init: init,
+			args: args{
+				key:      "bar",
+				pkColumn: "foo",
+				es: []testentry{
+					{
+						Foo: "1",
+						Bar: "1b",
+					},
+				},
+			},
+			wantW: `foo,bar
+1,1b
+`,
+		},
+		{
+			name: "replace duplicated key",
+			init: initDuplicates,
+			args: args{
+				key:      "foo",
+				pkColumn: "foo",
+				es: []testentry{
+					{
+						Foo: "1",
+						Bar: "1d",
+					},
+				},
+			},
+			wantW: `foo,bar
+1,1d
+2,2b
+`,
+		},
+		{
+			name: "invalid column",
+			init: init,
+			args: args{
+				key:      "foo",
+				pkColumn: "baz",
+				es: []testentry{
+					{
+						Foo: "1",
+						Bar: "1b",
+					},
+				},
+			},
+			wantW: `foo,bar
+1,1b
+2,2b
+`,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d, err := tt.init()
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer os.RemoveAll(d.o.Dir)
+
+			err = d.Upsert(tt.args.key, tt.args.pkColumn, tt.args.es...)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("DB.Upsert() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+
+			w := &bytes.Buffer{}
+			if err = d.Get(w, tt.args.key); err != nil {
+				t.Fatal(err)
+			}
+
+			if gotW := w.String(); gotW != tt.wantW {
+				t.Errorf("DB.Upsert() = %v, want %v", gotW, tt.wantW)
+			}
+		})
+	}
+}
+
+func TestDB_UpdateRows(t *testing.T) {
+	type args struct {
+		key string
+		fn  func(testentry) (testentry, bool, error)
+	}
+
+	type testcase struct {
+		name    string
+		args    args
+		wantW   string
+		wantErr bool
+	}
+
+	tests := []testcase{
+		{
+			name: "modify and skip",
+			args: args{
+				key: "foo",
+				fn: func(e testentry) (testentry, bool, error) {
+					if e.Foo == "2" {
+						return e, false, nil
+					}
+
+					e.Bar += "_updated"
+					return e, true, nil
+				},
+			},
+			wantW: `foo,bar
+1,1b_updated
+3,3b_updated
+`,
+		},
+		{
+			name: "error",
+			args: args{
+				key: "foo",
+				fn: func(e testentry) (testentry, bool, error) {
+					return e, false, errors.New("nope")
+				},
+			},
+			wantW: `foo,bar
+1,1b
+2,2b
+3,3b
+`,
+			wantErr: true,
+		},
+		{
+			name: "not found",
+			args: args{
+				key: "bar",
+				fn: func(e testentry) (testentry, bool, error) {
+					return e, true, nil
+				},
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var opts Options
+			opts.Dir = fmt.Sprintf("test_%d", time.Now().UnixNano())
+			opts.Name = "foo"
+			d, err := makeDB[testentry](opts, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer os.RemoveAll(d.o.Dir)
+
+			tvs := []testentry{
+				{
+					Foo: "1",
+					Bar: "1b",
+				},
+				{
+					Foo: "2",
+					Bar: "2b",
+				},
+				{
+					Foo: "3",
+					Bar: "3b",
+				},
+			}
+
+			if err = d.Append("foo", tvs...); err != nil {
+				t.Fatal(err)
+			}
+
+			err = d.UpdateRows(tt.args.key, tt.args.fn)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("DB.UpdateRows() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+
+			if len(tt.wantW) == 0 {
+				return
+			}
+
+			w := &bytes.Buffer{}
+			if err = d.Get(w, tt.args.key); err != nil {
+				t.Fatal(err)
+			}
+
+			if gotW := w.String(); gotW != tt.wantW {
+				t.Errorf("DB.UpdateRows() = %v, want %v", gotW, tt.wantW)
+			}
+		})
+	}
+}
+
+func TestDB_DeleteRows(t *testing.T) {
+	type args struct {
+		key string
+		fn  func(values []string) bool
+	}
+
+	type testcase struct {
+		name    string
+		args    args
+		wantW   string
+		wantErr bool
+	}
+
+	tests := []testcase{
+		{
+			name: "basic",
+			args: args{
+				key: "foo",
+				fn: func(values []string) bool {
+					return values[0] == "2"
+				},
+			},
+			wantW: `foo,bar
+1,1b
+3,3b
+`,
+		},
+		{
+			name: "all",
+			args: args{
+				key: "foo",
+				fn: func(values []string) bool {
+					return true
+				},
+			},
+			wantW: `foo,bar
+`,
+		},
+		{
+			name: "not found",
+			args: args{
+				key: "bar",
+				fn: func(values []string) bool {
+					return true
+				},
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var opts Options
+			opts.Dir = fmt.Sprintf("test_%d", time.Now().UnixNano())
+			opts.Name = "foo"
+			d, err := makeDB[testentry](opts, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer os.RemoveAll(d.o.Dir)
+
+			tvs := []testentry{
+				{
+					Foo: "1",
+					Bar: "1b",
+				},
+				{
+					Foo: "2",
+					Bar: "2b",
+				},
+				{
+					Foo: "3",
+					Bar: "3b",
+				},
+			}
+
+			if err = d.Append("foo", tvs...); err != nil {
+				t.Fatal(err)
+			}
+
+			err = d.DeleteRows(tt.args.key, tt.args.fn)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("DB.DeleteRows() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+
+			if len(tt.wantW) == 0 {
+				return
+			}
+
+			w := &bytes.Buffer{}
+			if err = d.Get(w, tt.args.key); err != nil {
+				t.Fatal(err)
+			}
+
+			if gotW := w.String(); gotW != tt.wantW {
+				t.Errorf("DB.DeleteRows() = %v, want %v", gotW, tt.wantW)
+			}
+		})
+	}
+}
+
+func TestDB_Truncate(t *testing.T) {
+	type args struct {
+		key string
+	}
+
+	type testcase struct {
+		name    string
+		args    args
+		wantW   string
+		wantErr bool
+	}
+
+	tests := []testcase{
+		{
+			name: "basic",
+			args: args{
+				key: "foo",
+			},
+			wantW: `foo,bar
+`,
+		},
+		{
+			name: "not found",
+			args: args{
+				key: "bar",
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var opts Options
+			opts.Dir = fmt.Sprintf("test_%d", time.Now().UnixNano())
+			opts.Name = "foo"
+			d, err := makeDB[testentry](opts, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer os.RemoveAll(d.o.Dir)
+
+			tvs := []testentry{
+				{
+					Foo: "1",
+					Bar: "1b",
+				},
+				{
+					Foo: "2",
+					Bar: "2b",
+				},
+			}
+
+			if err = d.Append("foo", tvs...); err != nil {
+				t.Fatal(err)
+			}
+
+			err = d.Truncate(tt.args.key)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("DB.Truncate() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+
+			if len(tt.wantW) == 0 {
+				return
+			}
+
+			w := &bytes.Buffer{}
+			if err = d.Get(w, tt.args.key); err != nil {
+				t.Fatal(err)
+			}
+
+			if gotW := w.String(); gotW != tt.wantW {
+				t.Errorf("DB.Truncate() = %v, want %v", gotW, tt.wantW)
+			}
+		})
+	}
+}
+
+func TestDB_AppendUnique(t *testing.T) {
+	type args struct {
+		key string
+		es  []testentry
+	}
+
+	type testcase struct {
+		name    string
+		args    args
+		wantW   string
+		wantErr bool
+	}
+
+	tests := []testcase{
+		{
+			name: "skip existing",
+			args: args{
+				key: "foo",
+				es: []testentry{
+					{
+						Foo: "2",
+						Bar: "2b",
+					},
+					{
+						Foo: "3",
+						Bar: "3b",
+					},
+					{
+						Foo: "3",
+						Bar: "3b",
+					},
+				},
+			},
+			wantW: `foo,bar
+1,1b
+2,2b
+3,3b
+`,
+		},
+		{
+			name: "new key",
+			args: args{
+				key: "bar",
+				es: []testentry{
+					{
+						Foo: "1",
+						Bar: "1b",
+					},
+					{
+						Foo: "1",
+						Bar: "1b",
+					},
+				},
+			},
+			wantW: `foo,bar
+1,1b
+`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var opts Options
+			opts.Dir = fmt.Sprintf("test_%d", time.Now().UnixNano())
+			opts.Name = "foo"
+			d, err := makeDB[testentry](opts, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer os.RemoveAll(d.o.Dir)
+
+			tvs := []testentry{
+				{
+					Foo: "1",
+					Bar: "1b",
+				},
+				{
+					Foo: "2",
+					Bar: "2b",
+				},
+			}
+
+			if err = d.Append("foo", tvs...); err != nil {
+				t.Fatal(err)
+			}
+
+			err = d.AppendUnique(tt.args.key, tt.args.es...)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("DB.AppendUnique() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+
+			w := &bytes.Buffer{}
+			if err = d.Get(w, tt.args.key); err != nil {
+				t.Fatal(err)
+			}
+
+			if gotW := w.String(); gotW != tt.wantW {
+				t.Errorf("DB.AppendUnique() = %v, want %v", gotW, tt.wantW)
+			}
+		})
+	}
+}
+
+func FuzzDB_RoundTrip(f *testing.F) {
+	var opts Options
+	opts.Dir = f.TempDir()
+	opts.Name = "foo"
+	d, err := makeDB[testentry](opts, nil)
+	if err != nil {
+		f.Fatal(err)
+	}
+
+	f.Add("key_1", "1", "1b")
+	f.Add("a/b", "with,comma", "with \"quotes\"")
+	f.Add("ключ", "multi\nline", "\r\n")
+	f.Add("", "", " leading space")
+	f.Fuzz(func(t *testing.T, key, foo, bar string) {
+		if len(escapeKey(key)) > 200 {
+			t.Skip("escaped key exceeds filesystem name limits")
+		}
+
+		defer d.Delete(key)
+
+		// encoding/csv normalizes carriage return + line feed pairs within cell values
+		want := testentry{Foo: normalizeCRLF(foo), Bar: normalizeCRLF(bar)}
+		if err := d.Append(key, testentry{Foo: foo, Bar: bar}); err != nil {
+			t.Fatal(err)
+		}
+
+		var got []testentry
+		if err := d.AppendWithFunc(key, func(r *Rows) (es []testentry, err error) {
+			err = r.ForEach(func(values []string) (err error) {
+				got = append(got, testentry{Foo: values[0], Bar: values[1]})
+				return
+			})
+			return
+		}); err != nil {
+			t.Fatal(err)
+		}
+
+		if len(got) != 1 || got[0] != want {
+			t.Fatalf("round trip of %q = %q, want %q", key, got, want)
+		}
+
+		var keys []string
+		if err := d.forEach(func(filename string, info fs.FileInfo) (err error) {
+			keys = append(keys, d.getKey(filename))
+			return
+		}); err != nil {
+			t.Fatal(err)
+		}
+
+		if len(keys) != 1 || keys[0] != key {
+			t.Fatalf("round trip of key %q = %q", key, keys)
+		}
+	})
+}
+
+func normalizeCRLF(value string) string {
+	return strings.ReplaceAll(value, "\r\n", "\n")
+}
+
+func TestDB_AppendMany(t *testing.T) {
+	type args struct {
+		m map[string][]testentry
+	}
+
+	type testcase struct {
+		name    string
+		args    args
+		wantW   map[string]string
+		wantErr bool
+	}
+
+	tests := []testcase{
+		{
+			name: "basic",
+			args: args{
+				m: map[string][]testentry{
+					"a": {
+						{
+							Foo: "1",
+							Bar: "1b",
+						},
+						{
+							Foo: "2",
+							Bar: "2b",
+						},
+					},
+					"b": {
+						{
+							Foo: "3",
+							Bar: "3b",
+						},
+					},
+					"c": {},
+				},
+			},
+			wantW: map[string]string{
+				"a": "foo,bar\n1,1b\n2,2b\n",
+				"b": "foo,bar\n3,3b\n",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var opts Options
+			opts.Dir = fmt.Sprintf("test_%d", time.Now().UnixNano())
+			opts.Name = "foo"
+			d, err := makeDB[testentry](opts, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer os.RemoveAll(d.o.Dir)
+
+			err = d.AppendMany(tt.args.m)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("DB.AppendMany() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+
+			for key, wantW := range tt.wantW {
+				w := &bytes.Buffer{}
+				if err = d.Get(w, key); err != nil {
+					t.Fatal(err)
+				}
+
+				if gotW := w.String(); gotW != wantW {
+					t.Errorf("DB.AppendMany() <%s> = %v, want %v", key, gotW, wantW)
+				}
+			}
+
+			if _, err = os.Stat(path.Join(d.getFullPath(), "foo.c.csv")); !os.IsNotExist(err) {
+				t.Errorf("DB.AppendMany() created file for empty key, err = %v", err)
+			}
+		})
+	}
+}
+
+func TestDB_forEach(t *testing.T) {
+	type testcase struct {
+		name     string
+		ordering Ordering
+		want     []string
+	}
+
+	tests := []testcase{
+		{
+			name:     "lexicographic",
+			ordering: OrderLexicographic,
+			want:     []string{"a", "b", "c"},
+		},
+		{
+			name:     "mod time",
+			ordering: OrderModTime,
+			want:     []string{"c", "a", "b"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var opts Options
+			opts.Dir = fmt.Sprintf("test_%d", time.Now().UnixNano())
+			opts.Name = "foo"
+			opts.Ordering = tt.ordering
+			d, err := makeDB[testentry](opts, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer os.RemoveAll(d.o.Dir)
+
+			now := time.Now()
+			modTimes := map[string]time.Time{
+				"c": now.Add(-time.Hour * 3),
+				"a": now.Add(-time.Hour * 2),
+				"b": now.Add(-time.Hour),
+			}
+
+			for key, modTime := range modTimes {
+				if err = d.Append(key, testentry{Foo: key}); err != nil {
+					t.Fatal(err)
+				}
+
+				_, filename := d.getFilename(key)
+				if err = os.Chtimes(filename, modTime, modTime); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			var got []string
+			if err = d.forEach(func(filename string, info fs.FileInfo) (err error) {
+				got = append(got, d.getKey(filename))
+				return
+			}); err != nil {
+				t.Fatal(err)
+			}
+
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("DB.forEach() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDB_Append(t *testing.T) {
+	type testcase struct {
+		name         string
+		atomicAppend bool
+		wantW        string
+	}
+
+	tests := []testcase{
+		{
+			name:  "basic",
+			wantW: "foo,bar\n1,1b\n2,2b\n3,3b\n",
+		},
+		{
+			name:         "atomic",
+			atomicAppend: true,
+			wantW:        "foo,bar\n1,1b\n2,2b\n3,3b\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var opts Options
+			opts.Dir = fmt.Sprintf("test_%d", time.Now().UnixNano())
+			opts.Name = "foo"
+			opts.AtomicAppend = tt.atomicAppend
+			d, err := makeDB[testentry](opts, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer os.RemoveAll(d.o.Dir)
+
+			tvs := []testentry{
+				{
+					Foo: "1",
+					Bar: "1b",
+				},
+				{
+					Foo: "2",
+					Bar: "2b",
+				},
+				{
+					Foo: "3",
+					Bar: "3b",
+				},
+			}
+
+			if err = d.Append("foo", tvs[:2]...); err != nil {
+				t.Fatal(err)
+			}
+
+			if err = d.AppendWithFunc("foo", func(r *Rows) ([]testentry, error) {
+				return tvs[2:], nil
+			}); err != nil {
+				t.Fatal(err)
+			}
+
+			w := &bytes.Buffer{}
+			if err = d.Get(w, "foo"); err != nil {
+				t.Fatal(err)
+			}
+
+			if gotW := w.String(); gotW != tt.wantW {
+				t.Errorf("DB.Append() = %v, want %v", gotW, tt.wantW)
+			}
+
+			entries, err := os.ReadDir(d.getFullPath())
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if len(entries) != 1 {
+				t.Errorf("DB.Append() left %d files within directory, want 1", len(entries))
+			}
+		})
+	}
+}
+
+func TestDB_inMemory(t *testing.T) {
+	var opts Options
+	opts.Name = "foo"
+	opts.InMemory = true
+
+	var exported []string
+	b := &mockBackend{
+		exportFn: func(ctx context.Context, prefix, filename string, r io.Reader) (newFilename string, err error) {
+			exported = append(exported, filename)
+			return filename, nil
+		},
+	}
+
+	d, err := makeDB[testentry](opts, b)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err = d.Append("1", testentry{Foo: "1", Bar: "1b"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err = d.Append("2", testentry{Foo: "2", Bar: "2b"}); err != nil {
+		t.Fatal(err)
+	}
+
+	w := &bytes.Buffer{}
+	if err = d.GetMerged(w, "1", "2"); err != nil {
+		t.Fatal(err)
+	}
+
+	if want := "foo,bar\n1,1b\n2,2b\n"; w.String() != want {
+		t.Errorf("DB.GetMerged() = %v, want %v", w.String(), want)
+	}
+
+	if err = d.backup(context.Background(), "", false); err != nil {
+		t.Fatal(err)
+	}
+
+	if want := []string{"foo.1.csv", "foo.2.csv"}; !reflect.DeepEqual(exported, want) {
+		t.Errorf("DB.backup() exported = %v, want %v", exported, want)
+	}
+
+	if _, err = os.Stat(d.getFullPath()); !os.IsNotExist(err) {
+		t.Errorf("in-memory DB created directory on disk, err = %v", err)
+	}
+}
+
+func TestDB_AppendContext(t *testing.T) {
+	type testcase struct {
+		name    string
+		cancel  bool
+		locked  bool
+		wantErr error
+	}
+
+	tests := []testcase{
+		{
+			name: "basic",
+		},
+		{
+			name:    "cancelled",
+			cancel:  true,
+			wantErr: context.Canceled,
+		},
+		{
+			name:    "cancelled while locked",
+			cancel:  true,
+			locked:  true,
+			wantErr: context.Canceled,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var opts Options
+			opts.Dir = fmt.Sprintf("test_%d", time.Now().UnixNano())
+			opts.Name = "foo"
+			d, err := makeDB[testentry](opts, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer os.RemoveAll(d.o.Dir)
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			if tt.locked {
+				d.mux.Lock()
+				time.AfterFunc(10*time.Millisecond, cancel)
+			} else if tt.cancel {
+				cancel()
+			}
+
+			err = d.AppendContext(ctx, "foo", testentry{Foo: "1", Bar: "1b"})
+			if tt.locked {
+				d.mux.Unlock()
+			}
+
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("DB.AppendContext() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			// Ensure the lock has been released
+			w := &bytes.Buffer{}
+			err = d.Get(w, "foo")
+			switch {
+			case tt.wantErr == nil && err != nil:
+				t.Fatal(err)
+			case tt.wantErr != nil && err != ErrBackendNotSet:
+				t.Fatalf("DB.Get() error = %v, want %v", err, ErrBackendNotSet)
+			}
+		})
+	}
+}
+
+func TestDB_GetContext(t *testing.T) {
+	type ctxKey struct{}
+
+	var opts Options
+	opts.Dir = fmt.Sprintf("test_%d", time.Now().UnixNano())
+	opts.Name = "foo"
+
+	var got any
+	b := &mockBackend{
+		importFn: func(ctx context.Context, prefix, filename string, w io.Writer) (err error) {
+			got = ctx.Value(ctxKey{})
+			_, err = w.Write([]byte("foo,bar\n"))
+			return
+		},
+	}
+
+	d, err := makeDB[testentry](opts, b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(d.o.Dir)
+
+	ctx := context.WithValue(context.Background(), ctxKey{}, "value")
+	w := &bytes.Buffer{}
+	if err = d.GetContext(ctx, w, "foo"); err != nil {
+		t.Fatal(err)
+	}
+
+	if got != "value" {
+		t.Errorf("Backend.Import() context value = %v, want %v", got, "value")
+	}
+
+	if gotW := w.String(); gotW != "foo,bar\n" {
+		t.Errorf("DB.GetContext() = %v, want %v", gotW, "foo,bar\n")
+	}
+}
+
+func TestDB_Get_newContext(t *testing.T) {
+	type ctxKey struct{}
+
+	type testcase struct {
+		name      string
+		cancel    bool
+		wantValue any
+		wantErr   error
+	}
+
+	tests := []testcase{
+		{
+			name:      "basic",
+			wantValue: "value",
+		},
+		{
+			name:    "cancelled",
+			cancel:  true,
+			wantErr: context.Canceled,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var opts Options
+			opts.Dir = fmt.Sprintf("test_%d", time.Now().UnixNano())
+			opts.Name = "foo"
+
+			var got any
+			b := &mockBackend{
+				importFn: func(ctx context.Context, prefix, filename string, w io.Writer) (err error) {
+					got = ctx.Value(ctxKey{})
+					_, err = w.Write([]byte("foo,bar\n"))
+					return
+				},
+			}
+
+			ctx, cancel := context.WithCancel(context.WithValue(context.Background(), ctxKey{}, "value"))
+			defer cancel()
+
+			d, err := New[testentry](ctx, opts, b)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer os.RemoveAll(d.o.Dir)
+
+			if tt.cancel {
+				cancel()
+			}
+
+			err = d.Get(&bytes.Buffer{}, "foo")
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("DB.Get() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if got != tt.wantValue {
+				t.Errorf("Backend.Import() context value = %v, want %v", got, tt.wantValue)
+			}
+		})
+	}
+}
+
+func TestDB_CloseContext(t *testing.T) {
+	type testcase struct {
+		name         string
+		inFlight     bool
+		failKey      string
+		wantExported []string
+		wantErr      error
+	}
+
+	tests := []testcase{
+		{
+			name:         "basic",
+			wantExported: []string{"foo.a.csv", "foo.b.csv"},
+		},
+		{
+			name:         "aggregated errors",
+			failKey:      "foo.a.csv",
+			wantExported: []string{"foo.b.csv"},
+			wantErr:      os.ErrPermission,
+		},
+		{
+			name:     "in-flight job exceeds deadline",
+			inFlight: true,
+			wantErr:  context.DeadlineExceeded,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var opts Options
+			opts.Dir = fmt.Sprintf("test_%d", time.Now().UnixNano())
+			opts.Name = "foo"
+
+			var exported []string
+			b := &mockBackend{
+				exportFn: func(ctx context.Context, prefix, filename string, r io.Reader) (string, error) {
+					if filename == tt.failKey {
+						return "", os.ErrPermission
+					}
+
+					exported = append(exported, filename)
+					return filename, nil
+				},
+			}
+
+			d, err := New[testentry](context.Background(), opts, b)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer os.RemoveAll(d.o.Dir)
+
+			for _, key := range []string{"a", "b"} {
+				if err = d.Append(key, testentry{Foo: "1", Bar: "1b"}); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			if tt.inFlight {
+				d.jobs.Add(1)
+				defer d.jobs.Done()
+			}
+
+			ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+			defer cancel()
+
+			err = d.CloseContext(ctx)
+			if !errors.Is(err, tt.wantErr) || (err == nil) != (tt.wantErr == nil) {
+				t.Fatalf("DB.CloseContext() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			sort.Strings(exported)
+			if !reflect.DeepEqual(exported, tt.wantExported) {
+				t.Errorf("DB.CloseContext() exported = %v, want %v", exported, tt.wantExported)
+			}
+		})
+	}
+}
+
+func TestDB_closed(t *testing.T) {
+	type testcase struct {
+		name string
+		fn   func(d *DB[testentry]) error
+	}
+
+	tests := []testcase{
+		{
+			name: "get",
+			fn: func(d *DB[testentry]) error {
+				return d.Get(&bytes.Buffer{}, "foo")
+			},
+		},
+		{
+			name: "append",
+			fn: func(d *DB[testentry]) error {
+				return d.Append("foo", testentry{Foo: "2", Bar: "2b"})
+			},
+		},
+		{
+			name: "delete",
+			fn: func(d *DB[testentry]) error {
+				return d.Delete("foo")
+			},
+		},
+		{
+			name: "truncate",
+			fn: func(d *DB[testentry]) error {
+				return d.Truncate("foo")
+			},
+		},
+		{
+			name: "export prefix",
+			fn: func(d *DB[testentry]) error {
+				return d.ExportPrefix("")
+			},
+		},
+		{
+			name: "close",
+			fn: func(d *DB[testentry]) error {
+				return d.Close()
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var opts Options
+			opts.Dir = fmt.Sprintf("test_%d", time.Now().UnixNano())
+			opts.Name = "foo"
+			d, err := New[testentry](context.Background(), opts, &mockBackend{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer os.RemoveAll(d.o.Dir)
+
+			if err = d.Append("foo", testentry{Foo: "1", Bar: "1b"}); err != nil {
+				t.Fatal(err)
+			}
+
+			if err = d.Close(); err != nil {
+				t.Fatal(err)
+			}
+
+			if err = tt.fn(d); err != ErrClosed {
+				t.Errorf("error = %v, want %v", err, ErrClosed)
+			}
+		})
+	}
+}
+
+func TestDB_Delete(t *testing.T) {
+	type testcase struct {
+		name              string
+		deleter           bool
+		deleteFromBackend bool
+		key               string
+		exportActive      bool
+
+		wantMakeErr error
+		wantErr     bool
+		wantDeleted []string
+	}
+
+	tests := []testcase{
+		{
+			name: "basic",
+			key:  "a",
+		},
+		{
+			name:    "not found",
+			key:     "missing",
+			wantErr: true,
+		},
+		{
+			name:              "from backend",
+			deleter:           true,
+			deleteFromBackend: true,
+			key:               "a",
+			wantDeleted:       []string{"foo.a.csv"},
+		},
+		{
+			name:              "spilled key from backend",
+			deleter:           true,
+			deleteFromBackend: true,
+			key:               "missing",
+			wantDeleted:       []string{"foo.missing.csv"},
+		},
+		{
+			name:              "backend without deleter",
+			deleteFromBackend: true,
+			wantMakeErr:       ErrDeleterNotImplemented,
+		},
+		{
+			name:         "export active",
+			key:          "a",
+			exportActive: true,
+			wantErr:      true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var opts Options
+			opts.Dir = fmt.Sprintf("test_%d", time.Now().UnixNano())
+			opts.Name = "foo"
+			opts.DeleteFromBackend = tt.deleteFromBackend
+
+			var b Backend = &mockBackend{}
+			deleter := &mockDeleterBackend{}
+			if tt.deleter {
+				b = deleter
+			}
+
+			d, err := makeDB[testentry](opts, b)
+			if err != tt.wantMakeErr {
+				t.Fatalf("makeDB() error = %v, wantErr %v", err, tt.wantMakeErr)
+			} else if err != nil {
+				return
+			}
+			defer os.RemoveAll(d.o.Dir)
+
+			if err = d.Append("a", testentry{Foo: "1", Bar: "1b"}); err != nil {
+				t.Fatal(err)
+			}
+
+			if err = d.setLastExported("foo.a.csv"); err != nil {
+				t.Fatal(err)
+			}
+
+			ctx := context.Background()
+			if tt.exportActive {
+				d.emux.Lock()
+				defer d.emux.Unlock()
+
+				var cancel func()
+				ctx, cancel = context.WithTimeout(ctx, 10*time.Millisecond)
+				defer cancel()
+			}
+
+			if err = d.DeleteContext(ctx, tt.key); (err != nil) != tt.wantErr {
+				t.Fatalf("DB.Delete() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if !reflect.DeepEqual(deleter.deleted, tt.wantDeleted) {
+				t.Errorf("DB.Delete() deleted = %v, want %v", deleter.deleted, tt.wantDeleted)
+			}
+
+			if tt.wantErr || tt.key != "a" {
+				return
+			}
+
+			for _, filename := range []string{"foo.a.csv", "foo.a.csv.exported"} {
+				if _, err = os.Stat(path.Join(d.getFullPath(), filename)); !os.IsNotExist(err) {
+					t.Errorf("DB.Delete() did not remove <%s>, error = %v", filename, err)
+				}
+			}
+		})
+	}
+}
+
+func TestDB_lockTimeout(t *testing.T) {
+	var opts Options
+	opts.Dir = fmt.Sprintf("test_%d", time.Now().UnixNano())
+	opts.Name = "foo"
+	opts.LockTimeout = 10 * time.Millisecond
+	d, err := makeDB[testentry](opts, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(d.o.Dir)
+
+	d.mux.Lock()
+	err = d.Append("foo", testentry{Foo: "1", Bar: "1b"})
+	d.mux.Unlock()
+
+	if !errors.Is(err, ErrBusy) || !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("DB.Append() error = %v, want %v", err, ErrBusy)
+	}
+
+	if err = d.Append("foo", testentry{Foo: "1", Bar: "1b"}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// syncFS is a fileSystem which counts the syncs of files and directories
+type syncFS struct {
+	fileSystem
+
+	files atomic.Int64
+	dirs  atomic.Int64
+}
+
+func (s *syncFS) OpenFile(name string, flag int, perm os.FileMode) (f file, err error) {
+	if f, err = s.fileSystem.OpenFile(name, flag, perm); err != nil {
+		return
+	}
+
+	return &syncFile{file: f, s: s}, nil
+}
+
+func (s *syncFS) SyncDir(name string) error {
+	s.dirs.Add(1)
+	return s.fileSystem.SyncDir(name)
+}
+
+type syncFile struct {
+	file
+
+	s *syncFS
+}
+
+func (f *syncFile) Sync() error {
+	f.s.files.Add(1)
+	return f.file.Sync()
+}
+
+func TestDB_SyncWrites(t *testing.T) {
+	type testcase struct {
+		name       string
+		syncWrites bool
+
+		wantFiles int64
+		wantDirs  int64
+	}
+
+	tests := []testcase{
+		{
+			name:       "enabled",
+			syncWrites: true,
+			wantFiles:  2,
+			wantDirs:   1,
+		},
+		{
+			name:       "disabled",
+			syncWrites: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var opts Options
+			opts.Dir = fmt.Sprintf("test_%d", time.Now().UnixNano())
+			opts.Name = "foo"
+			opts.SyncWrites = tt.syncWrites
+			d, err := makeDB[testentry](opts, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer os.RemoveAll(d.o.Dir)
+
+			s := &syncFS{fileSystem: d.fs}
+			d.fs = s
+			for i := 0; i < 2; i++ {
+				if err = d.Append("a", testentry{Foo: "1", Bar: "1b"}); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			if got := s.files.Load(); got != tt.wantFiles {
+				t.Errorf("file syncs = %d, want %d", got, tt.wantFiles)
+			}
+
+			if got := s.dirs.Load(); got != tt.wantDirs {
+				t.Errorf("directory syncs = %d, want %d", got, tt.wantDirs)
+			}
+		})
+	}
+}
+
+func TestDB_splitBackends(t *testing.T) {
+	dir := fmt.Sprintf("test_%d", time.Now().UnixNano())
+	defer os.RemoveAll(dir)
+
+	archive := &mockBackend{
+		importFn: func(ctx context.Context, prefix, filename string, w io.Writer) (err error) {
+			if filename != "foo.old.csv" {
+				return os.ErrNotExist
+			}
+
+			_, err = w.Write([]byte("foo,bar\nold,old\n"))
+			return
+		},
+		exportFn: func(ctx context.Context, prefix, filename string, r io.Reader) (string, error) {
+			return "", errors.New("archive is read-only")
+		},
+	}
+
+	var exported []string
+	destination := &mockBackend{
+		importFn: func(ctx context.Context, prefix, filename string, w io.Writer) error {
+			return errors.New("destination is write-only")
+		},
+		exportFn: func(ctx context.Context, prefix, filename string, r io.Reader) (string, error) {
+			exported = append(exported, filename)
+			return filename, nil
+		},
+	}
+
+	var opts Options
+	opts.Dir = dir
+	opts.Name = "foo"
+	opts.ImportBackend = archive
+	opts.ExportBackend = destination
+	d, err := makeDB[testentry](opts, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	w := &bytes.Buffer{}
+	if err = d.Get(w, "old"); err != nil {
+		t.Fatal(err)
+	}
+
+	if want := "foo,bar\nold,old\n"; w.String() != want {
+		t.Errorf("DB.Get() = %q, want %q", w.String(), want)
+	}
+
+	if err = d.Append("new", testentry{Foo: "new", Bar: "new"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err = d.ExportPrefix("new"); err != nil {
+		t.Fatal(err)
+	}
+
+	if want := []string{"foo.new.csv"}; !reflect.DeepEqual(exported, want) {
+		t.Errorf("exported = %v, want %v", exported, want)
+	}
+}
+
+func TestDB_contextVariants(t *testing.T) {
+	type testcase struct {
+		name string
+		fn   func(ctx context.Context, d *DB[testentry]) error
+	}
+
+	tests := []testcase{
+		{
+			name: "AppendJSONContext",
+			fn: func(ctx context.Context, d *DB[testentry]) error {
+				return d.AppendJSONContext(ctx, "a", strings.NewReader(`{"Foo":"2","Bar":"2b"}`))
+			},
+		},
+		{
+			name: "AppendRawContext",
+			fn: func(ctx context.Context, d *DB[testentry]) error {
+				return d.AppendRawContext(ctx, "a", strings.NewReader("2,2b\n"))
+			},
+		},
+		{
+			name: "ImportFileContext",
+			fn: func(ctx context.Context, d *DB[testentry]) error {
+				return d.ImportFileContext(ctx, "a", d.getPath("foo.a.csv"), true)
+			},
+		},
+		{
+			name: "GetSQLContext",
+			fn: func(ctx context.Context, d *DB[testentry]) error {
+				return d.GetSQLContext(ctx, io.Discard, "a", SQLOptions{Table: "a"})
+			},
+		},
+		{
+			name: "CopyKeyContext",
+			fn: func(ctx context.Context, d *DB[testentry]) error {
+				return d.CopyKeyContext(ctx, "a", "b")
+			},
+		},
+		{
+			name: "EvictContext",
+			fn: func(ctx context.Context, d *DB[testentry]) (err error) {
+				if err = d.ExportPrefixContext(ctx, ""); err != nil {
+					return
+				}
+
+				return d.EvictContext(ctx, "a")
+			},
+		},
+		{
+			name: "SetHoldContext",
+			fn: func(ctx context.Context, d *DB[testentry]) error {
+				return d.SetHoldContext(ctx, "a")
+			},
+		},
+		{
+			name: "ClearHoldContext",
+			fn: func(ctx context.Context, d *DB[testentry]) error {
+				return d.ClearHoldContext(ctx, "a")
+			},
+		},
+		{
+			name: "QuarantineContext",
+			fn: func(ctx context.Context, d *DB[testentry]) error {
+				return d.QuarantineContext(ctx, "a")
+			},
+		},
+		{
+			name: "MarkConsumedContext",
+			fn: func(ctx context.Context, d *DB[testentry]) error {
+				return d.MarkConsumedContext(ctx, "a", time.Now())
+			},
+		},
+		{
+			name: "RepairHeaderContext",
+			fn: func(ctx context.Context, d *DB[testentry]) (err error) {
+				_, err = d.RepairHeaderContext(ctx, "a")
+				return
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var opts Options
+			opts.Dir = fmt.Sprintf("test_%d", time.Now().UnixNano())
+			opts.Name = "foo"
+			d, err := makeDB[testentry](opts, &mockBackend{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer os.RemoveAll(d.o.Dir)
+
+			if err = d.Append("a", testentry{Foo: "1", Bar: "1b"}); err != nil {
+				t.Fatal(err)
+			}
+
+			ctx, cancel := context.WithCancel(context.Background())
+			cancel()
+			if err = tt.fn(ctx, &d); !errors.Is(err, context.Canceled) {
+				t.Fatalf("error = %v, want %v", err, context.Canceled)
+			}
+
+			if err = tt.fn(context.Background(), &d); err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
+
+func TestDB_AppendJSONContext_contextColumns(t *testing.T) {
+	var opts Options
+	opts.Dir = fmt.Sprintf("test_%d", time.Now().UnixNano())
+	opts.Name = "foo"
+	opts.ContextColumns = []ContextColumn{{Name: "request_id", Value: ContextValue(requestIDKey{})}}
+	d, err := makeDB[testentry](opts, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(d.o.Dir)
+
+	ctx := context.WithValue(context.Background(), requestIDKey{}, "r1")
+	if err = d.AppendJSONContext(ctx, "a", strings.NewReader(`[{"Foo":"1","Bar":"1b"}]`)); err != nil {
+		t.Fatal(err)
+	}
+
+	w := &bytes.Buffer{}
+	if err = d.Get(w, "a"); err != nil {
+		t.Fatal(err)
+	}
+
+	if want := "foo,bar,request_id\n1,1b,r1\n"; w.String() != want {
+		t.Errorf("DB.Get() = %q, want %q", w.String(), want)
+	}
+}
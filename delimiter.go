@@ -0,0 +1,14 @@
+package csvdb
+
+import (
+	"errors"
+	"unicode/utf8"
+)
+
+// ErrInvalidDelimiter is returned when Options.Delimiter cannot separate the fields of a row
+var ErrInvalidDelimiter = errors.New("invalid delimiter, must be a valid rune other than a quote, carriage return or newline")
+
+// validDelimiter will return whether a rune can be used as the delimiter of a csv.Reader and csv.Writer
+func validDelimiter(r rune) bool {
+	return r != 0 && r != '"' && r != '\r' && r != '\n' && utf8.ValidRune(r) && r != utf8.RuneError
+}
@@ -0,0 +1,143 @@
+package csvdb
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDB_Delimiter(t *testing.T) {
+	type testcase struct {
+		name string
+		opts func(o *Options)
+	}
+
+	tests := []testcase{
+		{name: "basic", opts: func(o *Options) {}},
+		{name: "row index", opts: func(o *Options) { o.RowIndexInterval = 1 }},
+		{name: "ndjson storage", opts: func(o *Options) { o.Storage = StorageNDJSON }},
+		{name: "row checksums", opts: func(o *Options) { o.RowChecksums = true }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var opts Options
+			opts.Dir = fmt.Sprintf("test_%d", time.Now().UnixNano())
+			opts.Name = "foo"
+			opts.Delimiter = '\t'
+			tt.opts(&opts)
+			d, err := makeDB[testentry](opts, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer os.RemoveAll(opts.Dir)
+
+			if err = d.Append("a", testentry{Foo: "1", Bar: "1,b"}); err != nil {
+				t.Fatal(err)
+			}
+
+			raw := "2\t2,b\n"
+			if opts.RowChecksums {
+				raw = "2\t2,b\t\n"
+			}
+
+			if err = d.AppendRaw("a", strings.NewReader(raw)); err != nil {
+				t.Fatal(err)
+			}
+
+			if err = d.UpdateRows("a", func(e testentry) (testentry, bool, error) {
+				e.Foo += "0"
+				return e, true, nil
+			}); err != nil {
+				t.Fatal(err)
+			}
+
+			w := &bytes.Buffer{}
+			if err = d.Get(w, "a"); err != nil {
+				t.Fatal(err)
+			}
+
+			header, want := "foo\tbar\n", []string{"10\t1,b", "20\t2,b"}
+			if opts.RowChecksums {
+				header = "foo\tbar\t" + RowChecksumColumn + "\n"
+			}
+
+			if !strings.HasPrefix(w.String(), header) {
+				t.Fatalf("DB.Get() = %q, want header %q", w.String(), header)
+			}
+
+			for _, row := range want {
+				if !strings.Contains(w.String(), "\n"+row) {
+					t.Errorf("DB.Get() = %q, want row %q", w.String(), row)
+				}
+			}
+
+			if opts.Storage != StorageCSV {
+				// Ranges cannot be read from stored rows
+				return
+			}
+
+			w.Reset()
+			if err = d.GetRange(w, "a", 1, 1); err != nil {
+				t.Fatal(err)
+			}
+
+			if !strings.HasPrefix(w.String(), header+want[1]) {
+				t.Errorf("DB.GetRange() = %q, want %q", w.String(), header+want[1])
+			}
+		})
+	}
+}
+
+func TestWriteSQL_delimiter(t *testing.T) {
+	w := &bytes.Buffer{}
+	o := SQLOptions{Table: "t", Delimiter: ';'}
+	if err := WriteSQL(w, strings.NewReader("foo;bar\n1;1,b\n"), o); err != nil {
+		t.Fatal(err)
+	}
+
+	if want := "INSERT INTO \"t\" (\"foo\", \"bar\") VALUES\n('1', '1,b');\n"; w.String() != want {
+		t.Errorf("WriteSQL() = %q, want %q", w.String(), want)
+	}
+}
+
+func TestOptions_Validate_delimiter(t *testing.T) {
+	type testcase struct {
+		name    string
+		opts    Options
+		wantErr error
+	}
+
+	tests := []testcase{
+		{
+			name: "default",
+			opts: Options{Dir: "test", Name: "foo"},
+		},
+		{
+			name: "tab",
+			opts: Options{Dir: "test", Name: "foo", Delimiter: '\t'},
+		},
+		{
+			name:    "quote",
+			opts:    Options{Dir: "test", Name: "foo", Delimiter: '"'},
+			wantErr: ErrInvalidDelimiter,
+		},
+		{
+			name:    "newline",
+			opts:    Options{Dir: "test", Name: "foo", Delimiter: '\n'},
+			wantErr: ErrInvalidDelimiter,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.opts.Validate(); !errors.Is(err, tt.wantErr) {
+				t.Errorf("Options.Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
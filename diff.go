@@ -0,0 +1,162 @@
+package csvdb
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+)
+
+// ErrDiffHeaderMismatch is returned when diffing keys whose headers do not match
+var ErrDiffHeaderMismatch = errors.New("cannot diff keys with mismatched headers")
+
+const (
+	// DiffAdded marks rows of the second key whose primary key is not held by the first
+	DiffAdded = "added"
+	// DiffRemoved marks rows of the first key whose primary key is not held by the second
+	DiffRemoved = "removed"
+	// DiffChanged marks rows of the second key whose values differ from the row of the first
+	// key holding the same primary key
+	DiffChanged = "changed"
+)
+
+// Diff will write the rows which differ between two keys, matched by the values of their
+// primary key column. The output is CSV, with a leading "change" column holding DiffAdded,
+// DiffRemoved or DiffChanged followed by the row. Added and changed rows are written as held
+// by keyB in its order, followed by the removed rows as held by keyA in its order. When a
+// primary key is repeated within a key, its last row is used. Versions of a key can be
+// compared by diffing against a copy made by CopyKey.
+func (d *DB[T]) Diff(w io.Writer, keyA, keyB, pkColumn string) (err error) {
+	return d.DiffContext(d.context(), w, keyA, keyB, pkColumn)
+}
+
+// DiffContext is the context-aware variant of Diff
+func (d *DB[T]) DiffContext(ctx context.Context, w io.Writer, keyA, keyB, pkColumn string) (err error) {
+	w, flush := d.encodeOutput(w)
+	defer flush(&err)
+
+	var (
+		header  []string
+		pkIndex int
+		order   []string
+		rows    = make(map[string][]string)
+	)
+
+	if err = d.readKey(ctx, keyA, func(r *csv.Reader) (err error) {
+		if header, err = r.Read(); err == io.EOF {
+			return fmt.Errorf("error diffing <%s>: %w", keyA, ErrEntryNotFound)
+		} else if err != nil {
+			return
+		}
+
+		if pkIndex = indexOf(header, pkColumn); pkIndex == -1 {
+			return fmt.Errorf("error diffing <%s>: %w <%s>", keyA, ErrColumnNotFound, pkColumn)
+		}
+
+		for i := 0; ; i++ {
+			var values []string
+			if values, err = r.Read(); err == io.EOF {
+				return nil
+			} else if err != nil {
+				return
+			}
+
+			if err = checkColumnCount(keyA, i, values, header); err != nil {
+				return
+			}
+
+			pk := values[pkIndex]
+			if _, ok := rows[pk]; !ok {
+				order = append(order, pk)
+			}
+
+			rows[pk] = values
+		}
+	}); err != nil {
+		return
+	}
+
+	cw := newCSVWriter(w, d.dialect)
+	if err = d.readKey(ctx, keyB, func(r *csv.Reader) (err error) {
+		var headerB []string
+		if headerB, err = r.Read(); err == io.EOF {
+			return fmt.Errorf("error diffing <%s>: %w", keyB, ErrEntryNotFound)
+		} else if err != nil {
+			return
+		}
+
+		if !reflect.DeepEqual(header, headerB) {
+			return ErrDiffHeaderMismatch
+		}
+
+		if err = cw.Write(append([]string{"change"}, header...)); err != nil {
+			return
+		}
+
+		seen := make(map[string]struct{}, len(rows))
+		for i := 0; ; i++ {
+			var values []string
+			if values, err = r.Read(); err == io.EOF {
+				break
+			} else if err != nil {
+				return
+			}
+
+			if err = checkColumnCount(keyB, i, values, headerB); err != nil {
+				return
+			}
+
+			pk := values[pkIndex]
+			seen[pk] = struct{}{}
+			switch old, ok := rows[pk]; {
+			case !ok:
+				err = cw.Write(append([]string{DiffAdded}, values...))
+			case !reflect.DeepEqual(old, values):
+				err = cw.Write(append([]string{DiffChanged}, values...))
+			}
+
+			if err != nil {
+				return
+			}
+		}
+
+		for _, pk := range order {
+			if _, ok := seen[pk]; ok {
+				continue
+			}
+
+			if err = cw.Write(append([]string{DiffRemoved}, rows[pk]...)); err != nil {
+				return
+			}
+		}
+
+		return nil
+	}); err != nil {
+		return
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
+
+// readKey will read the file of a key while its lock is held for reading
+func (d *DB[T]) readKey(ctx context.Context, key string, fn func(r *csv.Reader) error) (err error) {
+	var unlock func()
+	if unlock, err = d.rlockKey(ctx, key); err != nil {
+		return
+	}
+	defer unlock()
+	defer d.trackHydration(key)()
+
+	var f file
+	if f, err = d.getOrDownload(ctx, key); err != nil {
+		return
+	}
+	defer f.Close()
+
+	// Records may be ragged when CSV.FieldsPerRecord is negative, so callers indexing
+	// values by the position of a column must check the length of each record
+	return fn(newCSVReader(newContextReader(ctx, f), d.dialect))
+}
@@ -0,0 +1,100 @@
+package csvdb
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestDB_Diff(t *testing.T) {
+	type testcase struct {
+		name     string
+		a        []testentry
+		b        []testentry
+		pkColumn string
+		want     string
+		wantErr  error
+	}
+
+	tests := []testcase{
+		{
+			name:     "identical",
+			a:        []testentry{{Foo: "1", Bar: "a"}, {Foo: "2", Bar: "b"}},
+			b:        []testentry{{Foo: "1", Bar: "a"}, {Foo: "2", Bar: "b"}},
+			pkColumn: "foo",
+			want:     "change,foo,bar\n",
+		},
+		{
+			name:     "added, changed and removed",
+			a:        []testentry{{Foo: "1", Bar: "a"}, {Foo: "2", Bar: "b"}, {Foo: "3", Bar: "c"}},
+			b:        []testentry{{Foo: "4", Bar: "d"}, {Foo: "2", Bar: "B"}, {Foo: "3", Bar: "c"}},
+			pkColumn: "foo",
+			want:     "change,foo,bar\nadded,4,d\nchanged,2,B\nremoved,1,a\n",
+		},
+		{
+			name:     "repeated primary keys",
+			a:        []testentry{{Foo: "1", Bar: "a"}, {Foo: "1", Bar: "b"}},
+			b:        []testentry{{Foo: "1", Bar: "b"}},
+			pkColumn: "foo",
+			want:     "change,foo,bar\n",
+		},
+		{
+			name:     "missing column",
+			a:        []testentry{{Foo: "1", Bar: "a"}},
+			b:        []testentry{{Foo: "1", Bar: "a"}},
+			pkColumn: "baz",
+			wantErr:  ErrColumnNotFound,
+		},
+		{
+			name:     "missing key",
+			a:        []testentry{{Foo: "1", Bar: "a"}},
+			pkColumn: "foo",
+			wantErr:  ErrEntryNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := fmt.Sprintf("test_%d", time.Now().UnixNano())
+			defer os.RemoveAll(dir)
+
+			var opts Options
+			opts.Dir = dir
+			opts.Name = "foo"
+			b := &mockBackend{
+				importFn: func(ctx context.Context, prefix, filename string, w io.Writer) error {
+					return os.ErrNotExist
+				},
+			}
+
+			d, err := makeDB[testentry](opts, b)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if err = d.Append("a", tt.a...); err != nil {
+				t.Fatal(err)
+			}
+
+			if err = d.Append("b", tt.b...); err != nil {
+				t.Fatal(err)
+			}
+
+			w := &bytes.Buffer{}
+			if err = d.Diff(w, "a", "b", tt.pkColumn); !errors.Is(err, tt.wantErr) {
+				t.Fatalf("DB.Diff() error = %v, wantErr %v", err, tt.wantErr)
+			} else if err != nil {
+				return
+			}
+
+			if w.String() != tt.want {
+				t.Errorf("DB.Diff() = %q, want %q", w.String(), tt.want)
+			}
+		})
+	}
+}
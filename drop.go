@@ -0,0 +1,111 @@
+package csvdb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"strings"
+)
+
+var (
+	// ErrInvalidConfirmation is returned when the confirmation provided to DropDB does not match the DB name
+	ErrInvalidConfirmation = errors.New("invalid confirmation, must match the DB name")
+	// ErrDeleterNotImplemented is returned when remote deletion is requested from a Backend which does not implement Deleter
+	ErrDeleterNotImplemented = errors.New("backend does not implement Deleter")
+)
+
+// DropDB will remove every key, export marker and quarantined file of the DB. The confirm
+// value must match the name of the DB as a guard against accidental use. When remote is
+// set, the exported files of the local keys are also deleted from the Backend, which must
+// implement Deleter. The DB remains usable once dropped.
+func (d *DB[T]) DropDB(ctx context.Context, confirm string, remote bool) (err error) {
+	if err = d.checkWritable(); err != nil {
+		return
+	}
+
+	if confirm != d.o.Name {
+		return ErrInvalidConfirmation
+	}
+
+	var deleter Deleter
+	if remote {
+		var ok bool
+		if deleter, ok = d.eb.(Deleter); !ok {
+			return ErrDeleterNotImplemented
+		}
+	}
+
+	// Prevent exports from uploading files while they are being dropped
+	d.emux.Lock()
+	defer d.emux.Unlock()
+
+	if err = d.lock(ctx); err != nil {
+		return
+	}
+	defer d.mux.Unlock()
+
+	// Nothing is dropped while any key is under legal hold
+	var holds []string
+	if holds, err = d.Holds(); err != nil {
+		return
+	} else if len(holds) > 0 {
+		return fmt.Errorf("%w: <%s>", ErrEntryHeld, strings.Join(holds, ", "))
+	}
+
+	if deleter != nil {
+		if err = d.forEach(func(filename string, info os.FileInfo) (err error) {
+			if err = deleter.Delete(d.request(withKey(ctx, d.getKey(filename)), OpDelete), d.remotePrefixOf(filename), d.exportName(filename)); err != nil && !os.IsNotExist(err) {
+				return
+			}
+
+			if err = d.deleteChecksum(ctx, deleter, filename); err != nil {
+				return
+			}
+
+			d.catalog.remove(d.getKey(filename))
+			return nil
+		}); err != nil {
+			return
+		}
+	}
+
+	fullPath := d.getFullPath()
+	if err = removeDir(d.fs, fullPath); err != nil {
+		return
+	}
+
+	d.quarantined = make(map[string]struct{})
+	d.integrityIssues = nil
+	if err = d.fs.MkdirAll(fullPath, 0744); err != nil {
+		return
+	}
+
+	return d.makeShards()
+}
+
+// removeDir will remove a directory and all of its contents
+func removeDir(fsys fileSystem, dir string) (err error) {
+	var entries []os.DirEntry
+	if entries, err = fsys.ReadDir(dir); os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return
+	}
+
+	for _, entry := range entries {
+		name := path.Join(dir, entry.Name())
+		if entry.IsDir() {
+			err = removeDir(fsys, name)
+		} else {
+			err = fsys.Remove(name)
+		}
+
+		if err != nil && !os.IsNotExist(err) {
+			return
+		}
+	}
+
+	return fsys.Remove(dir)
+}
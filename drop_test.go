@@ -0,0 +1,135 @@
+package csvdb
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+)
+
+type mockDeleterBackend struct {
+	mockBackend
+
+	deleted []string
+}
+
+func (m *mockDeleterBackend) Delete(ctx context.Context, prefix, filename string) (err error) {
+	m.deleted = append(m.deleted, filename)
+	return
+}
+
+func TestDB_DropDB(t *testing.T) {
+	type testcase struct {
+		name     string
+		inMemory bool
+		deleter  bool
+		confirm  string
+		remote   bool
+
+		wantErr     error
+		wantDeleted []string
+	}
+
+	tests := []testcase{
+		{
+			name:    "local",
+			confirm: "foo",
+		},
+		{
+			name:     "in memory",
+			inMemory: true,
+			confirm:  "foo",
+		},
+		{
+			name:        "remote",
+			deleter:     true,
+			confirm:     "foo",
+			remote:      true,
+			wantDeleted: []string{"foo.a.csv", "foo.b.csv"},
+		},
+		{
+			name:    "invalid confirmation",
+			confirm: "bar",
+			wantErr: ErrInvalidConfirmation,
+		},
+		{
+			name:    "remote without deleter",
+			confirm: "foo",
+			remote:  true,
+			wantErr: ErrDeleterNotImplemented,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var opts Options
+			opts.Dir = fmt.Sprintf("test_%d", time.Now().UnixNano())
+			opts.Name = "foo"
+			opts.InMemory = tt.inMemory
+
+			var b Backend = &mockBackend{}
+			deleter := &mockDeleterBackend{}
+			if tt.deleter {
+				b = deleter
+			}
+
+			d, err := makeDB[testentry](opts, b)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer os.RemoveAll(d.o.Dir)
+
+			for _, key := range []string{"a", "b", "c"} {
+				if err = d.Append(key, testentry{Foo: "1", Bar: "1b"}); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			if err = d.setLastExported("foo.a.csv"); err != nil {
+				t.Fatal(err)
+			}
+
+			if err = d.Quarantine("c"); err != nil {
+				t.Fatal(err)
+			}
+
+			if err = d.DropDB(context.Background(), tt.confirm, tt.remote); err != tt.wantErr {
+				t.Fatalf("DB.DropDB() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			sort.Strings(deleter.deleted)
+			if !reflect.DeepEqual(deleter.deleted, tt.wantDeleted) {
+				t.Errorf("DB.DropDB() deleted = %v, want %v", deleter.deleted, tt.wantDeleted)
+			}
+
+			var entries []os.DirEntry
+			if entries, err = d.fs.ReadDir(d.getFullPath()); err != nil {
+				t.Fatal(err)
+			}
+
+			if tt.wantErr != nil {
+				if len(entries) == 0 {
+					t.Errorf("DB.DropDB() removed files on error")
+				}
+
+				return
+			}
+
+			if len(entries) != 0 {
+				t.Errorf("DB.DropDB() remaining entries = %d, want 0", len(entries))
+			}
+
+			if keys := d.Quarantined(); len(keys) != 0 {
+				t.Errorf("DB.Quarantined() = %v, want none", keys)
+			}
+
+			// Ensure the DB remains usable
+			if err = d.Append("a", testentry{Foo: "1", Bar: "1b"}); err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
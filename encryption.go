@@ -0,0 +1,438 @@
+package csvdb
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"regexp"
+)
+
+// encryptedExt is the extension of exported encrypted files
+const encryptedExt = ".enc"
+
+// maxRecordSize is the maximum number of plaintext bytes sealed within a single record
+const maxRecordSize = 64 * 1024
+
+// streamIDSize is the size of the random id of an encrypted stream
+const streamIDSize = 16
+
+var (
+	// recordMagic prefixes every encrypted record
+	recordMagic = [4]byte{'C', 'S', 'E', '1'}
+	// streamRecordMagic prefixes the stream record, which holds the id of a stream and starts it
+	streamRecordMagic = [4]byte{'C', 'S', 'S', '1'}
+)
+
+var (
+	// ErrEncryptionIncompatible is returned when Options.Encryption is set alongside options
+	// which access files at byte offsets
+	ErrEncryptionIncompatible = errors.New("invalid encryption, cannot be set alongside rowIndexInterval or tailRepair")
+	// ErrInvalidEncryptedRecord is returned when a stored file is not encrypted, is corrupt or
+	// was encrypted with a different key
+	ErrInvalidEncryptedRecord = errors.New("invalid encrypted record")
+)
+
+// KeyID identifies a version of a named key
+type KeyID struct {
+	Name    string
+	Version uint32
+}
+
+// KeyProvider provides the keys files are encrypted with. Keys must be 16, 24 or 32 bytes,
+// selecting AES-128, AES-192 or AES-256.
+type KeyProvider interface {
+	// CurrentKey returns the id of the key new data is encrypted with
+	CurrentKey() (id KeyID, err error)
+	// Key returns the key of an id, keys must remain available for as long as data
+	// encrypted with them is stored
+	Key(id KeyID) (key []byte, err error)
+}
+
+// encryption will return the codec files are encrypted with, ok is false when files are
+// stored unencrypted
+func (o *Options) encryption() (c aesCodec, ok bool) {
+	switch {
+	case o.KEK != nil:
+		return aesCodec{env: newEnvelope(o.KEK)}, true
+	case o.Encryption != nil:
+		return aesCodec{kp: o.Encryption}, true
+	default:
+		return
+	}
+}
+
+// encryptedData matches the files holding rows, such as data files, their temporary files
+// and their journals
+var encryptedData = regexp.MustCompile(`\.csv(\.|$)`)
+
+var _ fileSystem = encryptFS{}
+
+// encryptFS is a fileSystem storing the files holding rows encrypted with AES-GCM. Each
+// write is sealed as records appended to the stream of the file, and reads open every record
+// in turn. Sizes and truncation apply to the stored bytes.
+type encryptFS struct {
+	fileSystem
+	c aesCodec
+}
+
+func (fsys encryptFS) Open(name string) (f file, err error) {
+	return fsys.wrap(fsys.fileSystem.Open(name))
+}
+
+func (fsys encryptFS) OpenFile(name string, flag int, perm os.FileMode) (f file, err error) {
+	if flag&os.O_TRUNC != 0 {
+		fsys.forget(name)
+	}
+
+	return fsys.wrap(fsys.fileSystem.OpenFile(name, flag, perm))
+}
+
+func (fsys encryptFS) Create(name string) (f file, err error) {
+	fsys.forget(name)
+	return fsys.wrap(fsys.fileSystem.Create(name))
+}
+
+func (fsys encryptFS) Remove(name string) error {
+	fsys.forget(name)
+	return fsys.fileSystem.Remove(name)
+}
+
+func (fsys encryptFS) Rename(oldpath, newpath string) error {
+	fsys.forget(newpath)
+	return fsys.fileSystem.Rename(oldpath, newpath)
+}
+
+func (fsys encryptFS) CreateTemp(dir, pattern string) (f file, err error) {
+	return fsys.wrap(fsys.fileSystem.CreateTemp(dir, pattern))
+}
+
+func (fsys encryptFS) wrap(f file, err error) (file, error) {
+	if err != nil || !encryptedData.MatchString(f.Name()) {
+		return f, err
+	}
+
+	return &codecFile{f: f, name: f.Name(), c: aesFileCodec{aesCodec: fsys.c, f: f}}, nil
+}
+
+// forget will drop the cached data key of a file which is about to be removed or replaced.
+// Data keys of files truncated otherwise are dropped once the cache of the envelope is full.
+func (fsys encryptFS) forget(name string) {
+	if fsys.c.env == nil || !encryptedData.MatchString(name) {
+		return
+	}
+
+	f, err := fsys.fileSystem.Open(name)
+	if err != nil {
+		return
+	}
+	defer f.Close()
+
+	if _, wrapped, err := fsys.c.readStart(f); err == nil {
+		fsys.c.env.forget(wrapped)
+	}
+}
+
+var _ Codec = aesCodec{}
+
+// aesCodec is a Codec sealing data as AES-GCM records. Each record holds the id of its key,
+// so records remain readable once the current key is rotated. When env is set, streams are
+// instead sealed with a data key of their own, which is held wrapped within a key record.
+//
+// Streams start with a stream record holding a random id. Records are authenticated alongside
+// the id of their stream and their offset within it, so records cannot be reordered, or moved
+// between streams, without failing to open.
+type aesCodec struct {
+	kp  KeyProvider
+	env *envelope
+}
+
+func (aesCodec) Extension() string { return encryptedExt }
+
+func (c aesCodec) NewWriter(w io.Writer) (io.WriteCloser, error) {
+	return c.newWriter(w, nil)
+}
+
+// newWriter will return a writer appending records to the provided stream. When the stream is
+// nil, a stream is started by writing its stream record, and key record when env is set.
+func (c aesCodec) newWriter(w io.Writer, s *stream) (aw *aesWriter, err error) {
+	if s == nil {
+		if s, err = c.start(w); err != nil {
+			return
+		}
+	}
+
+	aw = &aesWriter{w: w, aead: s.dataKey, header: recordHeader(dataKeyID), id: s.id, off: s.size}
+	if c.env != nil {
+		return
+	}
+
+	var id KeyID
+	if id, err = c.kp.CurrentKey(); err != nil {
+		return nil, err
+	}
+
+	if len(id.Name) > math.MaxUint8 {
+		return nil, fmt.Errorf("invalid key name <%s>, cannot exceed %d bytes", id.Name, math.MaxUint8)
+	}
+
+	if aw.aead, err = c.aead(id); err != nil {
+		return nil, err
+	}
+
+	aw.header = recordHeader(id)
+	return
+}
+
+// start will write the start of a stream to the provided writer
+func (c aesCodec) start(w io.Writer) (s *stream, err error) {
+	s = &stream{id: make([]byte, streamIDSize)}
+	if _, err = rand.Read(s.id); err != nil {
+		return
+	}
+
+	bs := append(append([]byte{}, streamRecordMagic[:]...), s.id...)
+	if c.env != nil {
+		var record []byte
+		if s.dataKey, record, err = c.env.generate(); err != nil {
+			return
+		}
+
+		bs = append(bs, record...)
+	}
+
+	if _, err = w.Write(bs); err != nil {
+		return
+	}
+
+	s.size = int64(len(bs))
+	return
+}
+
+func (c aesCodec) NewReader(r io.Reader) (io.ReadCloser, error) {
+	a := &aesReader{c: c}
+	a.r = &countingReader{r: bufio.NewReader(r), n: &a.off}
+	return io.NopCloser(a), nil
+}
+
+func (c aesCodec) aead(id KeyID) (aead cipher.AEAD, err error) {
+	var key []byte
+	if key, err = c.kp.Key(id); err != nil {
+		return
+	}
+
+	if aead, err = newGCM(key); err != nil {
+		return nil, fmt.Errorf("error initializing key <%s:%d>: %w", id.Name, id.Version, err)
+	}
+
+	return
+}
+
+// stream is the start of a stream records are appended to
+type stream struct {
+	id []byte
+	// dataKey is the data key of the key record of the stream, set when env is set
+	dataKey cipher.AEAD
+	// size is the size of the stream, which is the offset of the next record
+	size int64
+}
+
+// recordHeader will return the header of the records sealed with a key
+func recordHeader(id KeyID) (header []byte) {
+	header = append(header, recordMagic[:]...)
+	header = append(header, byte(len(id.Name)))
+	header = append(header, id.Name...)
+	return binary.BigEndian.AppendUint32(header, id.Version)
+}
+
+// recordAAD will return the data authenticated alongside the contents of a record, which is
+// its header, the id of its stream and its offset within the stream
+func recordAAD(header, id []byte, off int64) (aad []byte) {
+	aad = append(aad, header...)
+	aad = append(aad, id...)
+	return binary.BigEndian.AppendUint64(aad, uint64(off))
+}
+
+// aesWriter buffers written bytes, sealing them as records once maxRecordSize is reached
+// and when closed
+type aesWriter struct {
+	w      io.Writer
+	aead   cipher.AEAD
+	header []byte
+	// id is the id of the stream, and off is the offset of the next record within it
+	id  []byte
+	off int64
+	buf bytes.Buffer
+}
+
+func (a *aesWriter) Write(p []byte) (n int, err error) {
+	n, _ = a.buf.Write(p)
+	for a.buf.Len() >= maxRecordSize {
+		if err = a.seal(a.buf.Next(maxRecordSize)); err != nil {
+			return
+		}
+	}
+
+	return
+}
+
+func (a *aesWriter) Close() (err error) {
+	if a.buf.Len() == 0 {
+		return
+	}
+
+	return a.seal(a.buf.Next(a.buf.Len()))
+}
+
+// seal will write a record of the provided plaintext
+func (a *aesWriter) seal(plaintext []byte) (err error) {
+	nonce := make([]byte, a.aead.NonceSize())
+	if _, err = rand.Read(nonce); err != nil {
+		return
+	}
+
+	record := append([]byte{}, a.header...)
+	record = append(record, nonce...)
+	record = binary.BigEndian.AppendUint32(record, uint32(len(plaintext)+a.aead.Overhead()))
+	record = a.aead.Seal(record, nonce, plaintext, recordAAD(a.header, a.id, a.off))
+	if _, err = a.w.Write(record); err != nil {
+		return
+	}
+
+	a.off += int64(len(record))
+	return
+}
+
+// aesReader opens every record of a reader in turn
+type aesReader struct {
+	c aesCodec
+	r io.Reader
+	// off is the number of bytes read, which is the offset of the next record
+	off int64
+	// id is the id of the stream, read from its stream record
+	id    []byte
+	aeads map[KeyID]cipher.AEAD
+	// dataKey is the data key of the last key record read
+	dataKey cipher.AEAD
+	buf     []byte
+}
+
+func (a *aesReader) Read(p []byte) (n int, err error) {
+	for len(a.buf) == 0 {
+		if a.buf, err = a.open(); err != nil {
+			return
+		}
+	}
+
+	n = copy(p, a.buf)
+	a.buf = a.buf[n:]
+	return
+}
+
+// open will read and open the next record, returning io.EOF once every record is read
+func (a *aesReader) open() (plaintext []byte, err error) {
+	off := a.off
+	var magic [4]byte
+	if _, err = io.ReadFull(a.r, magic[:]); err == io.EOF {
+		return
+	} else if err != nil {
+		return nil, ErrInvalidEncryptedRecord
+	}
+
+	if magic == streamRecordMagic && off == 0 {
+		a.id = make([]byte, streamIDSize)
+		if _, err = io.ReadFull(a.r, a.id); err != nil {
+			return nil, ErrInvalidEncryptedRecord
+		}
+
+		return nil, nil
+	} else if a.id == nil {
+		// Streams start with their stream record
+		return nil, ErrInvalidEncryptedRecord
+	}
+
+	if magic == keyRecordMagic && a.c.env != nil {
+		// Records which follow are sealed with the data key of the key record
+		if a.dataKey, err = a.c.env.readKey(a.r); err != nil {
+			return
+		}
+
+		return nil, nil
+	} else if magic != recordMagic {
+		return nil, ErrInvalidEncryptedRecord
+	}
+
+	var id KeyID
+	var nameLen [1]byte
+	if _, err = io.ReadFull(a.r, nameLen[:]); err != nil {
+		return nil, ErrInvalidEncryptedRecord
+	}
+
+	name := make([]byte, nameLen[0])
+	if _, err = io.ReadFull(a.r, name); err != nil {
+		return nil, ErrInvalidEncryptedRecord
+	}
+
+	id.Name = string(name)
+	if err = binary.Read(a.r, binary.BigEndian, &id.Version); err != nil {
+		return nil, ErrInvalidEncryptedRecord
+	}
+
+	var aead cipher.AEAD
+	if aead, err = a.aead(id); err != nil {
+		return
+	}
+
+	nonce := make([]byte, aead.NonceSize())
+	if _, err = io.ReadFull(a.r, nonce); err != nil {
+		return nil, ErrInvalidEncryptedRecord
+	}
+
+	var size uint32
+	if err = binary.Read(a.r, binary.BigEndian, &size); err != nil || size > maxRecordSize+uint32(aead.Overhead()) {
+		return nil, ErrInvalidEncryptedRecord
+	}
+
+	ciphertext := make([]byte, size)
+	if _, err = io.ReadFull(a.r, ciphertext); err != nil {
+		return nil, ErrInvalidEncryptedRecord
+	}
+
+	if plaintext, err = aead.Open(ciphertext[:0], nonce, ciphertext, recordAAD(recordHeader(id), a.id, off)); err != nil {
+		return nil, ErrInvalidEncryptedRecord
+	}
+
+	return
+}
+
+// aead will return the AEAD of a key, which is cached for the lifetime of the reader
+func (a *aesReader) aead(id KeyID) (aead cipher.AEAD, err error) {
+	if a.c.env != nil {
+		if id != dataKeyID || a.dataKey == nil {
+			return nil, ErrInvalidEncryptedRecord
+		}
+
+		return a.dataKey, nil
+	}
+
+	if aead, ok := a.aeads[id]; ok {
+		return aead, nil
+	}
+
+	if aead, err = a.c.aead(id); err != nil {
+		return
+	}
+
+	if a.aeads == nil {
+		a.aeads = make(map[KeyID]cipher.AEAD)
+	}
+
+	a.aeads[id] = aead
+	return
+}
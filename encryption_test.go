@@ -0,0 +1,318 @@
+package csvdb
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestDB_Encryption(t *testing.T) {
+	type testcase struct {
+		name string
+		opts func(o *Options)
+	}
+
+	tests := []testcase{
+		{name: "basic", opts: func(o *Options) {}},
+		{name: "in memory", opts: func(o *Options) { o.InMemory = true }},
+		{name: "write ahead log", opts: func(o *Options) { o.WriteAheadLog = true }},
+		{name: "atomic append", opts: func(o *Options) { o.AtomicAppend = true }},
+		{name: "compression", opts: func(o *Options) { o.Compression = CompressionGzip }},
+		{name: "max open files", opts: func(o *Options) { o.MaxOpenFiles = 2 }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var (
+				mux      sync.Mutex
+				exported = make(map[string][]byte)
+			)
+
+			b := &mockBackend{
+				exportFn: func(ctx context.Context, prefix, filename string, r io.Reader) (string, error) {
+					bs, err := io.ReadAll(r)
+					mux.Lock()
+					exported[filename] = bs
+					mux.Unlock()
+					return filename, err
+				},
+				importFn: func(ctx context.Context, prefix, filename string, w io.Writer) error {
+					mux.Lock()
+					bs, ok := exported[filename]
+					mux.Unlock()
+					if !ok {
+						return os.ErrNotExist
+					}
+
+					_, err := w.Write(bs)
+					return err
+				},
+			}
+
+			kp := &testKeyProvider{
+				current: KeyID{Name: "primary", Version: 1},
+				keys: map[KeyID][]byte{
+					{Name: "primary", Version: 1}: bytes.Repeat([]byte{1}, 32),
+					{Name: "primary", Version: 2}: bytes.Repeat([]byte{2}, 16),
+				},
+			}
+
+			var opts Options
+			opts.Dir = fmt.Sprintf("test_%d", time.Now().UnixNano())
+			opts.Name = "foo"
+			opts.Encryption = kp
+			tt.opts(&opts)
+			d, err := makeDB[testentry](opts, b)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer os.RemoveAll(opts.Dir)
+
+			if err = d.Append("a", testentry{Foo: "1", Bar: "secret_1"}); err != nil {
+				t.Fatal(err)
+			}
+
+			// Records written after a rotation are sealed with the new key
+			kp.setCurrent(KeyID{Name: "primary", Version: 2})
+			if err = d.Upsert("a", "foo", testentry{Foo: "2", Bar: "secret_2"}); err != nil {
+				t.Fatal(err)
+			}
+
+			want := "foo,bar\n1,secret_1\n2,secret_2\n"
+			assertGet := func(want string) {
+				t.Helper()
+				w := &bytes.Buffer{}
+				if err := d.Get(w, "a"); err != nil {
+					t.Fatal(err)
+				}
+
+				if w.String() != want {
+					t.Errorf("DB.Get() = %q, want %q", w.String(), want)
+				}
+			}
+
+			assertGet(want)
+			if !opts.InMemory {
+				matches, err := filepath.Glob(filepath.Join(opts.Dir, "foo", "foo.a.csv*"))
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				for _, match := range matches {
+					bs, err := os.ReadFile(match)
+					if err != nil {
+						t.Fatal(err)
+					}
+
+					if bytes.Contains(bs, []byte("secret")) {
+						t.Errorf("stored file <%s> holds plaintext %q", match, bs)
+					}
+				}
+			}
+
+			if err = d.ExportPrefix(""); err != nil {
+				t.Fatal(err)
+			}
+
+			exportName := d.dataName("foo.a.csv")
+			bs, ok := exported[exportName]
+			if !ok || filepath.Ext(exportName) != ".enc" {
+				t.Fatalf("exported files = %v, want %s", exported, exportName)
+			}
+
+			if bytes.Contains(bs, []byte("secret")) {
+				t.Errorf("exported file holds plaintext %q", bs)
+			}
+
+			if err = d.Delete("a"); err != nil {
+				t.Fatal(err)
+			}
+
+			// Downloads are decrypted and stored encrypted again
+			assertGet(want)
+			if err = d.Append("a", testentry{Foo: "3", Bar: "secret_3"}); err != nil {
+				t.Fatal(err)
+			}
+
+			assertGet(want + "3,secret_3\n")
+
+			// Records cannot be opened with a different key
+			kp.keys[KeyID{Name: "primary", Version: 2}] = bytes.Repeat([]byte{3}, 16)
+			if err = d.Get(io.Discard, "a"); !errors.Is(err, ErrInvalidEncryptedRecord) {
+				t.Errorf("DB.Get() error = %v, wantErr %v", err, ErrInvalidEncryptedRecord)
+			}
+		})
+	}
+}
+
+func TestDB_Encryption_records(t *testing.T) {
+	type testcase struct {
+		name string
+		// tamper will return the records of <a> altered, provided the records of <a> and <b>
+		tamper func(a, b [][]byte) [][]byte
+	}
+
+	tests := []testcase{
+		{
+			name: "reordered",
+			tamper: func(a, b [][]byte) [][]byte {
+				n := len(a)
+				a[n-2], a[n-1] = a[n-1], a[n-2]
+				return a
+			},
+		},
+		{
+			// The records of both files are of the same sizes, so the moved record is held
+			// at the offset it was written at
+			name: "moved between files",
+			tamper: func(a, b [][]byte) [][]byte {
+				a[len(a)-1] = b[len(b)-1]
+				return a
+			},
+		},
+		{
+			name: "removed",
+			tamper: func(a, b [][]byte) [][]byte {
+				return append(a[:1], a[2:]...)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			kp := &testKeyProvider{
+				current: KeyID{Name: "primary", Version: 1},
+				keys:    map[KeyID][]byte{{Name: "primary", Version: 1}: bytes.Repeat([]byte{1}, 32)},
+			}
+
+			var opts Options
+			opts.Dir = fmt.Sprintf("test_%d", time.Now().UnixNano())
+			opts.Name = "foo"
+			opts.Encryption = kp
+			d, err := makeDB[testentry](opts, &mockBackend{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer os.RemoveAll(opts.Dir)
+
+			records := make(map[string][][]byte)
+			for _, key := range []string{"a", "b"} {
+				for i := 1; i <= 3; i++ {
+					if err = d.Append(key, testentry{Foo: fmt.Sprint(i), Bar: "secret"}); err != nil {
+						t.Fatal(err)
+					}
+				}
+
+				bs, err := os.ReadFile(filepath.Join(opts.Dir, "foo", "foo."+key+".csv"))
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				records[key] = splitRecords(t, bs)
+			}
+
+			if err = d.Get(io.Discard, "a"); err != nil {
+				t.Fatal(err)
+			}
+
+			tampered := bytes.Join(tt.tamper(records["a"], records["b"]), nil)
+			if err = os.WriteFile(filepath.Join(opts.Dir, "foo", "foo.a.csv"), tampered, 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			if err = d.Get(io.Discard, "a"); !errors.Is(err, ErrInvalidEncryptedRecord) {
+				t.Errorf("DB.Get() error = %v, wantErr %v", err, ErrInvalidEncryptedRecord)
+			}
+		})
+	}
+}
+
+// splitRecords will split a stored file into its stream record and each of its records
+func splitRecords(t *testing.T, bs []byte) (records [][]byte) {
+	t.Helper()
+	n := len(streamRecordMagic) + streamIDSize
+	records = append(records, bs[:n])
+	for bs = bs[n:]; len(bs) > 0; bs = bs[n:] {
+		if !bytes.HasPrefix(bs, recordMagic[:]) {
+			t.Fatalf("invalid record %q", bs)
+		}
+
+		// Magic, key name, key version, nonce and the size of the ciphertext
+		n = len(recordMagic) + 1 + int(bs[len(recordMagic)]) + 4 + 12
+		n += 4 + int(binary.BigEndian.Uint32(bs[n:]))
+		records = append(records, bs[:n])
+	}
+
+	return
+}
+
+func TestOptions_Validate_encryption(t *testing.T) {
+	type testcase struct {
+		name    string
+		opts    Options
+		wantErr error
+	}
+
+	kp := &testKeyProvider{}
+	tests := []testcase{
+		{
+			name: "basic",
+			opts: Options{Dir: "test", Name: "foo", Encryption: kp},
+		},
+		{
+			name:    "row index",
+			opts:    Options{Dir: "test", Name: "foo", Encryption: kp, RowIndexInterval: 10},
+			wantErr: ErrEncryptionIncompatible,
+		},
+		{
+			name:    "tail repair",
+			opts:    Options{Dir: "test", Name: "foo", Encryption: kp, TailRepair: TailRepairTruncate},
+			wantErr: ErrEncryptionIncompatible,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.opts.Validate(); !errors.Is(err, tt.wantErr) {
+				t.Errorf("Options.Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+type testKeyProvider struct {
+	mux     sync.Mutex
+	current KeyID
+	keys    map[KeyID][]byte
+}
+
+func (kp *testKeyProvider) CurrentKey() (id KeyID, err error) {
+	kp.mux.Lock()
+	defer kp.mux.Unlock()
+	return kp.current, nil
+}
+
+func (kp *testKeyProvider) Key(id KeyID) (key []byte, err error) {
+	kp.mux.Lock()
+	defer kp.mux.Unlock()
+	key, ok := kp.keys[id]
+	if !ok {
+		return nil, fmt.Errorf("key <%s:%d> not found", id.Name, id.Version)
+	}
+
+	return
+}
+
+func (kp *testKeyProvider) setCurrent(id KeyID) {
+	kp.mux.Lock()
+	defer kp.mux.Unlock()
+	kp.current = id
+}
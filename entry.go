@@ -1,6 +1,48 @@
 package csvdb
 
+import (
+	"errors"
+	"reflect"
+)
+
+// ErrUnmarshalerNotImplemented is returned when an Entry needs to be parsed but does not implement Unmarshaler
+var ErrUnmarshalerNotImplemented = errors.New("entry does not implement Unmarshaler")
+
 type Entry interface {
 	Keys() []string
 	Values() []string
 }
+
+// Unmarshaler is an optional interface implemented by an Entry pointer, allowing
+// the Entry to be parsed from a row of values
+type Unmarshaler interface {
+	UnmarshalCSV(keys, values []string) error
+}
+
+// unmarshalEntry will parse an entry of T from a row. When T is a pointer, the entry
+// it points to is parsed.
+func unmarshalEntry[T Entry](keys, values []string) (e T, err error) {
+	e = newEntry[T]()
+	u, ok := any(e).(Unmarshaler)
+	if !ok {
+		u, ok = any(&e).(Unmarshaler)
+	}
+
+	if !ok {
+		err = ErrUnmarshalerNotImplemented
+		return
+	}
+
+	err = u.UnmarshalCSV(keys, values)
+	return
+}
+
+// newEntry will return an entry of T whose methods may be called without a value. When T is
+// a pointer, the value it points to is allocated so its methods are not called on a nil pointer.
+func newEntry[T Entry]() (e T) {
+	if t := reflect.TypeOf(&e).Elem(); t.Kind() == reflect.Pointer {
+		e = reflect.New(t.Elem()).Interface().(T)
+	}
+
+	return
+}
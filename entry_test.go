@@ -12,3 +12,16 @@ func (t testentry) Keys() []string {
 func (t testentry) Values() []string {
 	return []string{t.Foo, t.Bar}
 }
+
+func (t *testentry) UnmarshalCSV(keys, values []string) (err error) {
+	for i, key := range keys {
+		switch key {
+		case "foo":
+			t.Foo = values[i]
+		case "bar":
+			t.Bar = values[i]
+		}
+	}
+
+	return
+}
@@ -0,0 +1,287 @@
+package csvdb
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"errors"
+	"io"
+	"os"
+	"sync"
+)
+
+// entryWriterBufferSize is the buffered size at which an EntryWriter will automatically flush
+const entryWriterBufferSize = 64 * 1024
+
+// ErrWriterClosed is returned when writing to a closed EntryWriter
+var ErrWriterClosed = errors.New("writer is closed")
+
+// Writer will return an EntryWriter for a key. The EntryWriter holds the file open
+// across writes and buffers entries in memory, writing whole records on Flush, on Close
+// or when the buffer grows large.
+func (d *DB[T]) Writer(key string) (ew *EntryWriter[T], err error) {
+	return d.WriterContext(context.Background(), key)
+}
+
+// WriterContext is the context-aware variant of Writer. The values of Options.ContextColumns
+// are taken from the provided context for every entry written by the EntryWriter.
+func (d *DB[T]) WriterContext(ctx context.Context, key string) (ew *EntryWriter[T], err error) {
+	var unlock func()
+	if unlock, err = d.lockKeys(ctx, key); err != nil {
+		return
+	}
+	defer unlock()
+
+	if err = d.prepareWrite(ctx, key); err != nil {
+		return
+	}
+
+	var e EntryWriter[T]
+	e.db = d
+	e.key = key
+	e.extra = d.contextValues(ctx)
+	_, e.filename = d.getFilename(key)
+	if e.f, err = getOrCreate(d.fs, e.filename); err != nil {
+		return
+	}
+
+	e.w = newCSVWriter(&e.buf, d.dialect)
+	ew = &e
+	d.addWriter(ew)
+	return
+}
+
+// EntryWriter is a long-lived handle for appending entries to a key
+type EntryWriter[T Entry] struct {
+	mux sync.Mutex
+
+	db       *DB[T]
+	key      string
+	filename string
+
+	f file
+
+	buf     bytes.Buffer
+	w       *csv.Writer
+	pending []T
+	// extra are the values of the context columns written after each entry
+	extra []string
+	// rows is the number of entries written, which indexes rows within errors
+	rows int
+
+	closed bool
+}
+
+// Write will buffer an entry to be written
+func (e *EntryWriter[T]) Write(entry T) (err error) {
+	e.mux.Lock()
+	defer e.mux.Unlock()
+
+	if e.closed {
+		return ErrWriterClosed
+	}
+
+	var values []string
+	if values, err = e.db.row(e.key, e.rows, entry, e.extra); err != nil {
+		return
+	}
+
+	if values, err = e.db.cp.protect(e.db.cp.columns(e.db.header(e.key, entry)), values); err != nil {
+		return
+	}
+
+	if err = e.w.Write(e.db.seal(values)); err != nil {
+		return
+	}
+
+	e.rows++
+	e.pending = append(e.pending, entry)
+	if e.buf.Len() < entryWriterBufferSize {
+		return
+	}
+
+	return e.flush()
+}
+
+// Flush will write all buffered entries to the file. Entries which cannot be written, such as
+// those exceeding the quota of the key, are discarded along with the rest of the buffer and the
+// error is returned, so the EntryWriter may be used for later entries.
+func (e *EntryWriter[T]) Flush() (err error) {
+	e.mux.Lock()
+	defer e.mux.Unlock()
+
+	if e.closed {
+		return ErrWriterClosed
+	}
+
+	return e.flush()
+}
+
+// Close will flush all buffered entries and close the file
+func (e *EntryWriter[T]) Close() (err error) {
+	e.mux.Lock()
+	defer e.mux.Unlock()
+
+	if e.closed {
+		return ErrWriterClosed
+	}
+
+	err = e.flush()
+	e.closed = true
+	e.db.removeWriter(e)
+	if cerr := e.f.Close(); err == nil {
+		err = cerr
+	}
+
+	return
+}
+
+func (e *EntryWriter[T]) flush() (err error) {
+	e.w.Flush()
+	if err = e.w.Error(); err != nil {
+		return
+	}
+
+	if e.buf.Len() == 0 {
+		return
+	}
+
+	d := e.db
+	var unlock func()
+	if unlock, err = d.lockKeys(context.Background(), e.key); err != nil {
+		return
+	}
+	defer unlock()
+
+	if err = d.prepareWrite(context.Background(), e.key); err != nil {
+		return
+	}
+
+	defer func() {
+		if err == nil {
+			return
+		}
+
+		// Buffered entries which cannot be written are discarded, so they do not block later writes
+		e.buf.Reset()
+		e.pending = e.pending[:0]
+	}()
+
+	if err = d.checkQuota(e.key); err != nil {
+		return
+	}
+
+	if err = e.refresh(); err != nil {
+		return
+	}
+
+	var info os.FileInfo
+	if info, err = e.f.Stat(); err != nil {
+		return
+	}
+
+	defer func() {
+		if err == nil {
+			return
+		}
+
+		// Partial records are removed, so a failed write leaves a valid file
+		if terr := e.f.Truncate(info.Size()); terr != nil {
+			d.o.Logger.Printf("csvdb.DB[%s].EntryWriter.flush(): error restoring <%s>: %v\n", d.o.Name, e.filename, terr)
+		}
+	}()
+
+	if info.Size() > 0 {
+		if err = e.checkHeader(info.Size()); err != nil {
+			return
+		}
+	}
+
+	a := d.newAppender(e.f, info.Size())
+	if info.Size() == 0 {
+		var header bytes.Buffer
+		hw := newCSVWriter(&header, d.dialect)
+		if err = d.writeHeader(hw, true, e.key, e.pending[0]); err != nil {
+			return
+		}
+
+		hw.Flush()
+		if _, err = a.Write(header.Bytes()); err != nil {
+			return
+		}
+	}
+
+	if _, err = a.Write(e.buf.Bytes()); err != nil {
+		return
+	}
+
+	if err = d.commit(a); err != nil {
+		return
+	}
+
+	if err = d.syncWrite(e.f, info.Size() == 0); err != nil {
+		return
+	}
+
+	d.updateRowIndex(e.f.Name())
+	d.updateChecksum(e.f.Name())
+	d.shadowAppend(e.key, e.pending)
+	d.recordAppend(e.key, info.Size() == 0, len(e.pending))
+	e.buf.Reset()
+	e.pending = e.pending[:0]
+	return
+}
+
+// checkHeader will return ErrInvalidColumnCount when the existing header of the file does not
+// have the same number of columns as the buffered rows
+func (e *EntryWriter[T]) checkHeader(size int64) (err error) {
+	var header []string
+	if header, err = readHeader(e.f, size, e.db.dialect); err != nil {
+		return
+	}
+
+	if _, err = e.f.Seek(0, io.SeekEnd); err != nil {
+		return
+	}
+
+	return checkColumnCount(e.key, e.rows-len(e.pending), e.db.header(e.key, e.pending[0]), header)
+}
+
+// refresh will re-open the file if it has been removed or replaced since it was opened.
+// Must be called while the DB lock is held.
+func (e *EntryWriter[T]) refresh() (err error) {
+	var held, current os.FileInfo
+	if held, err = e.f.Stat(); err != nil {
+		return
+	}
+
+	current, err = e.db.fs.Stat(e.filename)
+	switch {
+	case err == nil && sameFile(held, current):
+		return
+	case err == nil, os.IsNotExist(err):
+	default:
+		return
+	}
+
+	var f file
+	if f, err = getOrCreate(e.db.fs, e.filename); err != nil {
+		return
+	}
+
+	e.f.Close()
+	e.f = f
+	return
+}
+
+func sameFile(a, b os.FileInfo) bool {
+	if os.SameFile(a, b) {
+		return true
+	}
+
+	if a.Sys() == nil {
+		return false
+	}
+
+	return a.Sys() == b.Sys()
+}
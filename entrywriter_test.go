@@ -0,0 +1,173 @@
+package csvdb
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestEntryWriter(t *testing.T) {
+	type testcase struct {
+		name     string
+		inMemory bool
+	}
+
+	tests := []testcase{
+		{
+			name: "disk",
+		},
+		{
+			name:     "in memory",
+			inMemory: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var opts Options
+			opts.Dir = fmt.Sprintf("test_%d", time.Now().UnixNano())
+			opts.Name = "foo"
+			opts.InMemory = tt.inMemory
+			d, err := makeDB[testentry](opts, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer os.RemoveAll(d.o.Dir)
+
+			get := func() string {
+				w := &bytes.Buffer{}
+				if err := d.Get(w, "foo"); err != nil {
+					t.Fatal(err)
+				}
+
+				return w.String()
+			}
+
+			ew, err := d.Writer("foo")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if err = ew.Write(testentry{Foo: "1", Bar: "1b"}); err != nil {
+				t.Fatal(err)
+			}
+
+			if err = ew.Write(testentry{Foo: "2", Bar: "2b"}); err != nil {
+				t.Fatal(err)
+			}
+
+			if err = ew.Flush(); err != nil {
+				t.Fatal(err)
+			}
+
+			if got, want := get(), "foo,bar\n1,1b\n2,2b\n"; got != want {
+				t.Fatalf("EntryWriter.Flush() = %v, want %v", got, want)
+			}
+
+			// Replace the underlying file, the writer should follow the new file
+			if err = d.Truncate("foo"); err != nil {
+				t.Fatal(err)
+			}
+
+			if err = ew.Write(testentry{Foo: "3", Bar: "3b"}); err != nil {
+				t.Fatal(err)
+			}
+
+			if err = ew.Close(); err != nil {
+				t.Fatal(err)
+			}
+
+			if got, want := get(), "foo,bar\n3,3b\n"; got != want {
+				t.Fatalf("EntryWriter.Close() = %v, want %v", got, want)
+			}
+
+			if err = ew.Write(testentry{Foo: "4", Bar: "4b"}); err != ErrWriterClosed {
+				t.Fatalf("EntryWriter.Write() error = %v, want %v", err, ErrWriterClosed)
+			}
+		})
+	}
+}
+
+// shortFile is a file whose next write only writes half of the provided bytes before failing
+type shortFile struct {
+	file
+
+	failed bool
+}
+
+func (f *shortFile) Write(bs []byte) (n int, err error) {
+	if f.failed {
+		return f.file.Write(bs)
+	}
+
+	f.failed = true
+	if n, err = f.file.Write(bs[:len(bs)/2]); err != nil {
+		return
+	}
+
+	return n, ErrInjectedFault
+}
+
+func TestEntryWriter_failedFlush(t *testing.T) {
+	var opts Options
+	opts.Dir = fmt.Sprintf("test_%d", time.Now().UnixNano())
+	opts.Name = "foo"
+	d, err := makeDB[testentry](opts, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(d.o.Dir)
+
+	get := func() string {
+		w := &bytes.Buffer{}
+		if err := d.Get(w, "foo"); err != nil {
+			t.Fatal(err)
+		}
+
+		return w.String()
+	}
+
+	ew, err := d.Writer("foo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ew.Close()
+
+	if err = ew.Write(testentry{Foo: "1", Bar: "1b"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err = ew.Flush(); err != nil {
+		t.Fatal(err)
+	}
+
+	ew.f = &shortFile{file: ew.f}
+	if err = ew.Write(testentry{Foo: "2", Bar: "2b"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err = ew.Flush(); !errors.Is(err, ErrInjectedFault) {
+		t.Fatalf("EntryWriter.Flush() error = %v, want %v", err, ErrInjectedFault)
+	}
+
+	// The partial record is removed
+	if got, want := get(), "foo,bar\n1,1b\n"; got != want {
+		t.Fatalf("EntryWriter.Flush() = %q, want %q", got, want)
+	}
+
+	// The failed entries are discarded, so later entries are written
+	if err = ew.Write(testentry{Foo: "3", Bar: "3b"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err = ew.Flush(); err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := get(), "foo,bar\n1,1b\n3,3b\n"; got != want {
+		t.Fatalf("EntryWriter.Flush() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,249 @@
+package csvdb
+
+import (
+	"container/list"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"io"
+	"math"
+	"sync"
+)
+
+const (
+	// dataKeySize is the size of generated data keys, selecting AES-256
+	dataKeySize = 32
+	// maxDataKeys is the maximum number of unwrapped data keys cached by an envelope
+	maxDataKeys = 1024
+)
+
+// keyRecordMagic prefixes the key record holding the wrapped data key of a stream
+var keyRecordMagic = [4]byte{'C', 'S', 'K', '1'}
+
+// dataKeyID is the id of the records sealed with the data key of their stream
+var dataKeyID = KeyID{}
+
+// ErrInvalidKEK is returned when Options.KEK is set alongside Options.Encryption
+var ErrInvalidKEK = errors.New("invalid kek, cannot be set alongside encryption")
+
+// KEK is a key encryption key, such as a key held by AWS KMS or GCP KMS, which wraps the
+// data keys files are encrypted with
+type KEK interface {
+	// WrapKey will encrypt a data key
+	WrapKey(dataKey []byte) (wrapped []byte, err error)
+	// UnwrapKey will decrypt a data key encrypted by WrapKey
+	UnwrapKey(wrapped []byte) (dataKey []byte, err error)
+}
+
+// envelope seals streams with data keys wrapped by a KEK. Unwrapped data keys are cached, so
+// the KEK is only called once per data key while it is in use. The cache holds up to
+// maxDataKeys keys, and keys are dropped once their files are removed or replaced.
+type envelope struct {
+	kek KEK
+
+	mux sync.Mutex
+	// lru holds the unwrapped data keys, the most recently used first
+	lru  *list.List
+	keys map[string]*list.Element
+}
+
+type dataKey struct {
+	wrapped string
+	aead    cipher.AEAD
+}
+
+func newEnvelope(kek KEK) *envelope {
+	return &envelope{kek: kek, lru: list.New(), keys: make(map[string]*list.Element)}
+}
+
+// generate will generate a data key, returning its AEAD and key record
+func (e *envelope) generate() (aead cipher.AEAD, record []byte, err error) {
+	key := make([]byte, dataKeySize)
+	if _, err = rand.Read(key); err != nil {
+		return
+	}
+
+	var wrapped []byte
+	if wrapped, err = e.kek.WrapKey(key); err != nil {
+		return
+	}
+
+	if len(wrapped) > math.MaxUint16 {
+		return nil, nil, ErrInvalidEncryptedRecord
+	}
+
+	if aead, err = newGCM(key); err != nil {
+		return
+	}
+
+	record = append(record, keyRecordMagic[:]...)
+	record = binary.BigEndian.AppendUint16(record, uint16(len(wrapped)))
+	record = append(record, wrapped...)
+	e.put(wrapped, aead)
+	return
+}
+
+// readKey will read the remainder of a key record following its magic, returning the AEAD
+// of its data key
+func (e *envelope) readKey(r io.Reader) (aead cipher.AEAD, err error) {
+	var wrapped []byte
+	if wrapped, err = readWrapped(r); err != nil {
+		return
+	}
+
+	return e.unwrap(wrapped)
+}
+
+func (e *envelope) unwrap(wrapped []byte) (aead cipher.AEAD, err error) {
+	if aead, ok := e.get(wrapped); ok {
+		return aead, nil
+	}
+
+	var key []byte
+	if key, err = e.kek.UnwrapKey(wrapped); err != nil {
+		return
+	}
+
+	if aead, err = newGCM(key); err != nil {
+		return
+	}
+
+	e.put(wrapped, aead)
+	return
+}
+
+func (e *envelope) get(wrapped []byte) (aead cipher.AEAD, ok bool) {
+	e.mux.Lock()
+	defer e.mux.Unlock()
+	var el *list.Element
+	if el, ok = e.keys[string(wrapped)]; !ok {
+		return
+	}
+
+	e.lru.MoveToFront(el)
+	return el.Value.(*dataKey).aead, true
+}
+
+// put will cache an unwrapped data key, dropping the least recently used keys once the cache
+// is full
+func (e *envelope) put(wrapped []byte, aead cipher.AEAD) {
+	e.mux.Lock()
+	defer e.mux.Unlock()
+	if el, ok := e.keys[string(wrapped)]; ok {
+		e.lru.MoveToFront(el)
+		return
+	}
+
+	e.keys[string(wrapped)] = e.lru.PushFront(&dataKey{wrapped: string(wrapped), aead: aead})
+	for e.lru.Len() > maxDataKeys {
+		k := e.lru.Remove(e.lru.Back()).(*dataKey)
+		delete(e.keys, k.wrapped)
+	}
+}
+
+// forget will drop a cached data key, once the file holding it is removed or replaced
+func (e *envelope) forget(wrapped []byte) {
+	e.mux.Lock()
+	defer e.mux.Unlock()
+	if el, ok := e.keys[string(wrapped)]; ok {
+		e.lru.Remove(el)
+		delete(e.keys, string(wrapped))
+	}
+}
+
+// readWrapped will read the remainder of a key record following its magic, returning its
+// wrapped data key
+func readWrapped(r io.Reader) (wrapped []byte, err error) {
+	var size uint16
+	if err = binary.Read(r, binary.BigEndian, &size); err != nil {
+		return nil, ErrInvalidEncryptedRecord
+	}
+
+	wrapped = make([]byte, size)
+	if _, err = io.ReadFull(r, wrapped); err != nil {
+		return nil, ErrInvalidEncryptedRecord
+	}
+
+	return
+}
+
+func newGCM(key []byte) (aead cipher.AEAD, err error) {
+	var block cipher.Block
+	if block, err = aes.NewCipher(key); err != nil {
+		return
+	}
+
+	return cipher.NewGCM(block)
+}
+
+var _ Codec = aesFileCodec{}
+
+// aesFileCodec is the Codec of a stored file, whose records are appended to the stream held by
+// the file. When env is set, records are sealed with the data key held by the key record of
+// the stream.
+type aesFileCodec struct {
+	aesCodec
+	f file
+}
+
+// NewWriter will append records to the stream of the file, starting the stream when the file
+// is empty. The position of the file is reset by the caller.
+func (c aesFileCodec) NewWriter(w io.Writer) (aw io.WriteCloser, err error) {
+	if _, err = c.f.Seek(0, io.SeekStart); err != nil {
+		return
+	}
+
+	var (
+		s       stream
+		wrapped []byte
+	)
+
+	switch s.id, wrapped, err = c.readStart(c.f); {
+	case err == io.EOF:
+		return c.newWriter(w, nil)
+	case err != nil:
+		return
+	}
+
+	if c.env != nil {
+		if s.dataKey, err = c.env.unwrap(wrapped); err != nil {
+			return
+		}
+	}
+
+	if s.size, err = c.f.Seek(0, io.SeekEnd); err != nil {
+		return
+	}
+
+	return c.newWriter(w, &s)
+}
+
+// readStart will read the start of a stream, returning its id and the wrapped data key of its
+// key record when env is set. io.EOF is returned when the stream is empty.
+func (c aesCodec) readStart(r io.Reader) (id, wrapped []byte, err error) {
+	var magic [4]byte
+	switch _, err = io.ReadFull(r, magic[:]); {
+	case err == io.EOF:
+		return
+	case err != nil, magic != streamRecordMagic:
+		return nil, nil, ErrInvalidEncryptedRecord
+	}
+
+	id = make([]byte, streamIDSize)
+	if _, err = io.ReadFull(r, id); err != nil {
+		return nil, nil, ErrInvalidEncryptedRecord
+	}
+
+	if c.env == nil {
+		return
+	}
+
+	if _, err = io.ReadFull(r, magic[:]); err != nil || magic != keyRecordMagic {
+		return nil, nil, ErrInvalidEncryptedRecord
+	}
+
+	wrapped, err = readWrapped(r)
+	return
+}
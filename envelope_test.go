@@ -0,0 +1,326 @@
+package csvdb
+
+import (
+	"bytes"
+	"context"
+	"crypto/cipher"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestDB_KEK(t *testing.T) {
+	type testcase struct {
+		name string
+		opts func(o *Options)
+	}
+
+	tests := []testcase{
+		{name: "basic", opts: func(o *Options) {}},
+		{name: "in memory", opts: func(o *Options) { o.InMemory = true }},
+		{name: "write ahead log", opts: func(o *Options) { o.WriteAheadLog = true }},
+		{name: "atomic append", opts: func(o *Options) { o.AtomicAppend = true }},
+		{name: "compression", opts: func(o *Options) { o.Compression = CompressionGzip }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var (
+				mux      sync.Mutex
+				exported = make(map[string][]byte)
+			)
+
+			b := &mockBackend{
+				exportFn: func(ctx context.Context, prefix, filename string, r io.Reader) (string, error) {
+					bs, err := io.ReadAll(r)
+					mux.Lock()
+					exported[filename] = bs
+					mux.Unlock()
+					return filename, err
+				},
+				importFn: func(ctx context.Context, prefix, filename string, w io.Writer) error {
+					mux.Lock()
+					bs, ok := exported[filename]
+					mux.Unlock()
+					if !ok {
+						return os.ErrNotExist
+					}
+
+					_, err := w.Write(bs)
+					return err
+				},
+			}
+
+			kek := newTestKEK(t)
+			var opts Options
+			opts.Dir = fmt.Sprintf("test_%d", time.Now().UnixNano())
+			opts.Name = "foo"
+			opts.KEK = kek
+			tt.opts(&opts)
+			d, err := makeDB[testentry](opts, b)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer os.RemoveAll(opts.Dir)
+
+			for _, key := range []string{"a", "b"} {
+				if err = d.Append(key, testentry{Foo: "1", Bar: "secret_1"}); err != nil {
+					t.Fatal(err)
+				}
+
+				if err = d.Append(key, testentry{Foo: "2", Bar: "secret_2"}); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			want := "foo,bar\n1,secret_1\n2,secret_2\n"
+			assertGet := func(key, want string) {
+				t.Helper()
+				w := &bytes.Buffer{}
+				if err := d.Get(w, key); err != nil {
+					t.Fatal(err)
+				}
+
+				if w.String() != want {
+					t.Errorf("DB.Get(%s) = %q, want %q", key, w.String(), want)
+				}
+			}
+
+			assertGet("a", want)
+			assertGet("b", want)
+			if !opts.InMemory {
+				// Each file holds a data key of its own
+				var wrapped [][]byte
+				for _, key := range []string{"a", "b"} {
+					matches, err := filepath.Glob(filepath.Join(opts.Dir, "foo", "foo."+key+".csv*"))
+					if err != nil || len(matches) != 1 {
+						t.Fatalf("stored files = %v, error = %v", matches, err)
+					}
+
+					bs, err := os.ReadFile(matches[0])
+					if err != nil {
+						t.Fatal(err)
+					}
+
+					// The key record follows the stream record
+					record := bs[len(streamRecordMagic)+streamIDSize:]
+					if bytes.Contains(bs, []byte("secret")) || !bytes.HasPrefix(record, keyRecordMagic[:]) {
+						t.Fatalf("invalid stored file <%s> %q", matches[0], bs)
+					}
+
+					size := int(record[4])<<8 | int(record[5])
+					wrapped = append(wrapped, record[6:6+size])
+				}
+
+				if bytes.Equal(wrapped[0], wrapped[1]) {
+					t.Errorf("files share a data key")
+				}
+			}
+
+			if err = d.ExportPrefix(""); err != nil {
+				t.Fatal(err)
+			}
+
+			bs, ok := exported[d.dataName("foo.a.csv")]
+			if !ok || bytes.Contains(bs, []byte("secret")) {
+				t.Fatalf("invalid exported files %v", exported)
+			}
+
+			if err = d.Delete("a"); err != nil {
+				t.Fatal(err)
+			}
+
+			// Downloads are decrypted and stored encrypted again
+			assertGet("a", want)
+			if err = d.Append("a", testentry{Foo: "3", Bar: "secret_3"}); err != nil {
+				t.Fatal(err)
+			}
+
+			assertGet("a", want+"3,secret_3\n")
+
+			if opts.InMemory {
+				return
+			}
+
+			// Files cannot be read once their data keys cannot be unwrapped
+			kek.fail = true
+			opts.KEK = kek
+			d2, err := makeDB[testentry](opts, b)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if err = d2.Get(io.Discard, "b"); !errors.Is(err, errTestUnwrap) {
+				t.Errorf("DB.Get() error = %v, wantErr %v", err, errTestUnwrap)
+			}
+		})
+	}
+}
+
+func TestEnvelope_keys(t *testing.T) {
+	e := newEnvelope(newTestKEK(t))
+	var records [][]byte
+	for i := 0; i < maxDataKeys+1; i++ {
+		_, record, err := e.generate()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		records = append(records, record[len(keyRecordMagic)+2:])
+	}
+
+	if len(e.keys) != maxDataKeys || e.lru.Len() != maxDataKeys {
+		t.Fatalf("cached keys = %d, want %d", len(e.keys), maxDataKeys)
+	}
+
+	// The least recently used key is dropped, and is unwrapped again once used
+	if _, ok := e.get(records[0]); ok {
+		t.Errorf("least recently used key is cached")
+	}
+
+	if _, err := e.unwrap(records[0]); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, ok := e.get(records[0]); !ok {
+		t.Errorf("unwrapped key is not cached")
+	}
+
+	if _, ok := e.get(records[1]); ok {
+		t.Errorf("least recently used key is cached")
+	}
+}
+
+func TestEncryptFS_forget(t *testing.T) {
+	type testcase struct {
+		name string
+		// replace will remove or replace <foo.a.csv>
+		replace func(fsys fileSystem) error
+		// wantKeys is the number of cached keys once replaced
+		wantKeys int
+	}
+
+	write := func(fsys fileSystem, name string) error {
+		f, err := fsys.Create(name)
+		if err != nil {
+			return err
+		}
+
+		if _, err = f.Write([]byte("foo,bar\n")); err != nil {
+			f.Close()
+			return err
+		}
+
+		return f.Close()
+	}
+
+	tests := []testcase{
+		{
+			name:    "remove",
+			replace: func(fsys fileSystem) error { return fsys.Remove("foo.a.csv") },
+		},
+		{
+			name:     "create",
+			replace:  func(fsys fileSystem) error { return write(fsys, "foo.a.csv") },
+			wantKeys: 1,
+		},
+		{
+			name: "truncate",
+			replace: func(fsys fileSystem) error {
+				f, err := fsys.OpenFile("foo.a.csv", os.O_WRONLY|os.O_TRUNC, 0644)
+				if err != nil {
+					return err
+				}
+
+				return f.Close()
+			},
+		},
+		{
+			name: "rename",
+			replace: func(fsys fileSystem) (err error) {
+				if err = write(fsys, "foo.b.csv"); err != nil {
+					return
+				}
+
+				return fsys.Rename("foo.b.csv", "foo.a.csv")
+			},
+			wantKeys: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := newEnvelope(newTestKEK(t))
+			fsys := encryptFS{fileSystem: newMemFS(0), c: aesCodec{env: e}}
+			if err := write(fsys, "foo.a.csv"); err != nil {
+				t.Fatal(err)
+			}
+
+			if len(e.keys) != 1 {
+				t.Fatalf("cached keys = %d, want 1", len(e.keys))
+			}
+
+			if err := tt.replace(fsys); err != nil {
+				t.Fatal(err)
+			}
+
+			if len(e.keys) != tt.wantKeys {
+				t.Errorf("cached keys = %d, want %d", len(e.keys), tt.wantKeys)
+			}
+		})
+	}
+}
+
+func TestOptions_Validate_kek(t *testing.T) {
+	kek := newTestKEK(t)
+	o := Options{Dir: "test", Name: "foo", KEK: kek, Encryption: &testKeyProvider{}}
+	if err := o.Validate(); !errors.Is(err, ErrInvalidKEK) {
+		t.Errorf("Options.Validate() error = %v, wantErr %v", err, ErrInvalidKEK)
+	}
+}
+
+var errTestUnwrap = errors.New("cannot unwrap key")
+
+// testKEK wraps data keys with an in-memory key
+type testKEK struct {
+	aead cipher.AEAD
+	fail bool
+}
+
+func newTestKEK(t *testing.T) *testKEK {
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		t.Fatal(err)
+	}
+
+	aead, err := newGCM(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return &testKEK{aead: aead}
+}
+
+func (k *testKEK) WrapKey(dataKey []byte) (wrapped []byte, err error) {
+	nonce := make([]byte, k.aead.NonceSize())
+	if _, err = rand.Read(nonce); err != nil {
+		return
+	}
+
+	return k.aead.Seal(nonce, nonce, dataKey, nil), nil
+}
+
+func (k *testKEK) UnwrapKey(wrapped []byte) (dataKey []byte, err error) {
+	if k.fail || len(wrapped) < k.aead.NonceSize() {
+		return nil, errTestUnwrap
+	}
+
+	size := k.aead.NonceSize()
+	return k.aead.Open(nil, wrapped[:size], wrapped[size:], nil)
+}
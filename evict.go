@@ -0,0 +1,61 @@
+package csvdb
+
+import (
+	"context"
+	"errors"
+	"os"
+)
+
+// ErrEntryNotExported is returned when evicting a key which has been modified since it was last exported
+var ErrEntryNotExported = errors.New("entry has not been exported since it was last modified")
+
+// Evict will remove the local file of a key without affecting the backend, which Get
+// will lazily download from. Keys which have been modified since they were last exported
+// cannot be evicted, as their changes would be lost.
+func (d *DB[T]) Evict(key string) (err error) {
+	return d.EvictContext(context.Background(), key)
+}
+
+// EvictContext is the context-aware variant of Evict
+func (d *DB[T]) EvictContext(ctx context.Context, key string) (err error) {
+	var unlock func()
+	if unlock, err = d.lockKeys(ctx, key); err != nil {
+		return
+	}
+	defer unlock()
+
+	// The evicted file is lazily downloaded from the import backend once exported
+	if d.ib == nil || d.eb == nil {
+		return ErrBackendNotSet
+	}
+
+	if err = d.checkQuarantine(key); err != nil {
+		return
+	}
+
+	if err = d.checkHold(key); err != nil {
+		return
+	}
+
+	name, filename := d.getFilename(key)
+	var info os.FileInfo
+	if info, err = d.fs.Stat(filename); os.IsNotExist(err) {
+		return ErrEntryNotFound
+	} else if err != nil {
+		return
+	}
+
+	if !d.isExported(name, info) {
+		return ErrEntryNotExported
+	}
+
+	if err = d.fs.Remove(filename); err != nil {
+		return
+	}
+
+	if err = d.fs.Remove(filename + ".exported"); err != nil && !os.IsNotExist(err) {
+		return
+	}
+
+	return nil
+}
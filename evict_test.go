@@ -0,0 +1,120 @@
+package csvdb
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"testing"
+	"time"
+)
+
+func TestDB_Evict(t *testing.T) {
+	type testcase struct {
+		name      string
+		noBackend bool
+		key       string
+		exported  bool
+
+		wantErr error
+	}
+
+	tests := []testcase{
+		{
+			name:     "basic",
+			key:      "a",
+			exported: true,
+		},
+		{
+			name:    "not exported",
+			key:     "a",
+			wantErr: ErrEntryNotExported,
+		},
+		{
+			name:    "not found",
+			key:     "missing",
+			wantErr: ErrEntryNotFound,
+		},
+		{
+			name:      "backend not set",
+			noBackend: true,
+			key:       "a",
+			exported:  true,
+			wantErr:   ErrBackendNotSet,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			remote := map[string][]byte{}
+			var b Backend = &mockBackend{
+				exportFn: func(ctx context.Context, prefix, filename string, r io.Reader) (string, error) {
+					bs, err := io.ReadAll(r)
+					remote[filename] = bs
+					return filename, err
+				},
+				importFn: func(ctx context.Context, prefix, filename string, w io.Writer) (err error) {
+					bs, ok := remote[filename]
+					if !ok {
+						return os.ErrNotExist
+					}
+
+					_, err = w.Write(bs)
+					return
+				},
+			}
+
+			if tt.noBackend {
+				b = nil
+			}
+
+			var opts Options
+			opts.Dir = fmt.Sprintf("test_%d", time.Now().UnixNano())
+			opts.Name = "foo"
+			d, err := makeDB[testentry](opts, b)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer os.RemoveAll(d.o.Dir)
+
+			if err = d.Append("a", testentry{Foo: "1", Bar: "1b"}); err != nil {
+				t.Fatal(err)
+			}
+
+			if tt.exported {
+				// Ensure the export marker is newer than the file
+				time.Sleep(10 * time.Millisecond)
+				if tt.noBackend {
+					err = d.setLastExported("foo.a.csv")
+				} else {
+					err = d.export(context.Background(), "foo.a.csv")
+				}
+
+				if err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			if err = d.Evict(tt.key); err != tt.wantErr {
+				t.Fatalf("DB.Evict() error = %v, wantErr %v", err, tt.wantErr)
+			} else if err != nil {
+				return
+			}
+
+			if _, err = os.Stat(path.Join(d.getFullPath(), "foo.a.csv")); !os.IsNotExist(err) {
+				t.Fatalf("DB.Evict() did not remove the local file, error = %v", err)
+			}
+
+			w := &bytes.Buffer{}
+			if err = d.Get(w, tt.key); err != nil {
+				t.Fatal(err)
+			}
+
+			if want := "foo,bar\n1,1b\n"; w.String() != want {
+				t.Errorf("DB.Get() = %q, want %q", w.String(), want)
+			}
+		})
+	}
+}
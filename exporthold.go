@@ -0,0 +1,36 @@
+package csvdb
+
+import "sort"
+
+// HoldExport will prevent a key from being exported until ReleaseExport is called.
+// Holds are kept in memory and do not persist across restarts.
+func (d *DB[T]) HoldExport(key string) {
+	d.mux.Lock()
+	defer d.mux.Unlock()
+	d.exportHolds[key] = struct{}{}
+}
+
+// ReleaseExport will release an export hold, the key will be included in the next export
+func (d *DB[T]) ReleaseExport(key string) {
+	d.mux.Lock()
+	defer d.mux.Unlock()
+	delete(d.exportHolds, key)
+}
+
+// ExportHolds will return the keys which currently have an export hold
+func (d *DB[T]) ExportHolds() (keys []string) {
+	d.mux.Lock()
+	defer d.mux.Unlock()
+	keys = make([]string, 0, len(d.exportHolds))
+	for key := range d.exportHolds {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+	return
+}
+
+func (d *DB[T]) isExportHeld(filename string) (held bool) {
+	_, held = d.exportHolds[d.getKey(filename)]
+	return
+}
@@ -0,0 +1,82 @@
+package csvdb
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestDB_HoldExport(t *testing.T) {
+	type testcase struct {
+		name      string
+		hold      []string
+		release   []string
+		wantHolds []string
+		wantCount int
+	}
+
+	tests := []testcase{
+		{
+			name:      "no holds",
+			wantHolds: []string{},
+			wantCount: 2,
+		},
+		{
+			name:      "held",
+			hold:      []string{"key_1"},
+			wantHolds: []string{"key_1"},
+			wantCount: 1,
+		},
+		{
+			name:      "released",
+			hold:      []string{"key_1", "key_2"},
+			release:   []string{"key_1"},
+			wantHolds: []string{"key_2"},
+			wantCount: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var opts Options
+			opts.Dir = fmt.Sprintf("test_%d", time.Now().UnixNano())
+			opts.Name = "foo"
+			d, err := makeDB[testentry](opts, &mockBackend{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer os.RemoveAll(d.o.Dir)
+
+			if err = d.Append("key_1", testentry{Foo: "1"}); err != nil {
+				t.Fatal(err)
+			}
+
+			if err = d.Append("key_2", testentry{Foo: "2"}); err != nil {
+				t.Fatal(err)
+			}
+
+			for _, key := range tt.hold {
+				d.HoldExport(key)
+			}
+
+			for _, key := range tt.release {
+				d.ReleaseExport(key)
+			}
+
+			if got := d.ExportHolds(); !reflect.DeepEqual(got, tt.wantHolds) {
+				t.Errorf("DB.ExportHolds() = %v, want %v", got, tt.wantHolds)
+			}
+
+			exportable, err := d.getExportable("")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if len(exportable) != tt.wantCount {
+				t.Errorf("DB.getExportable() count = %v, wantCount %v", len(exportable), tt.wantCount)
+			}
+		})
+	}
+}
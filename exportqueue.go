@@ -0,0 +1,179 @@
+package csvdb
+
+import (
+	"os"
+	"sync"
+	"time"
+)
+
+// maxExportBackoff is the maximum number of times the export interval is doubled while a
+// file continues to fail to export
+const maxExportBackoff = 5
+
+// ExportQueueItem is a file pending export
+type ExportQueueItem struct {
+	Key      string `json:"key"`
+	Filename string `json:"filename"`
+
+	// Attempts is the number of consecutive failed exports of the file
+	Attempts int `json:"attempts"`
+	// LastError is the error of the last failed export
+	LastError string `json:"lastError,omitempty"`
+	// LastAttempt is the time of the last failed export
+	LastAttempt time.Time `json:"lastAttempt,omitempty"`
+	// NextAttempt is the time the file is next exported by the background export, zero
+	// when it is exported on the next pass
+	NextAttempt time.Time `json:"nextAttempt,omitempty"`
+	// Skipped is set when the file was skipped with SkipExport
+	Skipped bool `json:"skipped"`
+}
+
+// exportQueue tracks the failed and skipped exports of files
+type exportQueue struct {
+	mux      sync.Mutex
+	failures map[string]exportFailure
+	// skips holds the modification time of skipped files
+	skips map[string]time.Time
+}
+
+type exportFailure struct {
+	attempts    int
+	err         error
+	lastAttempt time.Time
+	nextAttempt time.Time
+}
+
+// ExportQueue will return the files pending export in the order they are exported, along
+// with the state of their failed exports. Skipped files are listed last.
+// Note: Keys with an export hold are not listed, see ExportHolds
+func (d *DB[T]) ExportQueue() (queue []ExportQueueItem, err error) {
+	var exportable []string
+	if exportable, err = d.getExportable(""); err != nil {
+		return
+	}
+
+	d.eq.mux.Lock()
+	defer d.eq.mux.Unlock()
+	queue = make([]ExportQueueItem, 0, len(exportable)+len(d.eq.skips))
+	for _, filename := range exportable {
+		queue = append(queue, d.queueItem(filename))
+	}
+
+	for filename := range d.eq.skips {
+		if !d.isSkipped(filename) {
+			continue
+		}
+
+		item := d.queueItem(filename)
+		item.Skipped = true
+		queue = append(queue, item)
+	}
+
+	return
+}
+
+// RequeueExport will clear the failed and skipped exports of a key, so it is exported
+// on the next pass of the background export
+func (d *DB[T]) RequeueExport(key string) {
+	filename, _ := d.getFilename(key)
+	d.eq.mux.Lock()
+	defer d.eq.mux.Unlock()
+	delete(d.eq.failures, filename)
+	delete(d.eq.skips, filename)
+}
+
+// SkipExport will remove a key from the export queue until it is next modified or
+// RequeueExport is called. Skips are kept in memory and do not persist across restarts.
+func (d *DB[T]) SkipExport(key string) (err error) {
+	filename, _ := d.getFilename(key)
+	var info os.FileInfo
+	if info, err = d.fs.Stat(d.getPath(filename)); os.IsNotExist(err) {
+		return ErrEntryNotFound
+	} else if err != nil {
+		return
+	}
+
+	d.eq.mux.Lock()
+	defer d.eq.mux.Unlock()
+	delete(d.eq.failures, filename)
+	d.eq.skips[filename] = info.ModTime()
+	return
+}
+
+// queueItem will return the queue item of a file. Must be called while the queue mutex is held.
+func (d *DB[T]) queueItem(filename string) (item ExportQueueItem) {
+	item.Key = d.getKey(filename)
+	item.Filename = filename
+	f, ok := d.eq.failures[filename]
+	if !ok {
+		return
+	}
+
+	item.Attempts = f.attempts
+	item.LastError = f.err.Error()
+	item.LastAttempt = f.lastAttempt
+	item.NextAttempt = f.nextAttempt
+	return
+}
+
+// isSkipped will return whether a file is skipped, removing skips of files which have
+// been modified since. Must be called while the queue mutex is held.
+func (d *DB[T]) isSkipped(filename string) (skipped bool) {
+	modTime, ok := d.eq.skips[filename]
+	if !ok {
+		return
+	}
+
+	info, err := d.fs.Stat(d.getPath(filename))
+	if err == nil && !info.ModTime().After(modTime) {
+		return true
+	}
+
+	delete(d.eq.skips, filename)
+	return
+}
+
+// isExportSkipped will return whether the export of a file is skipped, given its file info
+func (d *DB[T]) isExportSkipped(filename string, info os.FileInfo) (skipped bool) {
+	d.eq.mux.Lock()
+	defer d.eq.mux.Unlock()
+	modTime, ok := d.eq.skips[filename]
+	return ok && !info.ModTime().After(modTime)
+}
+
+// dueForExport will filter the files whose failed exports are backing off
+func (d *DB[T]) dueForExport(exportable []string, now time.Time) (due []string) {
+	d.eq.mux.Lock()
+	defer d.eq.mux.Unlock()
+	due = exportable[:0]
+	// Passes are made every export interval, files due before the middle of the next
+	// interval are exported on this pass
+	now = now.Add(d.o.ExportInterval / 2)
+	for _, filename := range exportable {
+		if f, ok := d.eq.failures[filename]; ok && now.Before(f.nextAttempt) {
+			continue
+		}
+
+		due = append(due, filename)
+	}
+
+	return
+}
+
+// recordExport will record the result of exporting a file. Failed exports are retried by
+// the background export with an exponential backoff, starting at the export interval.
+func (d *DB[T]) recordExport(filename string, err error) {
+	d.eq.mux.Lock()
+	defer d.eq.mux.Unlock()
+	if err == nil || err == ErrBackendNotSet {
+		delete(d.eq.failures, filename)
+		return
+	}
+
+	f := d.eq.failures[filename]
+	f.attempts++
+	f.err = err
+	f.lastAttempt = time.Now()
+	f.nextAttempt = f.lastAttempt.Add(d.o.ExportInterval << min(f.attempts-1, maxExportBackoff))
+	d.eq.failures[filename] = f
+}
@@ -0,0 +1,109 @@
+package csvdb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestDB_ExportQueue(t *testing.T) {
+	errExport := errors.New("export failed")
+	var attempts int
+	b := &mockBackend{
+		exportFn: func(ctx context.Context, prefix, filename string, r io.Reader) (string, error) {
+			if filename != "foo.bad.csv" {
+				return filename, nil
+			}
+
+			attempts++
+			return filename, errExport
+		},
+	}
+
+	var opts Options
+	opts.Dir = fmt.Sprintf("test_%d", time.Now().UnixNano())
+	opts.Name = "foo"
+	d, err := makeDB[testentry](opts, b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(d.o.Dir)
+
+	for _, key := range []string{"a", "bad"} {
+		if err = d.Append(key, testentry{Foo: "1"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	assertQueue := func(want ...ExportQueueItem) {
+		t.Helper()
+		queue, err := d.ExportQueue()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(queue) != len(want) {
+			t.Fatalf("DB.ExportQueue() = %v, want %v", queue, want)
+		}
+
+		for i, item := range queue {
+			if item.Key != want[i].Key || item.Attempts != want[i].Attempts || item.Skipped != want[i].Skipped {
+				t.Errorf("DB.ExportQueue()[%d] = %+v, want %+v", i, item, want[i])
+			}
+
+			if (item.Attempts > 0) != !item.NextAttempt.IsZero() {
+				t.Errorf("DB.ExportQueue()[%d] next attempt = %v with %d attempts", i, item.NextAttempt, item.Attempts)
+			}
+		}
+	}
+
+	assertBackup := func(backoff bool, wantAttempts int, wantErr bool) {
+		t.Helper()
+		if err := d.backup(context.Background(), "", backoff); (err != nil) != wantErr {
+			t.Fatalf("DB.backup() error = %v, wantErr %v", err, wantErr)
+		}
+
+		if attempts != wantAttempts {
+			t.Fatalf("invalid number of attempts, expected %d and received %d", wantAttempts, attempts)
+		}
+	}
+
+	assertQueue(ExportQueueItem{Key: "a"}, ExportQueueItem{Key: "bad"})
+
+	assertBackup(true, 1, true)
+	assertQueue(ExportQueueItem{Key: "bad", Attempts: 1})
+
+	// Failed exports back off in the background
+	assertBackup(true, 1, false)
+
+	// Manual exports retry immediately
+	assertBackup(false, 2, true)
+	assertQueue(ExportQueueItem{Key: "bad", Attempts: 2})
+
+	d.RequeueExport("bad")
+	assertQueue(ExportQueueItem{Key: "bad"})
+	assertBackup(true, 3, true)
+
+	if err = d.SkipExport("bad"); err != nil {
+		t.Fatal(err)
+	}
+
+	assertQueue(ExportQueueItem{Key: "bad", Skipped: true})
+	assertBackup(false, 3, false)
+
+	// Skips lapse once the key is modified
+	time.Sleep(20 * time.Millisecond)
+	if err = d.Append("bad", testentry{Foo: "2"}); err != nil {
+		t.Fatal(err)
+	}
+
+	assertQueue(ExportQueueItem{Key: "bad"})
+
+	if err = d.SkipExport("missing"); err != ErrEntryNotFound {
+		t.Fatalf("DB.SkipExport() error = %v, wantErr %v", err, ErrEntryNotFound)
+	}
+}
@@ -0,0 +1,240 @@
+package csvdb
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+// ErrInjectedFault is the default error returned by injected backend failures
+var ErrInjectedFault = errors.New("injected fault")
+
+// Faults is a fault injection layer used to verify recovery logic against realistic
+// failure modes. Faults are injected into the DB by setting Options.Faults, and can be
+// changed at any time while the DB is in use.
+type Faults struct {
+	mux sync.Mutex
+
+	backendFailures int
+	backendErr      error
+	writeDelay      time.Duration
+	partialReads    int
+}
+
+// FailBackend will fail the next n backend calls with the provided error.
+// Note: ErrInjectedFault is used when the error is nil
+func (f *Faults) FailBackend(n int, err error) {
+	if err == nil {
+		err = ErrInjectedFault
+	}
+
+	f.mux.Lock()
+	defer f.mux.Unlock()
+	f.backendFailures = n
+	f.backendErr = err
+}
+
+// DelayWrites will delay every disk write by the provided duration, 0 disables the delay
+func (f *Faults) DelayWrites(delay time.Duration) {
+	f.mux.Lock()
+	defer f.mux.Unlock()
+	f.writeDelay = delay
+}
+
+// PartialReads will limit every disk read to return at most n bytes, 0 disables the limit
+func (f *Faults) PartialReads(n int) {
+	f.mux.Lock()
+	defer f.mux.Unlock()
+	f.partialReads = n
+}
+
+// Reset will remove all injected faults
+func (f *Faults) Reset() {
+	f.mux.Lock()
+	defer f.mux.Unlock()
+	f.backendFailures = 0
+	f.backendErr = nil
+	f.writeDelay = 0
+	f.partialReads = 0
+}
+
+func (f *Faults) backendFault() (err error) {
+	f.mux.Lock()
+	defer f.mux.Unlock()
+	if f.backendFailures <= 0 {
+		return
+	}
+
+	f.backendFailures--
+	return f.backendErr
+}
+
+func (f *Faults) getWriteDelay() time.Duration {
+	f.mux.Lock()
+	defer f.mux.Unlock()
+	return f.writeDelay
+}
+
+func (f *Faults) getPartialReads() int {
+	f.mux.Lock()
+	defer f.mux.Unlock()
+	return f.partialReads
+}
+
+var _ Backend = &faultBackend{}
+
+// wrapFaults will wrap a Backend to inject the provided faults, nil Backends are left unset
+func wrapFaults(b Backend, f *Faults) Backend {
+	if b == nil {
+		return nil
+	}
+
+	return &faultBackend{Backend: b, f: f}
+}
+
+// faultBackend is a Backend which injects failures before calling the wrapped Backend
+type faultBackend struct {
+	Backend
+
+	f *Faults
+}
+
+func (b *faultBackend) Import(ctx context.Context, prefix, filename string, w io.Writer) (err error) {
+	if err = b.f.backendFault(); err != nil {
+		return
+	}
+
+	return b.Backend.Import(ctx, prefix, filename, w)
+}
+
+func (b *faultBackend) Export(ctx context.Context, prefix, filename string, r io.Reader) (newFilename string, err error) {
+	if err = b.f.backendFault(); err != nil {
+		return
+	}
+
+	return b.Backend.Export(ctx, prefix, filename, r)
+}
+
+func (b *faultBackend) Delete(ctx context.Context, prefix, filename string) (err error) {
+	deleter, ok := b.Backend.(Deleter)
+	if !ok {
+		return ErrDeleterNotImplemented
+	}
+
+	if err = b.f.backendFault(); err != nil {
+		return
+	}
+
+	return deleter.Delete(ctx, prefix, filename)
+}
+
+func (b *faultBackend) Rename(ctx context.Context, prefix, filename, newFilename string) (err error) {
+	renamer, ok := b.Backend.(Renamer)
+	if !ok {
+		return ErrRenamerNotImplemented
+	}
+
+	if err = b.f.backendFault(); err != nil {
+		return
+	}
+
+	return renamer.Rename(ctx, prefix, filename, newFilename)
+}
+
+func (b *faultBackend) List(ctx context.Context, prefix string) (filenames []string, err error) {
+	lister, ok := b.Backend.(Lister)
+	if !ok {
+		return nil, ErrListerNotImplemented
+	}
+
+	if err = b.f.backendFault(); err != nil {
+		return
+	}
+
+	return lister.List(ctx, prefix)
+}
+
+func (b *faultBackend) Head(ctx context.Context, prefix, filename string) (exists bool, err error) {
+	header, ok := b.Backend.(Header)
+	if !ok {
+		return false, ErrHeaderNotImplemented
+	}
+
+	if err = b.f.backendFault(); err != nil {
+		return
+	}
+
+	return header.Head(ctx, prefix, filename)
+}
+
+func (b *faultBackend) Stat(ctx context.Context, prefix, filename string) (info RemoteInfo, err error) {
+	stater, ok := b.Backend.(Stater)
+	if !ok {
+		return info, ErrStaterNotImplemented
+	}
+
+	if err = b.f.backendFault(); err != nil {
+		return
+	}
+
+	return stater.Stat(ctx, prefix, filename)
+}
+
+var _ fileSystem = &faultFS{}
+
+// faultFS is a fileSystem whose files inject delays and partial reads
+type faultFS struct {
+	fileSystem
+
+	f *Faults
+}
+
+func (fsys *faultFS) Open(name string) (file, error) {
+	return fsys.wrap(fsys.fileSystem.Open(name))
+}
+
+func (fsys *faultFS) OpenFile(name string, flag int, perm os.FileMode) (file, error) {
+	return fsys.wrap(fsys.fileSystem.OpenFile(name, flag, perm))
+}
+
+func (fsys *faultFS) Create(name string) (file, error) {
+	return fsys.wrap(fsys.fileSystem.Create(name))
+}
+
+func (fsys *faultFS) CreateTemp(dir, pattern string) (file, error) {
+	return fsys.wrap(fsys.fileSystem.CreateTemp(dir, pattern))
+}
+
+func (fsys *faultFS) wrap(f file, err error) (file, error) {
+	if err != nil {
+		return nil, err
+	}
+
+	return &faultFile{file: f, f: fsys.f}, nil
+}
+
+// faultFile is a file which injects delays and partial reads
+type faultFile struct {
+	file
+
+	f *Faults
+}
+
+func (f *faultFile) Read(bs []byte) (n int, err error) {
+	if limit := f.f.getPartialReads(); limit > 0 && len(bs) > limit {
+		bs = bs[:limit]
+	}
+
+	return f.file.Read(bs)
+}
+
+func (f *faultFile) Write(bs []byte) (n int, err error) {
+	if delay := f.f.getWriteDelay(); delay > 0 {
+		time.Sleep(delay)
+	}
+
+	return f.file.Write(bs)
+}
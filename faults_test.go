@@ -0,0 +1,131 @@
+package csvdb
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestFaults(t *testing.T) {
+	type testcase struct {
+		name   string
+		inject func(f *Faults)
+
+		wantGetErr error
+		// wantRecovered is whether or not a retried Get succeeds
+		wantRecovered bool
+		wantMinAppend time.Duration
+	}
+
+	errTimeout := errors.New("timeout")
+	tests := []testcase{
+		{
+			name:          "none",
+			inject:        func(f *Faults) {},
+			wantRecovered: true,
+		},
+		{
+			name: "fail backend",
+			inject: func(f *Faults) {
+				f.FailBackend(1, errTimeout)
+			},
+			wantGetErr:    errTimeout,
+			wantRecovered: true,
+		},
+		{
+			name: "fail backend default error",
+			inject: func(f *Faults) {
+				f.FailBackend(2, nil)
+			},
+			wantGetErr: ErrInjectedFault,
+		},
+		{
+			name: "delay writes",
+			inject: func(f *Faults) {
+				f.DelayWrites(5 * time.Millisecond)
+			},
+			wantRecovered: true,
+			wantMinAppend: 5 * time.Millisecond,
+		},
+		{
+			name: "partial reads",
+			inject: func(f *Faults) {
+				f.PartialReads(3)
+			},
+			wantRecovered: true,
+		},
+		{
+			name: "reset",
+			inject: func(f *Faults) {
+				f.FailBackend(10, nil)
+				f.PartialReads(1)
+				f.Reset()
+			},
+			wantRecovered: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := &mockBackend{
+				importFn: func(ctx context.Context, prefix, filename string, w io.Writer) (err error) {
+					_, err = w.Write([]byte("foo,bar\nremote,remote\n"))
+					return
+				},
+			}
+
+			var opts Options
+			opts.Dir = fmt.Sprintf("test_%d", time.Now().UnixNano())
+			opts.Name = "foo"
+			opts.Faults = &Faults{}
+			d, err := makeDB[testentry](opts, b)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer os.RemoveAll(d.o.Dir)
+
+			tt.inject(opts.Faults)
+
+			w := &bytes.Buffer{}
+			if err = d.Get(w, "remote"); !errors.Is(err, tt.wantGetErr) {
+				t.Fatalf("DB.Get() error = %v, wantErr %v", err, tt.wantGetErr)
+			}
+
+			w.Reset()
+			if err = d.Get(w, "remote"); (err == nil) != tt.wantRecovered {
+				t.Fatalf("DB.Get() retry error = %v, wantRecovered %v", err, tt.wantRecovered)
+			} else if err == nil && w.String() != "foo,bar\nremote,remote\n" {
+				t.Errorf("DB.Get() = %q", w.String())
+			}
+
+			es := make([]testentry, 100)
+			for i := range es {
+				es[i] = testentry{Foo: strconv.Itoa(i), Bar: "bar"}
+			}
+
+			start := time.Now()
+			if err = d.Append("local", es...); err != nil {
+				t.Fatal(err)
+			}
+
+			if elapsed := time.Since(start); elapsed < tt.wantMinAppend {
+				t.Errorf("DB.Append() took %v, want at least %v", elapsed, tt.wantMinAppend)
+			}
+
+			w.Reset()
+			if err = d.Get(w, "local"); err != nil {
+				t.Fatal(err)
+			}
+
+			if want := 100 + 1; bytes.Count(w.Bytes(), []byte("\n")) != want {
+				t.Errorf("DB.Get() rows = %d, want %d", bytes.Count(w.Bytes(), []byte("\n")), want)
+			}
+		})
+	}
+}
@@ -0,0 +1,60 @@
+package csvdb
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+// flightGroup de-duplicates concurrent calls sharing the same key, so only one call
+// runs at a time and concurrent callers share its result
+type flightGroup struct {
+	mux   sync.Mutex
+	calls map[string]*flightCall
+}
+
+type flightCall struct {
+	done chan struct{}
+	err  error
+}
+
+// do will run fn unless a call for the key is already in flight, in which case the
+// result of that call is shared. Should the shared call fail due to its own context
+// ending, the call is retried while the provided context is still active.
+func (g *flightGroup) do(ctx context.Context, key string, fn func() error) (err error) {
+	for {
+		g.mux.Lock()
+		if g.calls == nil {
+			g.calls = make(map[string]*flightCall)
+		}
+
+		c, ok := g.calls[key]
+		if !ok {
+			c = &flightCall{done: make(chan struct{})}
+			g.calls[key] = c
+			g.mux.Unlock()
+
+			c.err = fn()
+			g.mux.Lock()
+			delete(g.calls, key)
+			g.mux.Unlock()
+			close(c.done)
+			return c.err
+		}
+
+		g.mux.Unlock()
+		select {
+		case <-c.done:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+
+		if !isContextError(c.err) || ctx.Err() != nil {
+			return c.err
+		}
+	}
+}
+
+func isContextError(err error) bool {
+	return errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded)
+}
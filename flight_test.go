@@ -0,0 +1,160 @@
+package csvdb
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func Test_flightGroup_do(t *testing.T) {
+	type testcase struct {
+		name          string
+		callers       int
+		leaderTimeout time.Duration
+
+		wantCalls int32
+	}
+
+	tests := []testcase{
+		{
+			name:      "single",
+			callers:   1,
+			wantCalls: 1,
+		},
+		{
+			name:      "shared",
+			callers:   8,
+			wantCalls: 1,
+		},
+		{
+			name:          "retried after leader context ends",
+			callers:       4,
+			leaderTimeout: 5 * time.Millisecond,
+			wantCalls:     2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var (
+				g     flightGroup
+				calls atomic.Int32
+				wg    sync.WaitGroup
+			)
+
+			started := make(chan struct{})
+			release := make(chan struct{})
+			leaderCtx := context.Background()
+			if tt.leaderTimeout > 0 {
+				var cancel func()
+				leaderCtx, cancel = context.WithTimeout(leaderCtx, tt.leaderTimeout)
+				defer cancel()
+			}
+
+			fn := func(ctx context.Context) func() error {
+				return func() error {
+					if calls.Add(1) == 1 {
+						close(started)
+					}
+
+					select {
+					case <-release:
+						return nil
+					case <-ctx.Done():
+						return ctx.Err()
+					}
+				}
+			}
+
+			errs := make([]error, tt.callers)
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				errs[0] = g.do(leaderCtx, "a", fn(leaderCtx))
+			}()
+
+			<-started
+			for i := 1; i < tt.callers; i++ {
+				wg.Add(1)
+				go func(i int) {
+					defer wg.Done()
+					errs[i] = g.do(context.Background(), "a", fn(context.Background()))
+				}(i)
+			}
+
+			time.Sleep(10 * time.Millisecond)
+			close(release)
+			wg.Wait()
+
+			if got := calls.Load(); got != tt.wantCalls {
+				t.Errorf("flightGroup.do() calls = %d, want %d", got, tt.wantCalls)
+			}
+
+			for i, err := range errs[1:] {
+				if err != nil {
+					t.Errorf("flightGroup.do() caller #%d error = %v", i+1, err)
+				}
+			}
+		})
+	}
+}
+
+func TestDB_Get_concurrentDownload(t *testing.T) {
+	var (
+		downloads atomic.Int32
+		release   = make(chan struct{})
+	)
+
+	b := &mockBackend{
+		importFn: func(ctx context.Context, prefix, filename string, w io.Writer) (err error) {
+			downloads.Add(1)
+			<-release
+			_, err = w.Write([]byte("foo,bar\n1,1b\n"))
+			return
+		},
+	}
+
+	var opts Options
+	opts.Dir = fmt.Sprintf("test_%d", time.Now().UnixNano())
+	opts.Name = "foo"
+	d, err := makeDB[testentry](opts, b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(d.o.Dir)
+
+	var wg sync.WaitGroup
+	outputs := make([]bytes.Buffer, 8)
+	errs := make([]error, len(outputs))
+	for i := range outputs {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			errs[i] = d.Get(&outputs[i], "a")
+		}(i)
+	}
+
+	time.Sleep(10 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	if got := downloads.Load(); got != 1 {
+		t.Errorf("DB.Get() downloads = %d, want 1", got)
+	}
+
+	for i := range outputs {
+		if errs[i] != nil {
+			t.Fatalf("DB.Get() error = %v", errs[i])
+		}
+
+		if want := "foo,bar\n1,1b\n"; outputs[i].String() != want {
+			t.Errorf("DB.Get() = %v, want %v", outputs[i].String(), want)
+		}
+	}
+}
@@ -0,0 +1,164 @@
+package csvdb
+
+import (
+	"io"
+	"os"
+	"time"
+)
+
+// fileSystem is the storage layer used by the DB
+type fileSystem interface {
+	Open(name string) (file, error)
+	OpenFile(name string, flag int, perm os.FileMode) (file, error)
+	Create(name string) (file, error)
+	CreateTemp(dir, pattern string) (file, error)
+	Remove(name string) error
+	Rename(oldpath, newpath string) error
+	Stat(name string) (os.FileInfo, error)
+	Chtimes(name string, atime, mtime time.Time) error
+	ReadDir(name string) ([]os.DirEntry, error)
+	MkdirAll(name string, perm os.FileMode) error
+	// SyncDir will commit the entries of a directory to stable storage
+	SyncDir(name string) error
+}
+
+// file is a handle to a file within a fileSystem
+type file interface {
+	io.Reader
+	io.Writer
+	io.Seeker
+	io.Closer
+
+	Name() string
+	Stat() (os.FileInfo, error)
+	Truncate(size int64) error
+	Sync() error
+}
+
+var _ fileSystem = osFS{}
+
+// osFS is a fileSystem backed by the local disk
+type osFS struct{}
+
+func (osFS) Open(name string) (file, error) {
+	return wrapOSFile(os.Open(name))
+}
+
+func (osFS) OpenFile(name string, flag int, perm os.FileMode) (file, error) {
+	return wrapOSFile(openFile(name, flag, perm))
+}
+
+func (osFS) Create(name string) (file, error) {
+	return wrapOSFile(os.Create(name))
+}
+
+func (osFS) CreateTemp(dir, pattern string) (f file, err error) {
+	var osf *os.File
+	if osf, err = os.CreateTemp(dir, pattern); err != nil {
+		return
+	}
+
+	if err = osf.Chmod(0644); err != nil {
+		osf.Close()
+		os.Remove(osf.Name())
+		return
+	}
+
+	return osf, nil
+}
+
+func (osFS) Remove(name string) error {
+	return os.Remove(name)
+}
+
+func (osFS) Rename(oldpath, newpath string) error {
+	return os.Rename(oldpath, newpath)
+}
+
+func (osFS) Stat(name string) (os.FileInfo, error) {
+	return os.Stat(name)
+}
+
+func (osFS) Chtimes(name string, atime, mtime time.Time) error {
+	return os.Chtimes(name, atime, mtime)
+}
+
+func (osFS) ReadDir(name string) ([]os.DirEntry, error) {
+	return os.ReadDir(name)
+}
+
+func (osFS) MkdirAll(name string, perm os.FileMode) error {
+	return os.MkdirAll(name, perm)
+}
+
+func (osFS) SyncDir(name string) (err error) {
+	var f *os.File
+	if f, err = os.Open(name); err != nil {
+		return
+	}
+
+	err = f.Sync()
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+
+	return
+}
+
+// wrapOSFile avoids returning a non-nil file interface holding a nil *os.File
+func wrapOSFile(f *os.File, err error) (file, error) {
+	if err != nil {
+		return nil, err
+	}
+
+	return f, nil
+}
+
+// readFile will read the entire contents of a file
+func readFile(fsys fileSystem, name string) (bs []byte, err error) {
+	var f file
+	if f, err = fsys.Open(name); err != nil {
+		return
+	}
+	defer f.Close()
+	return io.ReadAll(f)
+}
+
+// dirReader is implemented by files which can read a directory in batches
+type dirReader interface {
+	ReadDir(n int) ([]os.DirEntry, error)
+}
+
+// readDirBatches will call the provided func with batches of up to n directory entries.
+// Directories which cannot be read in batches are read in full and passed as a single batch.
+func readDirBatches(fsys fileSystem, name string, n int, fn func([]os.DirEntry) error) (err error) {
+	var dr dirReader
+	if f, err := fsys.Open(name); err == nil {
+		defer f.Close()
+		dr, _ = f.(dirReader)
+	}
+
+	if dr == nil {
+		var entries []os.DirEntry
+		if entries, err = fsys.ReadDir(name); err != nil {
+			return
+		}
+
+		return fn(entries)
+	}
+
+	for {
+		var entries []os.DirEntry
+		entries, err = dr.ReadDir(n)
+		switch {
+		case err == io.EOF:
+			return nil
+		case err != nil:
+			return
+		}
+
+		if err = fn(entries); err != nil {
+			return
+		}
+	}
+}
@@ -1,3 +1,5 @@
 module github.com/itsmontoya/csvdb
 
 go 1.21.0
+
+require golang.org/x/text v0.14.0
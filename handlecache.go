@@ -0,0 +1,123 @@
+package csvdb
+
+import (
+	"container/list"
+	"sync"
+)
+
+// handleCache is an LRU cache of the file handles used by appends, saving the open and close
+// of hot keys. Handles are checked out while in use, so only idle handles are ever evicted.
+type handleCache struct {
+	mux sync.Mutex
+	max int
+	// lru holds the idle handles, the most recently used first
+	lru     *list.List
+	handles map[string]*list.Element
+}
+
+type cachedHandle struct {
+	filename string
+	f        file
+}
+
+func newHandleCache(max int) *handleCache {
+	var c handleCache
+	c.max = max
+	c.lru = list.New()
+	c.handles = make(map[string]*list.Element)
+	return &c
+}
+
+// get will check out the cached handle of a file
+func (c *handleCache) get(filename string) (f file, ok bool) {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+	var e *list.Element
+	if e, ok = c.handles[filename]; !ok {
+		return
+	}
+
+	c.lru.Remove(e)
+	delete(c.handles, filename)
+	return e.Value.(*cachedHandle).f, true
+}
+
+// put will return a handle to the cache, closing the least recently used handles once the cache is full
+func (c *handleCache) put(filename string, f file) {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+	if e, ok := c.handles[filename]; ok {
+		// Cannot occur while the key is locked, keep the newer handle regardless
+		c.lru.Remove(e)
+		e.Value.(*cachedHandle).f.Close()
+	}
+
+	c.handles[filename] = c.lru.PushFront(&cachedHandle{filename: filename, f: f})
+	for c.lru.Len() > c.max {
+		h := c.lru.Remove(c.lru.Back()).(*cachedHandle)
+		delete(c.handles, h.filename)
+		h.f.Close()
+	}
+}
+
+// remove will close the cached handle of a file, once the file has been removed or replaced
+func (c *handleCache) remove(filename string) {
+	if f, ok := c.get(filename); ok {
+		f.Close()
+	}
+}
+
+// closeAll will close every cached handle
+func (c *handleCache) closeAll() {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+	for filename, e := range c.handles {
+		e.Value.(*cachedHandle).f.Close()
+		delete(c.handles, filename)
+	}
+
+	c.lru.Init()
+}
+
+// handleFS is a fileSystem which invalidates the cached handles of files as they are
+// removed or replaced, so appends never write to an unlinked file
+type handleFS struct {
+	fileSystem
+
+	c *handleCache
+}
+
+func (fsys *handleFS) Remove(name string) error {
+	fsys.c.remove(name)
+	return fsys.fileSystem.Remove(name)
+}
+
+func (fsys *handleFS) Rename(oldpath, newpath string) error {
+	fsys.c.remove(oldpath)
+	fsys.c.remove(newpath)
+	return fsys.fileSystem.Rename(oldpath, newpath)
+}
+
+// openAppend will open a file to be appended to, reusing its cached handle when MaxOpenFiles
+// is set. The file must be released by releaseAppend once the append has completed.
+func (d *DB[T]) openAppend(filename string) (f file, err error) {
+	if d.handles != nil {
+		var ok bool
+		if f, ok = d.handles.get(filename); ok {
+			return
+		}
+	}
+
+	return getOrCreate(d.fs, filename)
+}
+
+// releaseAppend will return the handle of an appended file to the cache, handles of
+// failed appends are closed
+func (d *DB[T]) releaseAppend(filename string, f file, err error) {
+	if d.handles == nil || err != nil {
+		f.Close()
+		return
+	}
+
+	d.handles.put(filename, f)
+}
@@ -0,0 +1,146 @@
+package csvdb
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// openCountFS is a fileSystem which counts the files opened
+type openCountFS struct {
+	fileSystem
+
+	opens atomic.Int64
+}
+
+func (o *openCountFS) OpenFile(name string, flag int, perm os.FileMode) (file, error) {
+	o.opens.Add(1)
+	return o.fileSystem.OpenFile(name, flag, perm)
+}
+
+func TestDB_MaxOpenFiles(t *testing.T) {
+	type testcase struct {
+		name         string
+		maxOpenFiles int
+		keys         []string
+
+		wantOpens int64
+	}
+
+	tests := []testcase{
+		{
+			name:         "disabled",
+			maxOpenFiles: 0,
+			keys:         []string{"a", "a", "a"},
+			wantOpens:    3,
+		},
+		{
+			name:         "hot key",
+			maxOpenFiles: 1,
+			keys:         []string{"a", "a", "a"},
+			wantOpens:    1,
+		},
+		{
+			name:         "evicted",
+			maxOpenFiles: 1,
+			keys:         []string{"a", "b", "a", "b"},
+			wantOpens:    4,
+		},
+		{
+			name:         "within capacity",
+			maxOpenFiles: 2,
+			keys:         []string{"a", "b", "a", "b"},
+			wantOpens:    2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var opts Options
+			opts.Dir = fmt.Sprintf("test_%d", time.Now().UnixNano())
+			opts.Name = "foo"
+			opts.MaxOpenFiles = tt.maxOpenFiles
+			d, err := makeDB[testentry](opts, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer os.RemoveAll(d.o.Dir)
+			defer d.Close()
+
+			// Count the opens beneath the handle cache
+			o := &openCountFS{fileSystem: d.fs}
+			if h, ok := d.fs.(*handleFS); ok {
+				o.fileSystem = h.fileSystem
+				h.fileSystem = o
+			} else {
+				d.fs = o
+			}
+
+			for _, key := range tt.keys {
+				if err = d.Append(key, testentry{Foo: "1", Bar: "1b"}); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			if got := o.opens.Load(); got != tt.wantOpens {
+				t.Errorf("opens = %d, want %d", got, tt.wantOpens)
+			}
+		})
+	}
+}
+
+func TestDB_MaxOpenFiles_invalidation(t *testing.T) {
+	var opts Options
+	opts.Dir = fmt.Sprintf("test_%d", time.Now().UnixNano())
+	opts.Name = "foo"
+	opts.MaxOpenFiles = 4
+	d, err := makeDB[testentry](opts, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(d.o.Dir)
+	defer d.Close()
+
+	if err = d.Append("a", testentry{Foo: "1", Bar: "1b"}); err != nil {
+		t.Fatal(err)
+	}
+
+	// Replaces the file, the cached handle must not be written to
+	if err = d.Upsert("a", "foo", testentry{Foo: "1", Bar: "1c"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err = d.Append("a", testentry{Foo: "2", Bar: "2b"}); err != nil {
+		t.Fatal(err)
+	}
+
+	w := &bytes.Buffer{}
+	if err = d.Get(w, "a"); err != nil {
+		t.Fatal(err)
+	}
+
+	if want := "foo,bar\n1,1c\n2,2b\n"; w.String() != want {
+		t.Fatalf("DB.Get() = %v, want %v", w.String(), want)
+	}
+
+	// Removes the file, appends must recreate it
+	if err = d.Delete("a"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err = d.Append("a", testentry{Foo: "3", Bar: "3b"}); err != nil {
+		t.Fatal(err)
+	}
+
+	w.Reset()
+	if err = d.Get(w, "a"); err != nil {
+		t.Fatal(err)
+	}
+
+	if want := "foo,bar\n3,3b\n"; w.String() != want {
+		t.Fatalf("DB.Get() = %v, want %v", w.String(), want)
+	}
+}
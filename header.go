@@ -0,0 +1,127 @@
+package csvdb
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"os"
+	"reflect"
+)
+
+// HasHeader will return whether or not the first row of a key matches the header of T, or the
+// header override of the key when set
+func (d *DB[T]) HasHeader(key string) (ok bool, err error) {
+	return d.HasHeaderContext(context.Background(), key)
+}
+
+// HasHeaderContext is the context-aware variant of HasHeader
+func (d *DB[T]) HasHeaderContext(ctx context.Context, key string) (ok bool, err error) {
+	var unlock func()
+	if unlock, err = d.rlockKey(ctx, key); err != nil {
+		return
+	}
+	defer unlock()
+
+	if err = d.checkQuarantine(key); err != nil {
+		return
+	}
+
+	_, filename := d.getFilename(key)
+	return d.hasHeader(key, filename)
+}
+
+// RepairHeader will insert the header of T, or the header override of the key when set, at the top
+// of a key's file when the first row does not match it. The file is rewritten atomically.
+func (d *DB[T]) RepairHeader(key string) (repaired bool, err error) {
+	return d.RepairHeaderContext(context.Background(), key)
+}
+
+// RepairHeaderContext is the context-aware variant of RepairHeader
+func (d *DB[T]) RepairHeaderContext(ctx context.Context, key string) (repaired bool, err error) {
+	var unlock func()
+	if unlock, err = d.lockKeys(ctx, key); err != nil {
+		return
+	}
+	defer unlock()
+
+	if err = d.checkQuarantine(key); err != nil {
+		return
+	}
+
+	_, filename := d.getFilename(key)
+	return d.repairHeader(key, filename)
+}
+
+func (d *DB[T]) hasHeader(key, filename string) (ok bool, err error) {
+	var f file
+	if f, err = d.fs.Open(filename); os.IsNotExist(err) {
+		err = ErrEntryNotFound
+		return
+	} else if err != nil {
+		return
+	}
+	defer f.Close()
+
+	var first []string
+	if first, err = newCSVReader(f, d.dialect).Read(); err == io.EOF {
+		// Empty files have nothing to repair
+		return true, nil
+	} else if err != nil {
+		return
+	}
+
+	return reflect.DeepEqual(first, d.header(key, newEntry[T]())), nil
+}
+
+func (d *DB[T]) repairHeader(key, filename string) (repaired bool, err error) {
+	var ok bool
+	if ok, err = d.hasHeader(key, filename); err != nil || ok {
+		return
+	}
+
+	var src file
+	if src, err = d.fs.Open(filename); err != nil {
+		return
+	}
+	defer src.Close()
+
+	var tmp file
+	if tmp, err = createTemp(d.fs, d.o.TempDir, filename); err != nil {
+		return
+	}
+
+	defer func() {
+		if err == nil {
+			return
+		}
+
+		tmp.Close()
+		d.fs.Remove(tmp.Name())
+	}()
+
+	var header bytes.Buffer
+	w := newCSVWriter(&header, d.dialect)
+	if err = w.Write(d.header(key, newEntry[T]())); err != nil {
+		return
+	}
+
+	w.Flush()
+	if _, err = tmp.Write(header.Bytes()); err != nil {
+		return
+	}
+
+	if _, err = io.Copy(tmp, src); err != nil {
+		return
+	}
+
+	if err = tmp.Close(); err != nil {
+		return
+	}
+
+	if err = d.fs.Rename(tmp.Name(), filename); err != nil {
+		return
+	}
+
+	d.o.Logger.Printf("csvdb.DB[%s].repairHeader(): inserted missing header into <%s>\n", d.o.Name, filename)
+	return true, nil
+}
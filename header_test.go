@@ -0,0 +1,131 @@
+package csvdb
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"testing"
+	"time"
+)
+
+func TestDB_RepairHeader(t *testing.T) {
+	type testcase struct {
+		name          string
+		contents      string
+		repairHeaders bool
+		wantHeader    bool
+		wantMerged    string
+		wantMergeErr  error
+	}
+
+	tests := []testcase{
+		{
+			name:       "valid",
+			contents:   "foo,bar\n1,1b\n",
+			wantHeader: true,
+			wantMerged: "foo,bar\n0,0b\n1,1b\n",
+		},
+		{
+			name:         "missing header",
+			contents:     "1,1b\n2,2b\n",
+			wantHeader:   false,
+			wantMergeErr: ErrHeaderMismatch,
+		},
+		{
+			name:          "missing header with repair",
+			contents:      "1,1b\n2,2b\n",
+			repairHeaders: true,
+			wantHeader:    false,
+			wantMerged:    "foo,bar\n0,0b\n1,1b\n2,2b\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var opts Options
+			opts.Dir = fmt.Sprintf("test_%d", time.Now().UnixNano())
+			opts.Name = "foo"
+			opts.RepairHeaders = tt.repairHeaders
+			d, err := makeDB[testentry](opts, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer os.RemoveAll(d.o.Dir)
+
+			if err = d.Append("a", testentry{Foo: "0", Bar: "0b"}); err != nil {
+				t.Fatal(err)
+			}
+
+			if err = os.WriteFile(path.Join(d.getFullPath(), "foo.b.csv"), []byte(tt.contents), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			ok, err := d.HasHeader("b")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if ok != tt.wantHeader {
+				t.Errorf("DB.HasHeader() = %v, want %v", ok, tt.wantHeader)
+			}
+
+			w := &bytes.Buffer{}
+			if err = d.GetMerged(w, "a", "b"); !errors.Is(err, tt.wantMergeErr) {
+				t.Fatalf("DB.GetMerged() error = %v, wantErr %v", err, tt.wantMergeErr)
+			}
+
+			if gotW := w.String(); tt.wantMergeErr == nil && gotW != tt.wantMerged {
+				t.Errorf("DB.GetMerged() = %v, want %v", gotW, tt.wantMerged)
+			}
+
+			repaired, err := d.RepairHeader("b")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if wantRepaired := !tt.wantHeader && !tt.repairHeaders; repaired != wantRepaired {
+				t.Errorf("DB.RepairHeader() = %v, want %v", repaired, wantRepaired)
+			}
+
+			if ok, err = d.HasHeader("b"); err != nil || !ok {
+				t.Errorf("DB.HasHeader() after repair = %v, %v", ok, err)
+			}
+		})
+	}
+}
+
+func TestDB_RepairHeader_pointerEntry(t *testing.T) {
+	var opts Options
+	opts.Dir = fmt.Sprintf("test_%d", time.Now().UnixNano())
+	opts.Name = "foo"
+	opts.RepairHeaders = true
+	d, err := makeDB[*testentry](opts, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(d.o.Dir)
+
+	if err = os.WriteFile(path.Join(d.getFullPath(), "foo.b.csv"), []byte("1,1b\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	w := &bytes.Buffer{}
+	if err = d.Get(w, "b"); err != nil {
+		t.Fatal(err)
+	}
+
+	if want := "foo,bar\n1,1b\n"; w.String() != want {
+		t.Errorf("DB.Get() = %v, want %v", w.String(), want)
+	}
+
+	ok, err := d.HasHeader("b")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !ok {
+		t.Errorf("DB.HasHeader() = %v, want %v", ok, true)
+	}
+}
@@ -0,0 +1,137 @@
+package csvdb
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+const (
+	// HeaderCaseNone will write the keys of entries as they are
+	HeaderCaseNone HeaderCase = iota
+	// HeaderCaseSnake will write the keys of entries as snake_case, such as user_id
+	HeaderCaseSnake
+	// HeaderCaseCamel will write the keys of entries as camelCase, such as userId
+	HeaderCaseCamel
+	// HeaderCaseUpper will write the keys of entries as upper snake case, such as USER_ID
+	HeaderCaseUpper
+)
+
+var (
+	// ErrInvalidHeaderCase is returned when Options.HeaderCase is unknown
+	ErrInvalidHeaderCase = errors.New("invalid headerCase, unknown value")
+	// ErrHeaderCaseCollision is returned when multiple keys of an entry transform to the same column
+	ErrHeaderCaseCollision = errors.New("cannot transform header, multiple keys transform to the same column")
+)
+
+// HeaderCase represents the naming style the keys of entries are transformed to when written as a header
+type HeaderCase uint8
+
+// transform will return a key in the naming style of the HeaderCase
+func (h HeaderCase) transform(key string) string {
+	if h == HeaderCaseNone {
+		return key
+	}
+
+	words := splitWords(key)
+	for i, word := range words {
+		switch {
+		case h == HeaderCaseUpper:
+			words[i] = strings.ToUpper(word)
+		case h == HeaderCaseCamel && i > 0:
+			rs := []rune(strings.ToLower(word))
+			rs[0] = unicode.ToUpper(rs[0])
+			words[i] = string(rs)
+		default:
+			words[i] = strings.ToLower(word)
+		}
+	}
+
+	if h == HeaderCaseCamel {
+		return strings.Join(words, "")
+	}
+
+	return strings.Join(words, "_")
+}
+
+// splitWords will split a key into its words, which are separated by underscores, hyphens,
+// spaces or changes of case. Runs of upper case letters, such as ID within userID, are kept
+// together as a single word.
+func splitWords(key string) (words []string) {
+	rs := []rune(key)
+	start := -1
+	for i, r := range rs {
+		if r == '_' || r == '-' || unicode.IsSpace(r) {
+			if start != -1 {
+				words = append(words, string(rs[start:i]))
+				start = -1
+			}
+
+			continue
+		}
+
+		if start == -1 {
+			start = i
+			continue
+		}
+
+		prev := rs[i-1]
+		lowerToUpper := unicode.IsUpper(r) && !unicode.IsUpper(prev)
+		// The final letter of an upper case run begins the next word, such as S within HTTPServer
+		acronymEnd := unicode.IsUpper(r) && unicode.IsUpper(prev) && i+1 < len(rs) && unicode.IsLower(rs[i+1])
+		if lowerToUpper || acronymEnd {
+			words = append(words, string(rs[start:i]))
+			start = i
+		}
+	}
+
+	if start != -1 {
+		words = append(words, string(rs[start:]))
+	}
+
+	return
+}
+
+// checkHeaderCase will ensure the keys of T do not collide once transformed
+func (d *DB[T]) checkHeaderCase() (err error) {
+	if d.o.HeaderCase == HeaderCaseNone {
+		return
+	}
+
+	seen := make(map[string]string)
+	for _, key := range newEntry[T]().Keys() {
+		column := d.o.HeaderCase.transform(key)
+		if other, ok := seen[column]; ok {
+			return fmt.Errorf("%w: <%s> and <%s> both transform to <%s>", ErrHeaderCaseCollision, other, key, column)
+		}
+
+		seen[column] = key
+	}
+
+	return
+}
+
+// entryKeys will return a header with the columns transformed by Options.HeaderCase replaced
+// by the keys of T, so rows can be unmarshaled into entries
+func (d *DB[T]) entryKeys(header []string) (keys []string) {
+	if d.o.HeaderCase == HeaderCaseNone {
+		return header
+	}
+
+	original := make(map[string]string)
+	for _, key := range newEntry[T]().Keys() {
+		original[d.o.HeaderCase.transform(key)] = key
+	}
+
+	keys = make([]string, len(header))
+	for i, column := range header {
+		if key, ok := original[column]; ok {
+			column = key
+		}
+
+		keys[i] = column
+	}
+
+	return
+}
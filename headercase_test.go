@@ -0,0 +1,163 @@
+package csvdb
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+)
+
+type collidingentry struct {
+	A string
+	B string
+}
+
+func (c collidingentry) Keys() []string {
+	return []string{"userId", "user_id"}
+}
+
+func (c collidingentry) Values() []string {
+	return []string{c.A, c.B}
+}
+
+func TestHeaderCase_transform(t *testing.T) {
+	type testcase struct {
+		key   string
+		snake string
+		camel string
+		upper string
+	}
+
+	tests := []testcase{
+		{key: "foo", snake: "foo", camel: "foo", upper: "FOO"},
+		{key: "userID", snake: "user_id", camel: "userId", upper: "USER_ID"},
+		{key: "UserName", snake: "user_name", camel: "userName", upper: "USER_NAME"},
+		{key: "HTTPServer", snake: "http_server", camel: "httpServer", upper: "HTTP_SERVER"},
+		{key: "created_at", snake: "created_at", camel: "createdAt", upper: "CREATED_AT"},
+		{key: "first-name", snake: "first_name", camel: "firstName", upper: "FIRST_NAME"},
+		{key: "Last Name", snake: "last_name", camel: "lastName", upper: "LAST_NAME"},
+		{key: "address2", snake: "address2", camel: "address2", upper: "ADDRESS2"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.key, func(t *testing.T) {
+			if got := HeaderCaseNone.transform(tt.key); got != tt.key {
+				t.Errorf("HeaderCaseNone.transform() = %v, want %v", got, tt.key)
+			}
+
+			if got := HeaderCaseSnake.transform(tt.key); got != tt.snake {
+				t.Errorf("HeaderCaseSnake.transform() = %v, want %v", got, tt.snake)
+			}
+
+			if got := HeaderCaseCamel.transform(tt.key); got != tt.camel {
+				t.Errorf("HeaderCaseCamel.transform() = %v, want %v", got, tt.camel)
+			}
+
+			if got := HeaderCaseUpper.transform(tt.key); got != tt.upper {
+				t.Errorf("HeaderCaseUpper.transform() = %v, want %v", got, tt.upper)
+			}
+		})
+	}
+}
+
+func TestDB_HeaderCase(t *testing.T) {
+	type testcase struct {
+		name       string
+		headerCase HeaderCase
+		want       string
+	}
+
+	tests := []testcase{
+		{name: "none", headerCase: HeaderCaseNone, want: "foo,bar\n1,updated\n"},
+		{name: "upper", headerCase: HeaderCaseUpper, want: "FOO,BAR\n1,updated\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var opts Options
+			opts.Dir = fmt.Sprintf("test_%d", time.Now().UnixNano())
+			opts.Name = "foo"
+			opts.HeaderCase = tt.headerCase
+			d, err := makeDB[testentry](opts, &mockBackend{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer os.RemoveAll(opts.Dir)
+
+			if err = d.Append("a", testentry{Foo: "1", Bar: "1b"}); err != nil {
+				t.Fatal(err)
+			}
+
+			// Rows are unmarshaled using the keys of the entry
+			if err = d.UpdateRows("a", func(e testentry) (testentry, bool, error) {
+				if e.Foo != "1" || e.Bar != "1b" {
+					return e, false, fmt.Errorf("unexpected entry %+v", e)
+				}
+
+				e.Bar = "updated"
+				return e, true, nil
+			}); err != nil {
+				t.Fatal(err)
+			}
+
+			w := &bytes.Buffer{}
+			if err = d.Get(w, "a"); err != nil {
+				t.Fatal(err)
+			}
+
+			if w.String() != tt.want {
+				t.Errorf("DB.Get() = %q, want %q", w.String(), tt.want)
+			}
+		})
+	}
+}
+
+func TestDB_HeaderCase_collision(t *testing.T) {
+	var opts Options
+	opts.Dir = fmt.Sprintf("test_%d", time.Now().UnixNano())
+	opts.Name = "foo"
+	opts.HeaderCase = HeaderCaseSnake
+	defer os.RemoveAll(opts.Dir)
+
+	if _, err := makeDB[collidingentry](opts, &mockBackend{}); !errors.Is(err, ErrHeaderCaseCollision) {
+		t.Errorf("makeDB() error = %v, want %v", err, ErrHeaderCaseCollision)
+	}
+}
+
+func TestDB_HeaderCase_pointerEntry(t *testing.T) {
+	var opts Options
+	opts.Dir = fmt.Sprintf("test_%d", time.Now().UnixNano())
+	opts.Name = "foo"
+	opts.HeaderCase = HeaderCaseUpper
+	d, err := makeDB[*testentry](opts, &mockBackend{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(opts.Dir)
+
+	if err = d.Append("a", &testentry{Foo: "1", Bar: "1b"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err = d.UpdateRows("a", func(e *testentry) (*testentry, bool, error) {
+		if e.Foo != "1" || e.Bar != "1b" {
+			return e, false, fmt.Errorf("unexpected entry %+v", e)
+		}
+
+		e.Bar = "updated"
+		return e, true, nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	w := &bytes.Buffer{}
+	if err = d.Get(w, "a"); err != nil {
+		t.Fatal(err)
+	}
+
+	if want := "FOO,BAR\n1,updated\n"; w.String() != want {
+		t.Errorf("DB.Get() = %q, want %q", w.String(), want)
+	}
+}
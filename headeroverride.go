@@ -0,0 +1,58 @@
+package csvdb
+
+import "errors"
+
+// ErrInvalidHeader is returned when a header override holds an empty or duplicated column
+var ErrInvalidHeader = errors.New("invalid header, columns must be non-empty and unique")
+
+func validateHeader(header []string) (err error) {
+	seen := make(map[string]struct{}, len(header))
+	for _, column := range header {
+		if _, ok := seen[column]; ok || column == "" {
+			return ErrInvalidHeader
+		}
+
+		seen[column] = struct{}{}
+	}
+
+	return
+}
+
+// headerOverride will return the header override of a key, which is the header of its policy
+// when set, otherwise Options.Header
+func (d *DB[T]) headerOverride(key string) (header []string) {
+	if p, ok := d.o.policyFor(key); ok && len(p.Header) > 0 {
+		return p.Header
+	}
+
+	return d.o.Header
+}
+
+// columns will return the entry columns of the files of a key, which is its header override
+// when set, otherwise the keys of the entry
+func (d *DB[T]) columns(key string, e Entry) (columns []string) {
+	if columns = d.headerOverride(key); len(columns) > 0 {
+		return
+	}
+
+	return e.Keys()
+}
+
+// values will return the values of an entry, matching its keys, in the order of the entry columns
+// of a key. Columns of the header override which are not keys of the entry are left empty, while
+// keys of the entry which are not within the header override are dropped.
+func (d *DB[T]) values(key string, keys, ev []string) (values []string) {
+	header := d.headerOverride(key)
+	if len(header) == 0 {
+		return ev
+	}
+
+	values = make([]string, len(header))
+	for i, column := range header {
+		if j := indexOf(keys, column); j != -1 {
+			values[i] = ev[j]
+		}
+	}
+
+	return
+}
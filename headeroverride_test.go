@@ -0,0 +1,112 @@
+package csvdb
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestDB_Header(t *testing.T) {
+	var opts Options
+	opts.Dir = fmt.Sprintf("test_%d", time.Now().UnixNano())
+	opts.Name = "foo"
+	opts.Header = []string{"bar", "baz", "foo"}
+	opts.Policies = []Policy{{Prefix: "legacy", Header: []string{"foo"}}}
+	d, err := makeDB[testentry](opts, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(opts.Dir)
+
+	for _, key := range []string{"a", "legacy/a"} {
+		if err = d.Append(key, testentry{Foo: "1", Bar: "1b"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	err = d.UpdateRows("a", func(e testentry) (testentry, bool, error) {
+		if e.Foo != "1" {
+			return e, false, fmt.Errorf("unexpected entry %+v", e)
+		}
+
+		e.Bar += "_updated"
+		return e, true, nil
+	})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err = d.Upsert("a", "foo", testentry{Foo: "2", Bar: "2b"}); err != nil {
+		t.Fatal(err)
+	}
+
+	// Entries are compared in the columns of the header override
+	for _, key := range []string{"a", "legacy/a"} {
+		if err = d.AppendUnique(key, testentry{Foo: "2", Bar: "2b"}, testentry{Foo: "1", Bar: "1b_updated"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := map[string]string{
+		"a":        "bar,baz,foo\n1b_updated,,1\n2b,,2\n",
+		"legacy/a": "foo\n1\n2\n",
+	}
+
+	for key, want := range tests {
+		w := &bytes.Buffer{}
+		if err = d.Get(w, key); err != nil {
+			t.Fatal(err)
+		}
+
+		if w.String() != want {
+			t.Errorf("DB.Get(%s) = %q, want %q", key, w.String(), want)
+		}
+
+		var ok bool
+		if ok, err = d.HasHeader(key); err != nil || !ok {
+			t.Errorf("DB.HasHeader(%s) = %v, error = %v, want true", key, ok, err)
+		}
+	}
+}
+
+func TestOptions_Validate_header(t *testing.T) {
+	type testcase struct {
+		name    string
+		opts    Options
+		wantErr error
+	}
+
+	tests := []testcase{
+		{
+			name: "header",
+			opts: Options{Dir: "test", Name: "foo", Header: []string{"bar", "foo"}},
+		},
+		{
+			name:    "empty column",
+			opts:    Options{Dir: "test", Name: "foo", Header: []string{"bar", ""}},
+			wantErr: ErrInvalidHeader,
+		},
+		{
+			name:    "duplicate column",
+			opts:    Options{Dir: "test", Name: "foo", Header: []string{"foo", "foo"}},
+			wantErr: ErrInvalidHeader,
+		},
+		{
+			name:    "duplicate policy column",
+			opts:    Options{Dir: "test", Name: "foo", Policies: []Policy{{Prefix: "a", Header: []string{"foo", "foo"}}}},
+			wantErr: ErrInvalidHeader,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.opts.Validate(); !errors.Is(err, tt.wantErr) {
+				t.Errorf("Options.Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,246 @@
+package csvdb
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"time"
+)
+
+const historyFilename = "history.jsonl"
+
+const (
+	// EventCreated is recorded when the file of a key is created
+	EventCreated EventType = iota
+	// EventAppended is recorded when rows are appended to a key
+	EventAppended
+	// EventExported is recorded when a key is exported to the backend
+	EventExported
+	// EventPurged is recorded when the file of a key is purged for expiring
+	EventPurged
+	// EventQuarantined is recorded when a key is quarantined
+	EventQuarantined
+	// EventDeleted is recorded when a key is deleted
+	EventDeleted
+	// EventRewritten is recorded when the rows of a key are rewritten, such as by Upsert or
+	// Restore
+	EventRewritten
+)
+
+// ErrInvalidEventType is returned when parsing an unknown event type
+var ErrInvalidEventType = errors.New("invalid event type")
+
+var eventTypeNames = [...]string{
+	EventCreated:     "created",
+	EventAppended:    "appended",
+	EventExported:    "exported",
+	EventPurged:      "purged",
+	EventQuarantined: "quarantined",
+	EventDeleted:     "deleted",
+	EventRewritten:   "rewritten",
+}
+
+// EventType represents a significant change to a key
+type EventType uint8
+
+func (e EventType) String() string {
+	if int(e) >= len(eventTypeNames) {
+		return fmt.Sprintf("EventType(%d)", e)
+	}
+
+	return eventTypeNames[e]
+}
+
+func (e EventType) MarshalText() (text []byte, err error) {
+	if int(e) >= len(eventTypeNames) {
+		return nil, ErrInvalidEventType
+	}
+
+	return []byte(e.String()), nil
+}
+
+func (e *EventType) UnmarshalText(text []byte) (err error) {
+	for i, name := range eventTypeNames {
+		if name == string(text) {
+			*e = EventType(i)
+			return
+		}
+	}
+
+	return fmt.Errorf("%w <%s>", ErrInvalidEventType, text)
+}
+
+// Event is a significant change to a key
+type Event struct {
+	Type EventType `json:"type"`
+	Time time.Time `json:"time"`
+	// Rows is the number of rows appended, set for EventAppended
+	Rows int `json:"rows,omitempty"`
+}
+
+// History will return the recorded events of a key, oldest first. Only the most recent
+// Options.HistorySize events are kept per key.
+func (d *DB[T]) History(key string) (events []Event) {
+	d.hmux.Lock()
+	defer d.hmux.Unlock()
+	return append(events, d.history[key]...)
+}
+
+// historyEvent is an event as it is persisted, alongside its key
+type historyEvent struct {
+	Key string `json:"key"`
+	Event
+}
+
+// recordAppend will record the rows appended to a key, preceded by its creation when the file was new
+func (d *DB[T]) recordAppend(key string, created bool, rows int) {
+	if created {
+		d.record(key, Event{Type: EventCreated})
+	}
+
+	d.record(key, Event{Type: EventAppended, Rows: rows})
+}
+
+// record will add an event to the history of a key, persisting it when PersistHistory is set
+func (d *DB[T]) record(key string, e Event) {
+	if d.o.HistorySize == 0 {
+		return
+	}
+
+	if e.Time.IsZero() {
+		e.Time = time.Now()
+	}
+
+	d.hmux.Lock()
+	defer d.hmux.Unlock()
+	d.addEvent(key, e)
+	if !d.o.PersistHistory {
+		return
+	}
+
+	if err := d.persistEvent(historyEvent{Key: key, Event: e}); err != nil {
+		d.o.Logger.Printf("csvdb.DB[%s].record(): error persisting event for <%s>: %v\n", d.o.Name, key, err)
+	}
+}
+
+// addEvent must be called while the history lock is held
+func (d *DB[T]) addEvent(key string, e Event) {
+	events := append(d.history[key], e)
+	if over := len(events) - d.o.HistorySize; over > 0 {
+		events = append(events[:0:0], events[over:]...)
+	}
+
+	d.history[key] = events
+}
+
+// persistEvent must be called while the history lock is held
+func (d *DB[T]) persistEvent(e historyEvent) (err error) {
+	var bs []byte
+	if bs, err = json.Marshal(e); err != nil {
+		return
+	}
+
+	var f file
+	if f, err = d.fs.OpenFile(d.getHistoryPath(), os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644); err != nil {
+		return
+	}
+	defer f.Close()
+
+	_, err = f.Write(append(bs, '\n'))
+	return
+}
+
+// loadHistory will load the persisted history, trimming the persisted file to the events which are kept
+func (d *DB[T]) loadHistory() (err error) {
+	d.history = make(map[string][]Event)
+	if d.o.HistorySize == 0 || !d.o.PersistHistory {
+		return
+	}
+
+	var f file
+	switch f, err = d.fs.Open(d.getHistoryPath()); {
+	case os.IsNotExist(err):
+		return nil
+	case err != nil:
+		return
+	}
+
+	var total int
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var e historyEvent
+		if err = json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			f.Close()
+			return fmt.Errorf("error parsing history: %v", err)
+		}
+
+		d.addEvent(e.Key, e.Event)
+		total++
+	}
+
+	f.Close()
+	if err = scanner.Err(); err != nil {
+		return
+	}
+
+	var kept int
+	for _, events := range d.history {
+		kept += len(events)
+	}
+
+	if kept == total {
+		return
+	}
+
+	return d.writeHistory()
+}
+
+// writeHistory will replace the persisted history with the events which are kept
+func (d *DB[T]) writeHistory() (err error) {
+	var tmp file
+	if tmp, err = createTemp(d.fs, d.o.TempDir, d.getHistoryPath()); err != nil {
+		return
+	}
+
+	w := bufio.NewWriter(tmp)
+	enc := json.NewEncoder(w)
+	for key, events := range d.history {
+		for _, e := range events {
+			if err == nil {
+				err = enc.Encode(historyEvent{Key: key, Event: e})
+			}
+		}
+	}
+
+	if err == nil {
+		err = w.Flush()
+	}
+
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+
+	if err != nil {
+		d.fs.Remove(tmp.Name())
+		return
+	}
+
+	return d.fs.Rename(tmp.Name(), d.getHistoryPath())
+}
+
+func (d *DB[T]) getHistoryPath() string {
+	return path.Join(d.getFullPath(), historyFilename)
+}
+
+// isNewFile will return whether a file is missing or empty, only checked when history is enabled
+func (d *DB[T]) isNewFile(filename string) bool {
+	if d.o.HistorySize == 0 {
+		return false
+	}
+
+	info, err := d.fs.Stat(filename)
+	return err != nil || info.Size() == 0
+}
@@ -0,0 +1,170 @@
+package csvdb
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDB_History(t *testing.T) {
+	type testcase struct {
+		name        string
+		historySize int
+		persist     bool
+		ops         func(d *DB[testentry]) error
+
+		wantTypes []EventType
+		wantRows  []int
+	}
+
+	appendTwice := func(d *DB[testentry]) (err error) {
+		if err = d.Append("a", testentry{Foo: "1", Bar: "1b"}); err != nil {
+			return
+		}
+
+		return d.AppendRaw("a", strings.NewReader("2,2b\n3,3b\n"))
+	}
+
+	tests := []testcase{
+		{
+			name: "disabled",
+			ops:  appendTwice,
+		},
+		{
+			name:        "appended",
+			historySize: 10,
+			ops:         appendTwice,
+			wantTypes:   []EventType{EventCreated, EventAppended, EventAppended},
+			wantRows:    []int{0, 1, 2},
+		},
+		{
+			name:        "bounded",
+			historySize: 2,
+			ops:         appendTwice,
+			wantTypes:   []EventType{EventAppended, EventAppended},
+			wantRows:    []int{1, 2},
+		},
+		{
+			name:        "lifecycle",
+			historySize: 10,
+			ops: func(d *DB[testentry]) (err error) {
+				if err = d.Append("a", testentry{Foo: "1", Bar: "1b"}); err != nil {
+					return
+				}
+
+				if err = d.export(context.Background(), "foo.a.csv"); err != nil {
+					return
+				}
+
+				if err = d.Quarantine("a"); err != nil {
+					return
+				}
+
+				if err = d.RestoreQuarantined("a"); err != nil {
+					return
+				}
+
+				return d.Delete("a")
+			},
+			wantTypes: []EventType{EventCreated, EventAppended, EventExported, EventQuarantined, EventDeleted},
+			wantRows:  []int{0, 1, 0, 0, 0},
+		},
+		{
+			name:        "purged",
+			historySize: 10,
+			ops: func(d *DB[testentry]) (err error) {
+				if err = d.Append("a", testentry{Foo: "1", Bar: "1b"}); err != nil {
+					return
+				}
+
+				time.Sleep(10 * time.Millisecond)
+				return d.purge("")
+			},
+			wantTypes: []EventType{EventCreated, EventAppended, EventPurged},
+			wantRows:  []int{0, 1, 0},
+		},
+		{
+			name:        "persisted",
+			historySize: 2,
+			persist:     true,
+			ops:         appendTwice,
+			wantTypes:   []EventType{EventAppended, EventAppended},
+			wantRows:    []int{1, 2},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var opts Options
+			opts.Dir = fmt.Sprintf("test_%d", time.Now().UnixNano())
+			opts.Name = "foo"
+			opts.FileTTL = time.Millisecond
+			opts.HistorySize = tt.historySize
+			opts.PersistHistory = tt.persist
+			defer os.RemoveAll(opts.Dir)
+
+			d, err := makeDB[testentry](opts, &mockBackend{})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if err = tt.ops(&d); err != nil {
+				t.Fatal(err)
+			}
+
+			check := func(events []Event) {
+				var (
+					types []EventType
+					rows  []int
+				)
+
+				for _, e := range events {
+					types = append(types, e.Type)
+					rows = append(rows, e.Rows)
+				}
+
+				if !reflect.DeepEqual(types, tt.wantTypes) || !reflect.DeepEqual(rows, tt.wantRows) {
+					t.Fatalf("DB.History() = %v %v, want %v %v", types, rows, tt.wantTypes, tt.wantRows)
+				}
+			}
+
+			check(d.History("a"))
+			if !tt.persist {
+				return
+			}
+
+			if d, err = makeDB[testentry](opts, &mockBackend{}); err != nil {
+				t.Fatal(err)
+			}
+
+			check(d.History("a"))
+		})
+	}
+}
+
+func TestEventType_UnmarshalText(t *testing.T) {
+	for _, e := range []EventType{EventCreated, EventAppended, EventExported, EventPurged, EventQuarantined, EventDeleted} {
+		text, err := e.MarshalText()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var got EventType
+		if err = got.UnmarshalText(text); err != nil {
+			t.Fatal(err)
+		}
+
+		if got != e {
+			t.Errorf("EventType.UnmarshalText(%s) = %v, want %v", text, got, e)
+		}
+	}
+
+	var e EventType
+	if err := e.UnmarshalText([]byte("unknown")); err == nil {
+		t.Error("EventType.UnmarshalText() expected error for unknown type")
+	}
+}
@@ -0,0 +1,85 @@
+package csvdb
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+)
+
+// ImportFile will merge an external CSV file into the file of a key. When hasHeader is set,
+// the columns of the external file are matched to the header of the key by name. Columns
+// missing from the external file are left empty and unknown columns return ErrColumnNotFound.
+// When hasHeader is not set, rows must match the columns of the key's header as-is.
+// Should any row fail, the file of the key is restored to its original state.
+func (d *DB[T]) ImportFile(key, filename string, hasHeader bool) (err error) {
+	return d.ImportFileContext(context.Background(), key, filename, hasHeader)
+}
+
+// ImportFileContext is the context-aware variant of ImportFile
+func (d *DB[T]) ImportFileContext(ctx context.Context, key, filename string, hasHeader bool) (err error) {
+	var f *os.File
+	if f, err = os.Open(filename); err != nil {
+		return
+	}
+	defer f.Close()
+
+	return d.appendRows(ctx, key, func(header []string, write func([]string) error) (rows int, err error) {
+		r := d.decodeInput(newContextReader(ctx, f))
+		if !hasHeader {
+			return appendRawRows(write, r, header, d.dialect)
+		}
+
+		return importRows(write, r, header, d.dialect)
+	})
+}
+
+func importRows(write func([]string) error, r io.Reader, header []string, dialect csvDialect) (rows int, err error) {
+	cr := newCSVReader(r, dialect)
+	var srcHeader []string
+	if srcHeader, err = cr.Read(); err == io.EOF {
+		return 0, nil
+	} else if err != nil {
+		return
+	}
+
+	// Map the index of each source column to its index within the header
+	mapping := make([]int, len(srcHeader))
+	for i, column := range srcHeader {
+		if mapping[i] = indexOf(header, column); mapping[i] == -1 {
+			err = fmt.Errorf("%w <%s>", ErrColumnNotFound, column)
+			return
+		}
+	}
+
+	values := make([]string, len(header))
+	var src []string
+	for i := 0; ; i++ {
+		if src, err = cr.Read(); err == io.EOF {
+			return rows, nil
+		} else if err != nil {
+			err = fmt.Errorf("error reading row #%d: %v", i, err)
+			return
+		}
+
+		for j := range values {
+			values[j] = ""
+		}
+
+		if len(src) > len(mapping) {
+			// Rows may be ragged when CSV.FieldsPerRecord is negative, missing values are left empty
+			err = fmt.Errorf("%w: row #%d has %d values, expected %d", ErrInvalidColumnCount, i, len(src), len(mapping))
+			return
+		}
+
+		for j, value := range src {
+			values[mapping[j]] = value
+		}
+
+		if err = write(values); err != nil {
+			return
+		}
+
+		rows++
+	}
+}
@@ -0,0 +1,101 @@
+package csvdb
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestDB_ImportFile(t *testing.T) {
+	type args struct {
+		key       string
+		input     string
+		hasHeader bool
+	}
+
+	type testcase struct {
+		name    string
+		args    args
+		wantW   string
+		wantErr bool
+	}
+
+	tests := []testcase{
+		{
+			name: "reordered header",
+			args: args{
+				key:       "foo",
+				input:     "bar,foo\n2b,2\n3b,3\n",
+				hasHeader: true,
+			},
+			wantW: "foo,bar\n1,1b\n2,2b\n3,3b\n",
+		},
+		{
+			name: "missing column",
+			args: args{
+				key:       "foo",
+				input:     "foo\n2\n",
+				hasHeader: true,
+			},
+			wantW: "foo,bar\n1,1b\n2,\n",
+		},
+		{
+			name: "unknown column",
+			args: args{
+				key:       "foo",
+				input:     "foo,baz\n2,2z\n",
+				hasHeader: true,
+			},
+			wantW:   "foo,bar\n1,1b\n",
+			wantErr: true,
+		},
+		{
+			name: "no header",
+			args: args{
+				key:   "bar",
+				input: "2,2b\n",
+			},
+			wantW: "foo,bar\n2,2b\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var opts Options
+			opts.Dir = fmt.Sprintf("test_%d", time.Now().UnixNano())
+			opts.Name = "foo"
+			d, err := makeDB[testentry](opts, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer os.RemoveAll(d.o.Dir)
+
+			if err = d.Append("foo", testentry{Foo: "1", Bar: "1b"}); err != nil {
+				t.Fatal(err)
+			}
+
+			filename := filepath.Join(t.TempDir(), "input.csv")
+			if err = os.WriteFile(filename, []byte(tt.args.input), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			err = d.ImportFile(tt.args.key, filename, tt.args.hasHeader)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("DB.ImportFile() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+
+			w := &bytes.Buffer{}
+			if err = d.Get(w, tt.args.key); err != nil {
+				t.Fatal(err)
+			}
+
+			if gotW := w.String(); gotW != tt.wantW {
+				t.Errorf("DB.ImportFile() = %v, want %v", gotW, tt.wantW)
+			}
+		})
+	}
+}
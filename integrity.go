@@ -0,0 +1,110 @@
+package csvdb
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+)
+
+const (
+	// IntegrityCheckOff will skip the integrity check on startup
+	IntegrityCheckOff IntegrityCheck = iota
+	// IntegrityCheckReport will log and record any invalid files
+	IntegrityCheckReport
+	// IntegrityCheckQuarantine will log, record and quarantine any invalid files
+	IntegrityCheckQuarantine
+	// IntegrityCheckStrict will cause New to return an error if any invalid files are found
+	IntegrityCheckStrict
+)
+
+var (
+	// ErrIntegrityCheckFailed is returned by New when invalid files are found while using IntegrityCheckStrict
+	ErrIntegrityCheckFailed = errors.New("integrity check failed")
+	// ErrInvalidIntegrityCheck is returned when Options.IntegrityCheck is unknown
+	ErrInvalidIntegrityCheck = errors.New("invalid integrityCheck, unknown value")
+)
+
+// IntegrityCheck represents the strictness of the startup integrity check
+type IntegrityCheck uint8
+
+// IntegrityIssue represents an invalid file discovered by the startup integrity check
+type IntegrityIssue struct {
+	Key string
+	Err error
+}
+
+func (i IntegrityIssue) Error() string {
+	return fmt.Sprintf("<%s>: %v", i.Key, i.Err)
+}
+
+// IntegrityIssues will return the issues discovered by the startup integrity check
+func (d *DB[T]) IntegrityIssues() (issues []IntegrityIssue) {
+	d.mux.Lock()
+	defer d.mux.Unlock()
+	return append(issues, d.integrityIssues...)
+}
+
+func (d *DB[T]) checkIntegrity() (err error) {
+	if d.o.IntegrityCheck == IntegrityCheckOff {
+		return
+	}
+
+	if err = d.forEach(func(filename string, info os.FileInfo) (err error) {
+		if verr := d.verifyFile(d.getPath(filename)); verr != nil {
+			issue := IntegrityIssue{Key: d.getKey(filename), Err: verr}
+			d.o.Logger.Printf("csvdb.DB[%s].checkIntegrity(): invalid file %v\n", d.o.Name, issue)
+			d.integrityIssues = append(d.integrityIssues, issue)
+		}
+
+		return
+	}); err != nil {
+		return
+	}
+
+	switch d.o.IntegrityCheck {
+	case IntegrityCheckQuarantine:
+		for _, issue := range d.integrityIssues {
+			if err = d.quarantine(issue.Key); err != nil {
+				return fmt.Errorf("error quarantining <%s>: %v", issue.Key, err)
+			}
+		}
+	case IntegrityCheckStrict:
+		if len(d.integrityIssues) == 0 {
+			return
+		}
+
+		errs := []error{ErrIntegrityCheckFailed}
+		for _, issue := range d.integrityIssues {
+			errs = append(errs, issue)
+		}
+
+		return errors.Join(errs...)
+	}
+
+	return
+}
+
+func (d *DB[T]) verifyFile(filename string) (err error) {
+	var f file
+	if f, err = d.fs.Open(filename); err != nil {
+		return
+	}
+	defer f.Close()
+
+	r := newCSVReader(f, d.dialect)
+	r.ReuseRecord = true
+	if _, err = r.Read(); err == io.EOF {
+		return errors.New("missing header")
+	} else if err != nil {
+		return
+	}
+
+	for {
+		if _, err = r.Read(); err == io.EOF {
+			return nil
+		} else if err != nil {
+			return
+		}
+	}
+}
@@ -0,0 +1,95 @@
+package csvdb
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestDB_checkIntegrity(t *testing.T) {
+	type testcase struct {
+		name            string
+		integrityCheck  IntegrityCheck
+		wantErr         error
+		wantIssues      []string
+		wantQuarantined []string
+	}
+
+	tests := []testcase{
+		{
+			name:            "off",
+			integrityCheck:  IntegrityCheckOff,
+			wantQuarantined: []string{},
+		},
+		{
+			name:            "report",
+			integrityCheck:  IntegrityCheckReport,
+			wantIssues:      []string{"empty", "ragged"},
+			wantQuarantined: []string{},
+		},
+		{
+			name:            "quarantine",
+			integrityCheck:  IntegrityCheckQuarantine,
+			wantIssues:      []string{"empty", "ragged"},
+			wantQuarantined: []string{"empty", "ragged"},
+		},
+		{
+			name:           "strict",
+			integrityCheck: IntegrityCheckStrict,
+			wantErr:        ErrIntegrityCheckFailed,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var opts Options
+			opts.Dir = fmt.Sprintf("test_%d", time.Now().UnixNano())
+			opts.Name = "foo"
+			defer os.RemoveAll(opts.Dir)
+
+			dir := path.Join(opts.Dir, opts.Name)
+			if err := os.MkdirAll(dir, 0744); err != nil {
+				t.Fatal(err)
+			}
+
+			files := map[string]string{
+				"foo.valid.csv":  "foo,bar\n1,1b\n",
+				"foo.empty.csv":  "",
+				"foo.ragged.csv": "foo,bar\n1,1b,1c\n",
+			}
+
+			for name, contents := range files {
+				if err := os.WriteFile(path.Join(dir, name), []byte(contents), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			opts.IntegrityCheck = tt.integrityCheck
+			d, err := makeDB[testentry](opts, nil)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("makeDB() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if err != nil {
+				return
+			}
+
+			var issues []string
+			for _, issue := range d.IntegrityIssues() {
+				issues = append(issues, issue.Key)
+			}
+
+			if !reflect.DeepEqual(issues, tt.wantIssues) {
+				t.Errorf("DB.IntegrityIssues() = %v, want %v", issues, tt.wantIssues)
+			}
+
+			if got := d.Quarantined(); !reflect.DeepEqual(got, tt.wantQuarantined) {
+				t.Errorf("DB.Quarantined() = %v, want %v", got, tt.wantQuarantined)
+			}
+		})
+	}
+}
@@ -0,0 +1,91 @@
+package csvdb
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"unicode"
+)
+
+// AppendJSON will read a JSON array or a newline-delimited JSON stream from the
+// provided reader and append the decoded entries to the given key. Fields are
+// mapped to the Entry using the standard encoding/json struct tags of T.
+func (d *DB[T]) AppendJSON(key string, r io.Reader) (err error) {
+	return d.AppendJSONContext(context.Background(), key, r)
+}
+
+// AppendJSONContext is the context-aware variant of AppendJSON
+func (d *DB[T]) AppendJSONContext(ctx context.Context, key string, r io.Reader) (err error) {
+	var es []T
+	if es, err = decodeJSONEntries[T](newContextReader(ctx, r)); err != nil {
+		return
+	}
+
+	return d.AppendContext(ctx, key, es...)
+}
+
+func decodeJSONEntries[T Entry](r io.Reader) (es []T, err error) {
+	br := bufio.NewReader(r)
+	var first rune
+	if first, err = peekNonSpace(br); err == io.EOF {
+		err = nil
+		return
+	} else if err != nil {
+		return
+	}
+
+	dec := json.NewDecoder(br)
+	if first != '[' {
+		return decodeJSONStream[T](dec)
+	}
+
+	// Read past opening bracket
+	if _, err = dec.Token(); err != nil {
+		return
+	}
+
+	for dec.More() {
+		var e T
+		if err = dec.Decode(&e); err != nil {
+			err = fmt.Errorf("error decoding entry #%d: %v", len(es), err)
+			return
+		}
+
+		es = append(es, e)
+	}
+
+	// Read past closing bracket
+	_, err = dec.Token()
+	return
+}
+
+func decodeJSONStream[T Entry](dec *json.Decoder) (es []T, err error) {
+	for {
+		var e T
+		switch err = dec.Decode(&e); err {
+		case nil:
+			es = append(es, e)
+		case io.EOF:
+			err = nil
+			return
+		default:
+			err = fmt.Errorf("error decoding entry #%d: %v", len(es), err)
+			return
+		}
+	}
+}
+
+func peekNonSpace(br *bufio.Reader) (r rune, err error) {
+	for {
+		if r, _, err = br.ReadRune(); err != nil {
+			return
+		}
+
+		if !unicode.IsSpace(r) {
+			err = br.UnreadRune()
+			return
+		}
+	}
+}
@@ -0,0 +1,83 @@
+package csvdb
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDB_AppendJSON(t *testing.T) {
+	type args struct {
+		key   string
+		input string
+	}
+
+	type testcase struct {
+		name    string
+		args    args
+		wantW   string
+		wantErr bool
+	}
+
+	tests := []testcase{
+		{
+			name: "array",
+			args: args{
+				key:   "foo",
+				input: `[{"Foo":"1","Bar":"1b"}, {"Foo":"2","Bar":"2b"}]`,
+			},
+			wantW: "foo,bar\n1,1b\n2,2b\n",
+		},
+		{
+			name: "ndjson",
+			args: args{
+				key:   "foo",
+				input: "{\"Foo\":\"1\",\"Bar\":\"1b\"}\n{\"Foo\":\"2\",\"Bar\":\"2b\"}\n",
+			},
+			wantW: "foo,bar\n1,1b\n2,2b\n",
+		},
+		{
+			name: "invalid",
+			args: args{
+				key:   "foo",
+				input: `[{"Foo":1}]`,
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var opts Options
+			opts.Dir = fmt.Sprintf("test_%d", time.Now().UnixNano())
+			opts.Name = "foo"
+			d, err := makeDB[testentry](opts, &mockBackend{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer os.RemoveAll(d.o.Dir)
+
+			err = d.AppendJSON(tt.args.key, strings.NewReader(tt.args.input))
+			if (err != nil) != tt.wantErr {
+				t.Errorf("DB.AppendJSON() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+
+			if tt.wantErr {
+				return
+			}
+
+			w := &bytes.Buffer{}
+			if err = d.Get(w, tt.args.key); err != nil {
+				t.Fatal(err)
+			}
+
+			if gotW := w.String(); gotW != tt.wantW {
+				t.Errorf("DB.AppendJSON() = %v, want %v", gotW, tt.wantW)
+			}
+		})
+	}
+}
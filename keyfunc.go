@@ -0,0 +1,38 @@
+package csvdb
+
+import (
+	"context"
+	"errors"
+)
+
+// ErrKeyFuncNotSet is returned when entries are appended without a key while Options.KeyFunc is unset
+var ErrKeyFuncNotSet = errors.New("cannot derive keys, keyFunc is not set")
+
+// KeyFunc will return the key an entry is appended to, allowing the routing of entries
+// (e.g. by tenant and date) to be defined once for the DB
+type KeyFunc func(e Entry) (key string)
+
+// AppendAuto will append entries to the keys derived from them by Options.KeyFunc.
+// Entries sharing a key are appended in the order they are provided.
+func (d *DB[T]) AppendAuto(es ...T) (err error) {
+	return d.AppendAutoContext(context.Background(), es...)
+}
+
+// AppendAutoContext is the context-aware variant of AppendAuto
+func (d *DB[T]) AppendAutoContext(ctx context.Context, es ...T) (err error) {
+	if d.o.KeyFunc == nil {
+		return ErrKeyFuncNotSet
+	}
+
+	if len(es) == 0 {
+		return
+	}
+
+	m := make(map[string][]T)
+	for _, e := range es {
+		key := d.o.KeyFunc(e)
+		m[key] = append(m[key], e)
+	}
+
+	return d.AppendManyContext(ctx, m)
+}
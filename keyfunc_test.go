@@ -0,0 +1,73 @@
+package csvdb
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestDB_AppendAuto(t *testing.T) {
+	type testcase struct {
+		name    string
+		keyFunc KeyFunc
+		es      []testentry
+		wantW   map[string]string
+		wantErr error
+	}
+
+	tests := []testcase{
+		{
+			name:    "basic",
+			keyFunc: func(e Entry) string { return "k" + e.(testentry).Bar },
+			es: []testentry{
+				{Foo: "1", Bar: "a"},
+				{Foo: "2", Bar: "b"},
+				{Foo: "3", Bar: "a"},
+			},
+			wantW: map[string]string{
+				"ka": "foo,bar\n1,a\n3,a\n",
+				"kb": "foo,bar\n2,b\n",
+			},
+		},
+		{
+			name:    "no entries",
+			keyFunc: func(e Entry) string { return "k" },
+		},
+		{
+			name:    "no key func",
+			es:      []testentry{{Foo: "1", Bar: "a"}},
+			wantErr: ErrKeyFuncNotSet,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var opts Options
+			opts.Dir = fmt.Sprintf("test_%d", time.Now().UnixNano())
+			opts.Name = "foo"
+			opts.KeyFunc = tt.keyFunc
+			d, err := makeDB[testentry](opts, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer os.RemoveAll(d.o.Dir)
+
+			if err = d.AppendAuto(tt.es...); err != tt.wantErr {
+				t.Fatalf("DB.AppendAuto() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			for key, wantW := range tt.wantW {
+				w := &bytes.Buffer{}
+				if err = d.Get(w, key); err != nil {
+					t.Fatal(err)
+				}
+
+				if w.String() != wantW {
+					t.Errorf("DB.Get(%s) = %v, want %v", key, w.String(), wantW)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,114 @@
+package csvdb
+
+import (
+	"context"
+	"hash/fnv"
+	"sort"
+)
+
+// keyLockShards is the number of locks keys are hashed across
+const keyLockShards = 64
+
+// keyLocks is a fixed set of locks which keys are hashed across, allowing operations on
+// keys within different shards to proceed in parallel
+type keyLocks [keyLockShards]timedMutex
+
+// shards will return the sorted and de-duplicated shard indexes of the provided keys,
+// so locks of multiple keys are always acquired in the same order
+func (k *keyLocks) shards(keys []string) (indexes []int) {
+	seen := make(map[int]struct{}, len(keys))
+	for _, key := range keys {
+		h := fnv.New32a()
+		h.Write([]byte(key))
+		i := int(h.Sum32() % keyLockShards)
+		if _, ok := seen[i]; ok {
+			continue
+		}
+
+		seen[i] = struct{}{}
+		indexes = append(indexes, i)
+	}
+
+	sort.Ints(indexes)
+	return
+}
+
+func (k *keyLocks) stats() (s MutexStats) {
+	for i := range k {
+		shard := k[i].stats()
+		s.Acquisitions += shard.Acquisitions
+		s.WaitTime += shard.WaitTime
+		s.HoldTime += shard.HoldTime
+		if shard.MaxWaitTime > s.MaxWaitTime {
+			s.MaxWaitTime = shard.MaxWaitTime
+		}
+
+		if shard.MaxHoldTime > s.MaxHoldTime {
+			s.MaxHoldTime = shard.MaxHoldTime
+		}
+	}
+
+	return
+}
+
+// lockKeys will acquire the lock of the DB shared along with the locks of the provided keys
+// exclusively. Directory-wide operations acquire the lock of the DB exclusively, and wait
+// for all key operations to complete. Keys are locked exclusively to be modified, so
+// ErrReadOnly is returned when ReadOnly is set.
+func (d *DB[T]) lockKeys(ctx context.Context, keys ...string) (unlock func(), err error) {
+	if err = d.checkWritable(); err != nil {
+		return
+	}
+
+	return d.lockKeysWith(ctx, false, keys)
+}
+
+// rlockKey will acquire the lock of a key for reading. Reads in spill mode remove the
+// files they download, so the lock of the key is acquired exclusively.
+func (d *DB[T]) rlockKey(ctx context.Context, key string) (unlock func(), err error) {
+	return d.lockKeysWith(ctx, !d.o.SpillToBackend, []string{key})
+}
+
+// holdKeys will acquire the locks of the provided keys exclusively, without respecting
+// LockTimeout or the closed state of the DB. Used by background jobs which must complete.
+func (d *DB[T]) holdKeys(keys ...string) (unlock func()) {
+	lockers := d.keyLockers(false, keys)
+	for _, mux := range lockers {
+		mux.Lock()
+	}
+
+	return func() { unlockAll(lockers) }
+}
+
+func (d *DB[T]) lockKeysWith(ctx context.Context, read bool, keys []string) (unlock func(), err error) {
+	lockers := d.keyLockers(read, keys)
+	if err = d.lockWith(ctx, lockers...); err != nil {
+		return
+	}
+
+	return func() { unlockAll(lockers) }, nil
+}
+
+// keyLockers will return the locks of the provided keys in the order they must be acquired,
+// starting with the lock of the DB which is always acquired shared
+func (d *DB[T]) keyLockers(read bool, keys []string) (lockers []tryLocker) {
+	shards := d.keyLocks.shards(keys)
+	lockers = make([]tryLocker, 0, len(shards)+1)
+	lockers = append(lockers, d.mux.readLocker())
+	for _, i := range shards {
+		if read {
+			lockers = append(lockers, d.keyLocks[i].readLocker())
+		} else {
+			lockers = append(lockers, &d.keyLocks[i])
+		}
+	}
+
+	return
+}
+
+// unlockAll will release the provided locks in the reverse order they were acquired
+func unlockAll(lockers []tryLocker) {
+	for i := len(lockers) - 1; i >= 0; i-- {
+		lockers[i].Unlock()
+	}
+}
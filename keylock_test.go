@@ -0,0 +1,111 @@
+package csvdb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func Test_keyLocks_shards(t *testing.T) {
+	var k keyLocks
+	a := k.shards([]string{"a"})
+	b := k.shards([]string{"b"})
+	if len(a) != 1 || len(b) != 1 {
+		t.Fatalf("keyLocks.shards() = %v %v, want one shard each", a, b)
+	}
+
+	got := k.shards([]string{"b", "a", "b", "a"})
+	want := []int{a[0], b[0]}
+	if want[0] > want[1] {
+		want = []int{b[0], a[0]}
+	} else if want[0] == want[1] {
+		want = want[:1]
+	}
+
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("keyLocks.shards() = %v, want %v", got, want)
+	}
+}
+
+func TestDB_lockKeys(t *testing.T) {
+	type testcase struct {
+		name string
+		// hold will hold a lock of the DB for the duration of the test case
+		hold func(d *DB[testentry]) (release func())
+		key  string
+
+		wantErr error
+	}
+
+	var k keyLocks
+	// Find a key which does not share a shard with "a"
+	other := "b"
+	for i := 0; k.shards([]string{other})[0] == k.shards([]string{"a"})[0]; i++ {
+		other = fmt.Sprintf("b%d", i)
+	}
+
+	tests := []testcase{
+		{
+			name: "same key",
+			hold: func(d *DB[testentry]) func() {
+				return d.holdKeys("a")
+			},
+			key:     "a",
+			wantErr: ErrBusy,
+		},
+		{
+			name: "different key",
+			hold: func(d *DB[testentry]) func() {
+				return d.holdKeys("a")
+			},
+			key: other,
+		},
+		{
+			name: "directory-wide lock",
+			hold: func(d *DB[testentry]) func() {
+				d.mux.Lock()
+				return d.mux.Unlock
+			},
+			key:     other,
+			wantErr: ErrBusy,
+		},
+		{
+			name: "shared lock",
+			hold: func(d *DB[testentry]) func() {
+				unlock, err := d.rlockKey(context.Background(), "a")
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				return unlock
+			},
+			key:     "a",
+			wantErr: ErrBusy,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var opts Options
+			opts.Dir = fmt.Sprintf("test_%d", time.Now().UnixNano())
+			opts.Name = "foo"
+			opts.LockTimeout = 20 * time.Millisecond
+			d, err := makeDB[testentry](opts, &mockBackend{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer os.RemoveAll(d.o.Dir)
+
+			release := tt.hold(&d)
+			err = d.Append(tt.key, testentry{Foo: "1", Bar: "1b"})
+			release()
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("DB.Append() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,96 @@
+package csvdb
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+const hexDigits = "0123456789ABCDEF"
+
+// escapeKey will escape a key so it can be safely used as part of a filename.
+// Path separators, percent signs, control characters, characters reserved by
+// common filesystems and invalid UTF-8 bytes are percent-encoded. All other
+// characters (including unicode) are left as-is so existing filenames are unaffected.
+func escapeKey(key string) string {
+	if !needsEscape(key) {
+		return key
+	}
+
+	var sb strings.Builder
+	for i := 0; i < len(key); {
+		r, size := utf8.DecodeRuneInString(key[i:])
+		if !shouldEscape(r, size) {
+			sb.WriteString(key[i : i+size])
+			i += size
+			continue
+		}
+
+		for _, b := range []byte(key[i : i+size]) {
+			sb.WriteByte('%')
+			sb.WriteByte(hexDigits[b>>4])
+			sb.WriteByte(hexDigits[b&0x0F])
+		}
+
+		i += size
+	}
+
+	return sb.String()
+}
+
+// unescapeKey will reverse the escaping performed by escapeKey
+func unescapeKey(escaped string) string {
+	if strings.IndexByte(escaped, '%') == -1 {
+		return escaped
+	}
+
+	bs := make([]byte, 0, len(escaped))
+	for i := 0; i < len(escaped); i++ {
+		if escaped[i] == '%' && i+2 < len(escaped) && isHex(escaped[i+1]) && isHex(escaped[i+2]) {
+			bs = append(bs, unhex(escaped[i+1])<<4|unhex(escaped[i+2]))
+			i += 2
+			continue
+		}
+
+		bs = append(bs, escaped[i])
+	}
+
+	return string(bs)
+}
+
+func needsEscape(key string) bool {
+	for i := 0; i < len(key); {
+		r, size := utf8.DecodeRuneInString(key[i:])
+		if shouldEscape(r, size) {
+			return true
+		}
+
+		i += size
+	}
+
+	return false
+}
+
+func shouldEscape(r rune, size int) bool {
+	switch {
+	case r == utf8.RuneError && size <= 1:
+		// Invalid UTF-8 byte
+		return true
+	case r < 0x20 || r == 0x7F:
+		return true
+	}
+
+	switch r {
+	case '%', '/', '\\', '<', '>', ':', '"', '|', '?', '*':
+		return true
+	default:
+		return false
+	}
+}
+
+func isHex(b byte) bool {
+	return strings.IndexByte(hexDigits, b) != -1
+}
+
+func unhex(b byte) byte {
+	return byte(strings.IndexByte(hexDigits, b))
+}
@@ -0,0 +1,62 @@
+package csvdb
+
+import "testing"
+
+func Test_escapeKey(t *testing.T) {
+	type testcase struct {
+		name string
+		key  string
+		want string
+	}
+
+	tests := []testcase{
+		{
+			name: "plain",
+			key:  "key_1",
+			want: "key_1",
+		},
+		{
+			name: "unicode",
+			key:  "ключ-🔑",
+			want: "ключ-🔑",
+		},
+		{
+			name: "path separators",
+			key:  "../a/b\\c",
+			want: "..%2Fa%2Fb%5Cc",
+		},
+		{
+			name: "percent and control",
+			key:  "100%\n",
+			want: "100%25%0A",
+		},
+		{
+			name: "invalid utf8",
+			key:  "a\xffb",
+			want: "a%FFb",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := escapeKey(tt.key); got != tt.want {
+				t.Errorf("escapeKey() = %v, want %v", got, tt.want)
+			}
+
+			if got := unescapeKey(tt.want); got != tt.key {
+				t.Errorf("unescapeKey() = %v, want %v", got, tt.key)
+			}
+		})
+	}
+}
+
+func Fuzz_escapeKey(f *testing.F) {
+	f.Add("key_1")
+	f.Add("a/b%2F\x00")
+	f.Add("ключ")
+	f.Fuzz(func(t *testing.T, key string) {
+		if got := unescapeKey(escapeKey(key)); got != key {
+			t.Fatalf("unescapeKey(escapeKey(%q)) = %q", key, got)
+		}
+	})
+}
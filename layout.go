@@ -0,0 +1,183 @@
+package csvdb
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"os"
+	"path"
+	"path/filepath"
+)
+
+// sidecarExts are the extensions of the files kept alongside the file of a key, which
+// follow it when it is moved between layouts
+var sidecarExts = []string{".exported", holdExt, consumedExt, indexExt, journalExt, checksumExt}
+
+// shardCount is the number of subdirectories files are spread across by ShardedLayout
+const shardCount = 256
+
+// shardOf will return the subdirectory of a file within the sharded layout
+func shardOf(name string) string {
+	h := fnv.New32a()
+	h.Write([]byte(name))
+	return fmt.Sprintf("%02x", h.Sum32()%shardCount)
+}
+
+// isShard will return whether a directory name is a subdirectory of the sharded layout
+func isShard(name string) bool {
+	if len(name) != 2 {
+		return false
+	}
+
+	for _, c := range name {
+		if (c < '0' || c > '9') && (c < 'a' || c > 'f') {
+			return false
+		}
+	}
+
+	return true
+}
+
+// getPath will return the path of a file of the DB, which is within the subdirectory of its
+// shard when ShardedLayout is set
+func (d *DB[T]) getPath(name string) string {
+	if !d.o.ShardedLayout {
+		return path.Join(d.getFullPath(), name)
+	}
+
+	return path.Join(d.getFullPath(), shardOf(name), name)
+}
+
+// makeShards will create the subdirectories of the sharded layout
+func (d *DB[T]) makeShards() (err error) {
+	if !d.o.ShardedLayout {
+		return
+	}
+
+	for i := 0; i < shardCount; i++ {
+		if err = d.fs.MkdirAll(path.Join(d.getFullPath(), fmt.Sprintf("%02x", i)), 0744); err != nil {
+			return
+		}
+	}
+
+	return
+}
+
+// dataDirs will return the directories holding the files of the DB
+func (d *DB[T]) dataDirs() (dirs []string, err error) {
+	if !d.o.ShardedLayout {
+		return []string{d.getFullPath()}, nil
+	}
+
+	return d.shardDirs()
+}
+
+// shardDirs will return the existing subdirectories of the sharded layout
+func (d *DB[T]) shardDirs() (dirs []string, err error) {
+	var entries []os.DirEntry
+	if entries, err = d.fs.ReadDir(d.getFullPath()); err != nil {
+		return
+	}
+
+	for _, entry := range entries {
+		if entry.IsDir() && isShard(entry.Name()) {
+			dirs = append(dirs, path.Join(d.getFullPath(), entry.Name()))
+		}
+	}
+
+	return
+}
+
+// readDataDirs will read the entries of every directory holding the files of the DB
+func (d *DB[T]) readDataDirs(fn func(dir string, entries []os.DirEntry) error) (err error) {
+	var dirs []string
+	if dirs, err = d.dataDirs(); err != nil {
+		return
+	}
+
+	for _, dir := range dirs {
+		var entries []os.DirEntry
+		if entries, err = d.fs.ReadDir(dir); err != nil {
+			return
+		}
+
+		if err = fn(dir, entries); err != nil {
+			return
+		}
+	}
+
+	return
+}
+
+// MigrateLayout will move the files of the DB, along with their markers, into the layout
+// selected by Options.ShardedLayout. It must be called once after ShardedLayout has been
+// changed for a directory holding files, as files outside of the selected layout are not
+// visible to the DB. Returns the number of files which were moved.
+func (d *DB[T]) MigrateLayout(ctx context.Context) (moved int, err error) {
+	if err = d.checkWritable(); err != nil {
+		return
+	}
+
+	// Prevent exports from reading files while they are being moved
+	if err = lockContext(ctx, &d.emux); err != nil {
+		return
+	}
+	defer d.emux.Unlock()
+
+	if err = d.lock(ctx); err != nil {
+		return
+	}
+	defer d.mux.Unlock()
+
+	if err = d.makeShards(); err != nil {
+		return
+	}
+
+	var dirs []string
+	if dirs, err = d.shardDirs(); err != nil {
+		return
+	}
+
+	dirs = append(dirs, d.getFullPath())
+	for _, dir := range dirs {
+		var entries []os.DirEntry
+		if entries, err = d.fs.ReadDir(dir); err != nil {
+			return
+		}
+
+		for _, entry := range entries {
+			name := entry.Name()
+			if entry.IsDir() || filepath.Ext(name) != ".csv" {
+				continue
+			}
+
+			dst := d.getPath(name)
+			if path.Join(dir, name) == dst {
+				continue
+			}
+
+			if err = d.move(path.Join(dir, name), dst); err != nil {
+				return
+			}
+
+			moved++
+		}
+	}
+
+	return
+}
+
+// move will move a file along with its markers
+func (d *DB[T]) move(src, dst string) (err error) {
+	if err = d.fs.Rename(src, dst); err != nil {
+		return
+	}
+
+	for _, ext := range sidecarExts {
+		if err = d.fs.Rename(src+ext, dst+ext); err != nil && !os.IsNotExist(err) {
+			return
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,133 @@
+package csvdb
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestDB_ShardedLayout(t *testing.T) {
+	dir := fmt.Sprintf("test_%d", time.Now().UnixNano())
+	defer os.RemoveAll(dir)
+
+	var opts Options
+	opts.Dir = dir
+	opts.Name = "foo"
+	opts.ShardedLayout = true
+	d, err := makeDB[testentry](opts, &mockBackend{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	keys := []string{"a", "b", "c/d"}
+	for _, key := range keys {
+		if err = d.Append(key, testentry{Foo: key, Bar: key}); err != nil {
+			t.Fatal(err)
+		}
+
+		name, filename := d.getFilename(key)
+		if want := filepath.Join(dir, "foo", shardOf(name), name); filepath.Clean(filename) != want {
+			t.Errorf("DB.getFilename(%s) = %v, want %v", key, filename, want)
+		}
+
+		if _, err = os.Stat(filename); err != nil {
+			t.Error(err)
+		}
+	}
+
+	got, _, err := d.ListKeys("", "", 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(got, keys) {
+		t.Errorf("DB.ListKeys() = %v, want %v", got, keys)
+	}
+
+	if err = d.SetHold("b"); err != nil {
+		t.Fatal(err)
+	}
+
+	holds, err := d.Holds()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want := []string{"b"}; !reflect.DeepEqual(holds, want) {
+		t.Errorf("DB.Holds() = %v, want %v", holds, want)
+	}
+
+	exportable, err := d.getExportable("")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(exportable) != len(keys) {
+		t.Errorf("DB.getExportable() = %v, want %d files", exportable, len(keys))
+	}
+}
+
+func TestDB_MigrateLayout(t *testing.T) {
+	dir := fmt.Sprintf("test_%d", time.Now().UnixNano())
+	defer os.RemoveAll(dir)
+
+	var opts Options
+	opts.Dir = dir
+	opts.Name = "foo"
+	flat, err := makeDB[testentry](opts, &mockBackend{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	keys := []string{"a", "b", "c"}
+	for _, key := range keys {
+		if err = flat.Append(key, testentry{Foo: key, Bar: key}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err = flat.SetHold("a"); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, sharded := range []bool{true, false} {
+		opts.ShardedLayout = sharded
+		d, err := makeDB[testentry](opts, &mockBackend{})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		moved, err := d.MigrateLayout(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if moved != len(keys) {
+			t.Errorf("DB.MigrateLayout() sharded = %v, moved = %d, want %d", sharded, moved, len(keys))
+		}
+
+		for _, key := range keys {
+			w := &bytes.Buffer{}
+			if err = d.Get(w, key); err != nil {
+				t.Fatal(err)
+			}
+
+			if want := fmt.Sprintf("foo,bar\n%s,%s\n", key, key); w.String() != want {
+				t.Errorf("DB.Get(%s) sharded = %v, got %q, want %q", key, sharded, w.String(), want)
+			}
+		}
+
+		if !d.isHeld("a") {
+			t.Errorf("hold was not moved along with the key, sharded = %v", sharded)
+		}
+
+		if moved, err = d.MigrateLayout(context.Background()); err != nil || moved != 0 {
+			t.Errorf("DB.MigrateLayout() repeated moved = %d, err = %v, want 0 and nil", moved, err)
+		}
+	}
+}
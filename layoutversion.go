@@ -0,0 +1,191 @@
+package csvdb
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path"
+	"strings"
+)
+
+const (
+	// LayoutVersion1 is the layout of directories written before keys were escaped within
+	// filenames. Keys are used as-is within filenames, and deleted keys may have left their
+	// export markers behind.
+	LayoutVersion1 LayoutVersion = iota + 1
+	// LayoutVersion2 is the current layout. Keys are escaped within filenames, see the
+	// round-trip guarantees of the README.
+	LayoutVersion2
+)
+
+// latestLayoutVersion is the layout files are written in when Options.LayoutVersion is unset
+const latestLayoutVersion = LayoutVersion2
+
+// ErrInvalidLayoutVersion is returned when Options.LayoutVersion is unknown
+var ErrInvalidLayoutVersion = errors.New("invalid layoutVersion, unknown value")
+
+// LayoutVersion is the version of the naming and marker conventions of the files of a DB.
+// Directories written by older versions of the package are opened in their layout by setting
+// Options.LayoutVersion, and converted in place by MigrateLayoutVersion.
+type LayoutVersion uint8
+
+// escape will return the key as it is named within filenames of the layout
+func (v LayoutVersion) escape(key string) string {
+	if v == LayoutVersion1 {
+		return key
+	}
+
+	return escapeKey(key)
+}
+
+// unescape will return the key named within filenames of the layout
+func (v LayoutVersion) unescape(escaped string) string {
+	if v == LayoutVersion1 {
+		return escaped
+	}
+
+	return unescapeKey(escaped)
+}
+
+// MigrationReport is the report of the changes made, or which would be made, by MigrateLayoutVersion
+type MigrationReport struct {
+	// From is the layout the files were migrated from
+	From LayoutVersion `json:"from"`
+	// To is the layout the files were migrated to
+	To LayoutVersion `json:"to"`
+	// DryRun is set when the changes were only reported
+	DryRun bool `json:"dryRun"`
+	// Renamed are the new names of the renamed files, by their previous name
+	Renamed map[string]string `json:"renamed"`
+	// Removed are the orphaned markers which were removed
+	Removed []string `json:"removed"`
+	// Conflicts are the files which could not be renamed, as their new name is already taken
+	Conflicts []string `json:"conflicts"`
+}
+
+// MigrateLayoutVersion will convert the files of the DB from the layout of Options.LayoutVersion
+// to the latest layout in place, renaming files (along with their markers) to the latest naming
+// and removing orphaned markers. Renamed files are exported again under their new name. When
+// dryRun is set, the changes are reported without being made. Once migrated, the DB must be
+// reopened with Options.LayoutVersion unset.
+func (d *DB[T]) MigrateLayoutVersion(ctx context.Context, dryRun bool) (r MigrationReport, err error) {
+	r.From, r.To, r.DryRun = d.o.LayoutVersion, latestLayoutVersion, dryRun
+	if r.From == 0 {
+		r.From = latestLayoutVersion
+	}
+
+	r.Renamed = make(map[string]string)
+	if !dryRun {
+		if err = d.checkWritable(); err != nil {
+			return
+		}
+	}
+
+	// Prevent exports from reading files while they are being moved
+	if err = lockContext(ctx, &d.emux); err != nil {
+		return
+	}
+	defer d.emux.Unlock()
+
+	if err = d.lock(ctx); err != nil {
+		return
+	}
+	defer d.mux.Unlock()
+
+	err = d.readDataDirs(func(dir string, entries []os.DirEntry) (err error) {
+		for _, entry := range entries {
+			name := entry.Name()
+			switch {
+			case entry.IsDir():
+			case strings.HasSuffix(name, ".csv"):
+				err = d.migrateFile(&r, dir, name)
+			case strings.HasSuffix(name, ".csv.exported"):
+				err = d.removeOrphan(&r, dir, name)
+			}
+
+			if err != nil {
+				return
+			}
+		}
+
+		return
+	})
+
+	return
+}
+
+// migrateFile will rename a file of the DB to its name within the latest layout
+func (d *DB[T]) migrateFile(r *MigrationReport, dir, name string) (err error) {
+	if _, ok := r.Renamed[name]; ok {
+		// The file was renamed ahead of its turn
+		return
+	}
+
+	escaped, ok := strings.CutPrefix(name, d.o.Name+".")
+	if !ok || r.From == r.To {
+		return
+	}
+
+	key := r.From.unescape(strings.TrimSuffix(escaped, ".csv"))
+	newName := d.o.Name + "." + r.To.escape(key) + ".csv"
+	if newName == name {
+		return
+	}
+
+	src, dst := path.Join(dir, name), d.getPath(newName)
+	if _, err = d.fs.Stat(dst); err == nil {
+		// The new name may be the name of another file within the previous layout, which is
+		// renamed first to free it
+		if err = d.migrateFile(r, path.Dir(dst), newName); err != nil {
+			return
+		}
+
+		if _, ok := r.Renamed[newName]; !ok {
+			r.Conflicts = append(r.Conflicts, name)
+			return
+		}
+	} else if !os.IsNotExist(err) {
+		return
+	}
+
+	r.Renamed[name] = newName
+	if r.DryRun {
+		return nil
+	}
+
+	if err = d.move(src, dst); err != nil {
+		return
+	}
+
+	// The backend holds the file under its previous name, it is exported again under its new name
+	if err = d.fs.Remove(dst + ".exported"); os.IsNotExist(err) {
+		err = nil
+	}
+
+	return
+}
+
+// removeOrphan will remove an export marker whose file no longer exists
+func (d *DB[T]) removeOrphan(r *MigrationReport, dir, name string) (err error) {
+	if _, ok := r.Renamed[strings.TrimSuffix(name, ".exported")]; ok {
+		// The marker was moved along with its file
+		return
+	}
+
+	if _, err = d.fs.Stat(path.Join(dir, strings.TrimSuffix(name, ".exported"))); err == nil {
+		return
+	} else if !os.IsNotExist(err) {
+		return
+	}
+
+	r.Removed = append(r.Removed, name)
+	if r.DryRun {
+		return nil
+	}
+
+	if err = d.fs.Remove(path.Join(dir, name)); os.IsNotExist(err) {
+		err = nil
+	}
+
+	return
+}
@@ -0,0 +1,212 @@
+package csvdb
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestDB_MigrateLayoutVersion(t *testing.T) {
+	dir := fmt.Sprintf("test_%d", time.Now().UnixNano())
+	defer os.RemoveAll(dir)
+
+	fullPath := filepath.Join(dir, "foo")
+	if err := os.MkdirAll(fullPath, 0744); err != nil {
+		t.Fatal(err)
+	}
+
+	// Files written by LayoutVersion1, where keys were not escaped
+	files := map[string]string{
+		"foo.a:b.csv":           "foo,bar\n1,1b\n",
+		"foo.a:b.csv.exported":  "",
+		"foo.plain.csv":         "foo,bar\n2,2b\n",
+		"foo.gone.csv.exported": "",
+	}
+
+	for name, contents := range files {
+		if err := os.WriteFile(filepath.Join(fullPath, name), []byte(contents), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	assertGet := func(d *DB[testentry], key, want string) {
+		t.Helper()
+		w := &bytes.Buffer{}
+		if err := d.Get(w, key); err != nil {
+			t.Fatalf("DB.Get(%s) error = %v", key, err)
+		}
+
+		if w.String() != want {
+			t.Errorf("DB.Get(%s) = %q, want %q", key, w.String(), want)
+		}
+	}
+
+	var opts Options
+	opts.Dir = dir
+	opts.Name = "foo"
+	opts.LayoutVersion = LayoutVersion1
+	d, err := makeDB[testentry](opts, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assertGet(&d, "a:b", files["foo.a:b.csv"])
+
+	want := MigrationReport{
+		From:    LayoutVersion1,
+		To:      LayoutVersion2,
+		DryRun:  true,
+		Renamed: map[string]string{"foo.a:b.csv": "foo.a%3Ab.csv"},
+		Removed: []string{"foo.gone.csv.exported"},
+	}
+
+	got, err := d.MigrateLayoutVersion(context.Background(), true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("DB.MigrateLayoutVersion() = %+v, want %+v", got, want)
+	}
+
+	for name := range files {
+		if _, err = os.Stat(filepath.Join(fullPath, name)); err != nil {
+			t.Errorf("dry run changed <%s>: %v", name, err)
+		}
+	}
+
+	want.DryRun = false
+	if got, err = d.MigrateLayoutVersion(context.Background(), false); err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("DB.MigrateLayoutVersion() = %+v, want %+v", got, want)
+	}
+
+	d.Close()
+	opts.LayoutVersion = 0
+	if d, err = makeDB[testentry](opts, nil); err != nil {
+		t.Fatal(err)
+	}
+	defer d.Close()
+
+	assertGet(&d, "a:b", files["foo.a:b.csv"])
+	assertGet(&d, "plain", files["foo.plain.csv"])
+	for _, name := range []string{"foo.a:b.csv", "foo.a%3Ab.csv.exported", "foo.gone.csv.exported"} {
+		if _, err = os.Stat(filepath.Join(fullPath, name)); !os.IsNotExist(err) {
+			t.Errorf("<%s> exists after migrating, error = %v", name, err)
+		}
+	}
+
+	// Migrating the latest layout changes nothing
+	if got, err = d.MigrateLayoutVersion(context.Background(), false); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(got.Renamed) > 0 || len(got.Removed) > 0 {
+		t.Errorf("DB.MigrateLayoutVersion() = %+v, want no changes", got)
+	}
+}
+
+func TestDB_MigrateLayoutVersion_chained(t *testing.T) {
+	dir := fmt.Sprintf("test_%d", time.Now().UnixNano())
+	defer os.RemoveAll(dir)
+
+	fullPath := filepath.Join(dir, "foo")
+	if err := os.MkdirAll(fullPath, 0744); err != nil {
+		t.Fatal(err)
+	}
+
+	// The key `a"b` is escaped to the name of the file of the key "a%22b", which is renamed first
+	files := map[string]string{
+		`foo.a"b.csv`:   "foo,bar\n1,1b\n",
+		"foo.a%22b.csv": "foo,bar\n2,2b\n",
+	}
+
+	for name, contents := range files {
+		if err := os.WriteFile(filepath.Join(fullPath, name), []byte(contents), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for _, dryRun := range []bool{true, false} {
+		var opts Options
+		opts.Dir = dir
+		opts.Name = "foo"
+		opts.LayoutVersion = LayoutVersion1
+		d, err := makeDB[testentry](opts, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		got, err := d.MigrateLayoutVersion(context.Background(), dryRun)
+		d.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		want := map[string]string{`foo.a"b.csv`: "foo.a%22b.csv", "foo.a%22b.csv": "foo.a%2522b.csv"}
+		if !reflect.DeepEqual(got.Renamed, want) || len(got.Conflicts) > 0 {
+			t.Fatalf("DB.MigrateLayoutVersion(%v) = %+v, want renamed %v", dryRun, got, want)
+		}
+	}
+
+	var opts Options
+	opts.Dir = dir
+	opts.Name = "foo"
+	d, err := makeDB[testentry](opts, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer d.Close()
+
+	for key, name := range map[string]string{`a"b`: `foo.a"b.csv`, "a%22b": "foo.a%22b.csv"} {
+		w := &bytes.Buffer{}
+		if err = d.Get(w, key); err != nil {
+			t.Fatal(err)
+		}
+
+		if w.String() != files[name] {
+			t.Errorf("DB.Get(%s) = %q, want %q", key, w.String(), files[name])
+		}
+	}
+}
+
+func TestOptions_Validate_layoutVersion(t *testing.T) {
+	type testcase struct {
+		name    string
+		opts    Options
+		wantErr error
+	}
+
+	tests := []testcase{
+		{
+			name: "latest",
+			opts: Options{Dir: "test", Name: "foo"},
+		},
+		{
+			name: "version 1",
+			opts: Options{Dir: "test", Name: "foo", LayoutVersion: LayoutVersion1},
+		},
+		{
+			name:    "unknown",
+			opts:    Options{Dir: "test", Name: "foo", LayoutVersion: LayoutVersion2 + 1},
+			wantErr: ErrInvalidLayoutVersion,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.opts.Validate(); !errors.Is(err, tt.wantErr) {
+				t.Errorf("Options.Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
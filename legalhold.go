@@ -0,0 +1,99 @@
+package csvdb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// holdExt is the extension of the marker written alongside the file of a key under legal hold
+const holdExt = ".hold"
+
+// ErrEntryHeld is returned when removing a key which is under legal hold
+var ErrEntryHeld = errors.New("entry is under legal hold")
+
+// SetHold will place a legal hold on a key. Held keys are never purged, regardless of their
+// TTL, and cannot be deleted or evicted until the hold is cleared. Holds are persisted
+// alongside the file of the key, so they are kept across restarts.
+func (d *DB[T]) SetHold(key string) (err error) {
+	return d.SetHoldContext(context.Background(), key)
+}
+
+// SetHoldContext is the context-aware variant of SetHold
+func (d *DB[T]) SetHoldContext(ctx context.Context, key string) (err error) {
+	var unlock func()
+	if unlock, err = d.lockKeys(ctx, key); err != nil {
+		return
+	}
+	defer unlock()
+
+	_, filename := d.getFilename(key)
+	var f file
+	if f, err = d.fs.Create(filename + holdExt); err != nil {
+		return
+	}
+
+	return f.Close()
+}
+
+// ClearHold will clear the legal hold of a key
+func (d *DB[T]) ClearHold(key string) (err error) {
+	return d.ClearHoldContext(context.Background(), key)
+}
+
+// ClearHoldContext is the context-aware variant of ClearHold
+func (d *DB[T]) ClearHoldContext(ctx context.Context, key string) (err error) {
+	var unlock func()
+	if unlock, err = d.lockKeys(ctx, key); err != nil {
+		return
+	}
+	defer unlock()
+
+	_, filename := d.getFilename(key)
+	if err = d.fs.Remove(filename + holdExt); os.IsNotExist(err) {
+		return nil
+	}
+
+	return
+}
+
+// Holds will return the keys which are under legal hold
+func (d *DB[T]) Holds() (keys []string, err error) {
+	keys = []string{}
+	if err = d.readDataDirs(func(dir string, entries []os.DirEntry) (err error) {
+		for _, entry := range entries {
+			if entry.IsDir() || filepath.Ext(entry.Name()) != holdExt {
+				continue
+			}
+
+			keys = append(keys, d.getKey(strings.TrimSuffix(entry.Name(), holdExt)))
+		}
+
+		return
+	}); err != nil {
+		return
+	}
+
+	sort.Strings(keys)
+	return
+}
+
+// isHeld must be called while the key or the DB is locked
+func (d *DB[T]) isHeld(key string) bool {
+	_, filename := d.getFilename(key)
+	_, err := d.fs.Stat(filename + holdExt)
+	return err == nil
+}
+
+// checkHold must be called while the key or the DB is locked
+func (d *DB[T]) checkHold(key string) (err error) {
+	if d.isHeld(key) {
+		return fmt.Errorf("%w: <%s>", ErrEntryHeld, key)
+	}
+
+	return
+}
@@ -0,0 +1,147 @@
+package csvdb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestDB_LegalHold(t *testing.T) {
+	type testcase struct {
+		name   string
+		remove func(d *DB[testentry], key string) error
+	}
+
+	tests := []testcase{
+		{
+			name: "delete",
+			remove: func(d *DB[testentry], key string) error {
+				return d.Delete(key)
+			},
+		},
+		{
+			name: "evict",
+			remove: func(d *DB[testentry], key string) error {
+				if err := d.ExportPrefix(""); err != nil {
+					return err
+				}
+
+				return d.Evict(key)
+			},
+		},
+		{
+			name: "delete prefix",
+			remove: func(d *DB[testentry], key string) error {
+				return d.DeletePrefix("held")
+			},
+		},
+		{
+			name: "drop",
+			remove: func(d *DB[testentry], key string) error {
+				return d.DropDB(context.Background(), d.o.Name, false)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := fmt.Sprintf("test_%d", time.Now().UnixNano())
+			defer os.RemoveAll(dir)
+
+			var opts Options
+			opts.Dir = dir
+			opts.Name = "foo"
+			d, err := makeDB[testentry](opts, &mockBackend{})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if err = d.Append("held/a", testentry{Foo: "1", Bar: "1b"}); err != nil {
+				t.Fatal(err)
+			}
+
+			if err = d.SetHold("held/a"); err != nil {
+				t.Fatal(err)
+			}
+
+			if err = tt.remove(&d, "held/a"); !errors.Is(err, ErrEntryHeld) {
+				t.Fatalf("remove error = %v, want %v", err, ErrEntryHeld)
+			}
+
+			if !hasFile(&d, "held/a") {
+				t.Fatal("held key was removed")
+			}
+
+			if err = d.ClearHold("held/a"); err != nil {
+				t.Fatal(err)
+			}
+
+			if err = tt.remove(&d, "held/a"); err != nil {
+				t.Fatalf("remove error = %v after ClearHold", err)
+			}
+		})
+	}
+}
+
+func TestDB_LegalHold_purge(t *testing.T) {
+	dir := fmt.Sprintf("test_%d", time.Now().UnixNano())
+	defer os.RemoveAll(dir)
+
+	var opts Options
+	opts.Dir = dir
+	opts.Name = "foo"
+	opts.FileTTL = 50 * time.Millisecond
+	d, err := makeDB[testentry](opts, &mockBackend{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, key := range []string{"a", "b"} {
+		if err = d.Append(key, testentry{Foo: "1", Bar: "1b"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err = d.SetHold("a"); err != nil {
+		t.Fatal(err)
+	}
+
+	time.Sleep(100 * time.Millisecond)
+	if err = d.purge(""); err != nil {
+		t.Fatal(err)
+	}
+
+	if !hasFile(&d, "a") {
+		t.Error("held key was purged")
+	}
+
+	if hasFile(&d, "b") {
+		t.Error("expired key was not purged")
+	}
+
+	// Holds are persisted across restarts
+	opts.FileTTL = 0
+	reopened, err := makeDB[testentry](opts, &mockBackend{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	holds, err := reopened.Holds()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want := []string{"a"}; !reflect.DeepEqual(holds, want) {
+		t.Errorf("DB.Holds() = %v, want %v", holds, want)
+	}
+}
+
+func hasFile(d *DB[testentry], key string) bool {
+	_, filename := d.getFilename(key)
+	_, err := d.fs.Stat(filename)
+	return err == nil
+}
@@ -0,0 +1,117 @@
+package csvdb
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// listKeysBatchSize is the number of directory entries read at a time while listing keys
+const listKeysBatchSize = 1024
+
+// ErrInvalidLimit is returned when a non-positive limit is provided to ListKeys
+var ErrInvalidLimit = errors.New("invalid limit, must be greater than 0")
+
+// errStopIteration is used to end a directory walk early
+var errStopIteration = errors.New("stop iteration")
+
+// IterKeys will return an iterator over the keys which begin with the provided prefix.
+// Keys are yielded in directory order and the directory is read in batches, so the
+// full set of keys is never held in memory. No keys are yielded once the DB has been
+// closed. The returned func is compatible with iter.Seq[string].
+func (d *DB[T]) IterKeys(prefix string) func(yield func(key string) bool) {
+	return func(yield func(key string) bool) {
+		if d.closed.Load() {
+			return
+		}
+
+		err := d.walkKeys(prefix, func(key string) (err error) {
+			if !yield(key) {
+				return errStopIteration
+			}
+
+			return
+		})
+
+		if err != nil && err != errStopIteration {
+			d.o.Logger.Printf("csvdb.DB[%s].IterKeys(): error iterating keys: %v\n", d.o.Name, err)
+		}
+	}
+}
+
+// ListKeys will return up to limit keys which begin with the provided prefix, in
+// lexicographical order, starting after the provided cursor. The returned next
+// cursor is passed to subsequent calls and is empty once all keys have been listed.
+func (d *DB[T]) ListKeys(prefix, cursor string, limit int) (keys []string, next string, err error) {
+	if limit <= 0 {
+		err = ErrInvalidLimit
+		return
+	}
+
+	if d.closed.Load() {
+		err = ErrClosed
+		return
+	}
+
+	// Only the smallest limit+1 keys are retained, the extra key signals another page exists
+	keep := limit + 1
+	if err = d.walkKeys(prefix, func(key string) (err error) {
+		if cursor != "" && key <= cursor {
+			return
+		}
+
+		keys = append(keys, key)
+		if len(keys) >= keep*2 {
+			sort.Strings(keys)
+			keys = keys[:keep]
+		}
+
+		return
+	}); err != nil {
+		return
+	}
+
+	sort.Strings(keys)
+	if len(keys) > limit {
+		keys = keys[:limit]
+		next = keys[limit-1]
+	}
+
+	return
+}
+
+func (d *DB[T]) walkKeys(prefix string, fn func(key string) error) (err error) {
+	namePrefix := d.o.Name + "."
+	var dirs []string
+	if dirs, err = d.dataDirs(); err != nil {
+		return
+	}
+
+	for _, dir := range dirs {
+		if err = readDirBatches(d.fs, dir, listKeysBatchSize, func(entries []os.DirEntry) (err error) {
+			for _, entry := range entries {
+				name := entry.Name()
+				if entry.IsDir() || filepath.Ext(name) != ".csv" || !strings.HasPrefix(name, namePrefix) {
+					continue
+				}
+
+				key := d.getKey(name)
+				if !strings.HasPrefix(key, prefix) {
+					continue
+				}
+
+				if err = fn(key); err != nil {
+					return
+				}
+			}
+
+			return
+		}); err != nil {
+			return
+		}
+	}
+
+	return
+}
@@ -0,0 +1,168 @@
+package csvdb
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+)
+
+func TestDB_IterKeys(t *testing.T) {
+	type testcase struct {
+		name     string
+		inMemory bool
+		prefix   string
+		stopAt   int
+		want     []string
+	}
+
+	tests := []testcase{
+		{
+			name: "all",
+			want: []string{"a/1", "a/2", "b/1", "c"},
+		},
+		{
+			name:   "prefix",
+			prefix: "a/",
+			want:   []string{"a/1", "a/2"},
+		},
+		{
+			name:   "no match",
+			prefix: "z",
+		},
+		{
+			name:     "in memory",
+			inMemory: true,
+			prefix:   "a/",
+			want:     []string{"a/1", "a/2"},
+		},
+		{
+			name:   "stop early",
+			stopAt: 2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var opts Options
+			opts.Dir = fmt.Sprintf("test_%d", time.Now().UnixNano())
+			opts.Name = "foo"
+			opts.InMemory = tt.inMemory
+			d, err := makeDB[testentry](opts, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer os.RemoveAll(d.o.Dir)
+
+			for _, key := range []string{"a/1", "a/2", "b/1", "c"} {
+				if err = d.Append(key, testentry{Foo: "1", Bar: "1b"}); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			var got []string
+			d.IterKeys(tt.prefix)(func(key string) bool {
+				got = append(got, key)
+				return tt.stopAt == 0 || len(got) < tt.stopAt
+			})
+
+			if tt.stopAt > 0 {
+				if len(got) != tt.stopAt {
+					t.Errorf("DB.IterKeys() yielded %d keys, want %d", len(got), tt.stopAt)
+				}
+
+				return
+			}
+
+			sort.Strings(got)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("DB.IterKeys() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDB_ListKeys(t *testing.T) {
+	type testcase struct {
+		name      string
+		prefix    string
+		limit     int
+		wantPages [][]string
+		wantErr   error
+	}
+
+	tests := []testcase{
+		{
+			name:      "single page",
+			limit:     10,
+			wantPages: [][]string{{"a/1", "a/2", "a/3", "b/1", "b/2"}},
+		},
+		{
+			name:      "multiple pages",
+			limit:     2,
+			wantPages: [][]string{{"a/1", "a/2"}, {"a/3", "b/1"}, {"b/2"}},
+		},
+		{
+			name:      "exact pages",
+			prefix:    "b/",
+			limit:     2,
+			wantPages: [][]string{{"b/1", "b/2"}},
+		},
+		{
+			name:      "prefix",
+			prefix:    "a/",
+			limit:     1,
+			wantPages: [][]string{{"a/1"}, {"a/2"}, {"a/3"}},
+		},
+		{
+			name:    "invalid limit",
+			limit:   0,
+			wantErr: ErrInvalidLimit,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var opts Options
+			opts.Dir = fmt.Sprintf("test_%d", time.Now().UnixNano())
+			opts.Name = "foo"
+			d, err := makeDB[testentry](opts, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer os.RemoveAll(d.o.Dir)
+
+			for _, key := range []string{"b/2", "a/3", "a/1", "b/1", "a/2"} {
+				if err = d.Append(key, testentry{Foo: "1", Bar: "1b"}); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			var (
+				gotPages [][]string
+				cursor   string
+			)
+
+			for {
+				var keys []string
+				keys, cursor, err = d.ListKeys(tt.prefix, cursor, tt.limit)
+				if err != tt.wantErr {
+					t.Fatalf("DB.ListKeys() error = %v, wantErr %v", err, tt.wantErr)
+				} else if err != nil {
+					return
+				}
+
+				gotPages = append(gotPages, keys)
+				if cursor == "" {
+					break
+				}
+			}
+
+			if !reflect.DeepEqual(gotPages, tt.wantPages) {
+				t.Errorf("DB.ListKeys() = %v, want %v", gotPages, tt.wantPages)
+			}
+		})
+	}
+}
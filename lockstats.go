@@ -0,0 +1,139 @@
+package csvdb
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Stats are the runtime statistics of a DB
+type Stats struct {
+	// Lock is the statistics of the main lock, held exclusively by directory-wide operations
+	Lock MutexStats
+	// KeyLock is the combined statistics of the key locks, held while individual keys are written
+	KeyLock MutexStats
+	// ExportLock is the statistics of the lock held while exports are running
+	ExportLock MutexStats
+	// PurgeLock is the statistics of the lock held while purges are running
+	PurgeLock MutexStats
+
+	// Downloads is the number of download attempts made to the backend, including
+	// attempts for keys which do not exist
+	Downloads uint64
+	// DownloadTime is the combined duration spent downloading from the backend
+	DownloadTime time.Duration
+
+	// Recovery is the statistics of the journals recovered when the DB was opened
+	Recovery RecoveryStats
+}
+
+// MutexStats are the wait and hold statistics of a lock
+type MutexStats struct {
+	// Acquisitions is the number of times the lock has been acquired
+	Acquisitions uint64
+	// WaitTime is the combined duration spent waiting to acquire the lock
+	WaitTime time.Duration
+	// MaxWaitTime is the longest duration spent waiting to acquire the lock
+	MaxWaitTime time.Duration
+	// HoldTime is the combined duration the lock has been held for
+	HoldTime time.Duration
+	// MaxHoldTime is the longest duration the lock has been held for
+	MaxHoldTime time.Duration
+}
+
+// Stats will return the runtime statistics of the DB
+func (d *DB[T]) Stats() (s Stats) {
+	s.Lock = d.mux.stats()
+	s.KeyLock = d.keyLocks.stats()
+	s.ExportLock = d.emux.stats()
+	s.PurgeLock = d.pmux.stats()
+	s.Downloads = d.downloads.Load()
+	s.DownloadTime = time.Duration(d.downloadTime.Load())
+	s.Recovery = d.recovery
+	return
+}
+
+// timedMutex is a mutex which records how long it is waited on and held for.
+// Only exclusive locks are recorded.
+type timedMutex struct {
+	sync.RWMutex
+
+	// acquired is only accessed while the lock is held
+	acquired time.Time
+
+	acquisitions atomic.Uint64
+	wait         atomic.Int64
+	maxWait      atomic.Int64
+	hold         atomic.Int64
+	maxHold      atomic.Int64
+}
+
+func (m *timedMutex) Lock() {
+	start := time.Now()
+	m.RWMutex.Lock()
+	m.acquired = time.Now()
+	m.recordWait(m.acquired.Sub(start))
+}
+
+func (m *timedMutex) TryLock() (ok bool) {
+	if ok = m.RWMutex.TryLock(); ok {
+		m.acquired = time.Now()
+		m.recordWait(0)
+	}
+
+	return
+}
+
+func (m *timedMutex) Unlock() {
+	hold := int64(time.Since(m.acquired))
+	m.hold.Add(hold)
+	storeMax(&m.maxHold, hold)
+	m.RWMutex.Unlock()
+}
+
+// readLocker will return a tryLocker which acquires the lock for reading
+func (m *timedMutex) readLocker() tryLocker {
+	return readLocker{m: m}
+}
+
+func (m *timedMutex) recordWait(wait time.Duration) {
+	m.acquisitions.Add(1)
+	m.wait.Add(int64(wait))
+	storeMax(&m.maxWait, int64(wait))
+}
+
+func (m *timedMutex) stats() (s MutexStats) {
+	s.Acquisitions = m.acquisitions.Load()
+	s.WaitTime = time.Duration(m.wait.Load())
+	s.MaxWaitTime = time.Duration(m.maxWait.Load())
+	s.HoldTime = time.Duration(m.hold.Load())
+	s.MaxHoldTime = time.Duration(m.maxHold.Load())
+	return
+}
+
+// storeMax will store the value when it is greater than the current value
+func storeMax(v *atomic.Int64, value int64) {
+	for {
+		current := v.Load()
+		if value <= current || v.CompareAndSwap(current, value) {
+			return
+		}
+	}
+}
+
+// readLocker acquires the read lock of a timedMutex through the tryLocker interface
+type readLocker struct {
+	m *timedMutex
+}
+
+func (r readLocker) Lock() {
+	r.m.RLock()
+}
+
+func (r readLocker) TryLock() bool {
+	return r.m.TryRLock()
+}
+
+func (r readLocker) Unlock() {
+	r.m.RUnlock()
+}
@@ -0,0 +1,122 @@
+package csvdb
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"testing"
+	"time"
+)
+
+func Test_timedMutex(t *testing.T) {
+	type testcase struct {
+		name string
+		hold time.Duration
+		// contended will acquire the lock while it is held
+		contended bool
+
+		wantAcquisitions uint64
+	}
+
+	tests := []testcase{
+		{
+			name:             "uncontended",
+			hold:             5 * time.Millisecond,
+			wantAcquisitions: 1,
+		},
+		{
+			name:             "contended",
+			hold:             5 * time.Millisecond,
+			contended:        true,
+			wantAcquisitions: 2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var m timedMutex
+			m.Lock()
+
+			done := make(chan struct{})
+			if tt.contended {
+				go func() {
+					m.Lock()
+					m.Unlock()
+					close(done)
+				}()
+			} else {
+				close(done)
+			}
+
+			time.Sleep(tt.hold)
+			m.Unlock()
+			<-done
+
+			s := m.stats()
+			if s.Acquisitions != tt.wantAcquisitions {
+				t.Errorf("timedMutex.stats() acquisitions = %v, want %v", s.Acquisitions, tt.wantAcquisitions)
+			}
+
+			if s.MaxHoldTime < tt.hold || s.HoldTime < s.MaxHoldTime {
+				t.Errorf("timedMutex.stats() hold = %v, max = %v, want at least %v", s.HoldTime, s.MaxHoldTime, tt.hold)
+			}
+
+			if tt.contended && s.MaxWaitTime == 0 {
+				t.Errorf("timedMutex.stats() max wait = %v, want greater than 0", s.MaxWaitTime)
+			}
+
+			if !m.TryLock() {
+				t.Fatal("timedMutex.TryLock() = false, want true")
+			}
+
+			m.Unlock()
+		})
+	}
+}
+
+func TestDB_Stats(t *testing.T) {
+	var opts Options
+	opts.Dir = fmt.Sprintf("test_%d", time.Now().UnixNano())
+	opts.Name = "foo"
+
+	b := &mockBackend{
+		importFn: func(ctx context.Context, prefix, filename string, w io.Writer) (err error) {
+			time.Sleep(5 * time.Millisecond)
+			_, err = w.Write([]byte("foo,bar\n"))
+			return
+		},
+	}
+
+	d, err := makeDB[testentry](opts, b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(d.o.Dir)
+
+	if err = d.Get(&bytes.Buffer{}, "foo"); err != nil {
+		t.Fatal(err)
+	}
+
+	s := d.Stats()
+	if s.Downloads != 1 {
+		t.Errorf("DB.Stats() downloads = %v, want 1", s.Downloads)
+	}
+
+	if s.DownloadTime < 5*time.Millisecond {
+		t.Errorf("DB.Stats() download time = %v, want at least 5ms", s.DownloadTime)
+	}
+
+	if s.Lock.Acquisitions != 0 {
+		t.Errorf("DB.Stats() lock acquisitions = %v, want reads to acquire the lock shared", s.Lock.Acquisitions)
+	}
+
+	if err = d.Append("foo", testentry{Foo: "1", Bar: "1b"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if s = d.Stats(); s.KeyLock.Acquisitions != 1 || s.Lock.Acquisitions != 0 {
+		t.Errorf("DB.Stats() key lock acquisitions = %v, lock acquisitions = %v, want 1 and 0", s.KeyLock.Acquisitions, s.Lock.Acquisitions)
+	}
+}
@@ -0,0 +1,227 @@
+package csvdb
+
+import (
+	"os"
+	"sync"
+	"time"
+)
+
+var _ fileSystem = &manifestFS{}
+
+// manifestFS is a fileSystem which keeps a manifest of the file info of the files it has
+// stat'd, so directory walks only stat the files which have changed since they were last
+// walked. Entries are invalidated as files are modified through the fileSystem.
+//
+// The manifest also assigns each modification a logical sequence number, which unlike
+// modification times is unaffected by the system clock. Sequences only span the lifetime
+// of the manifest, files which have not been modified since it was created have none.
+type manifestFS struct {
+	fileSystem
+
+	mux     sync.Mutex
+	entries map[string]*manifestEntry
+	// seq is the sequence of the last modification
+	seq uint64
+}
+
+// manifestEntry is the cached result of a stat
+type manifestEntry struct {
+	// gen is incremented as the file is modified, results of stats which began before a
+	// modification are discarded
+	gen   uint64
+	valid bool
+	info  os.FileInfo
+	err   error
+
+	// seq is the sequence of the last modification of the file
+	seq uint64
+	// mark is a sequence recorded against the file, such as the sequence of the file an
+	// export marker was written for. It is kept until the file is removed.
+	mark   uint64
+	marked bool
+}
+
+func newManifestFS(fsys fileSystem) *manifestFS {
+	return &manifestFS{fileSystem: fsys, entries: make(map[string]*manifestEntry)}
+}
+
+func (fsys *manifestFS) Stat(name string) (info os.FileInfo, err error) {
+	fsys.mux.Lock()
+	e := fsys.entry(name)
+	if e.valid {
+		fsys.mux.Unlock()
+		return e.info, e.err
+	}
+
+	gen := e.gen
+	fsys.mux.Unlock()
+
+	info, err = fsys.fileSystem.Stat(name)
+	if err != nil && !os.IsNotExist(err) {
+		// Only results describing the file are cached
+		return
+	}
+
+	fsys.mux.Lock()
+	if e.gen == gen {
+		e.valid, e.info, e.err = true, info, err
+	}
+
+	fsys.mux.Unlock()
+	return
+}
+
+func (fsys *manifestFS) OpenFile(name string, flag int, perm os.FileMode) (f file, err error) {
+	if flag&(os.O_WRONLY|os.O_RDWR|os.O_CREATE|os.O_TRUNC|os.O_APPEND) == 0 {
+		return fsys.fileSystem.OpenFile(name, flag, perm)
+	}
+
+	f, err = fsys.fileSystem.OpenFile(name, flag, perm)
+	fsys.invalidate(name, true)
+	if err != nil {
+		return
+	}
+
+	return &manifestFile{file: f, fsys: fsys}, nil
+}
+
+func (fsys *manifestFS) Create(name string) (f file, err error) {
+	f, err = fsys.fileSystem.Create(name)
+	fsys.invalidate(name, true)
+	if err != nil {
+		return
+	}
+
+	return &manifestFile{file: f, fsys: fsys}, nil
+}
+
+func (fsys *manifestFS) CreateTemp(dir, pattern string) (f file, err error) {
+	if f, err = fsys.fileSystem.CreateTemp(dir, pattern); err != nil {
+		return
+	}
+
+	fsys.invalidate(f.Name(), true)
+	return &manifestFile{file: f, fsys: fsys}, nil
+}
+
+func (fsys *manifestFS) Remove(name string) (err error) {
+	err = fsys.fileSystem.Remove(name)
+	fsys.invalidate(name, true)
+	if err == nil {
+		fsys.mux.Lock()
+		delete(fsys.entries, name)
+		fsys.mux.Unlock()
+	}
+
+	return
+}
+
+// Rename will move the entry of a file along with it, so its sequence and mark are kept
+func (fsys *manifestFS) Rename(oldpath, newpath string) (err error) {
+	if err = fsys.fileSystem.Rename(oldpath, newpath); err != nil {
+		fsys.invalidate(oldpath, false)
+		fsys.invalidate(newpath, false)
+		return
+	}
+
+	fsys.mux.Lock()
+	defer fsys.mux.Unlock()
+	if e, ok := fsys.entries[newpath]; ok {
+		e.gen++
+		e.valid, e.info, e.err = false, nil, nil
+	}
+
+	e, ok := fsys.entries[oldpath]
+	if !ok {
+		// The file has not been modified since the manifest was created
+		e = &manifestEntry{}
+	}
+
+	delete(fsys.entries, oldpath)
+	e.gen++
+	e.valid, e.info, e.err = false, nil, nil
+	fsys.entries[newpath] = e
+	return
+}
+
+func (fsys *manifestFS) Chtimes(name string, atime, mtime time.Time) (err error) {
+	err = fsys.fileSystem.Chtimes(name, atime, mtime)
+	fsys.invalidate(name, false)
+	return
+}
+
+// entry will return the entry of a file, creating it when missing
+// Note: Must be called while mux is held
+func (fsys *manifestFS) entry(name string) (e *manifestEntry) {
+	var ok bool
+	if e, ok = fsys.entries[name]; !ok {
+		e = &manifestEntry{}
+		fsys.entries[name] = e
+	}
+
+	return
+}
+
+// invalidate will discard the cached file info of a file, along with any stat in progress.
+// When modified is set, the file is assigned the next sequence.
+func (fsys *manifestFS) invalidate(name string, modified bool) {
+	fsys.mux.Lock()
+	defer fsys.mux.Unlock()
+	e := fsys.entry(name)
+	e.gen++
+	e.valid, e.info, e.err = false, nil, nil
+	if modified {
+		fsys.seq++
+		e.seq = fsys.seq
+	}
+}
+
+// sequence will return the sequence of the last modification of a file, zero when it has
+// not been modified since the manifest was created
+func (fsys *manifestFS) sequence(name string) (seq uint64) {
+	fsys.mux.Lock()
+	defer fsys.mux.Unlock()
+	if e, ok := fsys.entries[name]; ok {
+		seq = e.seq
+	}
+
+	return
+}
+
+// setMark will record a sequence against a file
+func (fsys *manifestFS) setMark(name string, seq uint64) {
+	fsys.mux.Lock()
+	defer fsys.mux.Unlock()
+	e := fsys.entry(name)
+	e.mark, e.marked = seq, true
+}
+
+// getMark will return the sequence recorded against a file, ok is false when none is
+func (fsys *manifestFS) getMark(name string) (seq uint64, ok bool) {
+	fsys.mux.Lock()
+	defer fsys.mux.Unlock()
+	if e, exists := fsys.entries[name]; exists {
+		seq, ok = e.mark, e.marked
+	}
+
+	return
+}
+
+// manifestFile is the handle of a file opened for writing through a manifestFS
+type manifestFile struct {
+	file
+
+	fsys *manifestFS
+}
+
+func (f *manifestFile) Write(p []byte) (n int, err error) {
+	n, err = f.file.Write(p)
+	f.fsys.invalidate(f.Name(), true)
+	return
+}
+
+func (f *manifestFile) Truncate(size int64) (err error) {
+	err = f.file.Truncate(size)
+	f.fsys.invalidate(f.Name(), true)
+	return
+}
@@ -0,0 +1,178 @@
+package csvdb
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestDB_ManifestCache(t *testing.T) {
+	type testcase struct {
+		name string
+		opts func(o *Options)
+	}
+
+	tests := []testcase{
+		{name: "basic", opts: func(o *Options) {}},
+		{name: "in memory", opts: func(o *Options) { o.InMemory = true }},
+		{name: "compression", opts: func(o *Options) { o.Compression = CompressionGzip }},
+		{name: "atomic append", opts: func(o *Options) { o.AtomicAppend = true }},
+		{name: "max open files", opts: func(o *Options) { o.MaxOpenFiles = 1 }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var opts Options
+			opts.Dir = fmt.Sprintf("test_%d", time.Now().UnixNano())
+			opts.Name = "foo"
+			opts.ManifestCache = true
+			tt.opts(&opts)
+			d, err := makeDB[testentry](opts, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer os.RemoveAll(opts.Dir)
+
+			if err = d.Append("a", testentry{Foo: "1", Bar: "1b"}); err != nil {
+				t.Fatal(err)
+			}
+
+			exportable, err := d.getExportable("")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if len(exportable) != 1 {
+				t.Fatalf("invalid number of exportable keys, expected 1 and received %d", len(exportable))
+			}
+
+			before, err := d.fs.Stat(d.getPath(exportable[0]))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if err = d.Append("a", testentry{Foo: "2", Bar: "2b"}); err != nil {
+				t.Fatal(err)
+			}
+
+			after, err := d.fs.Stat(d.getPath(exportable[0]))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if after.Size() <= before.Size() {
+				t.Fatalf("invalid size, expected size to grow from %d and received %d", before.Size(), after.Size())
+			}
+
+			if err = d.Delete("a"); err != nil {
+				t.Fatal(err)
+			}
+
+			if exportable, err = d.getExportable(""); err != nil {
+				t.Fatal(err)
+			}
+
+			if len(exportable) != 0 {
+				t.Fatalf("invalid number of exportable keys, expected 0 and received %d", len(exportable))
+			}
+		})
+	}
+}
+
+func TestDB_ManifestCache_readOnly(t *testing.T) {
+	var opts Options
+	opts.Dir = fmt.Sprintf("test_%d", time.Now().UnixNano())
+	opts.Name = "foo"
+	opts.ManifestCache = true
+	opts.ReadOnly = true
+	defer os.RemoveAll(opts.Dir)
+	if _, err := makeDB[testentry](opts, nil); err == nil {
+		t.Fatal("expected error and received nil")
+	}
+}
+
+func TestDB_getExportable_sequences(t *testing.T) {
+	type testcase struct {
+		name          string
+		manifestCache bool
+		// write is set to modify the key after it is exported
+		write bool
+		// skew will offset the modification times of the file and its export marker
+		skew           func(d *DB[testentry], filename string) error
+		wantExportable int
+	}
+
+	backdate := func(d *DB[testentry], filename string) error {
+		modTime := time.Now().Add(-time.Hour)
+		return d.fs.Chtimes(filename, modTime, modTime)
+	}
+
+	postdateMarker := func(d *DB[testentry], filename string) error {
+		modTime := time.Now().Add(time.Hour)
+		return d.fs.Chtimes(filename+".exported", modTime, modTime)
+	}
+
+	tests := []testcase{
+		{
+			name:           "backdated write",
+			manifestCache:  true,
+			write:          true,
+			skew:           backdate,
+			wantExportable: 1,
+		},
+		{
+			name:           "postdated marker",
+			manifestCache:  true,
+			skew:           postdateMarker,
+			wantExportable: 0,
+		},
+		{
+			name:           "postdated marker without manifest",
+			skew:           postdateMarker,
+			wantExportable: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var opts Options
+			opts.Dir = fmt.Sprintf("test_%d", time.Now().UnixNano())
+			opts.Name = "foo"
+			opts.ManifestCache = tt.manifestCache
+			d, err := makeDB[testentry](opts, &mockBackend{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer os.RemoveAll(opts.Dir)
+
+			if err = d.Append("a", testentry{Foo: "1", Bar: "1b"}); err != nil {
+				t.Fatal(err)
+			}
+
+			if err = d.export(context.Background(), "foo.a.csv"); err != nil {
+				t.Fatal(err)
+			}
+
+			if tt.write {
+				if err = d.Append("a", testentry{Foo: "2", Bar: "2b"}); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			if err = tt.skew(&d, d.getPath("foo.a.csv")); err != nil {
+				t.Fatal(err)
+			}
+
+			exportable, err := d.getExportable("")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if len(exportable) != tt.wantExportable {
+				t.Errorf("invalid number of exportable keys, expected %d and received %d", tt.wantExportable, len(exportable))
+			}
+		})
+	}
+}
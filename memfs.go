@@ -0,0 +1,441 @@
+package csvdb
+
+import (
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrMemoryLimitExceeded is returned when a write would exceed Options.MaxMemory
+var ErrMemoryLimitExceeded = errors.New("memory limit exceeded")
+
+var _ fileSystem = &memFS{}
+
+func newMemFS(limit int64) *memFS {
+	var m memFS
+	m.files = make(map[string]*memData)
+	m.dirs = map[string]struct{}{".": {}, "/": {}}
+	m.limit = limit
+	return &m
+}
+
+// memFS is a fileSystem which keeps all data in memory
+type memFS struct {
+	mux sync.Mutex
+
+	files map[string]*memData
+	dirs  map[string]struct{}
+
+	size  int64
+	limit int64
+
+	tmpSeq uint64
+}
+
+func (m *memFS) Open(name string) (file, error) {
+	return m.OpenFile(name, os.O_RDONLY, 0)
+}
+
+func (m *memFS) OpenFile(name string, flag int, perm os.FileMode) (f file, err error) {
+	m.mux.Lock()
+	defer m.mux.Unlock()
+	name = path.Clean(name)
+
+	data, ok := m.files[name]
+	switch {
+	case ok && flag&os.O_CREATE != 0 && flag&os.O_EXCL != 0:
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrExist}
+	case ok:
+	case flag&os.O_CREATE == 0:
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+	default:
+		if _, ok := m.dirs[path.Dir(name)]; !ok {
+			return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+		}
+
+		data = &memData{modTime: time.Now(), linked: true}
+		m.files[name] = data
+	}
+
+	if flag&os.O_TRUNC != 0 && flag&(os.O_WRONLY|os.O_RDWR) != 0 {
+		m.resize(data, 0)
+	}
+
+	return &memFile{fs: m, name: name, data: data, flag: flag}, nil
+}
+
+func (m *memFS) Create(name string) (file, error) {
+	return m.OpenFile(name, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0644)
+}
+
+func (m *memFS) CreateTemp(dir, pattern string) (f file, err error) {
+	for {
+		m.mux.Lock()
+		m.tmpSeq++
+		seq := strconv.FormatUint(m.tmpSeq, 10)
+		m.mux.Unlock()
+
+		name := pattern + seq
+		if i := strings.LastIndex(pattern, "*"); i != -1 {
+			name = pattern[:i] + seq + pattern[i+1:]
+		}
+
+		f, err = m.OpenFile(path.Join(dir, name), os.O_RDWR|os.O_CREATE|os.O_EXCL, 0600)
+		if !os.IsExist(err) {
+			return
+		}
+	}
+}
+
+func (m *memFS) Remove(name string) error {
+	m.mux.Lock()
+	defer m.mux.Unlock()
+	name = path.Clean(name)
+
+	if data, ok := m.files[name]; ok {
+		m.unlink(name, data)
+		return nil
+	}
+
+	if _, ok := m.dirs[name]; ok {
+		for filename := range m.files {
+			if path.Dir(filename) == name {
+				return &fs.PathError{Op: "remove", Path: name, Err: errors.New("directory not empty")}
+			}
+		}
+
+		delete(m.dirs, name)
+		return nil
+	}
+
+	return &fs.PathError{Op: "remove", Path: name, Err: fs.ErrNotExist}
+}
+
+func (m *memFS) Rename(oldpath, newpath string) error {
+	m.mux.Lock()
+	defer m.mux.Unlock()
+	oldpath = path.Clean(oldpath)
+	newpath = path.Clean(newpath)
+
+	data, ok := m.files[oldpath]
+	if !ok {
+		return &os.LinkError{Op: "rename", Old: oldpath, New: newpath, Err: fs.ErrNotExist}
+	}
+
+	if _, ok := m.dirs[path.Dir(newpath)]; !ok {
+		return &os.LinkError{Op: "rename", Old: oldpath, New: newpath, Err: fs.ErrNotExist}
+	}
+
+	if existing, ok := m.files[newpath]; ok && existing != data {
+		m.unlink(newpath, existing)
+	}
+
+	delete(m.files, oldpath)
+	m.files[newpath] = data
+	return nil
+}
+
+func (m *memFS) Chtimes(name string, atime, mtime time.Time) error {
+	m.mux.Lock()
+	defer m.mux.Unlock()
+	name = path.Clean(name)
+
+	data, ok := m.files[name]
+	if !ok {
+		return &fs.PathError{Op: "chtimes", Path: name, Err: fs.ErrNotExist}
+	}
+
+	data.modTime = mtime
+	return nil
+}
+
+func (m *memFS) Stat(name string) (os.FileInfo, error) {
+	m.mux.Lock()
+	defer m.mux.Unlock()
+	name = path.Clean(name)
+
+	if data, ok := m.files[name]; ok {
+		return data.info(name), nil
+	}
+
+	if _, ok := m.dirs[name]; ok {
+		return memFileInfo{name: path.Base(name), dir: true}, nil
+	}
+
+	return nil, &fs.PathError{Op: "stat", Path: name, Err: fs.ErrNotExist}
+}
+
+func (m *memFS) ReadDir(name string) (entries []os.DirEntry, err error) {
+	m.mux.Lock()
+	defer m.mux.Unlock()
+	name = path.Clean(name)
+
+	if _, ok := m.dirs[name]; !ok {
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: fs.ErrNotExist}
+	}
+
+	for filename, data := range m.files {
+		if path.Dir(filename) == name {
+			entries = append(entries, fs.FileInfoToDirEntry(data.info(filename)))
+		}
+	}
+
+	for dir := range m.dirs {
+		if dir != name && path.Dir(dir) == name {
+			entries = append(entries, fs.FileInfoToDirEntry(memFileInfo{name: path.Base(dir), dir: true}))
+		}
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Name() < entries[j].Name()
+	})
+
+	return
+}
+
+func (m *memFS) MkdirAll(name string, perm os.FileMode) error {
+	m.mux.Lock()
+	defer m.mux.Unlock()
+
+	for name = path.Clean(name); ; name = path.Dir(name) {
+		if _, ok := m.files[name]; ok {
+			return &fs.PathError{Op: "mkdir", Path: name, Err: errors.New("not a directory")}
+		}
+
+		m.dirs[name] = struct{}{}
+		if name == "." || name == "/" {
+			return nil
+		}
+	}
+}
+
+// SyncDir is a no-op, as the contents of a memFS are never persisted
+func (m *memFS) SyncDir(name string) error {
+	return nil
+}
+
+func (m *memFS) unlink(name string, data *memData) {
+	delete(m.files, name)
+	m.size -= int64(len(data.bs))
+	data.linked = false
+}
+
+// resize must be called while the lock is held
+func (m *memFS) resize(data *memData, size int64) (err error) {
+	delta := size - int64(len(data.bs))
+	if data.linked && delta > 0 && m.limit > 0 && m.size+delta > m.limit {
+		return ErrMemoryLimitExceeded
+	}
+
+	switch {
+	case size <= int64(len(data.bs)):
+		data.bs = data.bs[:size]
+	case size <= int64(cap(data.bs)):
+		tail := data.bs[len(data.bs):size]
+		for i := range tail {
+			tail[i] = 0
+		}
+
+		data.bs = data.bs[:size]
+	default:
+		bs := make([]byte, size, size*2)
+		copy(bs, data.bs)
+		data.bs = bs
+	}
+
+	if data.linked {
+		m.size += delta
+	}
+
+	data.modTime = time.Now()
+	return
+}
+
+type memData struct {
+	bs      []byte
+	modTime time.Time
+	linked  bool
+}
+
+func (d *memData) info(name string) memFileInfo {
+	return memFileInfo{name: path.Base(name), size: int64(len(d.bs)), modTime: d.modTime, data: d}
+}
+
+type memFile struct {
+	fs   *memFS
+	name string
+	data *memData
+	flag int
+
+	offset int64
+	closed bool
+}
+
+func (f *memFile) Name() string {
+	return f.name
+}
+
+func (f *memFile) Read(bs []byte) (n int, err error) {
+	f.fs.mux.Lock()
+	defer f.fs.mux.Unlock()
+
+	if err = f.check("read"); err != nil {
+		return
+	}
+
+	if f.offset >= int64(len(f.data.bs)) {
+		return 0, io.EOF
+	}
+
+	n = copy(bs, f.data.bs[f.offset:])
+	f.offset += int64(n)
+	return
+}
+
+func (f *memFile) Write(bs []byte) (n int, err error) {
+	f.fs.mux.Lock()
+	defer f.fs.mux.Unlock()
+
+	if err = f.check("write"); err != nil {
+		return
+	}
+
+	if f.flag&(os.O_WRONLY|os.O_RDWR) == 0 {
+		return 0, &fs.PathError{Op: "write", Path: f.name, Err: fs.ErrPermission}
+	}
+
+	if f.flag&os.O_APPEND != 0 {
+		f.offset = int64(len(f.data.bs))
+	}
+
+	end := f.offset + int64(len(bs))
+	if end > int64(len(f.data.bs)) {
+		if err = f.fs.resize(f.data, end); err != nil {
+			return
+		}
+	}
+
+	n = copy(f.data.bs[f.offset:], bs)
+	f.offset += int64(n)
+	f.data.modTime = time.Now()
+	return
+}
+
+func (f *memFile) Seek(offset int64, whence int) (n int64, err error) {
+	f.fs.mux.Lock()
+	defer f.fs.mux.Unlock()
+
+	if err = f.check("seek"); err != nil {
+		return
+	}
+
+	switch whence {
+	case io.SeekStart:
+	case io.SeekCurrent:
+		offset += f.offset
+	case io.SeekEnd:
+		offset += int64(len(f.data.bs))
+	}
+
+	if offset < 0 {
+		return 0, &fs.PathError{Op: "seek", Path: f.name, Err: fs.ErrInvalid}
+	}
+
+	f.offset = offset
+	return offset, nil
+}
+
+func (f *memFile) Stat() (os.FileInfo, error) {
+	f.fs.mux.Lock()
+	defer f.fs.mux.Unlock()
+
+	if err := f.check("stat"); err != nil {
+		return nil, err
+	}
+
+	return f.data.info(f.name), nil
+}
+
+func (f *memFile) Truncate(size int64) (err error) {
+	f.fs.mux.Lock()
+	defer f.fs.mux.Unlock()
+
+	if err = f.check("truncate"); err != nil {
+		return
+	}
+
+	return f.fs.resize(f.data, size)
+}
+
+func (f *memFile) Sync() error {
+	return nil
+}
+
+func (f *memFile) Close() error {
+	f.fs.mux.Lock()
+	defer f.fs.mux.Unlock()
+
+	if err := f.check("close"); err != nil {
+		return err
+	}
+
+	f.closed = true
+	return nil
+}
+
+func (f *memFile) check(op string) error {
+	if f.closed {
+		return &fs.PathError{Op: op, Path: f.name, Err: fs.ErrClosed}
+	}
+
+	return nil
+}
+
+type memFileInfo struct {
+	name    string
+	size    int64
+	modTime time.Time
+	dir     bool
+
+	// data is used to identify the underlying file
+	data *memData
+}
+
+func (m memFileInfo) Name() string {
+	return m.name
+}
+
+func (m memFileInfo) Size() int64 {
+	return m.size
+}
+
+func (m memFileInfo) Mode() os.FileMode {
+	if m.dir {
+		return fs.ModeDir | 0744
+	}
+
+	return 0644
+}
+
+func (m memFileInfo) ModTime() time.Time {
+	return m.modTime
+}
+
+func (m memFileInfo) IsDir() bool {
+	return m.dir
+}
+
+func (m memFileInfo) Sys() any {
+	if m.data == nil {
+		return nil
+	}
+
+	return m.data
+}
@@ -0,0 +1,167 @@
+package csvdb
+
+import (
+	"errors"
+	"io"
+	"os"
+	"reflect"
+	"testing"
+)
+
+func Test_memFS(t *testing.T) {
+	type testcase struct {
+		name    string
+		limit   int64
+		fn      func(m *memFS) (got string, err error)
+		want    string
+		wantErr error
+	}
+
+	write := func(m *memFS, name, value string) (err error) {
+		var f file
+		if f, err = getOrCreate(m, name); err != nil {
+			return
+		}
+		defer f.Close()
+		_, err = io.WriteString(f, value)
+		return
+	}
+
+	read := func(m *memFS, name string) (got string, err error) {
+		var bs []byte
+		bs, err = readFile(m, name)
+		return string(bs), err
+	}
+
+	tests := []testcase{
+		{
+			name: "append",
+			fn: func(m *memFS) (got string, err error) {
+				if err = write(m, "foo.csv", "hello"); err != nil {
+					return
+				}
+
+				if err = write(m, "foo.csv", " world"); err != nil {
+					return
+				}
+
+				return read(m, "foo.csv")
+			},
+			want: "hello world",
+		},
+		{
+			name: "truncate",
+			fn: func(m *memFS) (got string, err error) {
+				if err = write(m, "foo.csv", "hello world"); err != nil {
+					return
+				}
+
+				var f file
+				if f, err = m.OpenFile("foo.csv", os.O_RDWR, 0); err != nil {
+					return
+				}
+				defer f.Close()
+
+				if err = f.Truncate(5); err != nil {
+					return
+				}
+
+				return read(m, "foo.csv")
+			},
+			want: "hello",
+		},
+		{
+			name: "rename and read dir",
+			fn: func(m *memFS) (got string, err error) {
+				if err = m.MkdirAll("a/b", 0744); err != nil {
+					return
+				}
+
+				if err = write(m, "a/foo.csv", "foo"); err != nil {
+					return
+				}
+
+				if err = m.Rename("a/foo.csv", "a/bar.csv"); err != nil {
+					return
+				}
+
+				var entries []os.DirEntry
+				if entries, err = m.ReadDir("a"); err != nil {
+					return
+				}
+
+				for _, entry := range entries {
+					got += entry.Name() + ";"
+				}
+
+				return
+			},
+			want: "b;bar.csv;",
+		},
+		{
+			name: "remove",
+			fn: func(m *memFS) (got string, err error) {
+				if err = write(m, "foo.csv", "foo"); err != nil {
+					return
+				}
+
+				if err = m.Remove("foo.csv"); err != nil {
+					return
+				}
+
+				return read(m, "foo.csv")
+			},
+			wantErr: os.ErrNotExist,
+		},
+		{
+			name:  "limit",
+			limit: 8,
+			fn: func(m *memFS) (got string, err error) {
+				if err = write(m, "foo.csv", "hello"); err != nil {
+					return
+				}
+
+				if err = write(m, "bar.csv", "world"); err != nil {
+					return
+				}
+
+				return read(m, "bar.csv")
+			},
+			wantErr: ErrMemoryLimitExceeded,
+		},
+		{
+			name:  "limit after remove",
+			limit: 8,
+			fn: func(m *memFS) (got string, err error) {
+				if err = write(m, "foo.csv", "hello"); err != nil {
+					return
+				}
+
+				if err = m.Remove("foo.csv"); err != nil {
+					return
+				}
+
+				if err = write(m, "bar.csv", "world"); err != nil {
+					return
+				}
+
+				return read(m, "bar.csv")
+			},
+			want: "world",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.fn(newMemFS(tt.limit))
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("memFS error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("memFS = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
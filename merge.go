@@ -0,0 +1,197 @@
+package csvdb
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+)
+
+const (
+	// MergeKeepLocal will keep the local copy of a key, discarding the copy held by the backend
+	MergeKeepLocal MergeStrategy = iota
+	// MergeAppendRemoteMissing will append the rows of the backend copy which are missing from the local copy
+	MergeAppendRemoteMissing
+	// MergePreferNewer will keep whichever copy was modified most recently
+	// Note: The Backend must implement Stater
+	MergePreferNewer
+	// MergeCustom will merge both copies using Options.MergeFunc
+	MergeCustom
+)
+
+var (
+	// ErrInvalidMergeStrategy is returned when Options.MergeStrategy is unknown
+	ErrInvalidMergeStrategy = errors.New("invalid mergeStrategy, unknown value")
+	// ErrMergeFuncNotSet is returned when Options.MergeStrategy is MergeCustom while Options.MergeFunc is unset
+	ErrMergeFuncNotSet = errors.New("invalid mergeFunc, cannot be nil when mergeStrategy is MergeCustom")
+	// ErrMergeHeaderMismatch is returned when the local and backend copies of a key have different headers
+	ErrMergeHeaderMismatch = errors.New("cannot merge, local and remote headers do not match")
+)
+
+// errNothingToMerge is returned to abort a rewrite when the backend copy adds no rows
+var errNothingToMerge = errors.New("nothing to merge")
+
+// MergeStrategy determines how the backend copy of a key is reconciled with an existing local copy
+type MergeStrategy uint8
+
+// MergeFunc will write the merged contents of the local and backend copies of a key to w
+type MergeFunc func(key string, local, remote io.Reader, w io.Writer) error
+
+// merge will reconcile the downloaded copy of a key with its existing local copy
+// Note: Must be called while the key is locked
+func (d *DB[T]) merge(ctx context.Context, key, remote string) (err error) {
+	name, filename := d.getFilename(key)
+	switch d.o.MergeStrategy {
+	case MergeAppendRemoteMissing:
+		err = d.appendRemoteMissing(ctx, filename, remote)
+	case MergePreferNewer:
+		err = d.preferNewer(ctx, name, filename, remote)
+	case MergeCustom:
+		err = d.mergeWith(key, filename, remote)
+	default:
+		// The local copy takes priority
+		return nil
+	}
+
+	if err == nil {
+		d.record(key, Event{Type: EventRewritten})
+	}
+
+	return
+}
+
+// appendRemoteMissing will append the rows of the remote file which do not exist within the local file
+func (d *DB[T]) appendRemoteMissing(ctx context.Context, filename, remote string) (err error) {
+	var rf file
+	if rf, err = d.fs.Open(remote); err != nil {
+		return
+	}
+	defer rf.Close()
+
+	err = rewriteFile(ctx, d.fs, d.o.TempDir, d.dialect, filename, func(header []string, r *csv.Reader, w *csv.Writer) (err error) {
+		rr := newCSVReader(newContextReader(ctx, rf), d.dialect)
+		var remoteHeader []string
+		switch remoteHeader, err = rr.Read(); {
+		case err == io.EOF:
+			return errNothingToMerge
+		case err != nil:
+			return
+		case header == nil:
+			header = remoteHeader
+		case rowKey(header) != rowKey(remoteHeader):
+			return ErrMergeHeaderMismatch
+		}
+
+		if err = w.Write(header); err != nil {
+			return
+		}
+
+		seen := make(map[string]struct{})
+		var values []string
+		for {
+			if values, err = r.Read(); err == io.EOF {
+				break
+			} else if err != nil {
+				return
+			}
+
+			seen[rowKey(values)] = struct{}{}
+			if err = w.Write(values); err != nil {
+				return
+			}
+		}
+
+		var appended int
+		for {
+			if values, err = rr.Read(); err == io.EOF {
+				break
+			} else if err != nil {
+				return
+			}
+
+			k := rowKey(values)
+			if _, ok := seen[k]; ok {
+				continue
+			}
+
+			seen[k] = struct{}{}
+			if err = w.Write(values); err != nil {
+				return
+			}
+
+			appended++
+		}
+
+		if appended == 0 {
+			return errNothingToMerge
+		}
+
+		return nil
+	})
+
+	if err == errNothingToMerge {
+		return nil
+	}
+
+	return
+}
+
+// preferNewer will replace the local file with the remote file when the backend copy was modified more recently
+func (d *DB[T]) preferNewer(ctx context.Context, name, filename, remote string) (err error) {
+	var info RemoteInfo
+	if info, err = d.statRemote(ctx, name); err != nil {
+		return
+	}
+
+	var local os.FileInfo
+	if local, err = d.fs.Stat(filename); err != nil {
+		return
+	}
+
+	if !info.ModTime.After(local.ModTime()) {
+		return
+	}
+
+	if err = d.fs.Rename(remote, filename); err != nil {
+		return
+	}
+
+	// The local copy matches the backend, it does not need to be exported
+	return d.setSynced(name)
+}
+
+// mergeWith will replace the local file with the output of the MergeFunc
+func (d *DB[T]) mergeWith(key, filename, remote string) (err error) {
+	var lf, rf file
+	if lf, err = d.fs.Open(filename); err != nil {
+		return
+	}
+	defer lf.Close()
+
+	if rf, err = d.fs.Open(remote); err != nil {
+		return
+	}
+	defer rf.Close()
+
+	var tmp file
+	if tmp, err = createTemp(d.fs, d.o.TempDir, filename); err != nil {
+		return
+	}
+
+	if err = d.o.MergeFunc(key, lf, rf, tmp); err != nil {
+		err = fmt.Errorf("error merging <%s>: %w", key, err)
+	}
+
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+
+	if err != nil {
+		d.fs.Remove(tmp.Name())
+		return
+	}
+
+	return d.fs.Rename(tmp.Name(), filename)
+}
@@ -0,0 +1,140 @@
+package csvdb
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"testing"
+	"time"
+)
+
+type mockMergeBackend struct {
+	mockBackend
+
+	modTime time.Time
+}
+
+func (m *mockMergeBackend) Stat(ctx context.Context, prefix, filename string) (info RemoteInfo, err error) {
+	info.Exists = true
+	info.ModTime = m.modTime
+	return
+}
+
+func TestDB_Preload_merge(t *testing.T) {
+	type testcase struct {
+		name      string
+		strategy  MergeStrategy
+		mergeFunc MergeFunc
+		noStater  bool
+		remote    string
+		modTime   time.Time
+
+		wantNewErr error
+		wantErr    error
+		wantW      string
+	}
+
+	tests := []testcase{
+		{
+			name:     "keep local",
+			strategy: MergeKeepLocal,
+			remote:   "foo,bar\n1,1b\n2,2b\n",
+			wantW:    "foo,bar\n1,1b\n3,3b\n",
+		},
+		{
+			name:     "append remote missing",
+			strategy: MergeAppendRemoteMissing,
+			remote:   "foo,bar\n1,1b\n2,2b\n",
+			wantW:    "foo,bar\n1,1b\n3,3b\n2,2b\n",
+		},
+		{
+			name:     "append remote missing with header mismatch",
+			strategy: MergeAppendRemoteMissing,
+			remote:   "baz,bar\n1,1b\n",
+			wantErr:  ErrMergeHeaderMismatch,
+			wantW:    "foo,bar\n1,1b\n3,3b\n",
+		},
+		{
+			name:     "prefer newer remote",
+			strategy: MergePreferNewer,
+			remote:   "foo,bar\n2,2b\n",
+			modTime:  time.Now().Add(time.Hour),
+			wantW:    "foo,bar\n2,2b\n",
+		},
+		{
+			name:     "prefer newer local",
+			strategy: MergePreferNewer,
+			remote:   "foo,bar\n2,2b\n",
+			modTime:  time.Now().Add(-time.Hour),
+			wantW:    "foo,bar\n1,1b\n3,3b\n",
+		},
+		{
+			name:       "prefer newer without stater",
+			strategy:   MergePreferNewer,
+			noStater:   true,
+			wantNewErr: ErrStaterNotImplemented,
+		},
+		{
+			name:     "custom",
+			strategy: MergeCustom,
+			mergeFunc: func(key string, local, remote io.Reader, w io.Writer) (err error) {
+				if _, err = io.Copy(w, remote); err != nil {
+					return
+				}
+
+				_, err = fmt.Fprintf(w, "%s,merged\n", key)
+				return
+			},
+			remote: "foo,bar\n2,2b\n",
+			wantW:  "foo,bar\n2,2b\na,merged\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := &mockMergeBackend{modTime: tt.modTime}
+			m.importFn = func(ctx context.Context, prefix, filename string, w io.Writer) (err error) {
+				_, err = io.WriteString(w, tt.remote)
+				return
+			}
+
+			var b Backend = m
+			if tt.noStater {
+				b = &m.mockBackend
+			}
+
+			var opts Options
+			opts.Dir = fmt.Sprintf("test_%d", time.Now().UnixNano())
+			opts.Name = "foo"
+			opts.MergeStrategy = tt.strategy
+			opts.MergeFunc = tt.mergeFunc
+			d, err := makeDB[testentry](opts, b)
+			if err != tt.wantNewErr {
+				t.Fatalf("makeDB() error = %v, wantErr %v", err, tt.wantNewErr)
+			} else if err != nil {
+				return
+			}
+			defer os.RemoveAll(d.o.Dir)
+
+			if err = d.Append("a", testentry{Foo: "1", Bar: "1b"}, testentry{Foo: "3", Bar: "3b"}); err != nil {
+				t.Fatal(err)
+			}
+
+			if err = d.Preload("a"); !errors.Is(err, tt.wantErr) {
+				t.Fatalf("DB.Preload() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			w := &bytes.Buffer{}
+			if err = d.Get(w, "a"); err != nil {
+				t.Fatal(err)
+			}
+
+			if w.String() != tt.wantW {
+				t.Fatalf("DB.Get() = %v, want %v", w.String(), tt.wantW)
+			}
+		})
+	}
+}
@@ -0,0 +1,117 @@
+package csvdb
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+)
+
+const (
+	// MergedHeadersStrict will return a HeaderMismatchError from GetMerged when the header of a
+	// key does not match the header of the first key
+	MergedHeadersStrict MergedHeaders = iota
+	// MergedHeadersUnion will write the union of the headers of every key from GetMerged, in
+	// the order columns first appear. Rows are written with the columns their key lacks left empty.
+	MergedHeadersUnion
+)
+
+var (
+	// ErrInvalidMergedHeaders is returned when Options.MergedHeaders is unknown
+	ErrInvalidMergedHeaders = errors.New("invalid mergedHeaders, unknown value")
+	// ErrHeaderMismatch is returned when the header of a key does not match the merged header
+	ErrHeaderMismatch = errors.New("header of key does not match the merged header")
+)
+
+// MergedHeaders determines how GetMerged handles keys with different headers
+type MergedHeaders uint8
+
+// HeaderMismatchError is returned by GetMerged when the header of a key does not match the
+// merged header, it wraps ErrHeaderMismatch
+type HeaderMismatchError struct {
+	Key string
+	// Header is the header of the key
+	Header []string
+	// Want is the merged header
+	Want []string
+}
+
+func (h *HeaderMismatchError) Error() string {
+	return fmt.Sprintf("<%s>: %v, %q != %q", h.Key, ErrHeaderMismatch, h.Header, h.Want)
+}
+
+func (h *HeaderMismatchError) Unwrap() error {
+	return ErrHeaderMismatch
+}
+
+// unionHeader will return the union of the headers of the provided keys, in the order
+// columns first appear. Keys which cannot be read are skipped, as they are by GetMerged.
+func (d *DB[T]) unionHeader(ctx context.Context, keys []string) (union []string, err error) {
+	for _, key := range keys {
+		err = d.readKey(ctx, key, func(r *csv.Reader) (err error) {
+			var header []string
+			if header, err = r.Read(); err == io.EOF {
+				return nil
+			} else if err != nil {
+				return
+			}
+
+			for _, column := range header {
+				if indexOf(union, column) == -1 {
+					union = append(union, column)
+				}
+			}
+
+			return
+		})
+
+		switch err {
+		case nil, ErrEntryNotFound, ErrBackendNotSet, ErrEntryQuarantined:
+		default:
+			return
+		}
+	}
+
+	return union, nil
+}
+
+// copyUnion will copy the rows of a key with the provided header to w, with their values
+// moved to the columns of the union header
+func copyUnion(w io.Writer, r io.Reader, key string, header, union []string, dialect csvDialect) (err error) {
+	mapping := make([]int, len(header))
+	for i, column := range header {
+		if mapping[i] = indexOf(union, column); mapping[i] == -1 {
+			// The header of the key changed since the union was read
+			return &HeaderMismatchError{Key: key, Header: header, Want: union}
+		}
+	}
+
+	cr := newCSVReader(r, dialect)
+	cr.FieldsPerRecord = -1
+	cw := newCSVWriter(w, dialect)
+	out := make([]string, len(union))
+	for {
+		var values []string
+		if values, err = cr.Read(); err == io.EOF {
+			cw.Flush()
+			return cw.Error()
+		} else if err != nil {
+			return
+		}
+
+		for i := range out {
+			out[i] = ""
+		}
+
+		for i, value := range values {
+			if i < len(mapping) {
+				out[mapping[i]] = value
+			}
+		}
+
+		if err = cw.Write(out); err != nil {
+			return
+		}
+	}
+}
@@ -0,0 +1,101 @@
+package csvdb
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestDB_GetMerged_headers(t *testing.T) {
+	type testcase struct {
+		name          string
+		mergedHeaders MergedHeaders
+		contents      string
+		want          string
+		wantErr       *HeaderMismatchError
+	}
+
+	tests := []testcase{
+		{
+			name:     "strict matching",
+			contents: "foo,bar\n1,1b\n",
+			want:     "foo,bar\n0,0b\n1,1b\n",
+		},
+		{
+			name:     "strict mismatch",
+			contents: "foo,baz\n1,1z\n",
+			wantErr:  &HeaderMismatchError{Key: "b", Header: []string{"foo", "baz"}, Want: []string{"foo", "bar"}},
+		},
+		{
+			name:     "strict reordered",
+			contents: "bar,foo\n1b,1\n",
+			wantErr:  &HeaderMismatchError{Key: "b", Header: []string{"bar", "foo"}, Want: []string{"foo", "bar"}},
+		},
+		{
+			name:          "union matching",
+			mergedHeaders: MergedHeadersUnion,
+			contents:      "foo,bar\n1,1b\n",
+			want:          "foo,bar\n0,0b\n1,1b\n",
+		},
+		{
+			name:          "union mismatch",
+			mergedHeaders: MergedHeadersUnion,
+			contents:      "baz,foo\n1z,1\n",
+			want:          "foo,bar,baz\n0,0b,\n1,,1z\n",
+		},
+		{
+			name:          "union empty",
+			mergedHeaders: MergedHeadersUnion,
+			contents:      "",
+			want:          "foo,bar\n0,0b\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var opts Options
+			opts.Dir = fmt.Sprintf("test_%d", time.Now().UnixNano())
+			opts.Name = "foo"
+			opts.MergedHeaders = tt.mergedHeaders
+			d, err := makeDB[testentry](opts, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer os.RemoveAll(d.o.Dir)
+
+			if err = d.Append("a", testentry{Foo: "0", Bar: "0b"}); err != nil {
+				t.Fatal(err)
+			}
+
+			if err = os.WriteFile(path.Join(d.getFullPath(), "foo.b.csv"), []byte(tt.contents), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			w := &bytes.Buffer{}
+			err = d.GetMerged(w, "a", "b", "missing")
+			if tt.wantErr != nil {
+				var herr *HeaderMismatchError
+				if !errors.As(err, &herr) || !errors.Is(err, ErrHeaderMismatch) {
+					t.Fatalf("DB.GetMerged() error = %v, wantErr %v", err, tt.wantErr)
+				}
+
+				if !reflect.DeepEqual(herr, tt.wantErr) {
+					t.Errorf("DB.GetMerged() error = %#v, want %#v", herr, tt.wantErr)
+				}
+
+				return
+			} else if err != nil {
+				t.Fatal(err)
+			}
+
+			if w.String() != tt.want {
+				t.Errorf("DB.GetMerged() = %q, want %q", w.String(), tt.want)
+			}
+		})
+	}
+}
@@ -6,18 +6,40 @@ import (
 	"os"
 	"path/filepath"
 	"time"
+
+	"golang.org/x/text/encoding"
+)
+
+const (
+	// OrderLexicographic will iterate files ordered by filename
+	OrderLexicographic Ordering = iota
+	// OrderModTime will iterate files ordered by modification time (oldest first), falling back to filename
+	OrderModTime
 )
 
 var (
-	ErrInvalidName      = errors.New("invalid name, cannot be empty")
-	ErrInvalidDirectory = errors.New("invalid dir, cannot be empty")
-	ErrInvalidFileTTL   = errors.New("invalid fileTTL, cannot be less than 0")
+	ErrInvalidName         = errors.New("invalid name, cannot be empty")
+	ErrInvalidDirectory    = errors.New("invalid dir, cannot be empty")
+	ErrInvalidFileTTL      = errors.New("invalid fileTTL, cannot be less than 0")
+	ErrInvalidOrdering     = errors.New("invalid ordering, must be OrderLexicographic or OrderModTime")
+	ErrInvalidMaxMemory    = errors.New("invalid maxMemory, cannot be less than 0")
+	ErrInvalidLockTimeout  = errors.New("invalid lockTimeout, cannot be less than 0")
+	ErrInvalidBackgroundIO = errors.New("invalid background IO limit, cannot be less than 0")
+	ErrInvalidHistorySize  = errors.New("invalid historySize, cannot be less than 0")
+	ErrInvalidMaxOpenFiles = errors.New("invalid maxOpenFiles, cannot be less than 0")
+	ErrInvalidReadOnly     = errors.New("invalid readOnly, cannot be set alongside spillToBackend, repairHeaders, persistHistory, tailRepair, manifestCache or quarantining integrity checks")
 )
 
 type Options struct {
 	Name string `json:"name" toml:"name"`
 	Dir  string `json:"dir" toml:"dir"`
 
+	// InMemory will keep all data in memory rather than on disk
+	// Note: Dir is optional when InMemory is set
+	InMemory bool `json:"inMemory" toml:"in-memory"`
+	// MaxMemory is the maximum number of bytes stored while InMemory is set, 0 is unlimited
+	MaxMemory int64 `json:"maxMemory" toml:"max-memory"`
+
 	Logger Logger
 
 	ExportInterval time.Duration `json:"exportInterval" toml:"export-interval"`
@@ -30,6 +52,285 @@ type Options struct {
 	FileTTL time.Duration `json:"fileTTL" toml:"file-ttl"`
 
 	ExpiryMonitor ExpiryMonitor
+
+	// RequireConsumed will only purge expired keys once downstream consumers have acknowledged
+	// their entire file through MarkConsumed or MarkConsumedOffset
+	RequireConsumed bool `json:"requireConsumed" toml:"require-consumed"`
+
+	// StreamBatchSize is the number of entries AppendStream buffers before flushing
+	// Note: Defaults to 1000
+	StreamBatchSize int `json:"streamBatchSize" toml:"stream-batch-size"`
+	// StreamFlushInterval is the maximum duration AppendStream buffers entries before flushing
+	// Note: Defaults to one second
+	StreamFlushInterval time.Duration `json:"streamFlushInterval" toml:"stream-flush-interval"`
+
+	// WarmupOnStart will download every file exported under the name of the DB during New,
+	// when the Backend implements Lister
+	WarmupOnStart bool `json:"warmupOnStart" toml:"warmup-on-start"`
+
+	// PreloadWorkers is the number of concurrent downloads made by Preload and GetMerged
+	// Note: Defaults to 4
+	PreloadWorkers int `json:"preloadWorkers" toml:"preload-workers"`
+
+	// SpillToBackend will remove local files as soon as they have been exported. Reads
+	// and writes of keys which are not held locally will re-hydrate them from the backend.
+	// Note: A backend is required when SpillToBackend is set
+	SpillToBackend bool `json:"spillToBackend" toml:"spill-to-backend"`
+
+	// AtomicAppend will stage appended rows within a temporary copy of the file which
+	// is then synced and renamed over the original. A crash mid-write will never leave
+	// a partially written record, at the cost of copying the file on every append.
+	AtomicAppend bool `json:"atomicAppend" toml:"atomic-append"`
+
+	// TempDir is the directory temporary files are staged within before being renamed into
+	// place, such as downloads and rewritten files. It must reside on the same filesystem as
+	// Dir so renames remain atomic, which is verified by New.
+	// Note: Defaults to the directory of the DB
+	TempDir string `json:"tempDir" toml:"temp-dir"`
+
+	// WriteAheadLog will write appended rows to a journal alongside the file of a key before
+	// they are applied. Should the process crash mid-apply, the journal is replayed by New.
+	// Note: WriteAheadLog cannot be set alongside AtomicAppend
+	WriteAheadLog bool `json:"writeAheadLog" toml:"write-ahead-log"`
+
+	// ReadOnly will refuse every modification of the DB, returning ErrReadOnly. Keys are only
+	// read locally or downloaded from the Backend, and no exports or purges are made, allowing
+	// replicas to safely share the directory of a writer.
+	// Note: ReadOnly cannot be set alongside options which modify files on startup or on read
+	ReadOnly bool `json:"readOnly" toml:"read-only"`
+
+	// ReplicaInterval will, when set alongside ReadOnly, treat the Backend as the source of
+	// truth. At each interval the keys held locally are compared with the Backend, and those
+	// which have since been changed are downloaded again, while those which have been removed
+	// are removed locally. See Refresh.
+	// Note: The Backend must implement Stater, and the directory must not be shared with a writer
+	ReplicaInterval time.Duration `json:"replicaInterval" toml:"replica-interval"`
+
+	// ShardedLayout will spread the files of the DB across 256 hashed subdirectories, such as
+	// <Dir>/<Name>/ab/<Name>.<key>.csv, keeping directory walks fast for large key counts
+	// Note: Existing files must be moved by MigrateLayout once this has been changed
+	ShardedLayout bool `json:"shardedLayout" toml:"sharded-layout"`
+	// LayoutVersion is the layout the files of the directory were written in, allowing
+	// directories written by older versions of the package to be read. See MigrateLayoutVersion.
+	// Note: Defaults to the latest layout
+	LayoutVersion LayoutVersion `json:"layoutVersion" toml:"layout-version"`
+
+	// MaxOpenFiles is the number of file handles kept open between appends, saving the open
+	// and close of frequently appended keys. Handles are closed once their files are removed
+	// or replaced, and when the DB is closed.
+	// Note: 0 disables the cache
+	MaxOpenFiles int `json:"maxOpenFiles" toml:"max-open-files"`
+
+	// RowIndexInterval is the number of rows between the entries of the sparse row index kept
+	// alongside each file, which lets GetRange and Tail seek to rows rather than scanning
+	// from the top of the file. The index is updated on append.
+	// Note: 0 disables the row index
+	RowIndexInterval int `json:"rowIndexInterval" toml:"row-index-interval"`
+
+	// SyncWrites will sync appended rows to stable storage before the append returns, along
+	// with the directory of newly created files, so acknowledged appends survive a power loss.
+	// Note: Syncing greatly reduces the throughput of appends
+	SyncWrites bool `json:"syncWrites" toml:"sync-writes"`
+
+	// WriteBehind will have Append enqueue entries into a per-key buffer rather than writing
+	// them immediately. Buffers are written in batches by a background flusher, or by Flush.
+	// Note: Enqueued entries are not visible to reads and are lost if the process dies before
+	// they have been flushed
+	WriteBehind bool `json:"writeBehind" toml:"write-behind"`
+	// WriteBehindBatchSize is the number of entries buffered for a key before they are written
+	// Note: Defaults to 1000
+	WriteBehindBatchSize int `json:"writeBehindBatchSize" toml:"write-behind-batch-size"`
+	// WriteBehindInterval is the maximum duration entries are buffered before being written
+	// Note: Defaults to one second
+	WriteBehindInterval time.Duration `json:"writeBehindInterval" toml:"write-behind-interval"`
+
+	// ContextColumns are extra columns written after the values of every appended entry, whose
+	// values are taken from the context of the append, see ContextColumn. Appends made without
+	// a context, such as Append, write the values returned for context.Background().
+	// Note: ContextColumns cannot be set alongside WriteBehind
+	ContextColumns []ContextColumn `json:"contextColumns" toml:"context-columns"`
+	// RowChecksums will append a RowChecksumColumn holding the checksum of the values of each
+	// row, which is verified as rows are read by Rows and UpdateRows. Rows found to be corrupted
+	// return ErrRowChecksumMismatch rather than being parsed.
+	RowChecksums bool `json:"rowChecksums" toml:"row-checksums"`
+
+	// Delimiter is the rune separating the fields of each row, such as '\t' for TSV. Files are
+	// stored and served using the delimiter.
+	// Note: Defaults to ','
+	Delimiter rune `json:"delimiter" toml:"delimiter"`
+	// CSV tunes the reading and writing of CSV, see CSVOptions
+	CSV CSVOptions `json:"csv" toml:"csv"`
+	// Encoding is the character set of the CSV ingested by AppendRaw and ImportFile and written
+	// by Get (and the other methods writing CSV), such as charmap.Windows1252. Files are stored
+	// as UTF-8, characters which cannot be encoded return an error when written.
+	// Note: Defaults to UTF-8
+	Encoding encoding.Encoding `json:"-" toml:"-"`
+	// BOM determines how byte order marks are handled
+	// Note: Defaults to BOMKeep
+	BOM BOM `json:"bom" toml:"bom"`
+
+	// DeleteFromBackend will also delete the exported file of a key from the Backend when
+	// the key is deleted
+	// Note: The Backend must implement Deleter when DeleteFromBackend is set
+	DeleteFromBackend bool `json:"deleteFromBackend" toml:"delete-from-backend"`
+
+	// ContentAddressed will export the contents of files under the hash of their contents,
+	// alongside a small reference file named after the key. Contents which already exist
+	// within the Backend are not uploaded again, deduplicating identical exports across replicas.
+	// Note: The Backend must implement Header or Lister when ContentAddressed is set
+	ContentAddressed bool `json:"contentAddressed" toml:"content-addressed"`
+
+	// PurgeFromBackend will also delete the exported file of a key from the Backend when
+	// the local file is purged for expiring, unless the policy of the key retains it
+	// Note: The Backend must implement Deleter when PurgeFromBackend is set
+	PurgeFromBackend bool `json:"purgeFromBackend" toml:"purge-from-backend"`
+
+	// RepairHeaders will insert the header of the Entry into files whose first row does not
+	// match it when they are read, using an atomic rewrite
+	RepairHeaders bool `json:"repairHeaders" toml:"repair-headers"`
+
+	// IntegrityCheck is the strictness of the startup integrity check, which verifies
+	// that every file has a header and consistent column counts
+	// Note: Defaults to IntegrityCheckOff
+	IntegrityCheck IntegrityCheck `json:"integrityCheck" toml:"integrity-check"`
+
+	// TailRepair determines how files whose final record was only partially written, such as
+	// when the process died mid-write, are handled on startup
+	// Note: Defaults to TailRepairOff
+	TailRepair TailRepair `json:"tailRepair" toml:"tail-repair"`
+
+	// HeaderCase is the naming style the keys of entries are transformed to when written as
+	// a header, rows are unmarshaled using the keys of entries. Column names provided to the
+	// DB, such as the primary key column of Upsert, refer to the transformed header.
+	// Note: Defaults to HeaderCaseNone
+	HeaderCase HeaderCase `json:"headerCase" toml:"header-case"`
+	// Header overrides the keys of entries as the entry columns of files, including their order,
+	// so the on-disk schema is independent of the fields of T. Values are matched to columns by
+	// the keys of entries: columns which are not keys of T are written empty, and keys of T which
+	// are not columns are not written. Rows are unmarshaled using the header of each file.
+	// Note: Files written before the header was set keep their existing header
+	Header []string `json:"header" toml:"header"`
+
+	// Compression determines how files are stored on disk. Gzipped files are stored as
+	// <Name>.<key>.csv.gz and exported gzipped, while reads decompress them transparently.
+	// Appends are compressed alongside the last appends of their file, whose stream is
+	// rewritten, unless SyncWrites or WriteAheadLog is set.
+	// Note: Defaults to CompressionNone, files stored with another compression are not readable.
+	// Compression cannot be set alongside RowIndexInterval or TailRepair.
+	Compression Compression `json:"compression" toml:"compression"`
+	// Codec is a custom Codec files are stored and exported with, such as the zstd Codec of
+	// github.com/itsmontoya/csvdb/zstd. Files are stored as <Name>.<key>.csv<Extension>.
+	// Note: Cannot be set alongside Compression, RowIndexInterval or TailRepair
+	Codec Codec `json:"-" toml:"-"`
+	// Encryption will encrypt the files holding rows with AES-GCM, using the keys of the
+	// KeyProvider. Files are decrypted transparently when read, and are exported encrypted
+	// with their exported filename suffixed with ".enc".
+	// Note: Files stored unencrypted are not readable once set. Cannot be set alongside
+	// RowIndexInterval or TailRepair.
+	Encryption KeyProvider `json:"-" toml:"-"`
+	// KEK will encrypt the files holding rows as Encryption does, using envelope encryption.
+	// Each file is encrypted with a data key of its own, which is stored at the start of the
+	// file wrapped by the KEK.
+	// Note: Cannot be set alongside Encryption, RowIndexInterval or TailRepair
+	KEK KEK `json:"-" toml:"-"`
+	// Storage is the format rows are stored in on disk, rows are still written as CSV and
+	// exported as CSV.
+	// Note: Defaults to StorageCSV, files stored in another format are not readable. Cannot
+	// be set alongside RowIndexInterval or TailRepair.
+	Storage Storage `json:"storage" toml:"storage"`
+	// Checksums determines whether files are checksummed, and whether checksums are verified
+	// when files are downloaded or opened. ErrChecksumMismatch is returned when verification fails.
+	// Note: Defaults to ChecksumsOff
+	Checksums Checksums `json:"checksums" toml:"checksums"`
+	// ColumnPolicies are the policies of columns holding sensitive values, such as emails or
+	// IP addresses, keyed by column name. Values are hashed or encrypted as they are written,
+	// so they are stored and exported protected.
+	ColumnPolicies map[string]ColumnPolicy `json:"columnPolicies" toml:"column-policies"`
+	// ColumnKeys provides the keys the values of columns with the ColumnEncrypt action are
+	// encrypted with
+	// Note: Must be set when any column policy has the ColumnEncrypt action
+	ColumnKeys KeyProvider `json:"-" toml:"-"`
+	// CompressExports will gzip every key when it is exported while keeping local files
+	// uncompressed, the exported filename is suffixed with ".gz" and downloads are decompressed
+	// Note: Files stored with Compression or Codec are exported with the same compression
+	CompressExports bool `json:"compressExports" toml:"compress-exports"`
+
+	// ManifestCache will keep a manifest of the file info of the files in Dir, so export and
+	// purge passes only stat the files which have been modified since the previous pass. The
+	// manifest also numbers modifications, so files are exported once modified regardless of
+	// the system clock, falling back to modification times for files exported before startup.
+	// Note: The files in Dir must only be modified by the DB, ManifestCache cannot be set
+	// alongside ReadOnly.
+	ManifestCache bool `json:"manifestCache" toml:"manifest-cache"`
+
+	// LockTimeout is the maximum duration spent waiting to acquire the lock of the DB,
+	// ErrBusy is returned once it has passed. Deadlines of provided contexts are also respected.
+	// Note: 0 will wait indefinitely
+	LockTimeout time.Duration `json:"lockTimeout" toml:"lock-timeout"`
+
+	// BackgroundIOOpsPerSecond is the maximum number of files opened or removed per second by
+	// exports and purges, so background maintenance does not starve foreground calls
+	// Note: 0 is unlimited
+	BackgroundIOOpsPerSecond int `json:"backgroundIOOpsPerSecond" toml:"background-io-ops-per-second"`
+	// BackgroundIOBytesPerSecond is the maximum number of bytes read per second by exports
+	// Note: 0 is unlimited
+	BackgroundIOBytesPerSecond int64 `json:"backgroundIOBytesPerSecond" toml:"background-io-bytes-per-second"`
+
+	// PublishCatalog will upload a catalog of the exported files, their schemas, row counts
+	// and time ranges to the Backend after each export cycle in which the catalog has changed
+	PublishCatalog bool `json:"publishCatalog" toml:"publish-catalog"`
+	// CatalogTimeColumn is the column whose RFC 3339 values form the time ranges of the catalog
+	// Note: Time ranges are omitted when unset
+	CatalogTimeColumn string `json:"catalogTimeColumn" toml:"catalog-time-column"`
+
+	// HistorySize is the number of events recorded per key, which are returned by History
+	// Note: 0 disables history
+	HistorySize int `json:"historySize" toml:"history-size"`
+	// PersistHistory will persist recorded events within the directory of the DB, so the
+	// history of keys is kept between restarts
+	PersistHistory bool `json:"persistHistory" toml:"persist-history"`
+
+	// MergeStrategy determines how the backend copy of a key hydrated by Preload or WarmupOnStart
+	// is reconciled with an existing local copy
+	// Note: Defaults to MergeKeepLocal
+	MergeStrategy MergeStrategy `json:"mergeStrategy" toml:"merge-strategy"`
+	// MergedHeaders determines how GetMerged handles keys whose headers differ
+	// Note: Defaults to MergedHeadersStrict
+	MergedHeaders MergedHeaders `json:"mergedHeaders" toml:"merged-headers"`
+	// MergeFunc merges the local and backend copies of a key when MergeStrategy is MergeCustom
+	MergeFunc MergeFunc `json:"-" toml:"-"`
+
+	// KeyFunc derives the key of an entry, allowing entries to be appended by AppendAuto
+	// without an explicit key
+	KeyFunc KeyFunc `json:"-" toml:"-"`
+
+	// PrefixFunc returns the backend prefix the files of a key are exported under, defaulting to
+	// Name. The catalog and snapshots remain under Name, and warmup only lists Name.
+	PrefixFunc PrefixFunc `json:"-" toml:"-"`
+
+	// ImportBackend overrides the Backend files are downloaded from, such as a read-only archive
+	ImportBackend Backend `json:"-" toml:"-"`
+	// ExportBackend overrides the Backend files are exported to, along with the catalog and
+	// any deletes or renames of exported files
+	ExportBackend Backend `json:"-" toml:"-"`
+
+	// Registry will have New register the DB, which is removed once the DB is closed
+	Registry *Registry `json:"-" toml:"-"`
+
+	// Faults will inject failures into backend calls and disk IO, for use within tests
+	Faults *Faults `json:"-" toml:"-"`
+
+	// Ordering is the order used when iterating files for exports and purges
+	// Note: Defaults to OrderLexicographic
+	Ordering Ordering `json:"ordering" toml:"ordering"`
+
+	// SQLiteDriver is the database/sql driver name used for SQLite snapshot exports
+	// Note: Defaults to "sqlite"
+	SQLiteDriver string `json:"sqliteDriver" toml:"sqlite-driver"`
+
+	// Policies override the TTL, export interval, compression, redaction and quota
+	// of the keys matching their prefix patterns
+	Policies []Policy `json:"policies" toml:"policies"`
 }
 
 func (o *Options) Validate() (err error) {
@@ -38,25 +339,156 @@ func (o *Options) Validate() (err error) {
 		errs = append(errs, ErrInvalidName)
 	}
 
-	if len(o.Dir) == 0 {
+	if len(o.Dir) == 0 && !o.InMemory {
 		errs = append(errs, ErrInvalidDirectory)
 	}
 
+	if o.IntegrityCheck > IntegrityCheckStrict {
+		errs = append(errs, ErrInvalidIntegrityCheck)
+	}
+
+	if o.TailRepair > TailRepairQuarantine {
+		errs = append(errs, ErrInvalidTailRepair)
+	}
+
+	if o.HeaderCase > HeaderCaseUpper {
+		errs = append(errs, ErrInvalidHeaderCase)
+	}
+
+	if err = validateHeader(o.Header); err != nil {
+		errs = append(errs, err)
+	}
+
+	if o.Compression > CompressionGzip {
+		errs = append(errs, ErrInvalidCompression)
+	} else if o.codec() != nil && (o.RowIndexInterval > 0 || o.TailRepair != TailRepairOff) {
+		errs = append(errs, ErrCompressionIncompatible)
+	}
+
+	if (o.Encryption != nil || o.KEK != nil) && (o.RowIndexInterval > 0 || o.TailRepair != TailRepairOff) {
+		errs = append(errs, ErrEncryptionIncompatible)
+	}
+
+	if o.Storage > StorageBinary {
+		errs = append(errs, ErrInvalidStorage)
+	} else if o.Storage != StorageCSV && (o.RowIndexInterval > 0 || o.TailRepair != TailRepairOff) {
+		errs = append(errs, ErrStorageIncompatible)
+	}
+
+	if o.Encryption != nil && o.KEK != nil {
+		errs = append(errs, ErrInvalidKEK)
+	}
+
+	if o.LayoutVersion > latestLayoutVersion {
+		errs = append(errs, ErrInvalidLayoutVersion)
+	}
+
+	if o.BOM > BOMEmit || (o.BOM == BOMEmit && o.Encoding != nil) {
+		errs = append(errs, ErrInvalidBOM)
+	}
+
+	if o.Delimiter != 0 && !validDelimiter(o.Delimiter) {
+		errs = append(errs, ErrInvalidDelimiter)
+	} else if err = o.CSV.validate(o.Delimiter); err != nil {
+		errs = append(errs, err)
+	}
+
+	if o.Checksums > ChecksumsOpen {
+		errs = append(errs, ErrInvalidChecksums)
+	}
+
+	if err := o.validateColumnPolicies(); err != nil {
+		errs = append(errs, err)
+	}
+
+	if o.Codec != nil && (o.Compression != CompressionNone || !validCodecExtension.MatchString(o.Codec.Extension())) {
+		errs = append(errs, ErrInvalidCodec)
+	}
+
+	if o.MaxMemory < 0 {
+		errs = append(errs, ErrInvalidMaxMemory)
+	}
+
 	if o.FileTTL < 0 {
 		errs = append(errs, ErrInvalidFileTTL)
 	}
 
+	if o.LockTimeout < 0 {
+		errs = append(errs, ErrInvalidLockTimeout)
+	}
+
+	if o.BackgroundIOOpsPerSecond < 0 || o.BackgroundIOBytesPerSecond < 0 {
+		errs = append(errs, ErrInvalidBackgroundIO)
+	}
+
+	if o.ReadOnly && (o.SpillToBackend || o.RepairHeaders || o.PersistHistory || o.TailRepair != TailRepairOff || o.ManifestCache || o.IntegrityCheck == IntegrityCheckQuarantine) {
+		errs = append(errs, ErrInvalidReadOnly)
+	}
+
+	if o.WriteAheadLog && o.AtomicAppend {
+		errs = append(errs, ErrInvalidWriteAheadLog)
+	}
+
+	if o.RowIndexInterval < 0 {
+		errs = append(errs, ErrInvalidRowIndexInterval)
+	}
+
+	if o.MaxOpenFiles < 0 {
+		errs = append(errs, ErrInvalidMaxOpenFiles)
+	}
+
+	if err = validateContextColumns(o); err != nil {
+		errs = append(errs, err)
+	}
+
+	if o.ReplicaInterval < 0 || (o.ReplicaInterval > 0 && !o.ReadOnly) {
+		errs = append(errs, ErrInvalidReplicaInterval)
+	}
+
+	if o.HistorySize < 0 {
+		errs = append(errs, ErrInvalidHistorySize)
+	}
+
+	if o.MergedHeaders > MergedHeadersUnion {
+		errs = append(errs, ErrInvalidMergedHeaders)
+	}
+
+	if o.MergeStrategy > MergeCustom {
+		errs = append(errs, ErrInvalidMergeStrategy)
+	} else if o.MergeStrategy == MergeCustom && o.MergeFunc == nil {
+		errs = append(errs, ErrMergeFuncNotSet)
+	}
+
+	if o.Ordering > OrderModTime {
+		errs = append(errs, ErrInvalidOrdering)
+	}
+
+	for i := range o.Policies {
+		if err = o.Policies[i].validate(); err != nil {
+			errs = append(errs, err)
+			break
+		}
+	}
+
 	return errors.Join(errs...)
 }
 
 func (o *Options) fill() {
 	o.Dir = filepath.Clean(o.Dir)
+	if o.TempDir != "" {
+		o.TempDir = filepath.Clean(o.TempDir)
+	}
 
 	if o.ExpiryMonitor == nil {
 		// Set default expiry monitor as a basic expiry monitor
 		o.ExpiryMonitor = basicExpiryMonitor(o.FileTTL)
 	}
 
+	if o.Delimiter == 0 {
+		// Set default delimiter as a comma
+		o.Delimiter = ','
+	}
+
 	if o.PurgeInterval == 0 {
 		// Set default purge interval for an hour
 		o.PurgeInterval = time.Hour
@@ -67,9 +499,50 @@ func (o *Options) fill() {
 		o.ExportInterval = time.Minute * 15
 	}
 
+	if o.StreamBatchSize <= 0 {
+		// Set default stream batch size
+		o.StreamBatchSize = 1000
+	}
+
+	if o.StreamFlushInterval <= 0 {
+		// Set default stream flush interval for a second
+		o.StreamFlushInterval = time.Second
+	}
+
+	if o.WriteBehindBatchSize <= 0 {
+		// Set default write-behind batch size
+		o.WriteBehindBatchSize = 1000
+	}
+
+	if o.WriteBehindInterval <= 0 {
+		// Set default write-behind interval for a second
+		o.WriteBehindInterval = time.Second
+	}
+
+	if o.PreloadWorkers <= 0 {
+		// Set default preload workers
+		o.PreloadWorkers = 4
+	}
+
+	if o.SQLiteDriver == "" {
+		// Set default SQLite driver name
+		o.SQLiteDriver = "sqlite"
+	}
+
 	if o.Logger == nil {
 		o.Logger = log.New(os.Stdout, "csvdb", log.Ldate|log.Ltime)
 	}
 }
 
 type ExpiryMonitor func(filename string, info os.FileInfo) (expired bool)
+
+// Ordering represents the order files are iterated in
+type Ordering uint8
+
+func (o Ordering) less(aName string, a os.FileInfo, bName string, b os.FileInfo) bool {
+	if o == OrderModTime && !a.ModTime().Equal(b.ModTime()) {
+		return a.ModTime().Before(b.ModTime())
+	}
+
+	return aName < bName
+}
@@ -7,9 +7,17 @@ import (
 
 func TestOptions_Validate(t *testing.T) {
 	type fields struct {
-		Name    string
-		Dir     string
-		FileTTL time.Duration
+		Name        string
+		Dir         string
+		FileTTL     time.Duration
+		Ordering    Ordering
+		InMemory    bool
+		Policies    []Policy
+		LockTimeout time.Duration
+		IOOps       int
+		Merge       MergeStrategy
+		ReadOnly    bool
+		Spill       bool
 	}
 
 	type testcase struct {
@@ -46,6 +54,16 @@ func TestOptions_Validate(t *testing.T) {
 			},
 			wantErr: true,
 		},
+		{
+			name: "pass - in memory",
+			fields: fields{
+				Name:     "foo",
+				Dir:      "",
+				FileTTL:  time.Hour,
+				InMemory: true,
+			},
+			wantErr: false,
+		},
 		{
 			name: "fail - fileTTL",
 			fields: fields{
@@ -55,14 +73,78 @@ func TestOptions_Validate(t *testing.T) {
 			},
 			wantErr: true,
 		},
+		{
+			name: "fail - ordering",
+			fields: fields{
+				Name:     "foo",
+				Dir:      "bar",
+				FileTTL:  time.Hour,
+				Ordering: 7,
+			},
+			wantErr: true,
+		},
+		{
+			name: "fail - lockTimeout",
+			fields: fields{
+				Name:        "foo",
+				Dir:         "bar",
+				LockTimeout: -time.Second,
+			},
+			wantErr: true,
+		},
+		{
+			name: "fail - background IO",
+			fields: fields{
+				Name:  "foo",
+				Dir:   "bar",
+				IOOps: -1,
+			},
+			wantErr: true,
+		},
+		{
+			name: "fail - merge func",
+			fields: fields{
+				Name:  "foo",
+				Dir:   "bar",
+				Merge: MergeCustom,
+			},
+			wantErr: true,
+		},
+		{
+			name: "fail - read only",
+			fields: fields{
+				Name:     "foo",
+				Dir:      "bar",
+				ReadOnly: true,
+				Spill:    true,
+			},
+			wantErr: true,
+		},
+		{
+			name: "fail - policy",
+			fields: fields{
+				Name:     "foo",
+				Dir:      "bar",
+				Policies: []Policy{{Prefix: "a", MaxBytes: -1}},
+			},
+			wantErr: true,
+		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			o := &Options{
-				Name:    tt.fields.Name,
-				Dir:     tt.fields.Dir,
-				FileTTL: tt.fields.FileTTL,
+				Name:                     tt.fields.Name,
+				Dir:                      tt.fields.Dir,
+				FileTTL:                  tt.fields.FileTTL,
+				Ordering:                 tt.fields.Ordering,
+				InMemory:                 tt.fields.InMemory,
+				Policies:                 tt.fields.Policies,
+				LockTimeout:              tt.fields.LockTimeout,
+				BackgroundIOOpsPerSecond: tt.fields.IOOps,
+				MergeStrategy:            tt.fields.Merge,
+				ReadOnly:                 tt.fields.ReadOnly,
+				SpillToBackend:           tt.fields.Spill,
 			}
 			if err := o.Validate(); (err != nil) != tt.wantErr {
 				t.Errorf("Options.Validate() error = %v, wantErr %v", err, tt.wantErr)
@@ -0,0 +1,340 @@
+package csvdb
+
+import (
+	"context"
+	"crypto/sha256"
+	"errors"
+	"hash"
+	"io"
+	"os"
+	"strings"
+	"time"
+)
+
+var (
+	// ErrInvalidPolicy is returned when a policy contains a negative value
+	ErrInvalidPolicy = errors.New("invalid policy, fileTTL, exportInterval and maxBytes cannot be less than 0")
+	// ErrQuotaExceeded is returned when appending to a key which has reached the MaxBytes of its policy
+	ErrQuotaExceeded = errors.New("quota exceeded")
+)
+
+// Policy overrides the behavior of the DB for the keys matching its prefix pattern
+type Policy struct {
+	// Prefix is the "/"-delimited prefix pattern of the keys the policy applies to. A segment
+	// of "*" matches any single segment, e.g. "tenants/*/logs". When multiple policies
+	// match a key, the policy with the most segments is used.
+	Prefix string `json:"prefix" toml:"prefix"`
+
+	// FileTTL is the file duration of the matching keys
+	// Note: Takes priority over Options.FileTTL and Options.ExpiryMonitor, 0 is unset
+	FileTTL time.Duration `json:"fileTTL" toml:"file-ttl"`
+	// ExportInterval is the minimum duration between exports of a matching key
+	// Note: 0 will export the key on every export
+	ExportInterval time.Duration `json:"exportInterval" toml:"export-interval"`
+	// Compress will gzip the matching keys when they are exported, the exported
+	// filename is suffixed with ".gz" and downloads are decompressed
+	Compress bool `json:"compress" toml:"compress"`
+	// Redact is the list of columns whose values are cleared when the matching keys
+	// are exported, the local files are unaffected
+	Redact []string `json:"redact" toml:"redact"`
+	// MaxBytes is the maximum file size of a matching key, appends to keys which have
+	// reached it are rejected with ErrQuotaExceeded
+	// Note: 0 is unlimited
+	MaxBytes int64 `json:"maxBytes" toml:"max-bytes"`
+	// RetainRemote will keep the exported files of matching keys within the backend
+	// when their local files are purged
+	// Note: Only applies when Options.PurgeFromBackend is set
+	RetainRemote bool `json:"retainRemote" toml:"retain-remote"`
+	// Header overrides the entry columns of the files of matching keys, see Options.Header
+	// Note: Takes priority over Options.Header
+	Header []string `json:"header" toml:"header"`
+}
+
+func (p *Policy) validate() (err error) {
+	if p.FileTTL < 0 || p.ExportInterval < 0 || p.MaxBytes < 0 {
+		return ErrInvalidPolicy
+	}
+
+	return validateHeader(p.Header)
+}
+
+// match will return the number of segments matched when the policy applies to the key
+func (p *Policy) match(key string) (segments int, ok bool) {
+	prefix := strings.TrimSuffix(p.Prefix, "/")
+	if prefix == "" {
+		return 0, true
+	}
+
+	patterns := strings.Split(prefix, "/")
+	parts := strings.Split(key, "/")
+	if len(parts) < len(patterns) {
+		return
+	}
+
+	for i, pattern := range patterns {
+		if pattern != "*" && pattern != parts[i] {
+			return
+		}
+	}
+
+	return len(patterns), true
+}
+
+// policyFor will return the most specific policy which matches the key
+func (o *Options) policyFor(key string) (p Policy, ok bool) {
+	best := -1
+	for _, policy := range o.Policies {
+		segments, matched := policy.match(key)
+		if !matched || segments <= best {
+			continue
+		}
+
+		best = segments
+		p = policy
+		ok = true
+	}
+
+	return
+}
+
+// isExpired will return whether or not a file has expired, respecting the policy of its key
+func (d *DB[T]) isExpired(filename string, info os.FileInfo) bool {
+	if p, ok := d.o.policyFor(d.getKey(filename)); ok && p.FileTTL > 0 {
+		return isExpiredBasic(p.FileTTL, info)
+	}
+
+	return d.o.ExpiryMonitor(filename, info)
+}
+
+// isExportDue will return whether or not the export interval of the policy of a key has passed
+func (d *DB[T]) isExportDue(filename string, lastExported time.Time) bool {
+	p, ok := d.o.policyFor(d.getKey(filename))
+	if !ok || p.ExportInterval == 0 {
+		return true
+	}
+
+	return time.Since(lastExported) >= p.ExportInterval
+}
+
+// checkQuota must be called while the lock is held, before rows are appended to a key
+func (d *DB[T]) checkQuota(key string) (err error) {
+	p, ok := d.o.policyFor(key)
+	if !ok || p.MaxBytes == 0 {
+		return
+	}
+
+	_, filename := d.getFilename(key)
+	var info os.FileInfo
+	switch info, err = d.fs.Stat(filename); {
+	case os.IsNotExist(err):
+		return nil
+	case err != nil:
+		return
+	case info.Size() >= p.MaxBytes:
+		return ErrQuotaExceeded
+	default:
+		return
+	}
+}
+
+// exportReader will return the reader and filename used to export a file, applying
+// the redaction and compression of the policy of its key, followed by encryption
+func (d *DB[T]) exportReader(filename string, r io.Reader) (rc io.ReadCloser, name string) {
+	rc, name = io.NopCloser(r), d.dataName(filename)
+	p, _ := d.o.policyFor(d.getKey(filename))
+	if len(p.Redact) > 0 {
+		rc = pipe(rc, func(w io.Writer, r io.Reader) error {
+			return redact(w, r, p.Redact, d.dialect)
+		})
+	}
+
+	for _, c := range d.exportCodecs(filename) {
+		c := c
+		rc = pipe(rc, func(w io.Writer, r io.Reader) (err error) {
+			var cw io.WriteCloser
+			if cw, err = c.NewWriter(w); err != nil {
+				return
+			}
+
+			if _, err = io.Copy(cw, r); err != nil {
+				cw.Close()
+				return
+			}
+
+			return cw.Close()
+		})
+	}
+
+	return
+}
+
+// exportName will return the filename a file is exported as
+func (d *DB[T]) exportName(filename string) (name string) {
+	if d.o.ContentAddressed {
+		return filename + ".ref"
+	}
+
+	return d.dataName(filename)
+}
+
+// dataName will return the filename the contents of a file are exported as
+func (d *DB[T]) dataName(filename string) (name string) {
+	return filename + d.exportExt(filename)
+}
+
+// exportExt will return the extensions of the codecs a file is exported with
+func (d *DB[T]) exportExt(filename string) (ext string) {
+	for _, c := range d.exportCodecs(filename) {
+		ext += c.Extension()
+	}
+
+	return
+}
+
+// exportCodecs will return the codecs a file is exported with, in the order they are applied
+func (d *DB[T]) exportCodecs(filename string) (cs []Codec) {
+	if c := d.exportCodec(filename); c != nil {
+		cs = append(cs, c)
+	}
+
+	if c, ok := d.o.encryption(); ok {
+		cs = append(cs, c)
+	}
+
+	return
+}
+
+// exportCodec will return the Codec a file is exported with, nil when exported uncompressed
+func (d *DB[T]) exportCodec(filename string) (c Codec) {
+	if c = d.o.codec(); c != nil {
+		return
+	}
+
+	if p, ok := d.o.policyFor(d.getKey(filename)); d.o.CompressExports || (ok && p.Compress) {
+		return GzipCodec{}
+	}
+
+	return nil
+}
+
+// importFile will import a file from the backend, decrypting and decompressing it when it was
+// exported encrypted or compressed
+func (d *DB[T]) importFile(ctx context.Context, name string, w io.Writer) (err error) {
+	ctx = withKey(ctx, d.getKey(name))
+	remote := d.dataName(name)
+	if d.o.ContentAddressed {
+		if remote, err = d.resolveRef(ctx, name); err != nil {
+			return
+		}
+	}
+
+	var (
+		h    hash.Hash
+		ierr error
+	)
+
+	if d.o.Checksums != ChecksumsOff {
+		// The downloaded bytes are verified once downloaded, a mismatch takes precedence
+		// over any error decoding them
+		h = sha256.New()
+		defer func() {
+			if ierr != nil {
+				return
+			}
+
+			if verr := d.verifyDownload(ctx, name, remote, h); verr != nil {
+				err = verr
+			}
+		}()
+	}
+
+	cs := d.exportCodecs(name)
+	if ext := d.exportExt(name); ext == "" || !strings.HasSuffix(remote, ext) {
+		ierr = d.ib.Import(d.request(ctx, OpImport), d.remotePrefixOf(name), remote, hashWriter(w, h))
+		return ierr
+	}
+
+	pr, pw := io.Pipe()
+	done := make(chan error, 1)
+	go func() {
+		done <- decode(w, pr, cs)
+	}()
+
+	ierr = d.ib.Import(d.request(ctx, OpImport), d.remotePrefixOf(name), remote, hashWriter(pw, h))
+	pw.CloseWithError(ierr)
+	err = ierr
+	if derr := <-done; err == nil {
+		err = derr
+	}
+
+	return
+}
+
+// decode will write the contents of a reader decoded by the provided codecs, which are
+// undone in reverse order. The reader is closed with the error of decoding.
+func decode(w io.Writer, pr *io.PipeReader, cs []Codec) (err error) {
+	defer func() { pr.CloseWithError(err) }()
+	r := io.Reader(pr)
+	for i := len(cs) - 1; i >= 0; i-- {
+		var rc io.ReadCloser
+		if rc, err = cs[i].NewReader(r); err != nil {
+			return
+		}
+		defer rc.Close()
+		r = rc
+	}
+
+	_, err = io.Copy(w, r)
+	return
+}
+
+// pipe will return a reader of the output of the provided func. The source is closed once
+// the func has completed, closing the returned reader will stop the func.
+func pipe(src io.ReadCloser, fn func(w io.Writer, r io.Reader) error) io.ReadCloser {
+	pr, pw := io.Pipe()
+	go func() {
+		err := fn(pw, src)
+		src.Close()
+		pw.CloseWithError(err)
+	}()
+
+	return pr
+}
+
+// redact will copy CSV rows from r to w, clearing the values of the provided columns
+func redact(w io.Writer, r io.Reader, columns []string, dialect csvDialect) (err error) {
+	cr := newCSVReader(r, dialect)
+	cr.FieldsPerRecord = -1
+	cw := newCSVWriter(w, dialect)
+
+	var indexes []int
+	for first := true; ; first = false {
+		var values []string
+		switch values, err = cr.Read(); err {
+		case nil:
+		case io.EOF:
+			cw.Flush()
+			return cw.Error()
+		default:
+			return
+		}
+
+		if first {
+			for _, column := range columns {
+				if i := indexOf(values, column); i != -1 {
+					indexes = append(indexes, i)
+				}
+			}
+		} else {
+			for _, i := range indexes {
+				if i < len(values) {
+					values[i] = ""
+				}
+			}
+		}
+
+		if err = cw.Write(values); err != nil {
+			return
+		}
+	}
+}
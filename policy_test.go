@@ -0,0 +1,273 @@
+package csvdb
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestOptions_policyFor(t *testing.T) {
+	o := Options{
+		Policies: []Policy{
+			{Prefix: "tenants", FileTTL: time.Hour},
+			{Prefix: "tenants/*/logs", FileTTL: time.Minute},
+			{Prefix: "tenants/acme/", FileTTL: time.Second},
+		},
+	}
+
+	tests := []struct {
+		name   string
+		key    string
+		want   time.Duration
+		wantOK bool
+	}{
+		{
+			name:   "top level",
+			key:    "tenants/foo/events",
+			want:   time.Hour,
+			wantOK: true,
+		},
+		{
+			name:   "wildcard",
+			key:    "tenants/foo/logs/2024",
+			want:   time.Minute,
+			wantOK: true,
+		},
+		{
+			name:   "most segments wins",
+			key:    "tenants/acme/logs",
+			want:   time.Minute,
+			wantOK: true,
+		},
+		{
+			name:   "exact segment",
+			key:    "tenants/acme/events",
+			want:   time.Second,
+			wantOK: true,
+		},
+		{
+			name: "partial segment",
+			key:  "tenantsfoo",
+		},
+		{
+			name: "no match",
+			key:  "users/foo",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, ok := o.policyFor(tt.key)
+			if ok != tt.wantOK {
+				t.Fatalf("Options.policyFor() ok = %v, want %v", ok, tt.wantOK)
+			}
+
+			if p.FileTTL != tt.want {
+				t.Errorf("Options.policyFor() fileTTL = %v, want %v", p.FileTTL, tt.want)
+			}
+		})
+	}
+}
+
+func TestDB_policies(t *testing.T) {
+	type testcase struct {
+		name            string
+		policy          Policy
+		compressExports bool
+		key             string
+
+		wantExportName string
+		wantExported   string
+		wantAppendErr  error
+		wantExpired    bool
+		wantExportable int
+	}
+
+	tests := []testcase{
+		{
+			name:           "no policy",
+			policy:         Policy{Prefix: "other"},
+			key:            "a/1",
+			wantExportName: "foo.a%2F1.csv",
+			wantExported:   "foo,bar\n1,1b\n",
+			wantExportable: 1,
+		},
+		{
+			name:           "redact",
+			policy:         Policy{Prefix: "a", Redact: []string{"bar", "missing"}},
+			key:            "a/1",
+			wantExportName: "foo.a%2F1.csv",
+			wantExported:   "foo,bar\n1,\n",
+			wantExportable: 1,
+		},
+		{
+			name:           "compress",
+			policy:         Policy{Prefix: "a", Compress: true},
+			key:            "a/1",
+			wantExportName: "foo.a%2F1.csv.gz",
+			wantExported:   "foo,bar\n1,1b\n",
+			wantExportable: 1,
+		},
+		{
+			name:            "compress exports",
+			policy:          Policy{Prefix: "other"},
+			compressExports: true,
+			key:             "a/1",
+			wantExportName:  "foo.a%2F1.csv.gz",
+			wantExported:    "foo,bar\n1,1b\n",
+			wantExportable:  1,
+		},
+		{
+			name:          "quota",
+			policy:        Policy{Prefix: "a", MaxBytes: 1},
+			key:           "a/1",
+			wantAppendErr: ErrQuotaExceeded,
+		},
+		{
+			name:           "file ttl",
+			policy:         Policy{Prefix: "a", FileTTL: time.Nanosecond},
+			key:            "a/1",
+			wantExpired:    true,
+			wantExportable: 1,
+		},
+		{
+			name:   "export interval",
+			policy: Policy{Prefix: "a", ExportInterval: time.Hour},
+			key:    "a/1",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var (
+				exportName string
+				exported   []byte
+			)
+
+			b := &mockBackend{
+				exportFn: func(ctx context.Context, prefix, filename string, r io.Reader) (string, error) {
+					bs, err := io.ReadAll(r)
+					exportName = filename
+					exported = bs
+					return filename, err
+				},
+				importFn: func(ctx context.Context, prefix, filename string, w io.Writer) (err error) {
+					if filename != exportName {
+						return os.ErrNotExist
+					}
+
+					_, err = w.Write(exported)
+					return
+				},
+			}
+
+			var opts Options
+			opts.Dir = fmt.Sprintf("test_%d", time.Now().UnixNano())
+			opts.Name = "foo"
+			opts.Policies = []Policy{tt.policy}
+			opts.CompressExports = tt.compressExports
+			d, err := makeDB[testentry](opts, b)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer os.RemoveAll(d.o.Dir)
+
+			if err = d.Append(tt.key, testentry{Foo: "1", Bar: "1b"}); err != nil {
+				t.Fatal(err)
+			}
+
+			if err = d.Append(tt.key, testentry{Foo: "2", Bar: "2b"}); err != tt.wantAppendErr {
+				t.Fatalf("DB.Append() error = %v, wantErr %v", err, tt.wantAppendErr)
+			} else if err != nil {
+				return
+			}
+
+			if tt.wantExportable == 0 {
+				// Mark the key as recently exported
+				name, _ := d.getFilename(tt.key)
+				if err = d.setLastExported(name); err != nil {
+					t.Fatal(err)
+				}
+
+				time.Sleep(time.Millisecond)
+				if err = d.Append(tt.key, testentry{Foo: "3", Bar: "3b"}); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			var exportable []string
+			if exportable, err = d.getExportable(""); err != nil {
+				t.Fatal(err)
+			}
+
+			if len(exportable) != tt.wantExportable {
+				t.Fatalf("DB.getExportable() = %v, want %d keys", exportable, tt.wantExportable)
+			}
+
+			var expired []string
+			if expired, err = d.getExpired(""); err != nil {
+				t.Fatal(err)
+			}
+
+			if (len(expired) > 0) != tt.wantExpired {
+				t.Errorf("DB.getExpired() = %v, wantExpired %v", expired, tt.wantExpired)
+			}
+
+			if tt.wantExported == "" {
+				return
+			}
+
+			// Only export the first row to simplify comparisons
+			if err = d.Truncate(tt.key); err != nil {
+				t.Fatal(err)
+			}
+
+			if err = d.Append(tt.key, testentry{Foo: "1", Bar: "1b"}); err != nil {
+				t.Fatal(err)
+			}
+
+			if err = d.exportAll(context.Background(), exportable); err != nil {
+				t.Fatal(err)
+			}
+
+			if exportName != tt.wantExportName {
+				t.Errorf("DB.export() name = %v, want %v", exportName, tt.wantExportName)
+			}
+
+			got := exported
+			if tt.policy.Compress || tt.compressExports {
+				var gz *gzip.Reader
+				if gz, err = gzip.NewReader(bytes.NewReader(exported)); err != nil {
+					t.Fatal(err)
+				}
+
+				if got, err = io.ReadAll(gz); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			if string(got) != tt.wantExported {
+				t.Errorf("DB.export() = %q, want %q", got, tt.wantExported)
+			}
+
+			// Ensure downloads are decompressed
+			if err = d.Delete(tt.key); err != nil {
+				t.Fatal(err)
+			}
+
+			w := &bytes.Buffer{}
+			if err = d.Get(w, tt.key); err != nil {
+				t.Fatal(err)
+			}
+
+			if w.String() != tt.wantExported {
+				t.Errorf("DB.Get() = %q, want %q", w.String(), tt.wantExported)
+			}
+		})
+	}
+}
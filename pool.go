@@ -0,0 +1,44 @@
+package csvdb
+
+import (
+	"bufio"
+	"encoding/csv"
+	"io"
+	"sync"
+)
+
+// bufWriters and bufReaders pool the buffers of csv.Writers and csv.Readers. The csv
+// package uses a provided bufio type directly when its buffer is large enough, so the
+// pooled buffers are sized to its default.
+var (
+	bufWriters = sync.Pool{New: func() any { return bufio.NewWriter(nil) }}
+	bufReaders = sync.Pool{New: func() any { return bufio.NewReader(nil) }}
+)
+
+// getCSVWriter will return a csv.Writer of the provided dialect using a pooled buffer. The
+// buffer must be returned with putBufWriter once the writer is no longer used.
+func getCSVWriter(w io.Writer, dialect csvDialect) (cw *csv.Writer, bw *bufio.Writer) {
+	bw = bufWriters.Get().(*bufio.Writer)
+	bw.Reset(w)
+	return newCSVWriter(bw, dialect), bw
+}
+
+// putBufWriter will return the buffer of a csv.Writer to the pool
+func putBufWriter(bw *bufio.Writer) {
+	bw.Reset(nil)
+	bufWriters.Put(bw)
+}
+
+// getCSVReader will return a csv.Reader of the provided dialect using a pooled buffer. The
+// buffer must be returned with putBufReader once the reader is no longer used.
+func getCSVReader(r io.Reader, dialect csvDialect) (cr *csv.Reader, br *bufio.Reader) {
+	br = bufReaders.Get().(*bufio.Reader)
+	br.Reset(r)
+	return newCSVReader(br, dialect), br
+}
+
+// putBufReader will return the buffer of a csv.Reader to the pool
+func putBufReader(br *bufio.Reader) {
+	br.Reset(nil)
+	bufReaders.Put(br)
+}
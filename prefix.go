@@ -0,0 +1,147 @@
+package csvdb
+
+import (
+	"context"
+	"os"
+	"strings"
+	"time"
+)
+
+// PrefixStats are the statistics of the local keys within a prefix
+type PrefixStats struct {
+	// Keys is the number of keys within the prefix
+	Keys int
+	// Size is the combined size of the keys in bytes
+	Size int64
+	// Oldest is the oldest modification time of the keys
+	Oldest time.Time
+	// Newest is the newest modification time of the keys
+	Newest time.Time
+}
+
+// PurgePrefix will remove the expired keys within the provided "/"-delimited prefix.
+// A prefix of "a/b" matches the key "a/b" and keys such as "a/b/c", but not "a/bc".
+func (d *DB[T]) PurgePrefix(prefix string) (err error) {
+	if err = d.checkWritable(); err != nil {
+		return
+	}
+
+	if d.closed.Load() {
+		return ErrClosed
+	}
+
+	return d.purge(prefix)
+}
+
+// ExportPrefix will export the keys within the provided "/"-delimited prefix
+// which have been modified since they were last exported
+func (d *DB[T]) ExportPrefix(prefix string) (err error) {
+	return d.ExportPrefixContext(context.Background(), prefix)
+}
+
+// ExportPrefixContext is the context-aware variant of ExportPrefix
+func (d *DB[T]) ExportPrefixContext(ctx context.Context, prefix string) (err error) {
+	if err = d.checkWritable(); err != nil {
+		return
+	}
+
+	if d.closed.Load() {
+		return ErrClosed
+	}
+
+	return d.backup(ctx, prefix, false)
+}
+
+// DeletePrefix will delete all the local keys within the provided "/"-delimited prefix. Each key
+// is deleted as it is by Delete, so its exported file is also deleted when DeleteFromBackend is set.
+func (d *DB[T]) DeletePrefix(prefix string) (err error) {
+	return d.DeletePrefixContext(context.Background(), prefix)
+}
+
+// DeletePrefixContext is the context-aware variant of DeletePrefix
+func (d *DB[T]) DeletePrefixContext(ctx context.Context, prefix string) (err error) {
+	if err = d.checkWritable(); err != nil {
+		return
+	}
+
+	// Prevent exports from reading the files while they are being removed
+	if err = d.lockWith(ctx, &d.emux, &d.mux); err != nil {
+		return
+	}
+	defer d.emux.Unlock()
+	defer d.mux.Unlock()
+
+	var filenames []string
+	if err = d.forEachWithin(prefix, func(filename string, info os.FileInfo) (err error) {
+		filenames = append(filenames, filename)
+		// Nothing is deleted while any key is under legal hold
+		return d.checkHold(d.getKey(filename))
+	}); err != nil {
+		return
+	}
+
+	for _, filename := range filenames {
+		if err = ctx.Err(); err != nil {
+			return
+		}
+
+		// The lock of the DB is held exclusively, so the locks of the keys are not needed
+		if err = d.deleteKey(ctx, d.getKey(filename)); err != nil {
+			return
+		}
+	}
+
+	return nil
+}
+
+// StatsForPrefix will return the statistics of the local keys within the provided "/"-delimited prefix
+func (d *DB[T]) StatsForPrefix(prefix string) (s PrefixStats, err error) {
+	return d.StatsForPrefixContext(context.Background(), prefix)
+}
+
+// StatsForPrefixContext is the context-aware variant of StatsForPrefix
+func (d *DB[T]) StatsForPrefixContext(ctx context.Context, prefix string) (s PrefixStats, err error) {
+	if err = d.lock(ctx); err != nil {
+		return
+	}
+	defer d.mux.Unlock()
+
+	err = d.forEachWithin(prefix, func(filename string, info os.FileInfo) (err error) {
+		s.Keys++
+		s.Size += info.Size()
+		modTime := info.ModTime()
+		if s.Oldest.IsZero() || modTime.Before(s.Oldest) {
+			s.Oldest = modTime
+		}
+
+		if modTime.After(s.Newest) {
+			s.Newest = modTime
+		}
+
+		return
+	})
+
+	return
+}
+
+// forEachWithin will iterate through the files whose keys are within the provided prefix
+func (d *DB[T]) forEachWithin(prefix string, fn func(filename string, info os.FileInfo) error) (err error) {
+	return d.forEach(func(filename string, info os.FileInfo) (err error) {
+		if !hasKeyPrefix(d.getKey(filename), prefix) {
+			return
+		}
+
+		return fn(filename, info)
+	})
+}
+
+// hasKeyPrefix will return whether or not a key is within a "/"-delimited prefix.
+// An empty prefix matches all keys.
+func hasKeyPrefix(key, prefix string) bool {
+	prefix = strings.TrimSuffix(prefix, "/")
+	if prefix == "" {
+		return true
+	}
+
+	return key == prefix || strings.HasPrefix(key, prefix+"/")
+}
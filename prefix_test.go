@@ -0,0 +1,199 @@
+package csvdb
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+)
+
+func Test_hasKeyPrefix(t *testing.T) {
+	type args struct {
+		key    string
+		prefix string
+	}
+
+	tests := []struct {
+		name string
+		args args
+		want bool
+	}{
+		{
+			name: "empty prefix",
+			args: args{key: "a/b", prefix: ""},
+			want: true,
+		},
+		{
+			name: "exact",
+			args: args{key: "a/b", prefix: "a/b"},
+			want: true,
+		},
+		{
+			name: "child",
+			args: args{key: "a/b/c", prefix: "a/b"},
+			want: true,
+		},
+		{
+			name: "trailing delimiter",
+			args: args{key: "a/b/c", prefix: "a/b/"},
+			want: true,
+		},
+		{
+			name: "sibling",
+			args: args{key: "a/bc", prefix: "a/b"},
+			want: false,
+		},
+		{
+			name: "parent",
+			args: args{key: "a", prefix: "a/b"},
+			want: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := hasKeyPrefix(tt.args.key, tt.args.prefix); got != tt.want {
+				t.Errorf("hasKeyPrefix() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDB_prefixOperations(t *testing.T) {
+	type testcase struct {
+		name      string
+		prefix    string
+		wantKeys  int
+		remaining []string
+	}
+
+	tests := []testcase{
+		{
+			name:      "tenant",
+			prefix:    "tenant_1",
+			wantKeys:  2,
+			remaining: []string{"tenant_10/2024", "tenant_2/2024"},
+		},
+		{
+			name:      "date",
+			prefix:    "tenant_1/2024",
+			wantKeys:  1,
+			remaining: []string{"tenant_1/2023", "tenant_10/2024", "tenant_2/2024"},
+		},
+		{
+			name:     "all",
+			prefix:   "",
+			wantKeys: 4,
+		},
+	}
+
+	keys := []string{"tenant_1/2023", "tenant_1/2024", "tenant_10/2024", "tenant_2/2024"}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var exported []string
+			b := &mockBackend{
+				exportFn: func(ctx context.Context, prefix, filename string, r io.Reader) (string, error) {
+					exported = append(exported, filename)
+					return filename, nil
+				},
+			}
+
+			var opts Options
+			opts.Dir = fmt.Sprintf("test_%d", time.Now().UnixNano())
+			opts.Name = "foo"
+			opts.ExpiryMonitor = func(filepath string, info os.FileInfo) bool { return true }
+			opts.HistorySize = 8
+			d, err := makeDB[testentry](opts, b)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer os.RemoveAll(d.o.Dir)
+
+			for _, key := range keys {
+				if err = d.Append(key, testentry{Foo: "1", Bar: "1b"}); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			var s PrefixStats
+			if s, err = d.StatsForPrefix(tt.prefix); err != nil {
+				t.Fatal(err)
+			}
+
+			if s.Keys != tt.wantKeys {
+				t.Errorf("DB.StatsForPrefix() keys = %v, want %v", s.Keys, tt.wantKeys)
+			}
+
+			if want := int64(tt.wantKeys * len("foo,bar\n1,1b\n")); s.Size != want {
+				t.Errorf("DB.StatsForPrefix() size = %v, want %v", s.Size, want)
+			}
+
+			if s.Oldest.After(s.Newest) {
+				t.Errorf("DB.StatsForPrefix() oldest %v is after newest %v", s.Oldest, s.Newest)
+			}
+
+			if err = d.ExportPrefix(tt.prefix); err != nil {
+				t.Fatal(err)
+			}
+
+			if len(exported) != tt.wantKeys {
+				t.Errorf("DB.ExportPrefix() exported = %v, want %d keys", exported, tt.wantKeys)
+			}
+
+			for _, key := range keys {
+				if err = d.MarkConsumed(key, time.Now()); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			if err = d.DeletePrefixContext(context.Background(), tt.prefix); err != nil {
+				t.Fatal(err)
+			}
+
+			for _, key := range keys {
+				if !hasKeyPrefix(key, tt.prefix) {
+					continue
+				}
+
+				// No side files of the deleted keys remain
+				_, filename := d.getFilename(key)
+				for _, name := range []string{filename, filename + ".exported", filename + consumedExt} {
+					if _, err = os.Stat(name); !os.IsNotExist(err) {
+						t.Errorf("DB.DeletePrefix() left <%s>, error = %v", name, err)
+					}
+				}
+
+				if events := d.History(key); len(events) == 0 || events[len(events)-1].Type != EventDeleted {
+					t.Errorf("DB.History(%s) = %v, want the last event to be %v", key, events, EventDeleted)
+				}
+			}
+
+			var remaining []string
+			d.IterKeys("")(func(key string) bool {
+				remaining = append(remaining, key)
+				return true
+			})
+
+			sort.Strings(remaining)
+			if !reflect.DeepEqual(remaining, tt.remaining) {
+				t.Errorf("DB.DeletePrefix() remaining = %v, want %v", remaining, tt.remaining)
+			}
+
+			if err = d.PurgePrefix(""); err != nil {
+				t.Fatal(err)
+			}
+
+			if s, err = d.StatsForPrefix(""); err != nil {
+				t.Fatal(err)
+			}
+
+			if s.Keys != 0 {
+				t.Errorf("DB.PurgePrefix() remaining keys = %v, want 0", s.Keys)
+			}
+		})
+	}
+}
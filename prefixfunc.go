@@ -0,0 +1,28 @@
+package csvdb
+
+import (
+	"errors"
+	"path/filepath"
+)
+
+// ErrRenameAcrossPrefixes is returned when remotely renaming a key to a key with a different backend prefix
+var ErrRenameAcrossPrefixes = errors.New("cannot rename remotely, keys have different backend prefixes")
+
+// PrefixFunc will return the backend prefix the files of a key are exported under, allowing
+// keys (e.g. of different tenants) to land under different remote prefixes
+type PrefixFunc func(key string) (prefix string)
+
+// remotePrefix will return the backend prefix of a key, which is the name of the DB unless
+// Options.PrefixFunc is set
+func (d *DB[T]) remotePrefix(key string) string {
+	if d.o.PrefixFunc == nil {
+		return d.o.Name
+	}
+
+	return d.o.PrefixFunc(key)
+}
+
+// remotePrefixOf will return the backend prefix of the key of a file
+func (d *DB[T]) remotePrefixOf(filename string) string {
+	return d.remotePrefix(d.getKey(filepath.Base(filename)))
+}
@@ -0,0 +1,142 @@
+package csvdb
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestDB_PrefixFunc(t *testing.T) {
+	type testcase struct {
+		name       string
+		prefixFunc PrefixFunc
+		want       map[string]string
+	}
+
+	tests := []testcase{
+		{
+			name: "default",
+			want: map[string]string{"foo.tenant1%2Fa.csv": "foo", "foo.tenant2%2Fb.csv": "foo"},
+		},
+		{
+			name: "per tenant",
+			prefixFunc: func(key string) string {
+				tenant, _, _ := strings.Cut(key, "/")
+				return "tenants/" + tenant
+			},
+			want: map[string]string{"foo.tenant1%2Fa.csv": "tenants/tenant1", "foo.tenant2%2Fb.csv": "tenants/tenant2"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var (
+				mux      sync.Mutex
+				exported = make(map[string]string)
+				contents = make(map[string][]byte)
+			)
+
+			b := &mockBackend{
+				exportFn: func(ctx context.Context, prefix, filename string, r io.Reader) (string, error) {
+					bs, err := io.ReadAll(r)
+					mux.Lock()
+					defer mux.Unlock()
+					exported[filename] = prefix
+					contents[prefix+"/"+filename] = bs
+					return filename, err
+				},
+				importFn: func(ctx context.Context, prefix, filename string, w io.Writer) error {
+					mux.Lock()
+					bs, ok := contents[prefix+"/"+filename]
+					mux.Unlock()
+					if !ok {
+						return os.ErrNotExist
+					}
+
+					_, err := w.Write(bs)
+					return err
+				},
+			}
+
+			var opts Options
+			opts.Dir = fmt.Sprintf("test_%d", time.Now().UnixNano())
+			opts.Name = "foo"
+			opts.PrefixFunc = tt.prefixFunc
+			d, err := makeDB[testentry](opts, b)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer os.RemoveAll(opts.Dir)
+
+			for _, key := range []string{"tenant1/a", "tenant2/b"} {
+				if err = d.Append(key, testentry{Foo: "1", Bar: "1b"}); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			if err = d.ExportPrefix(""); err != nil {
+				t.Fatal(err)
+			}
+
+			for filename, prefix := range tt.want {
+				if exported[filename] != prefix {
+					t.Errorf("exported prefix of <%s> = %q, want %q", filename, exported[filename], prefix)
+				}
+			}
+
+			// Keys are downloaded from their own prefix
+			if err = d.Delete("tenant2/b"); err != nil {
+				t.Fatal(err)
+			}
+
+			w := &bytes.Buffer{}
+			if err = d.Get(w, "tenant2/b"); err != nil {
+				t.Fatal(err)
+			}
+
+			if want := "foo,bar\n1,1b\n"; w.String() != want {
+				t.Errorf("DB.Get() = %q, want %q", w.String(), want)
+			}
+		})
+	}
+}
+
+func TestDB_Rename_acrossPrefixes(t *testing.T) {
+	var opts Options
+	opts.Dir = fmt.Sprintf("test_%d", time.Now().UnixNano())
+	opts.Name = "foo"
+	opts.PrefixFunc = func(key string) string {
+		tenant, _, _ := strings.Cut(key, "/")
+		return tenant
+	}
+
+	b := &mockRenamerBackend{}
+	d, err := makeDB[testentry](opts, b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(opts.Dir)
+
+	if err = d.Append("tenant1/a", testentry{Foo: "1", Bar: "1b"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err = d.Rename("tenant1/a", "tenant2/a", true); !errors.Is(err, ErrRenameAcrossPrefixes) {
+		t.Fatalf("DB.Rename() error = %v, wantErr %v", err, ErrRenameAcrossPrefixes)
+	}
+
+	if err = d.Rename("tenant1/a", "tenant1/b", true); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(b.renamed) != 1 {
+		t.Errorf("renamed = %v, want a single rename", b.renamed)
+	}
+}
@@ -0,0 +1,258 @@
+package csvdb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+// Preload will download the provided keys from the backend using up to PreloadWorkers
+// concurrent downloads, warming the local cache ahead of the first reads. Keys which are
+// quarantined or do not exist on the backend are skipped. Keys which are held locally are
+// skipped unless they are to be merged, see Options.MergeStrategy.
+func (d *DB[T]) Preload(keys ...string) (err error) {
+	return d.PreloadContext(context.Background(), keys...)
+}
+
+// PreloadContext is the context-aware variant of Preload
+func (d *DB[T]) PreloadContext(ctx context.Context, keys ...string) (err error) {
+	if d.ib == nil {
+		return ErrBackendNotSet
+	}
+
+	var (
+		mux  sync.Mutex
+		wg   sync.WaitGroup
+		errs []error
+	)
+
+	queue := make(chan string)
+	for i := 0; i < d.o.PreloadWorkers && i < len(keys); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for key := range queue {
+				if err := d.preload(ctx, key); err != nil {
+					mux.Lock()
+					errs = append(errs, fmt.Errorf("error preloading <%s>: %w", key, err))
+					mux.Unlock()
+				}
+			}
+		}()
+	}
+
+	for _, key := range keys {
+		select {
+		case queue <- key:
+		case <-ctx.Done():
+		}
+	}
+
+	close(queue)
+	wg.Wait()
+	if err = ctx.Err(); err != nil {
+		return
+	}
+
+	return errors.Join(errs...)
+}
+
+// preload will download a key into a temporary file without holding the lock, the file
+// is then moved into place, or merged with the local copy when the key is held locally
+func (d *DB[T]) preload(ctx context.Context, key string) (err error) {
+	var ok bool
+	if ok, err = d.needsPreload(ctx, key); err != nil || !ok {
+		return
+	}
+
+	name, filename := d.getFilename(key)
+	var tmp file
+	if tmp, err = createTemp(d.fs, d.o.TempDir, filename); err != nil {
+		return
+	}
+	defer d.fs.Remove(tmp.Name())
+
+	start := time.Now()
+	err = d.importFile(ctx, name, tmp)
+	d.downloads.Add(1)
+	d.downloadTime.Add(int64(time.Since(start)))
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+
+	switch {
+	case err == nil:
+	case os.IsNotExist(err):
+		return nil
+	default:
+		return
+	}
+
+	// Hydrating keys is permitted while ReadOnly is set, so the lock is acquired directly
+	var unlock func()
+	if unlock, err = d.lockKeysWith(ctx, false, []string{key}); err != nil {
+		return
+	}
+	defer unlock()
+
+	switch _, err = d.fs.Stat(filename); {
+	case err == nil:
+		// Key exists locally, reconcile the local copy with the download
+		return d.merge(ctx, key, tmp.Name())
+	case !os.IsNotExist(err):
+		return
+	}
+
+	if err = d.fs.Rename(tmp.Name(), filename); err != nil {
+		return
+	}
+
+	// The local copy matches the backend, it does not need to be exported
+	return d.setSynced(name)
+}
+
+func (d *DB[T]) needsPreload(ctx context.Context, key string) (ok bool, err error) {
+	var unlock func()
+	if unlock, err = d.rlockKey(ctx, key); err != nil {
+		return
+	}
+	defer unlock()
+
+	if _, quarantined := d.quarantined[key]; quarantined {
+		return
+	}
+
+	_, filename := d.getFilename(key)
+	switch _, err = d.fs.Stat(filename); {
+	case err == nil:
+		// Keys held locally are only downloaded when they are to be merged
+		return d.o.MergeStrategy != MergeKeepLocal, nil
+	case os.IsNotExist(err):
+		return true, nil
+	default:
+		return
+	}
+}
+
+// prefetcher will download keys ahead of an ordered reader, holding at most PreloadWorkers
+// keys which have been fetched but not yet read. No locks are held while fetched keys wait
+// to be read, so readers acquiring the locks in order never deadlock with prefetches.
+type prefetcher[T Entry] struct {
+	d    *DB[T]
+	keys []string
+
+	cancel  func()
+	wg      sync.WaitGroup
+	slots   chan struct{}
+	fetched []chan fetched
+	read    int
+}
+
+// fetched is the result of prefetching a key
+type fetched struct {
+	// release will remove the download of the key, see trackHydration
+	release func()
+	// skip is set when the key does not exist and is to be skipped by the reader
+	skip bool
+}
+
+// prefetchAll will begin downloading the provided keys concurrently, see prefetcher
+func (d *DB[T]) prefetchAll(ctx context.Context, keys []string) (p *prefetcher[T]) {
+	p = &prefetcher[T]{
+		d:       d,
+		keys:    keys,
+		slots:   make(chan struct{}, d.o.PreloadWorkers),
+		fetched: make([]chan fetched, len(keys)),
+	}
+
+	for i := range p.fetched {
+		p.fetched[i] = make(chan fetched, 1)
+	}
+
+	ctx, p.cancel = context.WithCancel(ctx)
+	p.wg.Add(1)
+	go func() {
+		defer p.wg.Done()
+		for i, key := range keys {
+			select {
+			case p.slots <- struct{}{}:
+			case <-ctx.Done():
+				return
+			}
+
+			p.wg.Add(1)
+			go func(i int, key string) {
+				defer p.wg.Done()
+				p.fetched[i] <- d.prefetch(ctx, key)
+			}(i, key)
+		}
+	}()
+
+	return
+}
+
+// next will wait for the key at the provided index to be fetched. The release func of the
+// result must be called while the lock of the key is held.
+func (p *prefetcher[T]) next(ctx context.Context, i int) (f fetched, err error) {
+	select {
+	case f = <-p.fetched[i]:
+	case <-ctx.Done():
+		return f, ctx.Err()
+	}
+
+	p.read = i + 1
+	<-p.slots
+	return
+}
+
+// close will stop prefetching and release the downloads of keys which were not read
+func (p *prefetcher[T]) close() {
+	p.cancel()
+	p.wg.Wait()
+	for i := p.read; i < len(p.keys); i++ {
+		select {
+		case f := <-p.fetched[i]:
+			p.d.unhydrate(p.keys[i], f.release)
+		default:
+		}
+	}
+}
+
+// prefetch will download a key when it is not held locally. Keys which cannot be read are
+// marked to be skipped, other errors are ignored as they are returned once the key is read.
+func (d *DB[T]) prefetch(ctx context.Context, key string) (out fetched) {
+	out.release = func() {}
+	unlock, err := d.rlockKey(ctx, key)
+	if err != nil {
+		return
+	}
+	defer unlock()
+
+	out.release = d.trackHydration(key)
+	var f file
+	switch f, err = d.getOrDownload(ctx, key); err {
+	case nil:
+		f.Close()
+	case ErrEntryNotFound, ErrBackendNotSet, ErrEntryQuarantined:
+		out.skip = true
+	}
+
+	return
+}
+
+// unhydrate will acquire the lock of a key to release a download made by prefetch
+func (d *DB[T]) unhydrate(key string, release func()) {
+	if !d.o.SpillToBackend {
+		return
+	}
+
+	unlock, err := d.rlockKey(context.Background(), key)
+	if err != nil {
+		return
+	}
+	defer unlock()
+	release()
+}
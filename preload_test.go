@@ -0,0 +1,250 @@
+package csvdb
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestDB_Preload(t *testing.T) {
+	type testcase struct {
+		name      string
+		workers   int
+		keys      []string
+		importErr error
+
+		wantErr         bool
+		wantDownloads   uint64
+		wantConcurrency int
+	}
+
+	tests := []testcase{
+		{
+			name:            "basic",
+			workers:         2,
+			keys:            []string{"a", "b", "c", "d", "missing"},
+			wantDownloads:   5,
+			wantConcurrency: 2,
+		},
+		{
+			name:            "single worker",
+			workers:         1,
+			keys:            []string{"a", "c"},
+			wantDownloads:   2,
+			wantConcurrency: 1,
+		},
+		{
+			name:            "skips local keys",
+			workers:         4,
+			keys:            []string{"local"},
+			wantDownloads:   0,
+			wantConcurrency: 0,
+		},
+		{
+			name:            "import error",
+			workers:         2,
+			keys:            []string{"a", "b"},
+			importErr:       errors.New("connection reset"),
+			wantErr:         true,
+			wantDownloads:   2,
+			wantConcurrency: 2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var (
+				mux         sync.Mutex
+				active      int
+				concurrency int
+			)
+
+			b := &mockBackend{
+				importFn: func(ctx context.Context, prefix, filename string, w io.Writer) (err error) {
+					mux.Lock()
+					active++
+					if active > concurrency {
+						concurrency = active
+					}
+					mux.Unlock()
+
+					time.Sleep(10 * time.Millisecond)
+
+					mux.Lock()
+					active--
+					mux.Unlock()
+
+					if tt.importErr != nil {
+						return tt.importErr
+					}
+
+					if filename == "foo.missing.csv" {
+						return os.ErrNotExist
+					}
+
+					_, err = w.Write([]byte("foo,bar\nremote,remote\n"))
+					return
+				},
+			}
+
+			var opts Options
+			opts.Dir = fmt.Sprintf("test_%d", time.Now().UnixNano())
+			opts.Name = "foo"
+			opts.PreloadWorkers = tt.workers
+			d, err := makeDB[testentry](opts, b)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer os.RemoveAll(d.o.Dir)
+
+			if err = d.Append("local", testentry{Foo: "1", Bar: "1b"}); err != nil {
+				t.Fatal(err)
+			}
+
+			if err = d.Preload(tt.keys...); (err != nil) != tt.wantErr {
+				t.Fatalf("DB.Preload() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			s := d.Stats()
+			if s.Downloads != tt.wantDownloads {
+				t.Errorf("DB.Preload() downloads = %v, want %v", s.Downloads, tt.wantDownloads)
+			}
+
+			if concurrency != tt.wantConcurrency {
+				t.Errorf("DB.Preload() concurrency = %v, want %v", concurrency, tt.wantConcurrency)
+			}
+
+			if tt.wantErr {
+				return
+			}
+
+			w := &bytes.Buffer{}
+			if err = d.Get(w, "local"); err != nil {
+				t.Fatal(err)
+			}
+
+			if want := "foo,bar\n1,1b\n"; w.String() != want {
+				t.Errorf("DB.Get() = %q, want %q", w.String(), want)
+			}
+
+			for _, key := range tt.keys {
+				if key == "missing" || key == "local" {
+					continue
+				}
+
+				w.Reset()
+				if err = d.Get(w, key); err != nil {
+					t.Fatal(err)
+				}
+
+				if want := "foo,bar\nremote,remote\n"; w.String() != want {
+					t.Errorf("DB.Get(%s) = %q, want %q", key, w.String(), want)
+				}
+			}
+
+			if got := d.Stats().Downloads; got != tt.wantDownloads {
+				t.Errorf("DB.Get() downloaded preloaded keys, downloads = %v, want %v", got, tt.wantDownloads)
+			}
+
+			var exportable []string
+			if exportable, err = d.getExportable(""); err != nil {
+				t.Fatal(err)
+			}
+
+			if len(exportable) != 1 {
+				t.Errorf("DB.getExportable() = %v, want only the local key", exportable)
+			}
+		})
+	}
+}
+
+func TestDB_GetMerged_prefetch(t *testing.T) {
+	type testcase struct {
+		name  string
+		spill bool
+	}
+
+	tests := []testcase{
+		{name: "basic"},
+		{name: "spill", spill: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var (
+				mux         sync.Mutex
+				active      int
+				concurrency int
+			)
+
+			b := &mockBackend{
+				importFn: func(ctx context.Context, prefix, filename string, w io.Writer) (err error) {
+					mux.Lock()
+					active++
+					if active > concurrency {
+						concurrency = active
+					}
+					mux.Unlock()
+
+					// Earlier keys take longer, so downloads complete out of order
+					time.Sleep(time.Duration(int('z'-filename[4])) * time.Millisecond)
+
+					mux.Lock()
+					active--
+					mux.Unlock()
+
+					if filename == "foo.missing.csv" {
+						return os.ErrNotExist
+					}
+
+					_, err = fmt.Fprintf(w, "foo,bar\n%s,remote\n", filename[4:5])
+					return
+				},
+			}
+
+			var opts Options
+			opts.Dir = fmt.Sprintf("test_%d", time.Now().UnixNano())
+			opts.Name = "foo"
+			opts.PreloadWorkers = 3
+			opts.SpillToBackend = tt.spill
+			d, err := makeDB[testentry](opts, b)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer os.RemoveAll(d.o.Dir)
+
+			w := &bytes.Buffer{}
+			if err = d.GetMerged(w, "a", "b", "missing", "c", "d", "e", "f"); err != nil {
+				t.Fatal(err)
+			}
+
+			want := "foo,bar\na,remote\nb,remote\nc,remote\nd,remote\ne,remote\nf,remote\n"
+			if w.String() != want {
+				t.Errorf("DB.GetMerged() = %q, want %q", w.String(), want)
+			}
+
+			if concurrency < 2 || concurrency > opts.PreloadWorkers {
+				t.Errorf("DB.GetMerged() concurrency = %v, want between 2 and %d", concurrency, opts.PreloadWorkers)
+			}
+
+			if got := d.Stats().Downloads; got != 7 {
+				t.Errorf("DB.GetMerged() downloads = %v, want 7", got)
+			}
+
+			keys, _, err := d.ListKeys("", "", 10)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if tt.spill && len(keys) != 0 {
+				t.Errorf("DB.GetMerged() left downloaded keys %v while spilling", keys)
+			}
+		})
+	}
+}
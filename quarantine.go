@@ -0,0 +1,170 @@
+package csvdb
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+)
+
+const quarantineDir = "quarantine"
+
+var (
+	// ErrEntryQuarantined is returned when an operation targets a quarantined key
+	ErrEntryQuarantined = errors.New("entry is quarantined")
+	// ErrEntryNotQuarantined is returned when restoring or discarding a key which is not quarantined
+	ErrEntryNotQuarantined = errors.New("entry is not quarantined")
+	// ErrEntryExists is returned when an operation would overwrite an existing key
+	ErrEntryExists = errors.New("entry already exists")
+)
+
+// Quarantine will move the file of a key into the quarantine area. Quarantined keys are
+// excluded from Get, GetMerged, Append, export and purge until restored or discarded.
+func (d *DB[T]) Quarantine(key string) (err error) {
+	return d.QuarantineContext(context.Background(), key)
+}
+
+// QuarantineContext is the context-aware variant of Quarantine
+func (d *DB[T]) QuarantineContext(ctx context.Context, key string) (err error) {
+	if err = d.checkWritable(); err != nil {
+		return
+	}
+
+	if err = d.lock(ctx); err != nil {
+		return
+	}
+	defer d.mux.Unlock()
+	return d.quarantine(key)
+}
+
+// RestoreQuarantined will move a quarantined file back into the DB
+func (d *DB[T]) RestoreQuarantined(key string) (err error) {
+	return d.RestoreQuarantinedContext(context.Background(), key)
+}
+
+// RestoreQuarantinedContext is the context-aware variant of RestoreQuarantined
+func (d *DB[T]) RestoreQuarantinedContext(ctx context.Context, key string) (err error) {
+	if err = d.checkWritable(); err != nil {
+		return
+	}
+
+	if err = d.lock(ctx); err != nil {
+		return
+	}
+	defer d.mux.Unlock()
+
+	if _, ok := d.quarantined[key]; !ok {
+		return ErrEntryNotQuarantined
+	}
+
+	name, filename := d.getFilename(key)
+	if _, err = d.fs.Stat(filename); err == nil {
+		return ErrEntryExists
+	} else if !os.IsNotExist(err) {
+		return
+	}
+
+	if err = d.fs.Rename(path.Join(d.getQuarantinePath(), name), filename); err != nil {
+		return
+	}
+
+	delete(d.quarantined, key)
+	return
+}
+
+// DiscardQuarantined will permanently remove a quarantined file
+func (d *DB[T]) DiscardQuarantined(key string) (err error) {
+	return d.DiscardQuarantinedContext(context.Background(), key)
+}
+
+// DiscardQuarantinedContext is the context-aware variant of DiscardQuarantined
+func (d *DB[T]) DiscardQuarantinedContext(ctx context.Context, key string) (err error) {
+	if err = d.checkWritable(); err != nil {
+		return
+	}
+
+	if err = d.lock(ctx); err != nil {
+		return
+	}
+	defer d.mux.Unlock()
+
+	if _, ok := d.quarantined[key]; !ok {
+		return ErrEntryNotQuarantined
+	}
+
+	name, _ := d.getFilename(key)
+	if err = d.fs.Remove(path.Join(d.getQuarantinePath(), name)); err != nil && !os.IsNotExist(err) {
+		return
+	}
+
+	delete(d.quarantined, key)
+	return nil
+}
+
+// Quarantined will return the keys which are currently quarantined
+func (d *DB[T]) Quarantined() (keys []string) {
+	d.mux.Lock()
+	defer d.mux.Unlock()
+	keys = make([]string, 0, len(d.quarantined))
+	for key := range d.quarantined {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+	return
+}
+
+func (d *DB[T]) quarantine(key string) (err error) {
+	if _, ok := d.quarantined[key]; ok {
+		return ErrEntryQuarantined
+	}
+
+	if err = d.fs.MkdirAll(d.getQuarantinePath(), 0744); err != nil {
+		return
+	}
+
+	name, filename := d.getFilename(key)
+	if err = d.fs.Rename(filename, path.Join(d.getQuarantinePath(), name)); os.IsNotExist(err) {
+		return ErrEntryNotFound
+	} else if err != nil {
+		return
+	}
+
+	d.quarantined[key] = struct{}{}
+	d.record(key, Event{Type: EventQuarantined})
+	return
+}
+
+func (d *DB[T]) checkQuarantine(key string) (err error) {
+	if _, ok := d.quarantined[key]; ok {
+		return ErrEntryQuarantined
+	}
+
+	return
+}
+
+func (d *DB[T]) loadQuarantined() (err error) {
+	d.quarantined = make(map[string]struct{})
+	entries, err := d.fs.ReadDir(d.getQuarantinePath())
+	if os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return
+	}
+
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != ".csv" {
+			continue
+		}
+
+		d.quarantined[d.getKey(entry.Name())] = struct{}{}
+	}
+
+	return
+}
+
+func (d *DB[T]) getQuarantinePath() string {
+	return path.Join(d.getFullPath(), quarantineDir)
+}
@@ -0,0 +1,150 @@
+package csvdb
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestDB_Quarantine(t *testing.T) {
+	type testcase struct {
+		name string
+		// action is performed after key "a" has been quarantined
+		action          func(d *DB[testentry]) error
+		wantErr         error
+		wantQuarantined []string
+		wantMerged      string
+		wantExportable  int
+	}
+
+	tests := []testcase{
+		{
+			name: "quarantined",
+			action: func(d *DB[testentry]) error {
+				return nil
+			},
+			wantQuarantined: []string{"a"},
+			wantMerged:      "foo,bar\n2,2b\n",
+			wantExportable:  1,
+		},
+		{
+			name: "get",
+			action: func(d *DB[testentry]) error {
+				return d.Get(&bytes.Buffer{}, "a")
+			},
+			wantErr:         ErrEntryQuarantined,
+			wantQuarantined: []string{"a"},
+			wantMerged:      "foo,bar\n2,2b\n",
+			wantExportable:  1,
+		},
+		{
+			name: "append",
+			action: func(d *DB[testentry]) error {
+				return d.Append("a", testentry{Foo: "3"})
+			},
+			wantErr:         ErrEntryQuarantined,
+			wantQuarantined: []string{"a"},
+			wantMerged:      "foo,bar\n2,2b\n",
+			wantExportable:  1,
+		},
+		{
+			name: "restore",
+			action: func(d *DB[testentry]) error {
+				return d.RestoreQuarantined("a")
+			},
+			wantQuarantined: []string{},
+			wantMerged:      "foo,bar\n1,1b\n2,2b\n",
+			wantExportable:  2,
+		},
+		{
+			name: "discard",
+			action: func(d *DB[testentry]) error {
+				return d.DiscardQuarantined("a")
+			},
+			wantQuarantined: []string{},
+			wantMerged:      "foo,bar\n2,2b\n",
+			wantExportable:  1,
+		},
+		{
+			name: "restore not quarantined",
+			action: func(d *DB[testentry]) error {
+				return d.RestoreQuarantined("b")
+			},
+			wantErr:         ErrEntryNotQuarantined,
+			wantQuarantined: []string{"a"},
+			wantMerged:      "foo,bar\n2,2b\n",
+			wantExportable:  1,
+		},
+		{
+			name: "reload",
+			action: func(d *DB[testentry]) (err error) {
+				var reloaded DB[testentry]
+				if reloaded, err = makeDB[testentry](d.o, d.eb); err != nil {
+					return
+				}
+
+				d.quarantined = reloaded.quarantined
+				return
+			},
+			wantQuarantined: []string{"a"},
+			wantMerged:      "foo,bar\n2,2b\n",
+			wantExportable:  1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var opts Options
+			opts.Dir = fmt.Sprintf("test_%d", time.Now().UnixNano())
+			opts.Name = "foo"
+			d, err := makeDB[testentry](opts, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer os.RemoveAll(d.o.Dir)
+
+			if err = d.Append("a", testentry{Foo: "1", Bar: "1b"}); err != nil {
+				t.Fatal(err)
+			}
+
+			if err = d.Append("b", testentry{Foo: "2", Bar: "2b"}); err != nil {
+				t.Fatal(err)
+			}
+
+			if err = d.Quarantine("a"); err != nil {
+				t.Fatal(err)
+			}
+
+			if err = tt.action(&d); !errors.Is(err, tt.wantErr) {
+				t.Errorf("action error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+
+			if got := d.Quarantined(); !reflect.DeepEqual(got, tt.wantQuarantined) {
+				t.Errorf("DB.Quarantined() = %v, want %v", got, tt.wantQuarantined)
+			}
+
+			w := &bytes.Buffer{}
+			if err = d.GetMerged(w, "a", "b"); err != nil {
+				t.Fatal(err)
+			}
+
+			if gotW := w.String(); gotW != tt.wantMerged {
+				t.Errorf("DB.GetMerged() = %v, want %v", gotW, tt.wantMerged)
+			}
+
+			exportable, err := d.getExportable("")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if len(exportable) != tt.wantExportable {
+				t.Errorf("DB.getExportable() count = %v, wantCount %v", len(exportable), tt.wantExportable)
+			}
+		})
+	}
+}
@@ -0,0 +1,178 @@
+package csvdb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+)
+
+// ErrInvalidColumnCount is returned when a row does not have the same number of columns as the header
+var ErrInvalidColumnCount = errors.New("invalid column count")
+
+// AppendRaw will validate and append pre-formatted CSV rows to a key. Each row must have
+// the same number of columns as the header of the key. If the first row matches the header,
+// it is skipped. Should any row fail validation, the file is restored to its original state.
+// The row checksum column, when present, is recomputed for each row.
+func (d *DB[T]) AppendRaw(key string, r io.Reader) (err error) {
+	return d.AppendRawContext(context.Background(), key, r)
+}
+
+// AppendRawContext is the context-aware variant of AppendRaw
+func (d *DB[T]) AppendRawContext(ctx context.Context, key string, r io.Reader) (err error) {
+	return d.appendRows(ctx, key, func(header []string, write func([]string) error) (int, error) {
+		return appendRawRows(write, d.decodeInput(newContextReader(ctx, r)), header, d.dialect)
+	})
+}
+
+// appendRows will provide the header of a key (writing it for new files) to the provided
+// func which writes rows and returns how many were written. Rows are written with the column
+// policies applied. Should the func fail, the file is restored to its original state.
+func (d *DB[T]) appendRows(ctx context.Context, key string, fn func(header []string, write func(values []string) error) (rows int, err error)) (err error) {
+	var unlock func()
+	if unlock, err = d.lockKeys(ctx, key); err != nil {
+		return
+	}
+	defer unlock()
+
+	if err = d.prepareWrite(ctx, key); err != nil {
+		return
+	}
+
+	if err = d.checkQuota(key); err != nil {
+		return
+	}
+
+	var f file
+	_, filename := d.getFilename(key)
+	if f, err = getOrCreate(d.fs, filename); err != nil {
+		return
+	}
+	defer f.Close()
+
+	var info os.FileInfo
+	if info, err = f.Stat(); err != nil {
+		return
+	}
+
+	var header []string
+	if header, err = readHeader(f, info.Size(), d.dialect); err != nil {
+		return
+	}
+
+	a := d.newAppender(f, info.Size())
+	w, bw := getCSVWriter(a, d.dialect)
+	defer putBufWriter(bw)
+	if header == nil {
+		header = d.header(key, newEntry[T]())
+		if err = w.Write(header); err != nil {
+			return
+		}
+	}
+
+	cs := d.cp.columns(header)
+	checksummed := rowChecksummed(header)
+	// Rows are mirrored to the shadow as they are provided, as the shadow protects them itself
+	var mirrored [][]string
+	write := func(values []string) (err error) {
+		if d.shadow != nil {
+			mirrored = append(mirrored, append([]string(nil), values...))
+		}
+
+		if values, err = d.cp.protect(cs, values); err != nil {
+			return
+		}
+
+		if checksummed {
+			// Provided checksums are replaced, as they may predate the protection of the values
+			values = append(values[:len(values)-1], rowChecksum(values[:len(values)-1]))
+		}
+
+		return w.Write(values)
+	}
+
+	var rows int
+	if rows, err = fn(header, write); err == nil {
+		w.Flush()
+		err = w.Error()
+	}
+
+	if err == nil {
+		err = d.commit(a)
+	}
+
+	if err == nil {
+		err = d.syncWrite(f, info.Size() == 0)
+	}
+
+	if err == nil {
+		d.updateRowIndex(filename)
+		d.updateChecksum(filename)
+		d.shadowAppendRows(key, mirrored)
+		d.recordAppend(key, info.Size() == 0, rows)
+		return
+	}
+
+	if terr := f.Truncate(info.Size()); terr != nil {
+		d.o.Logger.Printf("csvdb.DB[%s].appendRows(): error restoring <%s>: %v\n", d.o.Name, filename, terr)
+	}
+
+	return
+}
+
+func appendRawRows(write func([]string) error, r io.Reader, header []string, dialect csvDialect) (rows int, err error) {
+	cr := newCSVReader(r, dialect)
+	cr.FieldsPerRecord = -1
+
+	var values []string
+	for i := 0; ; i++ {
+		if values, err = cr.Read(); err == io.EOF {
+			return rows, nil
+		} else if err != nil {
+			return
+		}
+
+		if i == 0 && reflect.DeepEqual(values, header) {
+			continue
+		}
+
+		if len(values) != len(header) {
+			err = fmt.Errorf("%w: row #%d has %d columns, expected %d", ErrInvalidColumnCount, i, len(values), len(header))
+			return
+		}
+
+		if err = write(values); err != nil {
+			return
+		}
+
+		rows++
+	}
+}
+
+// checkColumnCount will return ErrInvalidColumnCount when a row of a key does not have the same
+// number of values as the header
+func checkColumnCount(key string, i int, values, header []string) (err error) {
+	if len(values) == len(header) {
+		return
+	}
+
+	return fmt.Errorf("%w: row #%d of <%s> has %d values, expected %d", ErrInvalidColumnCount, i, key, len(values), len(header))
+}
+
+func readHeader(f file, size int64, dialect csvDialect) (header []string, err error) {
+	if size == 0 {
+		return
+	}
+
+	if _, err = f.Seek(0, io.SeekStart); err != nil {
+		return
+	}
+
+	if header, err = newCSVReader(f, dialect).Read(); err != nil {
+		err = fmt.Errorf("error reading header: %v", err)
+	}
+
+	return
+}
@@ -0,0 +1,116 @@
+package csvdb
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDB_AppendRaw(t *testing.T) {
+	type args struct {
+		key   string
+		input string
+	}
+
+	type testcase struct {
+		name    string
+		args    args
+		wantW   string
+		wantErr bool
+	}
+
+	tests := []testcase{
+		{
+			name: "existing key",
+			args: args{
+				key:   "foo",
+				input: "2,2b\n\"3,\",3b\n",
+			},
+			wantW: "foo,bar\n1,1b\n2,2b\n\"3,\",3b\n",
+		},
+		{
+			name: "with header",
+			args: args{
+				key:   "foo",
+				input: "foo,bar\n2,2b\n",
+			},
+			wantW: "foo,bar\n1,1b\n2,2b\n",
+		},
+		{
+			name: "new key",
+			args: args{
+				key:   "bar",
+				input: "2,2b\n",
+			},
+			wantW: "foo,bar\n2,2b\n",
+		},
+		{
+			name: "invalid column count",
+			args: args{
+				key:   "foo",
+				input: "2,2b\n3,3b,3c\n",
+			},
+			wantW:   "foo,bar\n1,1b\n",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var opts Options
+			opts.Dir = fmt.Sprintf("test_%d", time.Now().UnixNano())
+			opts.Name = "foo"
+			d, err := makeDB[testentry](opts, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer os.RemoveAll(d.o.Dir)
+
+			if err = d.Append("foo", testentry{Foo: "1", Bar: "1b"}); err != nil {
+				t.Fatal(err)
+			}
+
+			err = d.AppendRaw(tt.args.key, strings.NewReader(tt.args.input))
+			if (err != nil) != tt.wantErr {
+				t.Errorf("DB.AppendRaw() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+
+			w := &bytes.Buffer{}
+			if err = d.Get(w, tt.args.key); err != nil {
+				t.Fatal(err)
+			}
+
+			if gotW := w.String(); gotW != tt.wantW {
+				t.Errorf("DB.AppendRaw() = %v, want %v", gotW, tt.wantW)
+			}
+		})
+	}
+}
+
+func TestDB_AppendRaw_pointerEntry(t *testing.T) {
+	var opts Options
+	opts.Dir = fmt.Sprintf("test_%d", time.Now().UnixNano())
+	opts.Name = "foo"
+	d, err := makeDB[*testentry](opts, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(d.o.Dir)
+
+	if err = d.AppendRaw("bar", strings.NewReader("2,2b\n")); err != nil {
+		t.Fatal(err)
+	}
+
+	w := &bytes.Buffer{}
+	if err = d.Get(w, "bar"); err != nil {
+		t.Fatal(err)
+	}
+
+	if want := "foo,bar\n2,2b\n"; w.String() != want {
+		t.Errorf("DB.AppendRaw() = %v, want %v", w.String(), want)
+	}
+}
@@ -0,0 +1,10 @@
+package csvdb
+
+// checkWritable will return ErrReadOnly when the DB was opened with Options.ReadOnly
+func (d *DB[T]) checkWritable() (err error) {
+	if d.o.ReadOnly {
+		return ErrReadOnly
+	}
+
+	return
+}
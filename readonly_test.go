@@ -0,0 +1,95 @@
+package csvdb
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestDB_ReadOnly(t *testing.T) {
+	dir := fmt.Sprintf("test_%d", time.Now().UnixNano())
+	defer os.RemoveAll(dir)
+
+	b := &mockBackend{
+		importFn: func(ctx context.Context, prefix, filename string, w io.Writer) (err error) {
+			if filename != "foo.remote.csv" {
+				return os.ErrNotExist
+			}
+
+			_, err = w.Write([]byte("foo,bar\nremote,remote\n"))
+			return
+		},
+	}
+
+	var opts Options
+	opts.Dir = dir
+	opts.Name = "foo"
+	writer, err := makeDB[testentry](opts, b)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err = writer.Append("local", testentry{Foo: "1", Bar: "1b"}); err != nil {
+		t.Fatal(err)
+	}
+
+	opts.ReadOnly = true
+	d, err := makeDB[testentry](opts, b)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	reads := map[string]string{
+		"local":  "foo,bar\n1,1b\n",
+		"remote": "foo,bar\nremote,remote\n",
+	}
+
+	for key, want := range reads {
+		w := &bytes.Buffer{}
+		if err = d.Get(w, key); err != nil {
+			t.Fatalf("DB.Get(%s) error = %v", key, err)
+		}
+
+		if w.String() != want {
+			t.Errorf("DB.Get(%s) = %v, want %v", key, w.String(), want)
+		}
+	}
+
+	modifications := map[string]func() error{
+		"Append":       func() error { return d.Append("local", testentry{Foo: "2", Bar: "2b"}) },
+		"Delete":       func() error { return d.Delete("local") },
+		"DeletePrefix": func() error { return d.DeletePrefix("") },
+		"PurgePrefix":  func() error { return d.PurgePrefix("") },
+		"ExportPrefix": func() error { return d.ExportPrefix("") },
+		"Quarantine":   func() error { return d.Quarantine("local") },
+		"Barrier":      func() error { return d.Barrier("local", true) },
+		"DropDB":       func() error { return d.DropDB(context.Background(), "foo", false) },
+		"Writer": func() (err error) {
+			_, err = d.Writer("local")
+			return
+		},
+	}
+
+	for name, fn := range modifications {
+		if err = fn(); err != ErrReadOnly {
+			t.Errorf("DB.%s() error = %v, want %v", name, err, ErrReadOnly)
+		}
+	}
+
+	w := &bytes.Buffer{}
+	if err = writer.Get(w, "local"); err != nil {
+		t.Fatal(err)
+	}
+
+	if want := reads["local"]; w.String() != want {
+		t.Fatalf("DB.Get() = %v, want %v", w.String(), want)
+	}
+
+	if err = d.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,169 @@
+package csvdb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// DefaultRegistry is a process-wide Registry, DBs are only registered to it when it is set
+// as Options.Registry
+var DefaultRegistry = NewRegistry()
+
+// NewRegistry will return a new, empty Registry
+func NewRegistry() *Registry {
+	return &Registry{dbs: make(map[registrant]struct{})}
+}
+
+// Registry tracks the DBs created by New with Options.Registry set, providing an aggregate
+// view of their usage and health along with closing them all at once. DBs are removed from
+// the Registry once they are closed.
+type Registry struct {
+	mux sync.Mutex
+	dbs map[registrant]struct{}
+}
+
+// registrant is implemented by every DB, regardless of its Entry type
+type registrant interface {
+	report() (r DBReport, err error)
+	CloseContext(ctx context.Context) error
+}
+
+// RegistryReport is the aggregate view of the DBs within a Registry
+type RegistryReport struct {
+	// DBs are the reports of each DB, ordered by directory and name
+	DBs []DBReport
+	// Keys is the combined number of local keys
+	Keys int
+	// Size is the combined size of the local keys in bytes
+	Size int64
+	// ExportBacklog is the combined number of keys waiting to be exported
+	ExportBacklog int
+	// Unhealthy is the number of DBs which are not healthy
+	Unhealthy int
+}
+
+// DBReport is the usage and health of a single DB
+type DBReport struct {
+	Dir  string
+	Name string
+
+	// Keys is the number of local keys
+	Keys int
+	// Size is the combined size of the local keys in bytes
+	Size int64
+	// ExportBacklog is the number of keys waiting to be exported
+	ExportBacklog int
+
+	// Quarantined is the number of quarantined keys
+	Quarantined int
+	// IntegrityIssues is the number of issues found by the startup integrity check
+	IntegrityIssues int
+}
+
+// Healthy will return whether the DB has no quarantined keys or integrity issues
+func (r DBReport) Healthy() bool {
+	return r.Quarantined == 0 && r.IntegrityIssues == 0
+}
+
+// Len will return the number of registered DBs
+func (r *Registry) Len() int {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	return len(r.dbs)
+}
+
+// Report will return the aggregate usage and health of the registered DBs
+func (r *Registry) Report() (rr RegistryReport, err error) {
+	var errs []error
+	for _, db := range r.list() {
+		var dr DBReport
+		switch dr, err = db.report(); {
+		case err == ErrClosed:
+			// Closed while reporting, it is no longer registered
+			continue
+		case err != nil:
+			errs = append(errs, fmt.Errorf("error reporting <%s/%s>: %w", dr.Dir, dr.Name, err))
+			continue
+		}
+
+		rr.DBs = append(rr.DBs, dr)
+		rr.Keys += dr.Keys
+		rr.Size += dr.Size
+		rr.ExportBacklog += dr.ExportBacklog
+		if !dr.Healthy() {
+			rr.Unhealthy++
+		}
+	}
+
+	sort.Slice(rr.DBs, func(i, j int) bool {
+		if rr.DBs[i].Dir != rr.DBs[j].Dir {
+			return rr.DBs[i].Dir < rr.DBs[j].Dir
+		}
+
+		return rr.DBs[i].Name < rr.DBs[j].Name
+	})
+
+	return rr, errors.Join(errs...)
+}
+
+// CloseAll will close every registered DB, returning the joined errors of the DBs
+// which failed to close
+func (r *Registry) CloseAll(ctx context.Context) (err error) {
+	var errs []error
+	for _, db := range r.list() {
+		if err = db.CloseContext(ctx); err != nil && err != ErrClosed {
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+func (r *Registry) register(db registrant) {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	r.dbs[db] = struct{}{}
+}
+
+func (r *Registry) unregister(db registrant) {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	delete(r.dbs, db)
+}
+
+func (r *Registry) list() (dbs []registrant) {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	dbs = make([]registrant, 0, len(r.dbs))
+	for db := range r.dbs {
+		dbs = append(dbs, db)
+	}
+
+	return
+}
+
+func (d *DB[T]) report() (r DBReport, err error) {
+	r.Dir = d.o.Dir
+	r.Name = d.o.Name
+
+	var s PrefixStats
+	if s, err = d.StatsForPrefix(""); err != nil {
+		return
+	}
+
+	r.Keys = s.Keys
+	r.Size = s.Size
+
+	var exportable []string
+	if exportable, err = d.getExportable(""); err != nil {
+		return
+	}
+
+	r.ExportBacklog = len(exportable)
+	r.Quarantined = len(d.Quarantined())
+	r.IntegrityIssues = len(d.IntegrityIssues())
+	return
+}
@@ -0,0 +1,87 @@
+package csvdb
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestRegistry(t *testing.T) {
+	dir := fmt.Sprintf("test_%d", time.Now().UnixNano())
+	defer os.RemoveAll(dir)
+
+	r := NewRegistry()
+	open := func(name string) *DB[testentry] {
+		var opts Options
+		opts.Dir = dir
+		opts.Name = name
+		opts.Registry = r
+		d, err := New[testentry](context.Background(), opts, &mockBackend{})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		return d
+	}
+
+	a := open("a")
+	b := open("b")
+	c := open("c")
+	if r.Len() != 3 {
+		t.Fatalf("Registry.Len() = %d, want 3", r.Len())
+	}
+
+	for _, key := range []string{"x", "y"} {
+		if err := a.Append(key, testentry{Foo: "1", Bar: "1b"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := b.Append("x", testentry{Foo: "1", Bar: "1b"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := b.Quarantine("x"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := c.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	rr, err := r.Report()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(rr.DBs) != 2 || rr.DBs[0].Name != "a" || rr.DBs[1].Name != "b" {
+		t.Fatalf("Registry.Report() DBs = %+v, want reports of <a> and <b>", rr.DBs)
+	}
+
+	want := "foo,bar\n1,1b\n"
+	if rr.Keys != 2 || rr.Size != int64(2*len(want)) {
+		t.Errorf("Registry.Report() Keys = %d, Size = %d, want 2 and %d", rr.Keys, rr.Size, 2*len(want))
+	}
+
+	if rr.ExportBacklog != 2 || rr.DBs[0].ExportBacklog != 2 {
+		t.Errorf("Registry.Report() ExportBacklog = %d, want 2", rr.ExportBacklog)
+	}
+
+	if rr.Unhealthy != 1 || !rr.DBs[0].Healthy() || rr.DBs[1].Healthy() {
+		t.Errorf("Registry.Report() Unhealthy = %d, want <b> to be unhealthy", rr.Unhealthy)
+	}
+
+	if err = r.CloseAll(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	if r.Len() != 0 {
+		t.Errorf("Registry.Len() = %d after CloseAll, want 0", r.Len())
+	}
+
+	if err = a.Append("z", testentry{Foo: "1", Bar: "1b"}); err != ErrClosed {
+		t.Errorf("DB.Append() error = %v after CloseAll, want %v", err, ErrClosed)
+	}
+}
@@ -0,0 +1,41 @@
+package csvdb
+
+import (
+	"context"
+	"sort"
+)
+
+// RemoteKeys will return the sorted keys exported to the Backend under the prefix of the DB.
+// ErrListerNotImplemented is returned when the Backend does not implement Lister.
+func (d *DB[T]) RemoteKeys() (keys []string, err error) {
+	return d.RemoteKeysContext(context.Background())
+}
+
+// RemoteKeysContext is the context-aware variant of RemoteKeys
+func (d *DB[T]) RemoteKeysContext(ctx context.Context) (keys []string, err error) {
+	if d.ib == nil {
+		err = ErrBackendNotSet
+		return
+	}
+
+	lister, ok := d.ib.(Lister)
+	if !ok {
+		err = ErrListerNotImplemented
+		return
+	}
+
+	var filenames []string
+	if filenames, err = lister.List(ctx, d.o.Name); err != nil {
+		return
+	}
+
+	keys = make([]string, 0, len(filenames))
+	for _, filename := range filenames {
+		if key, ok := d.getExportedKey(filename); ok {
+			keys = append(keys, key)
+		}
+	}
+
+	sort.Strings(keys)
+	return
+}
@@ -0,0 +1,67 @@
+package csvdb
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestDB_RemoteKeys(t *testing.T) {
+	type testcase struct {
+		name     string
+		backend  Backend
+		policies []Policy
+
+		want    []string
+		wantErr error
+	}
+
+	filenames := []string{"foo.c.csv.gz", "foo.b%2F1.csv", "foo.a.csv", "bar.a.csv", "foo.a.csv.exported"}
+	tests := []testcase{
+		{
+			name:    "basic",
+			backend: &mockListerBackend{filenames: filenames},
+			want:    []string{"a", "b/1"},
+		},
+		{
+			name:     "compressed",
+			backend:  &mockListerBackend{filenames: filenames},
+			policies: []Policy{{Prefix: "c", Compress: true}},
+			want:     []string{"a", "b/1", "c"},
+		},
+		{
+			name:    "backend without lister",
+			backend: &mockBackend{},
+			wantErr: ErrListerNotImplemented,
+		},
+		{
+			name:    "backend not set",
+			wantErr: ErrBackendNotSet,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var opts Options
+			opts.Dir = fmt.Sprintf("test_%d", time.Now().UnixNano())
+			opts.Name = "foo"
+			opts.Policies = tt.policies
+			d, err := makeDB[testentry](opts, tt.backend)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer os.RemoveAll(opts.Dir)
+
+			got, err := d.RemoteKeys()
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("DB.RemoteKeys() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if fmt.Sprint(got) != fmt.Sprint(tt.want) {
+				t.Errorf("DB.RemoteKeys() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,127 @@
+package csvdb
+
+import (
+	"context"
+	"errors"
+	"os"
+)
+
+// ErrRenamerNotImplemented is returned when remote renaming is requested from a Backend which does not implement Renamer
+var ErrRenamerNotImplemented = errors.New("backend does not implement Renamer")
+
+// Rename will atomically rename the file of a key along with its export marker. When
+// remote is set, the exported file is also renamed on the Backend, which must implement Renamer,
+// and both keys must share the same backend prefix. The local files are renamed first, and are
+// restored when the remote rename fails.
+func (d *DB[T]) Rename(key, newKey string, remote bool) (err error) {
+	return d.RenameContext(context.Background(), key, newKey, remote)
+}
+
+// RenameContext is the context-aware variant of Rename
+func (d *DB[T]) RenameContext(ctx context.Context, key, newKey string, remote bool) (err error) {
+	var renamer Renamer
+	if remote {
+		var ok bool
+		if renamer, ok = d.eb.(Renamer); !ok {
+			return ErrRenamerNotImplemented
+		}
+
+		if d.remotePrefix(key) != d.remotePrefix(newKey) {
+			return ErrRenameAcrossPrefixes
+		}
+	}
+
+	var unlock func()
+	if unlock, err = d.lockKeys(ctx, key, newKey); err != nil {
+		return
+	}
+	defer unlock()
+
+	if err = d.prepareWrite(ctx, key); err != nil {
+		return
+	}
+
+	if err = d.prepareWrite(ctx, newKey); err != nil {
+		return
+	}
+
+	name, filename := d.getFilename(key)
+	newName, newFilename := d.getFilename(newKey)
+	if _, err = d.fs.Stat(filename); os.IsNotExist(err) {
+		return ErrEntryNotFound
+	} else if err != nil {
+		return
+	}
+
+	if _, err = d.fs.Stat(newFilename); err == nil {
+		return ErrEntryExists
+	} else if !os.IsNotExist(err) {
+		return
+	}
+
+	// Files are renamed locally first, so they are restored when the remote rename fails
+	var undo func()
+	if undo, err = d.renameLocal(filename, newFilename); err != nil {
+		return
+	}
+
+	if renamer == nil {
+		return
+	}
+
+	if err = d.renameRemote(ctx, renamer, key, name, newName); err != nil {
+		undo()
+		return
+	}
+
+	d.catalog.move(key, newKey, d.exportName(newName))
+	return
+}
+
+// renameLocal will rename the file of a key along with its side files, returning a func which
+// restores them. Renamed files are restored when any rename fails.
+func (d *DB[T]) renameLocal(filename, newFilename string) (undo func(), err error) {
+	var renamed []string
+	undo = func() {
+		for i := len(renamed) - 1; i >= 0; i-- {
+			if rerr := d.fs.Rename(newFilename+renamed[i], filename+renamed[i]); rerr != nil {
+				d.o.Logger.Printf("csvdb.DB[%s].renameLocal(): error restoring <%s>: %v\n", d.o.Name, filename+renamed[i], rerr)
+			}
+		}
+	}
+
+	// Export markers, legal holds and consumption marks follow the file of the key
+	for _, ext := range []string{"", ".exported", holdExt, consumedExt} {
+		switch err = d.fs.Rename(filename+ext, newFilename+ext); {
+		case err == nil:
+			renamed = append(renamed, ext)
+		case ext != "" && os.IsNotExist(err):
+		default:
+			undo()
+			return nil, err
+		}
+	}
+
+	return undo, nil
+}
+
+// renameRemote will rename the exported file of a key along with its checksum. The exported
+// file is renamed back when its checksum fails to be renamed.
+func (d *DB[T]) renameRemote(ctx context.Context, renamer Renamer, key, name, newName string) (err error) {
+	rctx := d.request(withKey(ctx, key), OpRename)
+	prefix := d.remotePrefix(key)
+	err = renamer.Rename(rctx, prefix, d.exportName(name), d.exportName(newName))
+	exported := err == nil
+	if err != nil && !os.IsNotExist(err) {
+		// Keys which have never been exported do not exist on the backend
+		return
+	}
+
+	if err = d.renameChecksum(ctx, renamer, name, newName); err != nil && exported {
+		if rerr := renamer.Rename(rctx, prefix, d.exportName(newName), d.exportName(name)); rerr != nil {
+			d.o.Logger.Printf("csvdb.DB[%s].renameRemote(): error restoring <%s>: %v\n", d.o.Name, d.exportName(name), rerr)
+		}
+	}
+
+	return
+}
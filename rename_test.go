@@ -0,0 +1,236 @@
+package csvdb
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+type mockRenamerBackend struct {
+	mockBackend
+
+	renamed [][2]string
+	// errs are returned when renaming the files they are keyed by
+	errs map[string]error
+}
+
+func (m *mockRenamerBackend) Rename(ctx context.Context, prefix, filename, newFilename string) (err error) {
+	if err = m.errs[filename]; err != nil {
+		return
+	}
+
+	m.renamed = append(m.renamed, [2]string{filename, newFilename})
+	return
+}
+
+func TestDB_Rename(t *testing.T) {
+	type testcase struct {
+		name     string
+		inMemory bool
+		renamer  bool
+		key      string
+		newKey   string
+		remote   bool
+
+		wantErr     error
+		wantRenamed [][2]string
+	}
+
+	tests := []testcase{
+		{
+			name:   "basic",
+			key:    "a",
+			newKey: "c",
+		},
+		{
+			name:     "in memory",
+			inMemory: true,
+			key:      "a",
+			newKey:   "c",
+		},
+		{
+			name:        "remote",
+			renamer:     true,
+			key:         "a",
+			newKey:      "tenant/a",
+			remote:      true,
+			wantRenamed: [][2]string{{"foo.a.csv", "foo.tenant%2Fa.csv"}},
+		},
+		{
+			name:    "remote without renamer",
+			key:     "a",
+			newKey:  "c",
+			remote:  true,
+			wantErr: ErrRenamerNotImplemented,
+		},
+		{
+			name:    "not found",
+			key:     "missing",
+			newKey:  "c",
+			wantErr: ErrEntryNotFound,
+		},
+		{
+			name:    "exists",
+			key:     "a",
+			newKey:  "b",
+			wantErr: ErrEntryExists,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var opts Options
+			opts.Dir = fmt.Sprintf("test_%d", time.Now().UnixNano())
+			opts.Name = "foo"
+			opts.InMemory = tt.inMemory
+
+			var b Backend = &mockBackend{}
+			renamer := &mockRenamerBackend{}
+			if tt.renamer {
+				b = renamer
+			}
+
+			d, err := makeDB[testentry](opts, b)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer os.RemoveAll(d.o.Dir)
+
+			for _, key := range []string{"a", "b"} {
+				if err = d.Append(key, testentry{Foo: "1", Bar: key}); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			if err = d.setLastExported("foo.a.csv"); err != nil {
+				t.Fatal(err)
+			}
+
+			if err = d.Rename(tt.key, tt.newKey, tt.remote); err != tt.wantErr {
+				t.Fatalf("DB.Rename() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if !reflect.DeepEqual(renamer.renamed, tt.wantRenamed) {
+				t.Errorf("DB.Rename() renamed = %v, want %v", renamer.renamed, tt.wantRenamed)
+			}
+
+			if tt.wantErr != nil {
+				return
+			}
+
+			w := &bytes.Buffer{}
+			if err = d.Get(w, tt.newKey); err != nil {
+				t.Fatal(err)
+			}
+
+			if want := "foo,bar\n1,a\n"; w.String() != want {
+				t.Errorf("DB.Get() = %q, want %q", w.String(), want)
+			}
+
+			if !d.getLastExported("foo.a.csv").IsZero() {
+				t.Errorf("DB.Rename() left the export marker of <foo.a.csv>")
+			}
+
+			newName, _ := d.getFilename(tt.newKey)
+			if d.getLastExported(newName).IsZero() {
+				t.Errorf("DB.Rename() did not move the export marker to <%s>", newName)
+			}
+		})
+	}
+}
+
+func TestDB_Rename_remoteFailure(t *testing.T) {
+	type testcase struct {
+		name string
+		// fail will inject the failure of the remote rename
+		fail func(f *Faults, b *mockRenamerBackend)
+
+		wantErr     error
+		wantRenamed [][2]string
+	}
+
+	tests := []testcase{
+		{
+			name:    "exported file",
+			fail:    func(f *Faults, b *mockRenamerBackend) { f.FailBackend(1, nil) },
+			wantErr: ErrInjectedFault,
+		},
+		{
+			name: "checksum",
+			fail: func(f *Faults, b *mockRenamerBackend) {
+				b.errs = map[string]error{"foo.a.csv" + checksumExt: ErrInjectedFault}
+			},
+			wantErr: ErrInjectedFault,
+			// The exported file is renamed back
+			wantRenamed: [][2]string{{"foo.a.csv", "foo.c.csv"}, {"foo.c.csv", "foo.a.csv"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var opts Options
+			opts.Dir = fmt.Sprintf("test_%d", time.Now().UnixNano())
+			opts.Name = "foo"
+			opts.Checksums = ChecksumsDownload
+			opts.Faults = &Faults{}
+
+			b := &mockRenamerBackend{}
+			d, err := makeDB[testentry](opts, b)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer os.RemoveAll(d.o.Dir)
+
+			if err = d.Append("a", testentry{Foo: "1", Bar: "a"}); err != nil {
+				t.Fatal(err)
+			}
+
+			if err = d.setLastExported("foo.a.csv"); err != nil {
+				t.Fatal(err)
+			}
+
+			if err = d.SetHold("a"); err != nil {
+				t.Fatal(err)
+			}
+
+			tt.fail(opts.Faults, b)
+			if err = d.Rename("a", "c", true); !errors.Is(err, tt.wantErr) {
+				t.Fatalf("DB.Rename() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if !reflect.DeepEqual(b.renamed, tt.wantRenamed) {
+				t.Errorf("DB.Rename() renamed = %v, want %v", b.renamed, tt.wantRenamed)
+			}
+
+			// The local files of the key are restored
+			w := &bytes.Buffer{}
+			if err = d.Get(w, "a"); err != nil {
+				t.Fatal(err)
+			}
+
+			if want := "foo,bar\n1,a\n"; w.String() != want {
+				t.Errorf("DB.Get() = %q, want %q", w.String(), want)
+			}
+
+			if d.getLastExported("foo.a.csv").IsZero() {
+				t.Errorf("DB.Rename() did not restore the export marker of <foo.a.csv>")
+			}
+
+			if _, err = os.Stat(filepath.Join(d.o.Dir, "foo", "foo.a.csv"+holdExt)); err != nil {
+				t.Errorf("DB.Rename() did not restore the legal hold of <foo.a.csv>: %v", err)
+			}
+
+			for _, ext := range []string{"", ".exported", holdExt} {
+				if _, err = os.Stat(filepath.Join(d.o.Dir, "foo", "foo.c.csv"+ext)); !os.IsNotExist(err) {
+					t.Errorf("DB.Rename() left <foo.c.csv%s>, error = %v", ext, err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,198 @@
+package csvdb
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+)
+
+const (
+	interactionImport = "import"
+	interactionExport = "export"
+)
+
+// ErrNoRecordedInteraction is returned by a ReplayBackend when no recorded interaction remains for a call
+var ErrNoRecordedInteraction = errors.New("no recorded interaction")
+
+// interaction is a recorded Backend call
+type interaction struct {
+	Op          string `json:"op"`
+	Prefix      string `json:"prefix"`
+	Filename    string `json:"filename"`
+	Data        []byte `json:"data,omitempty"`
+	NewFilename string `json:"newFilename,omitempty"`
+	Err         string `json:"err,omitempty"`
+	NotExist    bool   `json:"notExist,omitempty"`
+}
+
+func (i *interaction) setErr(err error) {
+	if err == nil {
+		return
+	}
+
+	i.Err = err.Error()
+	i.NotExist = os.IsNotExist(err) || errors.Is(err, os.ErrNotExist)
+}
+
+func (i *interaction) err() error {
+	switch {
+	case i.NotExist:
+		return os.ErrNotExist
+	case i.Err != "":
+		return errors.New(i.Err)
+	default:
+		return nil
+	}
+}
+
+func (i *interaction) key() string {
+	return i.Op + ":" + i.Prefix + "/" + i.Filename
+}
+
+var _ Backend = &RecordBackend{}
+
+// RecordBackend is a Backend decorator which records every Import and Export to a file
+// as JSON lines, to later be replayed by a ReplayBackend
+type RecordBackend struct {
+	mux sync.Mutex
+	b   Backend
+	f   *os.File
+	enc *json.Encoder
+}
+
+// NewRecordBackend will return a RecordBackend which records the calls made to the
+// provided Backend. Interactions are appended to the file when it exists.
+func NewRecordBackend(b Backend, filename string) (r *RecordBackend, err error) {
+	var f *os.File
+	if f, err = os.OpenFile(filename, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644); err != nil {
+		return
+	}
+
+	r = &RecordBackend{b: b, f: f, enc: json.NewEncoder(f)}
+	return
+}
+
+func (r *RecordBackend) Import(ctx context.Context, prefix, filename string, w io.Writer) (err error) {
+	var buf bytes.Buffer
+	err = r.b.Import(ctx, prefix, filename, io.MultiWriter(w, &buf))
+	i := interaction{Op: interactionImport, Prefix: prefix, Filename: filename, Data: buf.Bytes()}
+	i.setErr(err)
+	if rerr := r.record(&i); rerr != nil && err == nil {
+		err = rerr
+	}
+
+	return
+}
+
+func (r *RecordBackend) Export(ctx context.Context, prefix, filename string, rdr io.Reader) (newFilename string, err error) {
+	var buf bytes.Buffer
+	newFilename, err = r.b.Export(ctx, prefix, filename, io.TeeReader(rdr, &buf))
+	i := interaction{Op: interactionExport, Prefix: prefix, Filename: filename, Data: buf.Bytes(), NewFilename: newFilename}
+	i.setErr(err)
+	if rerr := r.record(&i); rerr != nil && err == nil {
+		err = rerr
+	}
+
+	return
+}
+
+// Close will close the recording file
+func (r *RecordBackend) Close() (err error) {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	return r.f.Close()
+}
+
+func (r *RecordBackend) record(i *interaction) (err error) {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	if err = r.enc.Encode(i); err != nil {
+		return fmt.Errorf("error recording interaction: %w", err)
+	}
+
+	return
+}
+
+var _ Backend = &ReplayBackend{}
+
+// ReplayBackend is a Backend which replays the interactions recorded by a RecordBackend.
+// The calls for each prefix and filename are replayed in the order they were recorded.
+type ReplayBackend struct {
+	mux          sync.Mutex
+	interactions map[string][]interaction
+}
+
+// NewReplayBackend will load the interactions recorded within the provided file
+func NewReplayBackend(filename string) (r *ReplayBackend, err error) {
+	var f *os.File
+	if f, err = os.Open(filename); err != nil {
+		return
+	}
+	defer f.Close()
+
+	r = &ReplayBackend{interactions: make(map[string][]interaction)}
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(nil, 1<<30)
+	for scanner.Scan() {
+		var i interaction
+		if err = json.Unmarshal(scanner.Bytes(), &i); err != nil {
+			return nil, fmt.Errorf("error parsing recorded interaction: %w", err)
+		}
+
+		key := i.key()
+		r.interactions[key] = append(r.interactions[key], i)
+	}
+
+	if err = scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return
+}
+
+func (r *ReplayBackend) Import(ctx context.Context, prefix, filename string, w io.Writer) (err error) {
+	var i interaction
+	if i, err = r.next(interactionImport, prefix, filename); err != nil {
+		return
+	}
+
+	if _, err = w.Write(i.Data); err != nil {
+		return
+	}
+
+	return i.err()
+}
+
+func (r *ReplayBackend) Export(ctx context.Context, prefix, filename string, rdr io.Reader) (newFilename string, err error) {
+	var i interaction
+	if i, err = r.next(interactionExport, prefix, filename); err != nil {
+		return
+	}
+
+	if _, err = io.Copy(io.Discard, rdr); err != nil {
+		return
+	}
+
+	return i.NewFilename, i.err()
+}
+
+func (r *ReplayBackend) next(op, prefix, filename string) (i interaction, err error) {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	key := (&interaction{Op: op, Prefix: prefix, Filename: filename}).key()
+	queue := r.interactions[key]
+	if len(queue) == 0 {
+		err = fmt.Errorf("%w: %s <%s/%s>", ErrNoRecordedInteraction, op, prefix, filename)
+		return
+	}
+
+	i = queue[0]
+	r.interactions[key] = queue[1:]
+	return
+}
@@ -0,0 +1,125 @@
+package csvdb
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestRecordBackend_replay(t *testing.T) {
+	type testcase struct {
+		name string
+		// run is called against both the recording and the replaying DB
+		run func(d *DB[testentry]) (got string, err error)
+
+		wantGot string
+		wantErr error
+	}
+
+	tests := []testcase{
+		{
+			name: "export and import",
+			run: func(d *DB[testentry]) (got string, err error) {
+				if err = d.Append("a", testentry{Foo: "1", Bar: "1b"}); err != nil {
+					return
+				}
+
+				if err = d.export(context.Background(), "foo.a.csv"); err != nil {
+					return
+				}
+
+				if err = d.Delete("a"); err != nil {
+					return
+				}
+
+				w := &bytes.Buffer{}
+				err = d.Get(w, "a")
+				return w.String(), err
+			},
+			wantGot: "foo,bar\n1,1b\n",
+		},
+		{
+			name: "not found",
+			run: func(d *DB[testentry]) (got string, err error) {
+				return "", d.Get(&bytes.Buffer{}, "missing")
+			},
+			wantErr: ErrEntryNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			remote := map[string][]byte{}
+			b := &mockBackend{
+				exportFn: func(ctx context.Context, prefix, filename string, r io.Reader) (string, error) {
+					bs, err := io.ReadAll(r)
+					remote[filename] = bs
+					return filename, err
+				},
+				importFn: func(ctx context.Context, prefix, filename string, w io.Writer) (err error) {
+					bs, ok := remote[filename]
+					if !ok {
+						return os.ErrNotExist
+					}
+
+					_, err = w.Write(bs)
+					return
+				},
+			}
+
+			recording := filepath.Join(t.TempDir(), "recording.jsonl")
+			rb, err := NewRecordBackend(b, recording)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			for i, backend := range []func() (Backend, error){
+				func() (Backend, error) { return rb, nil },
+				func() (Backend, error) {
+					if err := rb.Close(); err != nil {
+						return nil, err
+					}
+
+					return NewReplayBackend(recording)
+				},
+			} {
+				var bk Backend
+				if bk, err = backend(); err != nil {
+					t.Fatal(err)
+				}
+
+				var opts Options
+				opts.Dir = fmt.Sprintf("test_%d", time.Now().UnixNano())
+				opts.Name = "foo"
+				var d DB[testentry]
+				if d, err = makeDB[testentry](opts, bk); err != nil {
+					t.Fatal(err)
+				}
+				defer os.RemoveAll(d.o.Dir)
+
+				got, err := tt.run(&d)
+				if err != tt.wantErr {
+					t.Fatalf("run %d error = %v, wantErr %v", i, err, tt.wantErr)
+				}
+
+				if got != tt.wantGot {
+					t.Errorf("run %d = %q, want %q", i, got, tt.wantGot)
+				}
+
+				// Interactions which were not recorded cannot be replayed
+				if _, ok := bk.(*ReplayBackend); ok {
+					err = d.Get(&bytes.Buffer{}, "unrecorded")
+					if !errors.Is(err, ErrNoRecordedInteraction) {
+						t.Errorf("DB.Get() error = %v, want %v", err, ErrNoRecordedInteraction)
+					}
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,121 @@
+package csvdb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+)
+
+// ErrInvalidReplicaInterval is returned when ReplicaInterval is negative, or set without ReadOnly
+var ErrInvalidReplicaInterval = errors.New("invalid replicaInterval, cannot be less than 0 and requires readOnly")
+
+// Refresh will compare the keys held locally with the Backend, which is treated as the source
+// of truth. Keys whose exported file has been modified since they were downloaded are
+// downloaded again, and keys whose exported file no longer exists are removed locally.
+// Returns the number of keys which were refreshed. Refresh is called at each
+// Options.ReplicaInterval, and requires the Backend to implement Stater.
+// Note: Modification times of the Backend are compared with the local clock
+func (d *DB[T]) Refresh(ctx context.Context) (refreshed int, err error) {
+	if d.ib == nil {
+		return 0, ErrBackendNotSet
+	}
+
+	var stale []string
+	if err = d.forEach(func(filename string, info os.FileInfo) (err error) {
+		var ok bool
+		if ok, err = d.isStale(ctx, filename, info); err != nil {
+			return fmt.Errorf("error checking <%s>: %w", filename, err)
+		} else if ok {
+			stale = append(stale, d.getKey(filename))
+		}
+
+		return
+	}); err != nil {
+		return
+	}
+
+	var keys []string
+	for _, key := range stale {
+		var ok bool
+		if ok, err = d.invalidate(ctx, key); err != nil {
+			return
+		} else if ok {
+			keys = append(keys, key)
+		}
+	}
+
+	// Keys whose exported file has been removed are skipped by Preload
+	return len(keys), d.PreloadContext(ctx, keys...)
+}
+
+// isStale will return whether the exported file of a local file has been modified or
+// removed since the local file was downloaded
+func (d *DB[T]) isStale(ctx context.Context, filename string, info os.FileInfo) (ok bool, err error) {
+	var remote RemoteInfo
+	if remote, err = d.statRemote(ctx, filename); err != nil {
+		return
+	}
+
+	if !remote.Exists {
+		return true, nil
+	}
+
+	fetched := info.ModTime()
+	if exported := d.getLastExported(filename); exported.After(fetched) {
+		fetched = exported
+	}
+
+	return remote.ModTime.After(fetched), nil
+}
+
+// invalidate will remove the local copy of a key, along with its export marker, so it is
+// downloaded again. Keys which were modified or removed since they were found stale are skipped.
+func (d *DB[T]) invalidate(ctx context.Context, key string) (ok bool, err error) {
+	// Removing stale copies is permitted while ReadOnly is set, so the lock is acquired directly
+	var unlock func()
+	if unlock, err = d.lockKeysWith(ctx, false, []string{key}); err != nil {
+		return
+	}
+	defer unlock()
+
+	name, filename := d.getFilename(key)
+	var info os.FileInfo
+	if info, err = d.fs.Stat(filename); os.IsNotExist(err) {
+		return false, nil
+	} else if err != nil {
+		return
+	}
+
+	// Re-check while the lock is held, as the key may have been downloaded again meanwhile
+	if ok, err = d.isStale(ctx, name, info); err != nil || !ok {
+		return
+	}
+
+	if err = d.fs.Remove(filename); err != nil {
+		return
+	}
+
+	if err = d.fs.Remove(filename + ".exported"); err != nil && !os.IsNotExist(err) {
+		return
+	}
+
+	return true, nil
+}
+
+func (d *DB[T]) asyncRefresh() {
+	ctx, cancel := context.WithTimeout(d.ctx, d.o.ReplicaInterval)
+	defer cancel()
+
+	start := time.Now()
+	refreshed, err := d.Refresh(ctx)
+	if err != nil {
+		d.o.Logger.Printf("csvdb.DB[%s].asyncRefresh(): error refreshing: %v\n", d.o.Name, err)
+		return
+	}
+
+	if refreshed > 0 {
+		d.o.Logger.Printf("csvdb.DB[%s].asyncRefresh(): refreshed %d keys in %v\n", d.o.Name, refreshed, time.Since(start))
+	}
+}
@@ -0,0 +1,225 @@
+package csvdb
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"testing"
+	"time"
+)
+
+type mockReplicaBackend struct {
+	mockBackend
+
+	mux      sync.Mutex
+	remote   map[string]string
+	modTimes map[string]time.Time
+}
+
+func newMockReplicaBackend() (m *mockReplicaBackend) {
+	m = &mockReplicaBackend{remote: make(map[string]string), modTimes: make(map[string]time.Time)}
+	m.importFn = func(ctx context.Context, prefix, filename string, w io.Writer) (err error) {
+		m.mux.Lock()
+		defer m.mux.Unlock()
+		bs, ok := m.remote[filename]
+		if !ok {
+			return os.ErrNotExist
+		}
+
+		_, err = io.WriteString(w, bs)
+		return
+	}
+
+	return
+}
+
+func (m *mockReplicaBackend) set(filename, bs string, modTime time.Time) {
+	m.mux.Lock()
+	defer m.mux.Unlock()
+	m.remote[filename] = bs
+	m.modTimes[filename] = modTime
+}
+
+func (m *mockReplicaBackend) remove(filename string) {
+	m.mux.Lock()
+	defer m.mux.Unlock()
+	delete(m.remote, filename)
+	delete(m.modTimes, filename)
+}
+
+func (m *mockReplicaBackend) Stat(ctx context.Context, prefix, filename string) (info RemoteInfo, err error) {
+	m.mux.Lock()
+	defer m.mux.Unlock()
+	_, info.Exists = m.remote[filename]
+	info.ModTime = m.modTimes[filename]
+	return
+}
+
+func TestDB_Refresh(t *testing.T) {
+	type testcase struct {
+		name   string
+		update func(b *mockReplicaBackend)
+
+		wantRefreshed int
+		wantA         string
+		wantBErr      error
+	}
+
+	tests := []testcase{
+		{
+			name:  "unchanged",
+			wantA: "foo,bar\na,1\n",
+		},
+		{
+			name: "modified",
+			update: func(b *mockReplicaBackend) {
+				b.set("foo.a.csv", "foo,bar\na,2\n", time.Now())
+			},
+			wantRefreshed: 1,
+			wantA:         "foo,bar\na,2\n",
+		},
+		{
+			name: "removed",
+			update: func(b *mockReplicaBackend) {
+				b.remove("foo.b.csv")
+			},
+			wantRefreshed: 1,
+			wantA:         "foo,bar\na,1\n",
+			wantBErr:      ErrEntryNotFound,
+		},
+		{
+			name: "modified and removed",
+			update: func(b *mockReplicaBackend) {
+				b.set("foo.a.csv", "foo,bar\na,2\n", time.Now())
+				b.remove("foo.b.csv")
+			},
+			wantRefreshed: 2,
+			wantA:         "foo,bar\na,2\n",
+			wantBErr:      ErrEntryNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := newMockReplicaBackend()
+			past := time.Now().Add(-time.Hour)
+			b.set("foo.a.csv", "foo,bar\na,1\n", past)
+			b.set("foo.b.csv", "foo,bar\nb,1\n", past)
+
+			var opts Options
+			opts.Dir = fmt.Sprintf("test_%d", time.Now().UnixNano())
+			opts.Name = "foo"
+			opts.ReadOnly = true
+			opts.ReplicaInterval = time.Hour
+			d, err := makeDB[testentry](opts, b)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer os.RemoveAll(opts.Dir)
+
+			w := &bytes.Buffer{}
+			for _, key := range []string{"a", "b"} {
+				if err = d.Get(w, key); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			if tt.update != nil {
+				tt.update(b)
+				// File modification times use a coarse clock, ensure downloads are made after the update
+				time.Sleep(20 * time.Millisecond)
+			}
+
+			refreshed, err := d.Refresh(context.Background())
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if refreshed != tt.wantRefreshed {
+				t.Errorf("DB.Refresh() refreshed = %d, want %d", refreshed, tt.wantRefreshed)
+			}
+
+			w.Reset()
+			if err = d.Get(w, "a"); err != nil {
+				t.Fatal(err)
+			}
+
+			if w.String() != tt.wantA {
+				t.Errorf("DB.Get(a) = %q, want %q", w.String(), tt.wantA)
+			}
+
+			if err = d.Get(io.Discard, "b"); !errors.Is(err, tt.wantBErr) {
+				t.Errorf("DB.Get(b) error = %v, want %v", err, tt.wantBErr)
+			}
+
+			if refreshed, err = d.Refresh(context.Background()); err != nil || refreshed != 0 {
+				t.Errorf("DB.Refresh() repeated refreshed = %d, err = %v, want 0 and nil", refreshed, err)
+			}
+		})
+	}
+}
+
+func TestNew_replica(t *testing.T) {
+	type testcase struct {
+		name     string
+		readOnly bool
+		interval time.Duration
+		backend  Backend
+		wantErr  error
+	}
+
+	tests := []testcase{
+		{
+			name:     "basic",
+			readOnly: true,
+			interval: time.Hour,
+			backend:  newMockReplicaBackend(),
+		},
+		{
+			name:     "negative interval",
+			readOnly: true,
+			interval: -time.Second,
+			backend:  newMockReplicaBackend(),
+			wantErr:  ErrInvalidReplicaInterval,
+		},
+		{
+			name:     "not read-only",
+			interval: time.Hour,
+			backend:  newMockReplicaBackend(),
+			wantErr:  ErrInvalidReplicaInterval,
+		},
+		{
+			name:     "no stater",
+			readOnly: true,
+			interval: time.Hour,
+			backend:  &mockBackend{},
+			wantErr:  ErrStaterNotImplemented,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var opts Options
+			opts.Dir = fmt.Sprintf("test_%d", time.Now().UnixNano())
+			opts.Name = "foo"
+			opts.ReadOnly = tt.readOnly
+			opts.ReplicaInterval = tt.interval
+			defer os.RemoveAll(opts.Dir)
+
+			d, err := New[testentry](context.Background(), opts, tt.backend)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("New() error = %v, wantErr %v", err, tt.wantErr)
+			} else if err != nil {
+				return
+			}
+
+			if err = d.Close(); err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
@@ -0,0 +1,69 @@
+package csvdb
+
+import "context"
+
+// Operation is the Backend method a request is made through
+type Operation string
+
+const (
+	// OpImport is a request made through Backend.Import
+	OpImport Operation = "import"
+	// OpExport is a request made through Backend.Export
+	OpExport Operation = "export"
+	// OpDelete is a request made through Deleter.Delete
+	OpDelete Operation = "delete"
+	// OpRename is a request made through Renamer.Rename
+	OpRename Operation = "rename"
+	// OpStat is a request made through Stater.Stat
+	OpStat Operation = "stat"
+	// OpList is a request made through Lister.List
+	OpList Operation = "list"
+	// OpHead is a request made through Header.Head
+	OpHead Operation = "head"
+)
+
+// RequestInfo is the metadata of a Backend request, available to Backends through the context
+// of the request by RequestInfoFromContext. It allows Backends to tag the objects they store
+// and attribute the cost of requests.
+type RequestInfo struct {
+	// DB is the name of the DB making the request
+	DB string
+	// Key is the key the request is made for, empty for requests which are not made for a
+	// single key such as publishing the catalog, listing files or exporting SQLite snapshots
+	Key string
+	// Op is the Backend method the request is made through
+	Op Operation
+	// TraceID is the trace ID set by WithTraceID on the context provided to the DB, if any
+	TraceID string
+}
+
+type (
+	requestInfoKey struct{}
+	requestKeyKey  struct{}
+	traceIDKey     struct{}
+)
+
+// WithTraceID will return a context carrying a trace ID, which is passed to the Backend
+// requests made by the DB using the context
+func WithTraceID(ctx context.Context, traceID string) context.Context {
+	return context.WithValue(ctx, traceIDKey{}, traceID)
+}
+
+// RequestInfoFromContext will return the metadata of the Backend request made with the context
+func RequestInfoFromContext(ctx context.Context) (info RequestInfo, ok bool) {
+	info, ok = ctx.Value(requestInfoKey{}).(RequestInfo)
+	return
+}
+
+// withKey will return a context carrying the key which subsequent Backend requests are made for
+func withKey(ctx context.Context, key string) context.Context {
+	return context.WithValue(ctx, requestKeyKey{}, key)
+}
+
+// request will return the context of a Backend request made through the provided method
+func (d *DB[T]) request(ctx context.Context, op Operation) context.Context {
+	info := RequestInfo{DB: d.o.Name, Op: op}
+	info.Key, _ = ctx.Value(requestKeyKey{}).(string)
+	info.TraceID, _ = ctx.Value(traceIDKey{}).(string)
+	return context.WithValue(ctx, requestInfoKey{}, info)
+}
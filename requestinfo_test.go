@@ -0,0 +1,74 @@
+package csvdb
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestDB_RequestInfo(t *testing.T) {
+	dir := fmt.Sprintf("test_%d", time.Now().UnixNano())
+	defer os.RemoveAll(dir)
+
+	var (
+		mux  sync.Mutex
+		got  []RequestInfo
+		seen = func(ctx context.Context) {
+			mux.Lock()
+			defer mux.Unlock()
+			info, ok := RequestInfoFromContext(ctx)
+			if !ok {
+				t.Error("request made without RequestInfo")
+			}
+
+			got = append(got, info)
+		}
+	)
+
+	b := &mockBackend{
+		importFn: func(ctx context.Context, prefix, filename string, w io.Writer) (err error) {
+			seen(ctx)
+			_, err = w.Write([]byte("foo,bar\n"))
+			return
+		},
+		exportFn: func(ctx context.Context, prefix, filename string, r io.Reader) (string, error) {
+			seen(ctx)
+			return filename, nil
+		},
+	}
+
+	var opts Options
+	opts.Dir = dir
+	opts.Name = "foo"
+	d, err := makeDB[testentry](opts, b)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := WithTraceID(context.Background(), "trace-1")
+	if err = d.GetContext(ctx, io.Discard, "remote"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err = d.Append("local", testentry{Foo: "1", Bar: "1b"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err = d.BarrierContext(WithTraceID(context.Background(), "trace-2"), "local", true); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []RequestInfo{
+		{DB: "foo", Key: "remote", Op: OpImport, TraceID: "trace-1"},
+		{DB: "foo", Key: "local", Op: OpExport, TraceID: "trace-2"},
+	}
+
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("RequestInfo = %+v, want %+v", got, want)
+	}
+}
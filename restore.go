@@ -0,0 +1,70 @@
+package csvdb
+
+import (
+	"context"
+	"os"
+	"time"
+)
+
+// Restore will replace the local copy of a key with the copy held by the backend, even when
+// the local file exists. The local file is only replaced once the download has succeeded.
+func (d *DB[T]) Restore(key string) (err error) {
+	return d.RestoreContext(context.Background(), key)
+}
+
+// RestoreContext is the context-aware variant of Restore
+func (d *DB[T]) RestoreContext(ctx context.Context, key string) (err error) {
+	var unlock func()
+	if unlock, err = d.lockKeys(ctx, key); err != nil {
+		return
+	}
+	defer unlock()
+
+	if d.ib == nil {
+		return ErrBackendNotSet
+	}
+
+	if err = d.checkQuarantine(key); err != nil {
+		return
+	}
+
+	name, filename := d.getFilename(key)
+	var tmp file
+	if tmp, err = createTemp(d.fs, d.o.TempDir, filename); err != nil {
+		return
+	}
+
+	defer func() {
+		if err == nil {
+			return
+		}
+
+		tmp.Close()
+		d.fs.Remove(tmp.Name())
+	}()
+
+	start := time.Now()
+	err = d.importFile(ctx, name, tmp)
+	d.downloads.Add(1)
+	d.downloadTime.Add(int64(time.Since(start)))
+	switch {
+	case err == nil:
+	case os.IsNotExist(err):
+		return ErrEntryNotFound
+	default:
+		return
+	}
+
+	if err = tmp.Close(); err != nil {
+		return
+	}
+
+	if err = d.fs.Rename(tmp.Name(), filename); err != nil {
+		return
+	}
+
+	d.record(key, Event{Type: EventRewritten})
+
+	// The local copy matches the backend, it does not need to be exported
+	return d.setSynced(name)
+}
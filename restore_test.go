@@ -0,0 +1,115 @@
+package csvdb
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDB_Restore(t *testing.T) {
+	type testcase struct {
+		name      string
+		noBackend bool
+		remote    map[string]string
+		importErr error
+
+		wantErr error
+		wantW   string
+	}
+
+	tests := []testcase{
+		{
+			name:   "basic",
+			remote: map[string]string{"foo.a.csv": "foo,bar\n2,2b\n"},
+			wantW:  "foo,bar\n2,2b\n",
+		},
+		{
+			name:    "not found",
+			remote:  map[string]string{},
+			wantErr: ErrEntryNotFound,
+			wantW:   "foo,bar\n1,1b\n",
+		},
+		{
+			name:      "import error",
+			importErr: errors.New("connection reset"),
+			wantErr:   errors.New("connection reset"),
+			wantW:     "foo,bar\n1,1b\n",
+		},
+		{
+			name:      "backend not set",
+			noBackend: true,
+			wantErr:   ErrBackendNotSet,
+			wantW:     "foo,bar\n1,1b\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var b Backend = &mockBackend{
+				importFn: func(ctx context.Context, prefix, filename string, w io.Writer) (err error) {
+					if tt.importErr != nil {
+						// Ensure partial downloads do not affect the local copy
+						w.Write([]byte("partial"))
+						return tt.importErr
+					}
+
+					value, ok := tt.remote[filename]
+					if !ok {
+						return os.ErrNotExist
+					}
+
+					_, err = io.WriteString(w, value)
+					return
+				},
+			}
+
+			if tt.noBackend {
+				b = nil
+			}
+
+			var opts Options
+			opts.Dir = fmt.Sprintf("test_%d", time.Now().UnixNano())
+			opts.Name = "foo"
+			d, err := makeDB[testentry](opts, b)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer os.RemoveAll(d.o.Dir)
+
+			if err = d.Append("a", testentry{Foo: "1", Bar: "1b"}); err != nil {
+				t.Fatal(err)
+			}
+
+			err = d.Restore("a")
+			if (err == nil) != (tt.wantErr == nil) || (err != nil && err.Error() != tt.wantErr.Error()) {
+				t.Fatalf("DB.Restore() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			w := &bytes.Buffer{}
+			if err = d.Get(w, "a"); err != nil {
+				t.Fatal(err)
+			}
+
+			if w.String() != tt.wantW {
+				t.Errorf("DB.Get() = %q, want %q", w.String(), tt.wantW)
+			}
+
+			var entries []os.DirEntry
+			if entries, err = os.ReadDir(d.getFullPath()); err != nil {
+				t.Fatal(err)
+			}
+
+			for _, entry := range entries {
+				if strings.HasSuffix(entry.Name(), ".tmp") {
+					t.Errorf("DB.Restore() left temporary file <%s>", entry.Name())
+				}
+			}
+		})
+	}
+}
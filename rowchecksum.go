@@ -0,0 +1,55 @@
+package csvdb
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+)
+
+// RowChecksumColumn is the header of the column holding the checksum of each row when
+// Options.RowChecksums is set. It is always the last column of a file.
+const RowChecksumColumn = "_checksum"
+
+// ErrRowChecksumMismatch is returned when the values of a row do not match its checksum
+var ErrRowChecksumMismatch = errors.New("row checksum mismatch")
+
+// seal will append the checksum of a row when Options.RowChecksums is set
+func (d *DB[T]) seal(values []string) []string {
+	if !d.o.RowChecksums {
+		return values
+	}
+
+	// The values are copied, as they may be backed by the Entry
+	return append(values[:len(values):len(values)], rowChecksum(values))
+}
+
+// rowChecksummed will return whether the rows of a header end with a checksum
+func rowChecksummed(header []string) bool {
+	return len(header) > 0 && header[len(header)-1] == RowChecksumColumn
+}
+
+// openRow will verify a row against its checksum, returning the values without the checksum
+func openRow(values []string) (out []string, err error) {
+	if len(values) == 0 {
+		return nil, ErrRowChecksumMismatch
+	}
+
+	out = values[:len(values)-1]
+	if rowChecksum(out) != values[len(values)-1] {
+		return nil, ErrRowChecksumMismatch
+	}
+
+	return
+}
+
+// rowChecksum will return the checksum of the values of a row, which is the hex encoded
+// first 8 bytes of the SHA-256 of the values, each followed by a unit separator
+func rowChecksum(values []string) string {
+	h := sha256.New()
+	for _, value := range values {
+		h.Write([]byte(value))
+		h.Write([]byte{0x1f})
+	}
+
+	return hex.EncodeToString(h.Sum(nil)[:8])
+}
@@ -0,0 +1,156 @@
+package csvdb
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDB_RowChecksums(t *testing.T) {
+	type testcase struct {
+		name  string
+		opts  func(o *Options)
+		write func(d *DB[testentry]) error
+	}
+
+	tests := []testcase{
+		{
+			name:  "append",
+			opts:  func(o *Options) {},
+			write: func(d *DB[testentry]) error { return d.Append("a", testentry{Foo: "1", Bar: "1b"}) },
+		},
+		{
+			name:  "atomic append",
+			opts:  func(o *Options) { o.AtomicAppend = true },
+			write: func(d *DB[testentry]) error { return d.Append("a", testentry{Foo: "1", Bar: "1b"}) },
+		},
+		{
+			name: "writer",
+			opts: func(o *Options) {},
+			write: func(d *DB[testentry]) (err error) {
+				var w *EntryWriter[testentry]
+				if w, err = d.Writer("a"); err != nil {
+					return
+				}
+
+				if err = w.Write(testentry{Foo: "1", Bar: "1b"}); err != nil {
+					return
+				}
+
+				return w.Close()
+			},
+		},
+		{
+			name:  "append raw",
+			opts:  func(o *Options) {},
+			write: func(d *DB[testentry]) error { return d.AppendRaw("a", strings.NewReader("1,1b,\n")) },
+		},
+		{
+			name:  "upsert",
+			opts:  func(o *Options) {},
+			write: func(d *DB[testentry]) error { return d.Upsert("a", "foo", testentry{Foo: "1", Bar: "1b"}) },
+		},
+		{
+			name: "column policies",
+			opts: func(o *Options) {
+				o.ColumnPolicies = map[string]ColumnPolicy{"bar": {Action: ColumnHash}}
+			},
+			write: func(d *DB[testentry]) error { return d.Append("a", testentry{Foo: "1", Bar: "1b"}) },
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var opts Options
+			opts.Dir = fmt.Sprintf("test_%d", time.Now().UnixNano())
+			opts.Name = "foo"
+			opts.RowChecksums = true
+			tt.opts(&opts)
+			d, err := makeDB[testentry](opts, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer os.RemoveAll(opts.Dir)
+
+			if err = tt.write(&d); err != nil {
+				t.Fatal(err)
+			}
+
+			if err = d.Append("a", testentry{Foo: "2", Bar: "2b"}); err != nil {
+				t.Fatal(err)
+			}
+
+			w := &bytes.Buffer{}
+			if err = d.Get(w, "a"); err != nil {
+				t.Fatal(err)
+			}
+
+			if !strings.HasPrefix(w.String(), "foo,bar,"+RowChecksumColumn+"\n") {
+				t.Fatalf("DB.Get() = %q, want header ending with %s", w.String(), RowChecksumColumn)
+			}
+
+			// Rows are provided to DeleteRows without their checksum, and kept as they are stored
+			if err = d.DeleteRows("a", func(values []string) bool {
+				if len(values) != 2 {
+					t.Errorf("DeleteRows() values = %q, want 2 values", values)
+				}
+
+				return values[0] == "1"
+			}); err != nil {
+				t.Fatal(err)
+			}
+
+			// Rows are verified and updated rows are checksummed again
+			if err = d.UpdateRows("a", func(e testentry) (testentry, bool, error) {
+				e.Foo += "0"
+				return e, true, nil
+			}); err != nil {
+				t.Fatal(err)
+			}
+
+			if err = d.AppendUnique("a", testentry{Foo: "20", Bar: "2b"}); err != nil {
+				t.Fatal(err)
+			}
+
+			w.Reset()
+			if err = d.Get(w, "a"); err != nil {
+				t.Fatal(err)
+			}
+
+			if strings.Contains(w.String(), "\n10,") {
+				t.Errorf("DB.Get() = %q, want the row of 1 deleted", w.String())
+			}
+
+			if n := strings.Count(w.String(), "\n20,"); n != 1 {
+				t.Errorf("DB.Get() = %q, want a single row of 20", w.String())
+			}
+
+			// A corrupted row is detected rather than parsed
+			filename := d.getPath("foo.a.csv")
+			bs, err := os.ReadFile(filename)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if err = os.WriteFile(filename, bytes.Replace(bs, []byte("20,"), []byte("21,"), 1), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			if err = d.UpdateRows("a", func(e testentry) (testentry, bool, error) { return e, true, nil }); !errors.Is(err, ErrRowChecksumMismatch) {
+				t.Errorf("DB.UpdateRows() error = %v, wantErr %v", err, ErrRowChecksumMismatch)
+			}
+
+			if err = d.DeleteRows("a", func([]string) bool { return false }); !errors.Is(err, ErrRowChecksumMismatch) {
+				t.Errorf("DB.DeleteRows() error = %v, wantErr %v", err, ErrRowChecksumMismatch)
+			}
+
+			if err = d.AppendUnique("a", testentry{Foo: "3", Bar: "3b"}); !errors.Is(err, ErrRowChecksumMismatch) {
+				t.Errorf("DB.AppendUnique() error = %v, wantErr %v", err, ErrRowChecksumMismatch)
+			}
+		})
+	}
+}
@@ -0,0 +1,330 @@
+package csvdb
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+)
+
+// indexExt is the extension of the sparse row index written alongside the file of a key
+const indexExt = ".idx"
+
+var (
+	// ErrInvalidRowIndexInterval is returned when Options.RowIndexInterval is negative
+	ErrInvalidRowIndexInterval = errors.New("invalid rowIndexInterval, cannot be negative")
+	// ErrInvalidRange is returned when a range is requested with a negative start or a non-positive count
+	ErrInvalidRange = errors.New("invalid range, start cannot be negative and count must be greater than 0")
+)
+
+// rowIndex is the sparse index of a file, holding the byte offset of every Interval rows
+type rowIndex struct {
+	Interval int `json:"interval"`
+	// Size is the size of the file covered by the index
+	Size int64 `json:"size"`
+	// Header is the offset the header of the file ends at
+	Header int64 `json:"header"`
+	// Rows is the number of rows following the header within Size
+	Rows int `json:"rows"`
+	// Offsets are the offsets of row 0, row Interval, row 2*Interval and so on
+	Offsets []int64 `json:"offsets"`
+}
+
+// GetRange will write the header of a key followed by up to count rows, starting at the
+// zero-based row start. When Options.RowIndexInterval is set, the rows are found by seeking
+// from the nearest indexed row rather than scanning from the top of the file.
+func (d *DB[T]) GetRange(w io.Writer, key string, start, count int) (err error) {
+	return d.GetRangeContext(d.context(), w, key, start, count)
+}
+
+// GetRangeContext is the context-aware variant of GetRange
+func (d *DB[T]) GetRangeContext(ctx context.Context, w io.Writer, key string, start, count int) (err error) {
+	w, flush := d.encodeOutput(w)
+	defer flush(&err)
+
+	if start < 0 || count <= 0 {
+		return ErrInvalidRange
+	}
+
+	return d.getRange(ctx, w, key, func(rows int) int {
+		return start
+	}, count)
+}
+
+// Tail will write the header of a key followed by its last n rows
+func (d *DB[T]) Tail(w io.Writer, key string, n int) (err error) {
+	return d.TailContext(d.context(), w, key, n)
+}
+
+// TailContext is the context-aware variant of Tail
+func (d *DB[T]) TailContext(ctx context.Context, w io.Writer, key string, n int) (err error) {
+	w, flush := d.encodeOutput(w)
+	defer flush(&err)
+
+	if n <= 0 {
+		return ErrInvalidRange
+	}
+
+	return d.getRange(ctx, w, key, func(rows int) int {
+		return max(rows-n, 0)
+	}, n)
+}
+
+// getRange will write the header of a key followed by up to count rows, starting at the row
+// returned by getStart for the number of rows within the file
+func (d *DB[T]) getRange(ctx context.Context, w io.Writer, key string, getStart func(rows int) int, count int) (err error) {
+	var unlock func()
+	if unlock, err = d.rlockKey(ctx, key); err != nil {
+		return
+	}
+	defer unlock()
+	defer d.trackHydration(key)()
+
+	var f file
+	if f, err = d.getOrDownload(ctx, key); err != nil {
+		return
+	}
+	defer f.Close()
+
+	_, filename := d.getFilename(key)
+	var idx rowIndex
+	if idx, err = d.getRowIndex(f, filename); err != nil {
+		return
+	}
+
+	if err = copyRange(ctx, w, f, 0, idx.Header); err != nil {
+		return
+	}
+
+	start := getStart(idx.Rows)
+	if start >= idx.Rows {
+		return
+	}
+
+	// Seek to the nearest indexed row and skip the rows up to the start
+	checkpoint := start / idx.Interval
+	from := idx.Offsets[checkpoint]
+	skip := start - checkpoint*idx.Interval
+	end := min(start+count, idx.Rows) - start
+	var offsets []int64
+	if offsets, err = rowOffsets(f, from, idx.Size, d.dialect, skip, skip+end); err != nil {
+		return
+	}
+
+	return copyRange(ctx, w, f, offsets[0], offsets[1])
+}
+
+// getRowIndex will return the row index of a file, catching it up with rows appended since
+// it was last updated. Indexes are only persisted when Options.RowIndexInterval is set.
+func (d *DB[T]) getRowIndex(f file, filename string) (idx rowIndex, err error) {
+	interval := d.o.RowIndexInterval
+	if interval == 0 {
+		// Only the first row is indexed, reads scan from the top of the file
+		interval = math.MaxInt
+	}
+
+	var info os.FileInfo
+	if info, err = f.Stat(); err != nil {
+		return
+	}
+
+	if d.o.RowIndexInterval > 0 {
+		idx = d.loadRowIndex(filename)
+	}
+
+	if idx.Interval != interval || idx.Size > info.Size() {
+		// Missing, built with another interval or covering a file which has since been truncated
+		idx = rowIndex{Interval: interval}
+	}
+
+	if idx.Size == info.Size() {
+		return
+	}
+
+	if err = idx.update(f, info.Size(), d.dialect); err != nil {
+		return
+	}
+
+	if d.o.RowIndexInterval == 0 || d.o.ReadOnly {
+		return
+	}
+
+	// The index is a cache, a failure to persist it only costs a later re-scan
+	if serr := d.saveRowIndex(filename, idx); serr != nil {
+		d.o.Logger.Printf("csvdb.DB[%s].getRowIndex(): error saving index of <%s>: %v\n", d.o.Name, filename, serr)
+	}
+
+	return
+}
+
+// updateRowIndex will catch the row index of a file up with an append
+func (d *DB[T]) updateRowIndex(filename string) {
+	if d.o.RowIndexInterval == 0 {
+		return
+	}
+
+	f, err := d.fs.Open(filename)
+	if err == nil {
+		_, err = d.getRowIndex(f, filename)
+		f.Close()
+	}
+
+	if err != nil {
+		d.o.Logger.Printf("csvdb.DB[%s].updateRowIndex(): error indexing <%s>: %v\n", d.o.Name, filename, err)
+	}
+}
+
+func (d *DB[T]) loadRowIndex(filename string) (idx rowIndex) {
+	f, err := d.fs.Open(filename + indexExt)
+	if err != nil {
+		return
+	}
+	defer f.Close()
+
+	if err = json.NewDecoder(f).Decode(&idx); err != nil {
+		// Indexes which cannot be read are rebuilt
+		return rowIndex{}
+	}
+
+	return
+}
+
+func (d *DB[T]) saveRowIndex(filename string, idx rowIndex) (err error) {
+	var tmp file
+	if tmp, err = createTemp(d.fs, d.o.TempDir, filename+indexExt); err != nil {
+		return
+	}
+
+	err = json.NewEncoder(tmp).Encode(idx)
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+
+	if err == nil {
+		err = d.fs.Rename(tmp.Name(), filename+indexExt)
+	}
+
+	if err != nil {
+		d.fs.Remove(tmp.Name())
+	}
+
+	return
+}
+
+// update will index the rows of a file between the last indexed row and size
+func (idx *rowIndex) update(f file, size int64, dialect csvDialect) (err error) {
+	from := int64(0)
+	row := 0
+	if n := len(idx.Offsets); n > 0 {
+		// Re-scan from the last indexed row, as rows since then are not indexed
+		from = idx.Offsets[n-1]
+		row = (n - 1) * idx.Interval
+	}
+
+	if _, err = f.Seek(from, io.SeekStart); err != nil {
+		return
+	}
+
+	r := newCSVReader(io.LimitReader(f, size-from), dialect)
+	r.FieldsPerRecord = -1
+	r.ReuseRecord = true
+	if from == 0 {
+		switch _, err = r.Read(); err {
+		case nil:
+		case io.EOF:
+			idx.Size = size
+			return nil
+		default:
+			return
+		}
+
+		idx.Header = r.InputOffset()
+	}
+
+	for {
+		offset := from + r.InputOffset()
+		switch _, err = r.Read(); err {
+		case nil:
+		case io.EOF:
+			idx.Size = size
+			idx.Rows = row
+			return nil
+		default:
+			return
+		}
+
+		if row%idx.Interval == 0 && row/idx.Interval == len(idx.Offsets) {
+			idx.Offsets = append(idx.Offsets, offset)
+		}
+
+		row++
+	}
+}
+
+// rowOffsets will return the offsets of the rows at the provided positions, relative to the
+// row at from. Positions past the final row resolve to the end of the file.
+func rowOffsets(f file, from, size int64, dialect csvDialect, positions ...int) (offsets []int64, err error) {
+	if _, err = f.Seek(from, io.SeekStart); err != nil {
+		return
+	}
+
+	r := newCSVReader(io.LimitReader(f, size-from), dialect)
+	r.FieldsPerRecord = -1
+	r.ReuseRecord = true
+	offsets = make([]int64, 0, len(positions))
+	for row := 0; len(offsets) < len(positions); row++ {
+		for len(offsets) < len(positions) && positions[len(offsets)] == row {
+			offsets = append(offsets, from+r.InputOffset())
+		}
+
+		switch _, err = r.Read(); err {
+		case nil:
+		case io.EOF:
+			for len(offsets) < len(positions) {
+				offsets = append(offsets, size)
+			}
+
+			return offsets, nil
+		default:
+			return
+		}
+	}
+
+	return
+}
+
+// copyRange will copy the bytes of a file between the start and end offsets
+func copyRange(ctx context.Context, w io.Writer, f file, start, end int64) (err error) {
+	if _, err = f.Seek(start, io.SeekStart); err != nil {
+		return
+	}
+
+	_, err = io.CopyN(w, newContextReader(ctx, f), end-start)
+	return
+}
+
+// indexFS is a fileSystem which removes the row index of files as they are removed or
+// replaced, so indexes only ever cover files which have been appended to
+type indexFS struct {
+	fileSystem
+}
+
+func (fsys indexFS) Remove(name string) error {
+	fsys.removeIndex(name)
+	return fsys.fileSystem.Remove(name)
+}
+
+func (fsys indexFS) Rename(oldpath, newpath string) error {
+	fsys.removeIndex(oldpath)
+	fsys.removeIndex(newpath)
+	return fsys.fileSystem.Rename(oldpath, newpath)
+}
+
+func (fsys indexFS) removeIndex(name string) {
+	if filepath.Ext(name) == ".csv" {
+		fsys.fileSystem.Remove(name + indexExt)
+	}
+}
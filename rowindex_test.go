@@ -0,0 +1,234 @@
+package csvdb
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDB_GetRange(t *testing.T) {
+	type testcase struct {
+		name string
+		// get reads the rows, given a DB holding rows 0 through 9 under the key "foo"
+		get     func(d *DB[testentry], w *bytes.Buffer) error
+		want    []int
+		wantErr error
+	}
+
+	tests := []testcase{
+		{
+			name: "start",
+			get: func(d *DB[testentry], w *bytes.Buffer) error {
+				return d.GetRange(w, "foo", 0, 3)
+			},
+			want: []int{0, 1, 2},
+		},
+		{
+			name: "middle",
+			get: func(d *DB[testentry], w *bytes.Buffer) error {
+				return d.GetRange(w, "foo", 4, 3)
+			},
+			want: []int{4, 5, 6},
+		},
+		{
+			name: "past end",
+			get: func(d *DB[testentry], w *bytes.Buffer) error {
+				return d.GetRange(w, "foo", 8, 5)
+			},
+			want: []int{8, 9},
+		},
+		{
+			name: "out of range",
+			get: func(d *DB[testentry], w *bytes.Buffer) error {
+				return d.GetRange(w, "foo", 12, 5)
+			},
+			want: []int{},
+		},
+		{
+			name: "tail",
+			get: func(d *DB[testentry], w *bytes.Buffer) error {
+				return d.Tail(w, "foo", 3)
+			},
+			want: []int{7, 8, 9},
+		},
+		{
+			name: "tail of more rows than held",
+			get: func(d *DB[testentry], w *bytes.Buffer) error {
+				return d.Tail(w, "foo", 20)
+			},
+			want: []int{0, 1, 2, 3, 4, 5, 6, 7, 8, 9},
+		},
+		{
+			name: "invalid range",
+			get: func(d *DB[testentry], w *bytes.Buffer) error {
+				return d.GetRange(w, "foo", -1, 3)
+			},
+			wantErr: ErrInvalidRange,
+		},
+		{
+			name: "missing key",
+			get: func(d *DB[testentry], w *bytes.Buffer) error {
+				return d.Tail(w, "bar", 3)
+			},
+			wantErr: ErrEntryNotFound,
+		},
+	}
+
+	for _, interval := range []int{0, 1, 3} {
+		for _, tt := range tests {
+			t.Run(fmt.Sprintf("%s with interval %d", tt.name, interval), func(t *testing.T) {
+				dir := fmt.Sprintf("test_%d", time.Now().UnixNano())
+				defer os.RemoveAll(dir)
+
+				var opts Options
+				opts.Dir = dir
+				opts.Name = "foo"
+				opts.RowIndexInterval = interval
+				b := &mockBackend{
+					importFn: func(ctx context.Context, prefix, filename string, w io.Writer) error {
+						return os.ErrNotExist
+					},
+				}
+
+				d, err := makeDB[testentry](opts, b)
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				// Appended across calls so the index is caught up on each append
+				for i := 0; i < 10; i += 2 {
+					if err = d.Append("foo", rangeEntry(i), rangeEntry(i+1)); err != nil {
+						t.Fatal(err)
+					}
+				}
+
+				w := &bytes.Buffer{}
+				if err = tt.get(&d, w); err != tt.wantErr {
+					t.Fatalf("error = %v, wantErr %v", err, tt.wantErr)
+				} else if err != nil {
+					return
+				}
+
+				if want := rangeFile(tt.want...); w.String() != want {
+					t.Errorf("got %q, want %q", w.String(), want)
+				}
+			})
+		}
+	}
+}
+
+func TestDB_GetRange_stale(t *testing.T) {
+	type testcase struct {
+		name string
+		// modify changes the key "foo" outside of Append, after its index has been built
+		modify func(d *DB[testentry]) error
+		want   []int
+	}
+
+	tests := []testcase{
+		{
+			name: "appended raw",
+			modify: func(d *DB[testentry]) error {
+				return d.AppendRaw("foo", strings.NewReader(rangeFile(6, 7)))
+			},
+			want: []int{0, 1, 2, 3, 4, 5, 6, 7},
+		},
+		{
+			name: "appended externally",
+			modify: func(d *DB[testentry]) error {
+				_, filename := d.getFilename("foo")
+				f, err := os.OpenFile(filename, os.O_WRONLY|os.O_APPEND, 0644)
+				if err != nil {
+					return err
+				}
+				defer f.Close()
+
+				_, err = f.WriteString(strings.TrimPrefix(rangeFile(6, 7, 8), "foo,bar\n"))
+				return err
+			},
+			want: []int{0, 1, 2, 3, 4, 5, 6, 7, 8},
+		},
+		{
+			name: "rewritten",
+			modify: func(d *DB[testentry]) error {
+				return d.DeleteRows("foo", func(values []string) bool {
+					return values[0] == "1" || values[0] == "2"
+				})
+			},
+			want: []int{0, 3, 4, 5},
+		},
+		{
+			name: "truncated",
+			modify: func(d *DB[testentry]) error {
+				if err := d.Truncate("foo"); err != nil {
+					return err
+				}
+
+				return d.Append("foo", rangeEntry(8))
+			},
+			want: []int{8},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := fmt.Sprintf("test_%d", time.Now().UnixNano())
+			defer os.RemoveAll(dir)
+
+			var opts Options
+			opts.Dir = dir
+			opts.Name = "foo"
+			opts.RowIndexInterval = 2
+			d, err := makeDB[testentry](opts, &mockBackend{})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			for i := 0; i < 6; i++ {
+				if err = d.Append("foo", rangeEntry(i)); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			_, filename := d.getFilename("foo")
+			if _, err = os.Stat(filename + indexExt); err != nil {
+				t.Fatalf("index was not written: %v", err)
+			}
+
+			if err = tt.modify(&d); err != nil {
+				t.Fatal(err)
+			}
+
+			w := &bytes.Buffer{}
+			if err = d.GetRange(w, "foo", 0, 10); err != nil {
+				t.Fatal(err)
+			}
+
+			if want := rangeFile(tt.want...); w.String() != want {
+				t.Errorf("DB.GetRange() = %q, want %q", w.String(), want)
+			}
+		})
+	}
+}
+
+// rangeEntry will return an entry whose second column spans multiple lines, so rows
+// cannot be found by counting newlines
+func rangeEntry(i int) testentry {
+	return testentry{Foo: fmt.Sprint(i), Bar: fmt.Sprintf("line\n%d", i)}
+}
+
+// rangeFile will return the file holding the entries of the provided rows
+func rangeFile(rows ...int) string {
+	var sb strings.Builder
+	sb.WriteString("foo,bar\n")
+	for _, i := range rows {
+		fmt.Fprintf(&sb, "%d,\"line\n%d\"\n", i, i)
+	}
+
+	return sb.String()
+}
@@ -1,24 +1,27 @@
 package csvdb
 
 import (
-	"encoding/csv"
 	"fmt"
 	"io"
 	"io/fs"
-	"os"
 	"sync"
 )
 
-func makeRows(f *os.File) (r Rows) {
+func makeRows(f file, dialect csvDialect) (r Rows) {
 	r.f = f
+	r.dialect = dialect
 	return
 }
 
 type Rows struct {
-	mux sync.Mutex
-	f   *os.File
+	mux     sync.Mutex
+	f       file
+	dialect csvDialect
 }
 
+// ForEach will call fn for each row following the header. The values slice is reused
+// between calls, so it must be copied when it is retained after fn returns. When the header
+// ends with RowChecksumColumn, each row is verified and provided without its checksum.
 func (r *Rows) ForEach(fn func([]string) error) (err error) {
 	r.mux.Lock()
 	defer r.mux.Unlock()
@@ -36,20 +39,31 @@ func (r *Rows) ForEach(fn func([]string) error) (err error) {
 		return
 	}
 
-	rr := csv.NewReader(r.f)
+	rr, br := getCSVReader(r.f, r.dialect)
+	defer putBufReader(br)
+	rr.ReuseRecord = true
 
 	// Read past Header
-	if _, err = rr.Read(); err != nil {
+	var header []string
+	if header, err = rr.Read(); err != nil {
 		err = fmt.Errorf("Rows.ForEach() error reading headers: %v", err)
 		return
 	}
 
+	checksummed := rowChecksummed(header)
 	var values []string
-	for {
+	for i := 0; ; i++ {
 		if values, err = rr.Read(); err != nil {
 			break
 		}
 
+		if checksummed {
+			if values, err = openRow(values); err != nil {
+				err = fmt.Errorf("Rows.ForEach() error reading row #%d: %w", i, err)
+				break
+			}
+		}
+
 		if err = fn(values); err != nil {
 			break
 		}
@@ -0,0 +1,99 @@
+package csvdb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"time"
+)
+
+var (
+	// ErrInvalidJobName is returned when a job is scheduled without a name
+	ErrInvalidJobName = errors.New("invalid job name, cannot be empty")
+	// ErrInvalidJobInterval is returned when a job is scheduled with an interval which is not positive
+	ErrInvalidJobInterval = errors.New("invalid job interval, must be greater than zero")
+	// ErrJobExists is returned when a job is scheduled under the name of a scheduled job
+	ErrJobExists = errors.New("job already scheduled")
+	// ErrJobNotFound is returned when unscheduling a job which is not scheduled
+	ErrJobNotFound = errors.New("job not found")
+)
+
+// Job is a user-defined job ran periodically by the DB. The provided context is
+// cancelled when the job is unscheduled or the DB is closed.
+type Job func(ctx context.Context) error
+
+// Schedule will run a job on every interval alongside the export and purge jobs, until it is
+// unscheduled or the DB is closed. Errors returned by the job are logged, as are panics, which
+// are recovered. A run is skipped while the previous run of the job is still active, and Close
+// waits for active runs to return.
+func (d *DB[T]) Schedule(name string, interval time.Duration, fn Job) (err error) {
+	if name == "" {
+		return ErrInvalidJobName
+	}
+
+	if interval <= 0 {
+		return ErrInvalidJobInterval
+	}
+
+	// The lock ensures the job is tracked before Close waits on the background jobs
+	if err = d.lock(context.Background()); err != nil {
+		return
+	}
+	defer d.mux.Unlock()
+
+	d.smux.Lock()
+	defer d.smux.Unlock()
+	if _, ok := d.scheduled[name]; ok {
+		return fmt.Errorf("%w: <%s>", ErrJobExists, name)
+	}
+
+	if d.scheduled == nil {
+		d.scheduled = make(map[string]func())
+	}
+
+	ctx, cancel := context.WithCancel(d.context())
+	d.scheduled[name] = cancel
+
+	var running atomic.Bool
+	d.jobs.Add(1)
+	go scan(ctx, &d.jobs, d.guard(name, func() {
+		if !running.CompareAndSwap(false, true) {
+			return
+		}
+		defer running.Store(false)
+
+		if err := fn(ctx); err != nil {
+			d.o.Logger.Printf("csvdb.DB[%s].Schedule(): error running job <%s>: %v\n", d.o.Name, name, err)
+		}
+	}), interval)
+	return
+}
+
+// Unschedule will stop a scheduled job, an active run of the job has its context cancelled
+func (d *DB[T]) Unschedule(name string) (err error) {
+	d.smux.Lock()
+	defer d.smux.Unlock()
+	cancel, ok := d.scheduled[name]
+	if !ok {
+		return fmt.Errorf("%w: <%s>", ErrJobNotFound, name)
+	}
+
+	cancel()
+	delete(d.scheduled, name)
+	return
+}
+
+// guard will wrap a background job, recovering and logging any panic so it cannot
+// take down the process
+func (d *DB[T]) guard(name string, fn func()) func() {
+	return func() {
+		defer func() {
+			if v := recover(); v != nil {
+				d.o.Logger.Printf("csvdb.DB[%s]: recovered from panic within job <%s>: %v\n", d.o.Name, name, v)
+			}
+		}()
+
+		fn()
+	}
+}
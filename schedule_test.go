@@ -0,0 +1,182 @@
+package csvdb
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// syncBuffer is a bytes.Buffer which is safe for concurrent use
+type syncBuffer struct {
+	mux sync.Mutex
+	buf bytes.Buffer
+}
+
+func (s *syncBuffer) Write(bs []byte) (int, error) {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	return s.buf.Write(bs)
+}
+
+func (s *syncBuffer) String() string {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	return s.buf.String()
+}
+
+func TestDB_Schedule(t *testing.T) {
+	type testcase struct {
+		name     string
+		jobName  string
+		interval time.Duration
+		fn       Job
+		// wantLog is logged by the job runs, when set
+		wantLog string
+		wantErr error
+	}
+
+	tests := []testcase{
+		{
+			name:     "basic",
+			jobName:  "report",
+			interval: 5 * time.Millisecond,
+			fn: func(ctx context.Context) error {
+				return nil
+			},
+		},
+		{
+			name:     "error",
+			jobName:  "report",
+			interval: 5 * time.Millisecond,
+			fn: func(ctx context.Context) error {
+				return errors.New("report failed")
+			},
+			wantLog: "error running job <report>: report failed",
+		},
+		{
+			name:     "panic",
+			jobName:  "report",
+			interval: 5 * time.Millisecond,
+			fn: func(ctx context.Context) error {
+				panic("boom")
+			},
+			wantLog: "recovered from panic within job <report>: boom",
+		},
+		{
+			name:     "no name",
+			interval: 5 * time.Millisecond,
+			wantErr:  ErrInvalidJobName,
+		},
+		{
+			name:    "no interval",
+			jobName: "report",
+			wantErr: ErrInvalidJobInterval,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := fmt.Sprintf("test_%d", time.Now().UnixNano())
+			defer os.RemoveAll(dir)
+
+			logs := &syncBuffer{}
+			var opts Options
+			opts.Dir = dir
+			opts.Name = "foo"
+			opts.Logger = log.New(logs, "", 0)
+			d, err := New[testentry](context.Background(), opts, &mockBackend{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer d.Close()
+
+			var runs atomic.Int64
+			fn := func(ctx context.Context) error {
+				runs.Add(1)
+				return tt.fn(ctx)
+			}
+
+			if err = d.Schedule(tt.jobName, tt.interval, fn); err != tt.wantErr {
+				t.Fatalf("DB.Schedule() error = %v, wantErr %v", err, tt.wantErr)
+			} else if err != nil {
+				return
+			}
+
+			time.Sleep(50 * time.Millisecond)
+			if err = d.Close(); err != nil {
+				t.Fatal(err)
+			}
+
+			count := runs.Load()
+			if count < 2 {
+				t.Errorf("job runs = %d, want at least 2", count)
+			}
+
+			if !strings.Contains(logs.String(), tt.wantLog) {
+				t.Errorf("logs = %q, want %q", logs.String(), tt.wantLog)
+			}
+
+			time.Sleep(20 * time.Millisecond)
+			if runs.Load() != count {
+				t.Error("job ran after the DB was closed")
+			}
+		})
+	}
+}
+
+func TestDB_Unschedule(t *testing.T) {
+	dir := fmt.Sprintf("test_%d", time.Now().UnixNano())
+	defer os.RemoveAll(dir)
+
+	var opts Options
+	opts.Dir = dir
+	opts.Name = "foo"
+	d, err := New[testentry](context.Background(), opts, &mockBackend{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer d.Close()
+
+	var runs atomic.Int64
+	fn := func(ctx context.Context) error {
+		runs.Add(1)
+		return nil
+	}
+
+	if err = d.Schedule("report", 5*time.Millisecond, fn); err != nil {
+		t.Fatal(err)
+	}
+
+	if err = d.Schedule("report", 5*time.Millisecond, fn); !errors.Is(err, ErrJobExists) {
+		t.Fatalf("DB.Schedule() error = %v, want %v", err, ErrJobExists)
+	}
+
+	time.Sleep(20 * time.Millisecond)
+	if err = d.Unschedule("report"); err != nil {
+		t.Fatal(err)
+	}
+
+	// Allow a run which was already started to finish
+	time.Sleep(10 * time.Millisecond)
+	count := runs.Load()
+	time.Sleep(20 * time.Millisecond)
+	if runs.Load() != count {
+		t.Error("job ran after it was unscheduled")
+	}
+
+	if err = d.Unschedule("report"); !errors.Is(err, ErrJobNotFound) {
+		t.Errorf("DB.Unschedule() error = %v, want %v", err, ErrJobNotFound)
+	}
+
+	if err = d.Schedule("report", 5*time.Millisecond, fn); err != nil {
+		t.Errorf("DB.Schedule() error = %v after Unschedule", err)
+	}
+}
@@ -0,0 +1,139 @@
+package csvdb
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"sort"
+)
+
+// ErrShadowNotSet is returned when a shadow comparison is requested without a shadow DB
+var ErrShadowNotSet = errors.New("shadow not set")
+
+// ShadowReport is the result of comparing a DB against its shadow
+type ShadowReport struct {
+	// Matching are the keys whose contents are identical in both DBs
+	Matching []string
+	// Mismatched are the keys which exist in both DBs with differing contents
+	Mismatched []string
+	// MissingFromShadow are the keys which only exist within the primary DB
+	MissingFromShadow []string
+	// MissingFromPrimary are the keys which only exist within the shadow DB
+	MissingFromPrimary []string
+}
+
+// IsConsistent returns whether or not the primary and shadow DBs match
+func (s *ShadowReport) IsConsistent() bool {
+	return len(s.Mismatched) == 0 && len(s.MissingFromShadow) == 0 && len(s.MissingFromPrimary) == 0
+}
+
+// SetShadow will set a shadow DB which mirrors all appends made to the DB.
+// Errors encountered while writing to the shadow are logged and do not
+// affect the primary write. Passing nil will disable shadow writes.
+func (d *DB[T]) SetShadow(shadow *DB[T]) {
+	d.mux.Lock()
+	defer d.mux.Unlock()
+	d.shadow = shadow
+}
+
+// CompareShadow will compare the local contents of the DB against the contents of the shadow DB
+func (d *DB[T]) CompareShadow() (r ShadowReport, err error) {
+	return d.CompareShadowContext(context.Background())
+}
+
+// CompareShadowContext is the context-aware variant of CompareShadow
+func (d *DB[T]) CompareShadowContext(ctx context.Context) (r ShadowReport, err error) {
+	if err = d.lock(ctx); err != nil {
+		return
+	}
+	defer d.mux.Unlock()
+
+	if d.shadow == nil {
+		err = ErrShadowNotSet
+		return
+	}
+
+	var primary, shadow map[string][]byte
+	if primary, err = d.readAllLocal(); err != nil {
+		return
+	}
+
+	d.shadow.mux.Lock()
+	shadow, err = d.shadow.readAllLocal()
+	d.shadow.mux.Unlock()
+	if err != nil {
+		return
+	}
+
+	for key, pbs := range primary {
+		sbs, ok := shadow[key]
+		switch {
+		case !ok:
+			r.MissingFromShadow = append(r.MissingFromShadow, key)
+		case bytes.Equal(pbs, sbs):
+			r.Matching = append(r.Matching, key)
+		default:
+			r.Mismatched = append(r.Mismatched, key)
+		}
+	}
+
+	for key := range shadow {
+		if _, ok := primary[key]; !ok {
+			r.MissingFromPrimary = append(r.MissingFromPrimary, key)
+		}
+	}
+
+	sort.Strings(r.Matching)
+	sort.Strings(r.Mismatched)
+	sort.Strings(r.MissingFromShadow)
+	sort.Strings(r.MissingFromPrimary)
+	return
+}
+
+func (d *DB[T]) shadowAppend(key string, es []T) {
+	if d.shadow == nil || len(es) == 0 {
+		return
+	}
+
+	if err := d.shadow.Append(key, es...); err != nil {
+		d.o.Logger.Printf("csvdb.DB[%s].shadowAppend(): error appending <%s> to shadow: %v\n", d.o.Name, key, err)
+	}
+}
+
+// shadowAppendRows will mirror the rows written by appendRows, such as those of AppendRaw
+// and ImportFile, to the shadow
+func (d *DB[T]) shadowAppendRows(key string, rows [][]string) {
+	if d.shadow == nil || len(rows) == 0 {
+		return
+	}
+
+	if err := d.shadow.appendRows(context.Background(), key, func(header []string, write func([]string) error) (n int, err error) {
+		for _, values := range rows {
+			if err = write(values); err != nil {
+				return
+			}
+
+			n++
+		}
+
+		return
+	}); err != nil {
+		d.o.Logger.Printf("csvdb.DB[%s].shadowAppendRows(): error appending <%s> to shadow: %v\n", d.o.Name, key, err)
+	}
+}
+
+func (d *DB[T]) readAllLocal() (out map[string][]byte, err error) {
+	out = make(map[string][]byte)
+	err = d.forEach(func(filename string, info os.FileInfo) (err error) {
+		var bs []byte
+		if bs, err = readFile(d.fs, d.getPath(filename)); err != nil {
+			return
+		}
+
+		out[d.getKey(filename)] = bs
+		return
+	})
+
+	return
+}
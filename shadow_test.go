@@ -0,0 +1,142 @@
+package csvdb
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDB_CompareShadow(t *testing.T) {
+	type testcase struct {
+		name    string
+		init    func(primary, shadow *DB[testentry]) error
+		want    ShadowReport
+		wantErr bool
+	}
+
+	tvs := []testentry{
+		{
+			Foo: "1",
+			Bar: "1b",
+		},
+		{
+			Foo: "2",
+			Bar: "2b",
+		},
+	}
+
+	tests := []testcase{
+		{
+			name: "mirrored",
+			init: func(primary, shadow *DB[testentry]) (err error) {
+				if err = primary.Append("a", tvs...); err != nil {
+					return
+				}
+
+				return primary.Append("b", tvs[0])
+			},
+			want: ShadowReport{
+				Matching: []string{"a", "b"},
+			},
+		},
+		{
+			name: "append raw",
+			init: func(primary, shadow *DB[testentry]) (err error) {
+				if err = primary.Append("a", tvs[0]); err != nil {
+					return
+				}
+
+				return primary.AppendRaw("a", strings.NewReader("2,2b\n"))
+			},
+			want: ShadowReport{
+				Matching: []string{"a"},
+			},
+		},
+		{
+			name: "import file",
+			init: func(primary, shadow *DB[testentry]) (err error) {
+				var f *os.File
+				if f, err = os.CreateTemp("", "csvdb_import_*.csv"); err != nil {
+					return
+				}
+				defer os.Remove(f.Name())
+				defer f.Close()
+
+				if _, err = f.WriteString("bar,foo\n1b,1\n2b,2\n"); err != nil {
+					return
+				}
+
+				return primary.ImportFile("a", f.Name(), true)
+			},
+			want: ShadowReport{
+				Matching: []string{"a"},
+			},
+		},
+		{
+			name: "diverged",
+			init: func(primary, shadow *DB[testentry]) (err error) {
+				if err = primary.Append("a", tvs...); err != nil {
+					return
+				}
+
+				primary.SetShadow(nil)
+				if err = primary.Append("a", tvs[0]); err != nil {
+					return
+				}
+
+				if err = primary.Append("b", tvs[0]); err != nil {
+					return
+				}
+
+				return shadow.Append("c", tvs[0])
+			},
+			want: ShadowReport{
+				Mismatched:         []string{"a"},
+				MissingFromShadow:  []string{"b"},
+				MissingFromPrimary: []string{"c"},
+			},
+		},
+	}
+
+	newDB := func(name string) (d DB[testentry], err error) {
+		var opts Options
+		opts.Dir = fmt.Sprintf("test_%s_%d", name, time.Now().UnixNano())
+		opts.Name = "foo"
+		return makeDB[testentry](opts, &mockBackend{})
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			primary, err := newDB("primary")
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer os.RemoveAll(primary.o.Dir)
+
+			shadow, err := newDB("shadow")
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer os.RemoveAll(shadow.o.Dir)
+
+			primary.SetShadow(&shadow)
+			if err = tt.init(&primary, &shadow); err != nil {
+				t.Fatal(err)
+			}
+
+			primary.SetShadow(&shadow)
+			got, err := primary.CompareShadow()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("DB.CompareShadow() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("DB.CompareShadow() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,237 @@
+package csvdb
+
+import (
+	"bufio"
+	"container/heap"
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+)
+
+var (
+	// ErrSortedMergeHeaderMismatch is returned when merging sorted keys whose headers do not match
+	ErrSortedMergeHeaderMismatch = errors.New("cannot merge keys with mismatched headers")
+	// ErrKeyNotSorted is returned when the rows of a key are not sorted by the merge column
+	ErrKeyNotSorted = errors.New("rows of key are not sorted by the merge column")
+)
+
+// LessFunc reports whether the value a sorts before the value b
+type LessFunc func(a, b string) bool
+
+// GetMergedSorted will merge keys whose rows are each sorted in ascending order by the
+// provided column, writing a single header followed by the rows of every key in globally
+// sorted order. Only one row of each key is held in memory at a time. Rows with equal values
+// are written in the order of their keys. When less is nil, values are compared as strings,
+// which suits values such as UTC RFC 3339 timestamps. Keys which do not exist are skipped,
+// and ErrKeyNotSorted is returned when the rows of a key are found out of order.
+func (d *DB[T]) GetMergedSorted(w io.Writer, column string, less LessFunc, keys ...string) (err error) {
+	return d.GetMergedSortedContext(d.context(), w, column, less, keys...)
+}
+
+// GetMergedSortedContext is the context-aware variant of GetMergedSorted
+func (d *DB[T]) GetMergedSortedContext(ctx context.Context, w io.Writer, column string, less LessFunc, keys ...string) (err error) {
+	w, flush := d.encodeOutput(w)
+	defer flush(&err)
+
+	if less == nil {
+		less = func(a, b string) bool { return a < b }
+	}
+
+	// Downloads are made concurrently before the keys are locked together
+	p := d.prefetchAll(ctx, keys)
+	defer p.close()
+
+	fetches := make([]fetched, 0, len(keys))
+	for i := range keys {
+		var f fetched
+		if f, err = p.next(ctx, i); err != nil {
+			break
+		}
+
+		fetches = append(fetches, f)
+	}
+
+	var unlock func()
+	if err == nil {
+		unlock, err = d.lockKeysWith(ctx, !d.o.SpillToBackend, keys)
+	}
+
+	if err != nil {
+		for i, f := range fetches {
+			d.unhydrate(keys[i], f.release)
+		}
+
+		return
+	}
+	defer unlock()
+
+	for i, key := range keys {
+		defer fetches[i].release()
+		defer d.trackHydration(key)()
+	}
+
+	m := mergeHeap{less: less}
+	var header []string
+	for i, key := range keys {
+		if fetches[i].skip {
+			continue
+		}
+
+		var c *mergeCursor
+		switch c, err = d.openCursor(ctx, key, i); err {
+		case nil:
+		case ErrEntryNotFound, ErrBackendNotSet, ErrEntryQuarantined:
+			continue
+		default:
+			return
+		}
+		defer c.close()
+
+		switch {
+		case c.header == nil:
+			continue
+		case header == nil:
+			header = c.header
+			if m.column = indexOf(header, column); m.column == -1 {
+				return fmt.Errorf("error merging <%s>: %w <%s>", key, ErrColumnNotFound, column)
+			}
+		case !reflect.DeepEqual(header, c.header):
+			return fmt.Errorf("error merging <%s>: %w", key, ErrSortedMergeHeaderMismatch)
+		}
+
+		if err = c.advance(m.column, less); err == io.EOF {
+			continue
+		} else if err != nil {
+			return
+		}
+
+		m.cursors = append(m.cursors, c)
+	}
+
+	if header == nil {
+		return
+	}
+
+	cw, bw := getCSVWriter(w, d.dialect)
+	defer putBufWriter(bw)
+	if err = cw.Write(header); err != nil {
+		return
+	}
+
+	heap.Init(&m)
+	for m.Len() > 0 {
+		c := m.cursors[0]
+		if err = cw.Write(c.values); err != nil {
+			return
+		}
+
+		switch err = c.advance(m.column, less); err {
+		case nil:
+			heap.Fix(&m, 0)
+		case io.EOF:
+			heap.Pop(&m)
+		default:
+			return
+		}
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
+
+// openCursor will open the file of a key, reading its header. Must be called while the
+// lock of the key is held.
+func (d *DB[T]) openCursor(ctx context.Context, key string, order int) (c *mergeCursor, err error) {
+	var f file
+	if f, err = d.getOrDownload(ctx, key); err != nil {
+		return
+	}
+
+	c = &mergeCursor{key: key, order: order, f: f}
+	c.r, c.br = getCSVReader(newContextReader(ctx, f), d.dialect)
+	if c.header, err = c.r.Read(); err == io.EOF {
+		c.header, err = nil, nil
+	} else if err != nil {
+		c.close()
+		return nil, err
+	}
+
+	// The header is retained, so records are only reused once it has been read
+	c.r.ReuseRecord = true
+	return
+}
+
+// mergeCursor is the current row of a key being merged
+type mergeCursor struct {
+	key   string
+	order int
+
+	f      file
+	r      *csv.Reader
+	br     *bufio.Reader
+	header []string
+	values []string
+	value  string
+	// row is the index of the next row read
+	row int
+}
+
+// advance will read the next row of the key, returning ErrKeyNotSorted when it sorts
+// before the previous row
+func (c *mergeCursor) advance(column int, less LessFunc) (err error) {
+	prev, first := c.value, c.values == nil
+	if c.values, err = c.r.Read(); err != nil {
+		return
+	}
+
+	if err = checkColumnCount(c.key, c.row, c.values, c.header); err != nil {
+		return
+	}
+
+	c.row++
+
+	if c.value = c.values[column]; !first && less(c.value, prev) {
+		return fmt.Errorf("error merging <%s>: %w", c.key, ErrKeyNotSorted)
+	}
+
+	return
+}
+
+func (c *mergeCursor) close() {
+	putBufReader(c.br)
+	c.f.Close()
+}
+
+// mergeHeap orders cursors by the value of their current row, followed by their key order
+type mergeHeap struct {
+	cursors []*mergeCursor
+	column  int
+	less    LessFunc
+}
+
+func (m *mergeHeap) Len() int { return len(m.cursors) }
+
+func (m *mergeHeap) Less(i, j int) bool {
+	a, b := m.cursors[i], m.cursors[j]
+	switch {
+	case m.less(a.value, b.value):
+		return true
+	case m.less(b.value, a.value):
+		return false
+	default:
+		return a.order < b.order
+	}
+}
+
+func (m *mergeHeap) Swap(i, j int) { m.cursors[i], m.cursors[j] = m.cursors[j], m.cursors[i] }
+
+func (m *mergeHeap) Push(x any) { m.cursors = append(m.cursors, x.(*mergeCursor)) }
+
+func (m *mergeHeap) Pop() any {
+	c := m.cursors[len(m.cursors)-1]
+	m.cursors = m.cursors[:len(m.cursors)-1]
+	return c
+}
@@ -0,0 +1,136 @@
+package csvdb
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestDB_GetMergedSorted(t *testing.T) {
+	type testcase struct {
+		name    string
+		entries map[string][]testentry
+		keys    []string
+		column  string
+		less    LessFunc
+
+		want    string
+		wantErr error
+	}
+
+	numeric := func(a, b string) bool {
+		x, _ := strconv.Atoi(a)
+		y, _ := strconv.Atoi(b)
+		return x < y
+	}
+
+	tests := []testcase{
+		{
+			name: "interleaved",
+			entries: map[string][]testentry{
+				"a": {{Foo: "1", Bar: "a"}, {Foo: "4", Bar: "a"}, {Foo: "5", Bar: "a"}},
+				"b": {{Foo: "2", Bar: "b"}, {Foo: "3", Bar: "b"}, {Foo: "6", Bar: "b"}},
+			},
+			keys:   []string{"a", "b"},
+			column: "foo",
+			want:   "foo,bar\n1,a\n2,b\n3,b\n4,a\n5,a\n6,b\n",
+		},
+		{
+			name: "ties follow key order",
+			entries: map[string][]testentry{
+				"a": {{Foo: "1", Bar: "a"}, {Foo: "2", Bar: "a"}},
+				"b": {{Foo: "1", Bar: "b"}, {Foo: "2", Bar: "b"}},
+			},
+			keys:   []string{"b", "a"},
+			column: "foo",
+			want:   "foo,bar\n1,b\n1,a\n2,b\n2,a\n",
+		},
+		{
+			name: "missing key",
+			entries: map[string][]testentry{
+				"a": {{Foo: "1", Bar: "a"}},
+			},
+			keys:   []string{"missing", "a"},
+			column: "foo",
+			want:   "foo,bar\n1,a\n",
+		},
+		{
+			name:   "no keys",
+			keys:   []string{"missing"},
+			column: "foo",
+			want:   "",
+		},
+		{
+			name: "custom less",
+			entries: map[string][]testentry{
+				"a": {{Foo: "9", Bar: "a"}, {Foo: "10", Bar: "a"}},
+				"b": {{Foo: "2", Bar: "b"}, {Foo: "11", Bar: "b"}},
+			},
+			keys:   []string{"a", "b"},
+			column: "foo",
+			less:   numeric,
+			want:   "foo,bar\n2,b\n9,a\n10,a\n11,b\n",
+		},
+		{
+			name: "not sorted",
+			entries: map[string][]testentry{
+				"a": {{Foo: "2", Bar: "a"}, {Foo: "1", Bar: "a"}},
+				"b": {{Foo: "1", Bar: "b"}},
+			},
+			keys:    []string{"a", "b"},
+			column:  "foo",
+			wantErr: ErrKeyNotSorted,
+		},
+		{
+			name: "missing column",
+			entries: map[string][]testentry{
+				"a": {{Foo: "1", Bar: "a"}},
+			},
+			keys:    []string{"a"},
+			column:  "baz",
+			wantErr: ErrColumnNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var opts Options
+			opts.Dir = fmt.Sprintf("test_%d", time.Now().UnixNano())
+			opts.Name = "foo"
+			b := &mockBackend{
+				importFn: func(ctx context.Context, prefix, filename string, w io.Writer) error {
+					return os.ErrNotExist
+				},
+			}
+
+			d, err := makeDB[testentry](opts, b)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer os.RemoveAll(opts.Dir)
+
+			for key, es := range tt.entries {
+				if err = d.Append(key, es...); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			w := &bytes.Buffer{}
+			if err = d.GetMergedSorted(w, tt.column, tt.less, tt.keys...); !errors.Is(err, tt.wantErr) {
+				t.Fatalf("DB.GetMergedSorted() error = %v, wantErr %v", err, tt.wantErr)
+			} else if err != nil {
+				return
+			}
+
+			if w.String() != tt.want {
+				t.Errorf("DB.GetMergedSorted() = %q, want %q", w.String(), tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,86 @@
+package csvdb
+
+import (
+	"context"
+	"os"
+)
+
+// prepareWrite must be called while the lock is held, before a key is written to
+func (d *DB[T]) prepareWrite(ctx context.Context, key string) (err error) {
+	if err = d.checkQuarantine(key); err != nil {
+		return
+	}
+
+	if !d.o.SpillToBackend {
+		return
+	}
+
+	return d.hydrate(ctx, key)
+}
+
+// hydrate will download a key from the backend when it does not exist locally, so
+// writes made while in spill mode extend the remote contents rather than replace them
+func (d *DB[T]) hydrate(ctx context.Context, key string) (err error) {
+	name, filename := d.getFilename(key)
+	if _, err = d.fs.Stat(filename); err == nil || !os.IsNotExist(err) {
+		return
+	}
+
+	switch err = d.attemptDownload(ctx, name, filename); err {
+	case nil:
+		return
+	case ErrEntryNotFound:
+		return nil
+	default:
+		return
+	}
+}
+
+// trackHydration will return a func which removes a key that was downloaded during a
+// read while in spill mode. The returned func must be called while the lock is held.
+func (d *DB[T]) trackHydration(key string) (release func()) {
+	release = func() {}
+	if !d.o.SpillToBackend {
+		return
+	}
+
+	_, filename := d.getFilename(key)
+	if _, err := d.fs.Stat(filename); !os.IsNotExist(err) {
+		return
+	}
+
+	return func() {
+		if err := d.fs.Remove(filename); err != nil && !os.IsNotExist(err) {
+			d.o.Logger.Printf("csvdb.DB[%s].trackHydration(): error removing <%s>: %v\n", d.o.Name, filename, err)
+		}
+	}
+}
+
+// spill will remove a local file and its export marker once it has been exported,
+// unless it has been modified since the export began
+func (d *DB[T]) spill(filename string, exported os.FileInfo) (err error) {
+	defer d.holdKeys(d.getKey(filename))()
+
+	fullpath := d.getPath(filename)
+	var info os.FileInfo
+	if info, err = d.fs.Stat(fullpath); os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return
+	}
+
+	if info.Size() != exported.Size() || !info.ModTime().Equal(exported.ModTime()) {
+		// File has been written to since the export began, it will be exported again
+		return
+	}
+
+	if err = d.fs.Remove(fullpath); err != nil {
+		return
+	}
+
+	if err = d.fs.Remove(fullpath + ".exported"); err != nil && !os.IsNotExist(err) {
+		return
+	}
+
+	return nil
+}
@@ -0,0 +1,95 @@
+package csvdb
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"testing"
+	"time"
+)
+
+func TestDB_SpillToBackend(t *testing.T) {
+	remote := map[string][]byte{}
+	b := &mockBackend{
+		importFn: func(ctx context.Context, prefix, filename string, w io.Writer) (err error) {
+			bs, ok := remote[filename]
+			if !ok {
+				return os.ErrNotExist
+			}
+
+			_, err = w.Write(bs)
+			return
+		},
+		exportFn: func(ctx context.Context, prefix, filename string, r io.Reader) (newFilename string, err error) {
+			if remote[filename], err = io.ReadAll(r); err != nil {
+				return
+			}
+
+			return filename, nil
+		},
+	}
+
+	var opts Options
+	opts.Dir = fmt.Sprintf("test_%d", time.Now().UnixNano())
+	opts.Name = "foo"
+	opts.SpillToBackend = true
+	d, err := makeDB[testentry](opts, b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(d.o.Dir)
+
+	countLocal := func() (count int) {
+		entries, err := os.ReadDir(d.getFullPath())
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		return len(entries)
+	}
+
+	if err = d.Append("a", testentry{Foo: "1", Bar: "1b"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err = d.backup(context.Background(), "", false); err != nil {
+		t.Fatal(err)
+	}
+
+	if count := countLocal(); count != 0 {
+		t.Fatalf("local file count after export = %d, want 0", count)
+	}
+
+	if err = d.Append("a", testentry{Foo: "2", Bar: "2b"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err = d.backup(context.Background(), "", false); err != nil {
+		t.Fatal(err)
+	}
+
+	want := "foo,bar\n1,1b\n2,2b\n"
+	if got := string(remote["foo.a.csv"]); got != want {
+		t.Fatalf("remote contents = %v, want %v", got, want)
+	}
+
+	w := &bytes.Buffer{}
+	if err = d.Get(w, "a"); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := w.String(); got != want {
+		t.Fatalf("DB.Get() = %v, want %v", got, want)
+	}
+
+	if count := countLocal(); count != 0 {
+		t.Fatalf("local file count after read = %d, want 0", count)
+	}
+
+	if _, err = makeDB[testentry](Options{Name: "foo", Dir: path.Join(opts.Dir, "nobackend"), SpillToBackend: true}, nil); err != ErrBackendNotSet {
+		t.Fatalf("makeDB() without backend error = %v, want %v", err, ErrBackendNotSet)
+	}
+}
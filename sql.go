@@ -0,0 +1,186 @@
+package csvdb
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"strings"
+)
+
+const (
+	// SQLDialectANSI quotes identifiers with double quotes, compatible with PostgreSQL and SQLite
+	SQLDialectANSI SQLDialect = iota
+	// SQLDialectMySQL quotes identifiers with backticks
+	SQLDialectMySQL
+)
+
+// ErrInvalidTable is returned when SQL options are missing a table name
+var ErrInvalidTable = errors.New("invalid table, cannot be empty")
+
+// SQLDialect represents the SQL dialect used when rendering INSERT statements
+type SQLDialect uint8
+
+func (s SQLDialect) quoteIdentifier(identifier string) string {
+	switch s {
+	case SQLDialectMySQL:
+		return "`" + strings.ReplaceAll(identifier, "`", "``") + "`"
+	default:
+		return `"` + strings.ReplaceAll(identifier, `"`, `""`) + `"`
+	}
+}
+
+func (s SQLDialect) quoteValue(value string) string {
+	value = strings.ReplaceAll(value, "'", "''")
+	if s == SQLDialectMySQL {
+		value = strings.ReplaceAll(value, `\`, `\\`)
+	}
+
+	return "'" + value + "'"
+}
+
+// SQLOptions are the options used when rendering rows as SQL INSERT statements
+type SQLOptions struct {
+	// Table is the name of the table to insert into
+	Table string
+	// Columns are the columns to include, in order. When empty, all header columns are used
+	Columns []string
+	// Dialect is the dialect used for quoting
+	Dialect SQLDialect
+	// BatchSize is the number of rows per INSERT statement, defaults to 100
+	BatchSize int
+	// Delimiter is the rune separating the fields of the CSV stream, defaults to ','. GetSQL
+	// defaults to the delimiter of the DB, and reads the stream with the CSV options of the DB.
+	Delimiter rune
+}
+
+func (o *SQLOptions) Validate() (err error) {
+	if len(o.Table) == 0 {
+		return ErrInvalidTable
+	}
+
+	return
+}
+
+func (o *SQLOptions) fill() {
+	if o.BatchSize <= 0 {
+		o.BatchSize = 100
+	}
+
+	if o.Delimiter == 0 {
+		o.Delimiter = ','
+	}
+}
+
+// GetSQL will write the rows of a key as batched SQL INSERT statements
+func (d *DB[T]) GetSQL(w io.Writer, key string, o SQLOptions) (err error) {
+	return d.GetSQLContext(d.context(), w, key, o)
+}
+
+// GetSQLContext is the context-aware variant of GetSQL
+func (d *DB[T]) GetSQLContext(ctx context.Context, w io.Writer, key string, o SQLOptions) (err error) {
+	var unlock func()
+	if unlock, err = d.rlockKey(ctx, key); err != nil {
+		return
+	}
+	defer unlock()
+	defer d.trackHydration(key)()
+
+	var f fs.File
+	if f, err = d.getOrDownload(ctx, key); err != nil {
+		return
+	}
+	defer f.Close()
+	dialect := d.dialect
+	if o.Delimiter != 0 {
+		dialect.comma = o.Delimiter
+	}
+
+	return writeSQL(w, newContextReader(ctx, f), o, dialect)
+}
+
+// WriteSQL will render a CSV stream (including header) as batched SQL INSERT statements
+func WriteSQL(w io.Writer, r io.Reader, o SQLOptions) (err error) {
+	o.fill()
+	return writeSQL(w, r, o, csvDialect{comma: o.Delimiter})
+}
+
+// writeSQL will render a CSV stream read with the provided dialect as WriteSQL does
+func writeSQL(w io.Writer, r io.Reader, o SQLOptions, dialect csvDialect) (err error) {
+	if err = o.Validate(); err != nil {
+		return
+	}
+
+	o.fill()
+
+	cr := newCSVReader(r, dialect)
+	var header []string
+	if header, err = cr.Read(); err == io.EOF {
+		return nil
+	} else if err != nil {
+		return
+	}
+
+	columns := o.Columns
+	if len(columns) == 0 {
+		columns = header
+	}
+
+	indexes := make([]int, len(columns))
+	quoted := make([]string, len(columns))
+	for i, column := range columns {
+		if indexes[i] = indexOf(header, column); indexes[i] == -1 {
+			return fmt.Errorf("%w <%s>", ErrColumnNotFound, column)
+		}
+
+		quoted[i] = o.Dialect.quoteIdentifier(column)
+	}
+
+	prefix := fmt.Sprintf("INSERT INTO %s (%s) VALUES\n", o.Dialect.quoteIdentifier(o.Table), strings.Join(quoted, ", "))
+
+	bw := bufio.NewWriter(w)
+	var (
+		values []string
+		count  int
+	)
+
+	rowValues := make([]string, len(columns))
+	for {
+		if values, err = cr.Read(); err == io.EOF {
+			err = nil
+			break
+		} else if err != nil {
+			return
+		}
+
+		// Rows may be ragged when CSV.FieldsPerRecord is negative
+		if len(values) != len(header) {
+			return fmt.Errorf("%w: row #%d has %d values, expected %d", ErrInvalidColumnCount, count, len(values), len(header))
+		}
+
+		if count%o.BatchSize == 0 {
+			if count > 0 {
+				bw.WriteString(";\n")
+			}
+
+			bw.WriteString(prefix)
+		} else {
+			bw.WriteString(",\n")
+		}
+
+		for i, index := range indexes {
+			rowValues[i] = o.Dialect.quoteValue(values[index])
+		}
+
+		bw.WriteString("(" + strings.Join(rowValues, ", ") + ")")
+		count++
+	}
+
+	if count > 0 {
+		bw.WriteString(";\n")
+	}
+
+	return bw.Flush()
+}
@@ -0,0 +1,125 @@
+package csvdb
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWriteSQL(t *testing.T) {
+	type args struct {
+		input string
+		o     SQLOptions
+	}
+
+	type testcase struct {
+		name    string
+		args    args
+		wantW   string
+		wantErr bool
+	}
+
+	tests := []testcase{
+		{
+			name: "basic",
+			args: args{
+				input: "foo,bar\n1,1b\n2,it's\n3,3b\n",
+				o: SQLOptions{
+					Table:     "entries",
+					BatchSize: 2,
+				},
+			},
+			wantW: `INSERT INTO "entries" ("foo", "bar") VALUES
+('1', '1b'),
+('2', 'it''s');
+INSERT INTO "entries" ("foo", "bar") VALUES
+('3', '3b');
+`,
+		},
+		{
+			name: "mysql with columns",
+			args: args{
+				input: "foo,bar\n1,1b\n",
+				o: SQLOptions{
+					Table:   "entries",
+					Columns: []string{"bar"},
+					Dialect: SQLDialectMySQL,
+				},
+			},
+			wantW: "INSERT INTO `entries` (`bar`) VALUES\n('1b');\n",
+		},
+		{
+			name: "empty",
+			args: args{
+				input: "",
+				o: SQLOptions{
+					Table: "entries",
+				},
+			},
+			wantW: "",
+		},
+		{
+			name: "missing column",
+			args: args{
+				input: "foo,bar\n1,1b\n",
+				o: SQLOptions{
+					Table:   "entries",
+					Columns: []string{"baz"},
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "missing table",
+			args: args{
+				input: "foo,bar\n1,1b\n",
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := &bytes.Buffer{}
+			err := WriteSQL(w, strings.NewReader(tt.args.input), tt.args.o)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("WriteSQL() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+
+			if gotW := w.String(); gotW != tt.wantW {
+				t.Errorf("WriteSQL() = %v, want %v", gotW, tt.wantW)
+			}
+		})
+	}
+}
+
+func TestDB_GetSQL_csvOptions(t *testing.T) {
+	var opts Options
+	opts.Dir = fmt.Sprintf("test_%d", time.Now().UnixNano())
+	opts.Name = "foo"
+	opts.Delimiter = ';'
+	opts.CSV = CSVOptions{Comment: '#', TrimLeadingSpace: true}
+	d, err := makeDB[testentry](opts, &mockBackend{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(opts.Dir)
+
+	if err = os.WriteFile(d.getPath("foo.a.csv"), []byte("foo; bar\n# note\n1; 1b\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// The file is read with the CSV options of the DB
+	w := &bytes.Buffer{}
+	if err = d.GetSQL(w, "a", SQLOptions{Table: "entries"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if want := "INSERT INTO \"entries\" (\"foo\", \"bar\") VALUES\n('1', '1b');\n"; w.String() != want {
+		t.Errorf("DB.GetSQL() = %q, want %q", w.String(), want)
+	}
+}
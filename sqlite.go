@@ -0,0 +1,160 @@
+package csvdb
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"strings"
+)
+
+// ExportSQLite will pack the provided keys into a single SQLite database (one table per key)
+// and export it via the backend using the provided filename. Every column is stored as TEXT.
+// Values are stored as Get writes them, and rows are stored without their RowChecksumColumn.
+// Note: A SQLite database/sql driver (e.g. modernc.org/sqlite) must be registered by the caller,
+// the driver name is configured with Options.SQLiteDriver
+func (d *DB[T]) ExportSQLite(ctx context.Context, filename string, keys ...string) (newFilename string, err error) {
+	if d.eb == nil {
+		err = ErrBackendNotSet
+		return
+	}
+
+	// The SQLite driver requires a file on disk
+	dir := d.getFullPath()
+	switch {
+	case d.o.InMemory:
+		dir = os.TempDir()
+	case d.o.TempDir != "":
+		dir = d.o.TempDir
+	}
+
+	var tmp *os.File
+	if tmp, err = os.CreateTemp(dir, "*.sqlite"); err != nil {
+		return
+	}
+	defer os.Remove(tmp.Name())
+
+	if err = tmp.Close(); err != nil {
+		return
+	}
+
+	if err = d.writeSQLite(ctx, tmp.Name(), keys); err != nil {
+		err = fmt.Errorf("error writing SQLite snapshot: %w", err)
+		return
+	}
+
+	var f *os.File
+	if f, err = os.Open(tmp.Name()); err != nil {
+		return
+	}
+	defer f.Close()
+
+	return d.eb.Export(d.request(ctx, OpExport), d.o.Name, filename, f)
+}
+
+func (d *DB[T]) writeSQLite(ctx context.Context, filename string, keys []string) (err error) {
+	var db *sql.DB
+	if db, err = sql.Open(d.o.SQLiteDriver, filename); err != nil {
+		return
+	}
+	defer db.Close()
+
+	var tx *sql.Tx
+	if tx, err = db.BeginTx(ctx, nil); err != nil {
+		return
+	}
+	defer tx.Rollback()
+
+	for _, key := range keys {
+		if err = d.writeSQLiteTable(ctx, tx, key); err != nil {
+			return fmt.Errorf("error writing <%s>: %w", key, err)
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		return
+	}
+
+	return db.Close()
+}
+
+func (d *DB[T]) writeSQLiteTable(ctx context.Context, tx *sql.Tx, key string) (err error) {
+	var unlock func()
+	if unlock, err = d.rlockKey(ctx, key); err != nil {
+		return
+	}
+	defer unlock()
+	defer d.trackHydration(key)()
+
+	var f fs.File
+	if f, err = d.getOrDownload(ctx, key); err != nil {
+		return
+	}
+	defer f.Close()
+
+	r := newCSVReader(f, d.dialect)
+	var header []string
+	if header, err = r.Read(); err != nil {
+		return
+	}
+
+	checksummed := rowChecksummed(header)
+	if checksummed {
+		header = header[:len(header)-1]
+	}
+
+	cs := d.cp.columns(header)
+	table := SQLDialectANSI.quoteIdentifier(key)
+	columns := make([]string, len(header))
+	placeholders := make([]string, len(header))
+	for i, column := range header {
+		columns[i] = SQLDialectANSI.quoteIdentifier(column)
+		placeholders[i] = "?"
+	}
+
+	create := fmt.Sprintf("CREATE TABLE %s (%s TEXT)", table, strings.Join(columns, " TEXT, "))
+	if _, err = tx.ExecContext(ctx, create); err != nil {
+		return
+	}
+
+	insert := fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s)", table, strings.Join(columns, ", "), strings.Join(placeholders, ", "))
+	var stmt *sql.Stmt
+	if stmt, err = tx.PrepareContext(ctx, insert); err != nil {
+		return
+	}
+	defer stmt.Close()
+
+	args := make([]any, len(header))
+	var values []string
+	for i := 0; ; i++ {
+		if values, err = r.Read(); err == io.EOF {
+			return nil
+		} else if err != nil {
+			return
+		}
+
+		if checksummed {
+			if values, err = openRow(values); err != nil {
+				return fmt.Errorf("error reading row #%d: %w", i, err)
+			}
+		}
+
+		if err = checkColumnCount(key, i, values, header); err != nil {
+			return
+		}
+
+		if err = d.cp.open(cs, values, true); err != nil {
+			return
+		}
+
+		for i, value := range values {
+			args[i] = value
+		}
+
+		if _, err = stmt.ExecContext(ctx, args...); err != nil {
+			return
+		}
+	}
+}
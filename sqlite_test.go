@@ -0,0 +1,248 @@
+package csvdb
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func init() {
+	sql.Register("csvdb_mock_sqlite", &mockSQLDriver{})
+}
+
+// mockSQLDriver writes every executed statement (with arguments) to the target file on commit
+type mockSQLDriver struct{}
+
+func (m *mockSQLDriver) Open(name string) (driver.Conn, error) {
+	return &mockSQLConn{filename: name}, nil
+}
+
+type mockSQLConn struct {
+	filename string
+	buf      bytes.Buffer
+}
+
+func (m *mockSQLConn) Prepare(query string) (driver.Stmt, error) {
+	return &mockSQLStmt{conn: m, query: query}, nil
+}
+
+func (m *mockSQLConn) Close() error {
+	return nil
+}
+
+func (m *mockSQLConn) Begin() (driver.Tx, error) {
+	return m, nil
+}
+
+func (m *mockSQLConn) Commit() error {
+	return os.WriteFile(m.filename, m.buf.Bytes(), 0644)
+}
+
+func (m *mockSQLConn) Rollback() error {
+	return nil
+}
+
+type mockSQLStmt struct {
+	conn  *mockSQLConn
+	query string
+}
+
+func (m *mockSQLStmt) Close() error {
+	return nil
+}
+
+func (m *mockSQLStmt) NumInput() int {
+	return strings.Count(m.query, "?")
+}
+
+func (m *mockSQLStmt) Exec(args []driver.Value) (driver.Result, error) {
+	fmt.Fprintf(&m.conn.buf, "%s %v\n", m.query, args)
+	return driver.RowsAffected(1), nil
+}
+
+func (m *mockSQLStmt) Query(args []driver.Value) (driver.Rows, error) {
+	return nil, errors.New("not supported")
+}
+
+func TestDB_ExportSQLite(t *testing.T) {
+	type args struct {
+		keys []string
+	}
+
+	type testcase struct {
+		name    string
+		opts    func(o *Options)
+		args    args
+		want    string
+		wantErr bool
+	}
+
+	tests := []testcase{
+		{
+			name: "basic",
+			args: args{
+				keys: []string{"a", "b"},
+			},
+			want: `CREATE TABLE "a" ("foo" TEXT, "bar" TEXT) []
+INSERT INTO "a" ("foo", "bar") VALUES (?, ?) [1 1b]
+INSERT INTO "a" ("foo", "bar") VALUES (?, ?) [2 2b]
+CREATE TABLE "b" ("foo" TEXT, "bar" TEXT) []
+INSERT INTO "b" ("foo", "bar") VALUES (?, ?) [2 2b]
+`,
+		},
+		{
+			name: "row checksums",
+			opts: func(o *Options) { o.RowChecksums = true },
+			args: args{
+				keys: []string{"a"},
+			},
+			want: `CREATE TABLE "a" ("foo" TEXT, "bar" TEXT) []
+INSERT INTO "a" ("foo", "bar") VALUES (?, ?) [1 1b]
+INSERT INTO "a" ("foo", "bar") VALUES (?, ?) [2 2b]
+`,
+		},
+		{
+			name: "encrypted column",
+			opts: func(o *Options) {
+				o.ColumnPolicies = map[string]ColumnPolicy{"bar": {Action: ColumnEncrypt}}
+				o.ColumnKeys = &testKeyProvider{
+					current: KeyID{Name: "columns", Version: 1},
+					keys:    map[KeyID][]byte{{Name: "columns", Version: 1}: bytes.Repeat([]byte{1}, 32)},
+				}
+			},
+			args: args{
+				keys: []string{"a"},
+			},
+			want: `CREATE TABLE "a" ("foo" TEXT, "bar" TEXT) []
+INSERT INTO "a" ("foo", "bar") VALUES (?, ?) [1 1b]
+INSERT INTO "a" ("foo", "bar") VALUES (?, ?) [2 2b]
+`,
+		},
+		{
+			name: "masked column",
+			opts: func(o *Options) {
+				o.ColumnPolicies = map[string]ColumnPolicy{"bar": {Action: ColumnHash, Mask: "***"}}
+			},
+			args: args{
+				keys: []string{"a"},
+			},
+			want: `CREATE TABLE "a" ("foo" TEXT, "bar" TEXT) []
+INSERT INTO "a" ("foo", "bar") VALUES (?, ?) [1 ***]
+INSERT INTO "a" ("foo", "bar") VALUES (?, ?) [2 ***]
+`,
+		},
+		{
+			name: "missing key",
+			args: args{
+				keys: []string{"a", "c"},
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got string
+			b := &mockBackend{
+				importFn: func(ctx context.Context, prefix, filename string, w io.Writer) (err error) {
+					return os.ErrNotExist
+				},
+				exportFn: func(ctx context.Context, prefix, filename string, r io.Reader) (newFilename string, err error) {
+					var bs []byte
+					if bs, err = io.ReadAll(r); err != nil {
+						return
+					}
+
+					got = string(bs)
+					return filename, nil
+				},
+			}
+
+			var opts Options
+			opts.Dir = fmt.Sprintf("test_%d", time.Now().UnixNano())
+			opts.Name = "foo"
+			opts.SQLiteDriver = "csvdb_mock_sqlite"
+			if tt.opts != nil {
+				tt.opts(&opts)
+			}
+
+			d, err := makeDB[testentry](opts, b)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer os.RemoveAll(d.o.Dir)
+
+			tvs := []testentry{
+				{
+					Foo: "1",
+					Bar: "1b",
+				},
+				{
+					Foo: "2",
+					Bar: "2b",
+				},
+			}
+
+			if err = d.Append("a", tvs...); err != nil {
+				t.Fatal(err)
+			}
+
+			if err = d.Append("b", tvs[1]); err != nil {
+				t.Fatal(err)
+			}
+
+			_, err = d.ExportSQLite(context.Background(), "snapshot.sqlite", tt.args.keys...)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("DB.ExportSQLite() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+
+			if got != tt.want {
+				t.Errorf("DB.ExportSQLite() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDB_ExportSQLite_locks(t *testing.T) {
+	b := &mockBackend{
+		exportFn: func(ctx context.Context, prefix, filename string, r io.Reader) (string, error) {
+			return filename, nil
+		},
+	}
+
+	var opts Options
+	opts.Dir = fmt.Sprintf("test_%d", time.Now().UnixNano())
+	opts.Name = "foo"
+	opts.SQLiteDriver = "csvdb_mock_sqlite"
+	d, err := makeDB[testentry](opts, b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(d.o.Dir)
+
+	if err = d.Append("a", testentry{Foo: "1", Bar: "1b"}); err != nil {
+		t.Fatal(err)
+	}
+
+	// Writes to other keys do not block the export
+	unlock, err := d.lockKeys(context.Background(), "b")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer unlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if _, err = d.ExportSQLite(ctx, "snapshot.sqlite", "a"); err != nil {
+		t.Fatalf("DB.ExportSQLite() error = %v", err)
+	}
+}
@@ -0,0 +1,19 @@
+package csvdb
+
+import (
+	"context"
+	"errors"
+)
+
+// ErrStaterNotImplemented is returned when remote file info is requested from a Backend which does not implement Stater
+var ErrStaterNotImplemented = errors.New("backend does not implement Stater")
+
+// statRemote will return the info of the exported file of a local file, when the Backend implements Stater
+func (d *DB[T]) statRemote(ctx context.Context, name string) (info RemoteInfo, err error) {
+	stater, ok := d.ib.(Stater)
+	if !ok {
+		return info, ErrStaterNotImplemented
+	}
+
+	return stater.Stat(d.request(withKey(ctx, d.getKey(name)), OpStat), d.remotePrefixOf(name), d.exportName(name))
+}
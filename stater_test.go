@@ -0,0 +1,116 @@
+package csvdb
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"testing"
+	"time"
+)
+
+type mockStaterBackend struct {
+	mockBackend
+
+	remote map[string]string
+	err    error
+}
+
+func (m *mockStaterBackend) Stat(ctx context.Context, prefix, filename string) (info RemoteInfo, err error) {
+	if m.err != nil {
+		return info, m.err
+	}
+
+	var bs string
+	bs, info.Exists = m.remote[filename]
+	info.Size = int64(len(bs))
+	return
+}
+
+func TestDB_attemptDownload_stater(t *testing.T) {
+	type testcase struct {
+		name    string
+		key     string
+		statErr error
+
+		wantErr     error
+		wantImports int
+		wantOutput  string
+	}
+
+	tests := []testcase{
+		{
+			name:        "exists",
+			key:         "a",
+			wantImports: 1,
+			wantOutput:  "foo,bar\n1,1b\n",
+		},
+		{
+			name:    "missing",
+			key:     "missing",
+			wantErr: ErrEntryNotFound,
+		},
+		{
+			name:    "stat error",
+			key:     "a",
+			statErr: errors.New("unavailable"),
+			wantErr: errors.New("unavailable"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var imports int
+			b := &mockStaterBackend{
+				remote: map[string]string{"foo.a.csv": "foo,bar\n1,1b\n"},
+				err:    tt.statErr,
+			}
+
+			b.importFn = func(ctx context.Context, prefix, filename string, w io.Writer) (err error) {
+				imports++
+				bs, ok := b.remote[filename]
+				if !ok {
+					return os.ErrNotExist
+				}
+
+				_, err = w.Write([]byte(bs))
+				return
+			}
+
+			var opts Options
+			opts.Dir = fmt.Sprintf("test_%d", time.Now().UnixNano())
+			opts.Name = "foo"
+			d, err := makeDB[testentry](opts, b)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer os.RemoveAll(d.o.Dir)
+
+			w := &bytes.Buffer{}
+			err = d.Get(w, tt.key)
+			if (err == nil) != (tt.wantErr == nil) || (err != nil && err.Error() != tt.wantErr.Error()) {
+				t.Fatalf("DB.Get() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if imports != tt.wantImports {
+				t.Errorf("DB.Get() imports = %d, want %d", imports, tt.wantImports)
+			}
+
+			if w.String() != tt.wantOutput {
+				t.Errorf("DB.Get() = %v, want %v", w.String(), tt.wantOutput)
+			}
+
+			if err == nil {
+				return
+			}
+
+			_, filename := d.getFilename(tt.key)
+			if _, err = os.Stat(filename); !os.IsNotExist(err) {
+				t.Errorf("DB.Get() left <%s> behind, error = %v", path.Base(filename), err)
+			}
+		})
+	}
+}
@@ -0,0 +1,317 @@
+package csvdb
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+const (
+	// StorageCSV will store rows as CSV
+	StorageCSV Storage = iota
+	// StorageNDJSON will store rows as newline-delimited JSON arrays of strings
+	StorageNDJSON
+	// StorageBinary will store rows as length-prefixed binary, each row is its number of
+	// values followed by each value prefixed by its length, all lengths are uvarints
+	StorageBinary
+)
+
+var (
+	// ErrInvalidStorage is returned when Options.Storage is unknown
+	ErrInvalidStorage = errors.New("invalid storage, unknown value")
+	// ErrStorageIncompatible is returned when Options.Storage is set alongside options which
+	// access files at byte offsets
+	ErrStorageIncompatible = errors.New("invalid storage, cannot be set alongside rowIndexInterval or tailRepair")
+)
+
+// Storage represents the format rows are stored in on disk. Rows are read and written as
+// CSV regardless of the format, and are exported as CSV.
+type Storage uint8
+
+// rowFormat encodes the rows of stored files
+type rowFormat interface {
+	appendRow(dst []byte, values []string) ([]byte, error)
+	readRow(r *bufio.Reader) ([]string, error)
+}
+
+func (s Storage) format() rowFormat {
+	switch s {
+	case StorageNDJSON:
+		return ndjsonFormat{}
+	case StorageBinary:
+		return binaryFormat{}
+	default:
+		return nil
+	}
+}
+
+type ndjsonFormat struct{}
+
+func (ndjsonFormat) appendRow(dst []byte, values []string) (out []byte, err error) {
+	var bs []byte
+	if bs, err = json.Marshal(values); err != nil {
+		return
+	}
+
+	return append(append(dst, bs...), '\n'), nil
+}
+
+func (ndjsonFormat) readRow(r *bufio.Reader) (values []string, err error) {
+	var line []byte
+	if line, err = r.ReadBytes('\n'); err == io.EOF && len(line) > 0 {
+		err = io.ErrUnexpectedEOF
+	}
+
+	if err != nil {
+		return
+	}
+
+	err = json.Unmarshal(line, &values)
+	return
+}
+
+type binaryFormat struct{}
+
+func (binaryFormat) appendRow(dst []byte, values []string) (out []byte, err error) {
+	dst = binary.AppendUvarint(dst, uint64(len(values)))
+	for _, value := range values {
+		dst = binary.AppendUvarint(dst, uint64(len(value)))
+		dst = append(dst, value...)
+	}
+
+	return dst, nil
+}
+
+func (binaryFormat) readRow(r *bufio.Reader) (values []string, err error) {
+	var n uint64
+	if n, err = binary.ReadUvarint(r); err != nil {
+		return
+	}
+
+	values = make([]string, n)
+	for i := range values {
+		var size uint64
+		if size, err = binary.ReadUvarint(r); err != nil {
+			return nil, io.ErrUnexpectedEOF
+		}
+
+		bs := make([]byte, size)
+		if _, err = io.ReadFull(r, bs); err != nil {
+			return nil, io.ErrUnexpectedEOF
+		}
+
+		values[i] = string(bs)
+	}
+
+	return
+}
+
+var _ fileSystem = storageFS{}
+
+// storageFS is a fileSystem storing the rows of data files in a rowFormat. Rows are written
+// as CSV and are stored once each is complete, reads convert the stored rows back to CSV.
+// Sizes and truncation apply to the stored bytes.
+type storageFS struct {
+	fileSystem
+	f rowFormat
+	// dialect is the dialect of the rows written and read as CSV
+	dialect csvDialect
+}
+
+func (fsys storageFS) Open(name string) (f file, err error) {
+	return fsys.wrap(fsys.fileSystem.Open(name))
+}
+
+func (fsys storageFS) OpenFile(name string, flag int, perm os.FileMode) (f file, err error) {
+	return fsys.wrap(fsys.fileSystem.OpenFile(name, flag, perm))
+}
+
+func (fsys storageFS) Create(name string) (f file, err error) {
+	return fsys.wrap(fsys.fileSystem.Create(name))
+}
+
+func (fsys storageFS) CreateTemp(dir, pattern string) (f file, err error) {
+	return fsys.wrap(fsys.fileSystem.CreateTemp(dir, pattern))
+}
+
+func (fsys storageFS) wrap(f file, err error) (file, error) {
+	if err != nil {
+		return f, err
+	}
+
+	if name := f.Name(); filepath.Ext(name) != ".csv" && !compressedTemp.MatchString(name) {
+		return f, nil
+	}
+
+	return &storageFile{file: f, format: fsys.f, dialect: fsys.dialect}, nil
+}
+
+// storageFile is the handle of a data file whose rows are stored in a rowFormat
+type storageFile struct {
+	file
+	format  rowFormat
+	dialect csvDialect
+
+	// pending holds written bytes which do not yet form a complete row
+	pending []byte
+
+	br  *bufio.Reader
+	out bytes.Buffer
+	cw  *csv.Writer
+	// eof is set once every row has been read
+	eof bool
+}
+
+func (s *storageFile) Read(p []byte) (n int, err error) {
+	if s.br == nil {
+		s.br = bufio.NewReader(s.file)
+		s.cw = newCSVWriter(&s.out, s.dialect)
+	}
+
+	for s.out.Len() == 0 {
+		if s.eof {
+			return 0, io.EOF
+		}
+
+		var values []string
+		if values, err = s.format.readRow(s.br); err == io.EOF {
+			s.eof = true
+			continue
+		} else if err != nil {
+			return
+		}
+
+		if err = s.cw.Write(values); err != nil {
+			return
+		}
+
+		s.cw.Flush()
+	}
+
+	return s.out.Read(p)
+}
+
+// Write will store the complete rows of the written bytes, the remainder is stored once
+// the row is completed by a later write or the file is synced or closed
+func (s *storageFile) Write(p []byte) (n int, err error) {
+	s.pending = append(s.pending, p...)
+	end := rowsEnd(s.pending)
+	if end == 0 {
+		return len(p), nil
+	}
+
+	if err = s.store(s.pending[:end]); err != nil {
+		return
+	}
+
+	s.pending = append(s.pending[:0], s.pending[end:]...)
+	return len(p), nil
+}
+
+// store will store the rows of the provided CSV at the end of the file
+func (s *storageFile) store(bs []byte) (err error) {
+	r := newCSVReader(bytes.NewReader(bs), s.dialect)
+	r.FieldsPerRecord = -1
+	var out []byte
+	for {
+		var values []string
+		if values, err = r.Read(); err == io.EOF {
+			break
+		} else if err != nil {
+			return
+		}
+
+		if out, err = s.format.appendRow(out, values); err != nil {
+			return
+		}
+	}
+
+	s.reset()
+	if _, err = s.file.Seek(0, io.SeekEnd); err != nil {
+		return
+	}
+
+	_, err = s.file.Write(out)
+	return
+}
+
+// flush will store the pending bytes as a final row
+func (s *storageFile) flush() (err error) {
+	if len(s.pending) == 0 {
+		return
+	}
+
+	if err = s.store(s.pending); err != nil {
+		return
+	}
+
+	s.pending = s.pending[:0]
+	return
+}
+
+// Seek supports seeking to the start of a file to read it again, or to its end to append
+func (s *storageFile) Seek(offset int64, whence int) (pos int64, err error) {
+	switch {
+	case offset == 0 && (whence == io.SeekStart || whence == io.SeekEnd):
+		s.reset()
+		return s.file.Seek(0, whence)
+	default:
+		return 0, errSeekEncoded
+	}
+}
+
+func (s *storageFile) Truncate(size int64) error {
+	s.pending = s.pending[:0]
+	s.reset()
+	return s.file.Truncate(size)
+}
+
+func (s *storageFile) Sync() (err error) {
+	if err = s.flush(); err != nil {
+		return
+	}
+
+	return s.file.Sync()
+}
+
+func (s *storageFile) Close() (err error) {
+	err = s.flush()
+	if cerr := s.file.Close(); err == nil {
+		err = cerr
+	}
+
+	return
+}
+
+// reset will discard the reader, so the next read starts from the start of the file
+func (s *storageFile) reset() {
+	if s.br != nil {
+		s.br.Reset(s.file)
+	}
+
+	s.out.Reset()
+	s.eof = false
+	s.file.Seek(0, io.SeekStart)
+}
+
+// rowsEnd will return the end of the last complete row of CSV, newlines within quoted
+// values do not end a row
+func rowsEnd(bs []byte) (end int) {
+	var quoted bool
+	for i, b := range bs {
+		switch {
+		case b == '"':
+			quoted = !quoted
+		case b == '\n' && !quoted:
+			end = i + 1
+		}
+	}
+
+	return
+}
@@ -0,0 +1,206 @@
+package csvdb
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestDB_Storage(t *testing.T) {
+	type testcase struct {
+		name    string
+		storage Storage
+		opts    func(o *Options)
+	}
+
+	tests := []testcase{
+		{name: "ndjson", storage: StorageNDJSON, opts: func(o *Options) {}},
+		{name: "ndjson in memory", storage: StorageNDJSON, opts: func(o *Options) { o.InMemory = true }},
+		{name: "ndjson write ahead log", storage: StorageNDJSON, opts: func(o *Options) { o.WriteAheadLog = true }},
+		{name: "ndjson atomic append", storage: StorageNDJSON, opts: func(o *Options) { o.AtomicAppend = true }},
+		{name: "binary", storage: StorageBinary, opts: func(o *Options) {}},
+		{name: "binary compression", storage: StorageBinary, opts: func(o *Options) { o.Compression = CompressionGzip }},
+		{name: "binary max open files", storage: StorageBinary, opts: func(o *Options) { o.MaxOpenFiles = 1 }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var (
+				mux      sync.Mutex
+				exported = make(map[string][]byte)
+			)
+
+			b := &mockBackend{
+				exportFn: func(ctx context.Context, prefix, filename string, r io.Reader) (string, error) {
+					bs, err := io.ReadAll(r)
+					mux.Lock()
+					exported[filename] = bs
+					mux.Unlock()
+					return filename, err
+				},
+				importFn: func(ctx context.Context, prefix, filename string, w io.Writer) error {
+					mux.Lock()
+					bs, ok := exported[filename]
+					mux.Unlock()
+					if !ok {
+						return os.ErrNotExist
+					}
+
+					_, err := w.Write(bs)
+					return err
+				},
+			}
+
+			var opts Options
+			opts.Dir = fmt.Sprintf("test_%d", time.Now().UnixNano())
+			opts.Name = "foo"
+			opts.Storage = tt.storage
+			tt.opts(&opts)
+			d, err := makeDB[testentry](opts, b)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer os.RemoveAll(opts.Dir)
+
+			if err = d.Append("a", testentry{Foo: "1", Bar: "line\nbreak"}); err != nil {
+				t.Fatal(err)
+			}
+
+			if err = d.Append("a", testentry{Foo: "2", Bar: `"quoted", value`}); err != nil {
+				t.Fatal(err)
+			}
+
+			if err = d.Upsert("a", "foo", testentry{Foo: "3", Bar: "3b"}); err != nil {
+				t.Fatal(err)
+			}
+
+			want := "foo,bar\n1,\"line\nbreak\"\n2,\"\"\"quoted\"\", value\"\n3,3b\n"
+			assertGet := func(want string) {
+				t.Helper()
+				w := &bytes.Buffer{}
+				if err := d.Get(w, "a"); err != nil {
+					t.Fatal(err)
+				}
+
+				if w.String() != want {
+					t.Errorf("DB.Get() = %q, want %q", w.String(), want)
+				}
+			}
+
+			assertGet(want)
+			if !opts.InMemory && opts.Compression == CompressionNone {
+				f, err := os.Open(filepath.Join(opts.Dir, "foo", "foo.a.csv"))
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				format := tt.storage.format()
+				br := bufio.NewReader(f)
+				var rows [][]string
+				for {
+					values, err := format.readRow(br)
+					if err == io.EOF {
+						break
+					} else if err != nil {
+						t.Fatal(err)
+					}
+
+					rows = append(rows, values)
+				}
+
+				f.Close()
+				wantRows := [][]string{{"foo", "bar"}, {"1", "line\nbreak"}, {"2", `"quoted", value`}, {"3", "3b"}}
+				if !reflect.DeepEqual(rows, wantRows) {
+					t.Errorf("stored rows = %q, want %q", rows, wantRows)
+				}
+			}
+
+			// Files are exported as CSV
+			if err = d.ExportPrefix(""); err != nil {
+				t.Fatal(err)
+			}
+
+			if got := string(exported[d.dataName("foo.a.csv")]); opts.Compression == CompressionNone && got != want {
+				t.Errorf("exported = %q, want %q", got, want)
+			}
+
+			if err = d.Delete("a"); err != nil {
+				t.Fatal(err)
+			}
+
+			// Downloads are stored in the format again
+			assertGet(want)
+			if err = d.Append("a", testentry{Foo: "4", Bar: "4b"}); err != nil {
+				t.Fatal(err)
+			}
+
+			assertGet(want + "4,4b\n")
+		})
+	}
+}
+
+func TestOptions_Validate_storage(t *testing.T) {
+	type testcase struct {
+		name    string
+		opts    Options
+		wantErr error
+	}
+
+	tests := []testcase{
+		{
+			name: "basic",
+			opts: Options{Dir: "test", Name: "foo", Storage: StorageBinary},
+		},
+		{
+			name:    "unknown",
+			opts:    Options{Dir: "test", Name: "foo", Storage: StorageBinary + 1},
+			wantErr: ErrInvalidStorage,
+		},
+		{
+			name:    "row index",
+			opts:    Options{Dir: "test", Name: "foo", Storage: StorageNDJSON, RowIndexInterval: 10},
+			wantErr: ErrStorageIncompatible,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.opts.Validate(); !errors.Is(err, tt.wantErr) {
+				t.Errorf("Options.Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestRowsEnd(t *testing.T) {
+	type testcase struct {
+		name string
+		bs   string
+		want int
+	}
+
+	tests := []testcase{
+		{name: "empty", bs: "", want: 0},
+		{name: "partial", bs: "a,b", want: 0},
+		{name: "complete", bs: "a,b\nc", want: 4},
+		{name: "quoted newline", bs: "a,\"b\nc", want: 0},
+		{name: "escaped quotes", bs: "a,\"b\"\"\nc\"\nd", want: 10},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := rowsEnd([]byte(tt.bs)); got != tt.want {
+				t.Errorf("rowsEnd() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,64 @@
+package csvdb
+
+import (
+	"context"
+	"time"
+)
+
+// AppendStream will drain the provided channel, appending the entries to a key. Entries are
+// written in batches, flushing whenever Options.StreamBatchSize entries are buffered or every
+// Options.StreamFlushInterval. AppendStream returns once the channel is closed or the context
+// is done, flushing any buffered entries before returning.
+func (d *DB[T]) AppendStream(ctx context.Context, key string, ch <-chan T) (err error) {
+	var ew *EntryWriter[T]
+	if ew, err = d.WriterContext(ctx, key); err != nil {
+		return
+	}
+
+	defer func() {
+		if cerr := ew.Close(); err == nil {
+			err = cerr
+		}
+	}()
+
+	ticker := time.NewTicker(d.o.StreamFlushInterval)
+	defer ticker.Stop()
+
+	var count int
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+
+		case e, ok := <-ch:
+			if !ok {
+				return
+			}
+
+			if err = ew.Write(e); err != nil {
+				return
+			}
+
+			if count++; count < d.o.StreamBatchSize {
+				continue
+			}
+
+			if err = ew.Flush(); err != nil {
+				return
+			}
+
+			count = 0
+
+		case <-ticker.C:
+			if count == 0 {
+				continue
+			}
+
+			if err = ew.Flush(); err != nil {
+				return
+			}
+
+			count = 0
+		}
+	}
+}
@@ -0,0 +1,82 @@
+package csvdb
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestDB_AppendStream(t *testing.T) {
+	type testcase struct {
+		name      string
+		batchSize int
+		cancel    bool
+		wantW     string
+		wantErr   error
+	}
+
+	tests := []testcase{
+		{
+			name:      "closed channel",
+			batchSize: 2,
+			wantW:     "foo,bar\n1,1b\n2,2b\n3,3b\n",
+		},
+		{
+			name:      "cancelled",
+			batchSize: 100,
+			cancel:    true,
+			wantW:     "foo,bar\n1,1b\n2,2b\n3,3b\n",
+			wantErr:   context.Canceled,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var opts Options
+			opts.Dir = fmt.Sprintf("test_%d", time.Now().UnixNano())
+			opts.Name = "foo"
+			opts.StreamBatchSize = tt.batchSize
+			d, err := makeDB[testentry](opts, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer os.RemoveAll(d.o.Dir)
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			ch := make(chan testentry)
+			errC := make(chan error, 1)
+			go func() {
+				errC <- d.AppendStream(ctx, "foo", ch)
+			}()
+
+			for i := 1; i <= 3; i++ {
+				ch <- testentry{Foo: fmt.Sprint(i), Bar: fmt.Sprintf("%db", i)}
+			}
+
+			if tt.cancel {
+				cancel()
+			} else {
+				close(ch)
+			}
+
+			if err = <-errC; !errors.Is(err, tt.wantErr) {
+				t.Fatalf("DB.AppendStream() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			w := &bytes.Buffer{}
+			if err = d.Get(w, "foo"); err != nil {
+				t.Fatal(err)
+			}
+
+			if gotW := w.String(); gotW != tt.wantW {
+				t.Errorf("DB.AppendStream() = %v, want %v", gotW, tt.wantW)
+			}
+		})
+	}
+}
@@ -0,0 +1,126 @@
+package csvdb
+
+import (
+	"errors"
+	"io"
+	"os"
+)
+
+const (
+	// TailRepairOff will leave files with an incomplete final record untouched
+	TailRepairOff TailRepair = iota
+	// TailRepairTruncate will truncate the incomplete final record of a file, logging a warning
+	TailRepairTruncate
+	// TailRepairQuarantine will quarantine files with an incomplete final record
+	TailRepairQuarantine
+)
+
+// ErrInvalidTailRepair is returned when Options.TailRepair is unknown
+var ErrInvalidTailRepair = errors.New("invalid tailRepair, unknown value")
+
+// TailRepair represents how files whose final record was only partially written are handled on startup
+type TailRepair uint8
+
+// repairTails will detect files whose final record was only partially written, such as
+// when the process died mid-write, and repair them according to Options.TailRepair
+func (d *DB[T]) repairTails() (err error) {
+	if d.o.TailRepair == TailRepairOff {
+		return
+	}
+
+	var truncated []string
+	if err = d.forEach(func(filename string, info os.FileInfo) (err error) {
+		var offset int64
+		if offset, err = d.findTail(d.getPath(filename), info.Size()); err != nil || offset == info.Size() {
+			return
+		}
+
+		truncated = append(truncated, filename)
+		if d.o.TailRepair != TailRepairTruncate {
+			return
+		}
+
+		d.o.Logger.Printf("csvdb.DB[%s].repairTails(): truncating incomplete final record of <%s> (%d bytes)\n", d.o.Name, filename, info.Size()-offset)
+		return d.truncate(d.getPath(filename), offset)
+	}); err != nil {
+		return
+	}
+
+	if d.o.TailRepair != TailRepairQuarantine {
+		return
+	}
+
+	for _, filename := range truncated {
+		d.o.Logger.Printf("csvdb.DB[%s].repairTails(): quarantining <%s> with incomplete final record\n", d.o.Name, filename)
+		if err = d.quarantine(d.getKey(filename)); err != nil {
+			return
+		}
+	}
+
+	return
+}
+
+// findTail will return the offset the complete records of a file end at. Files are only
+// parsed when their final byte is not a newline, as csv.Writer terminates every record.
+func (d *DB[T]) findTail(filename string, size int64) (offset int64, err error) {
+	if size == 0 {
+		return
+	}
+
+	var f file
+	if f, err = d.fs.Open(filename); err != nil {
+		return
+	}
+	defer f.Close()
+
+	last := make([]byte, 1)
+	if _, err = f.Seek(size-1, io.SeekStart); err != nil {
+		return
+	}
+
+	if _, err = io.ReadFull(f, last); err != nil {
+		return
+	}
+
+	if last[0] == '\n' {
+		return size, nil
+	}
+
+	if _, err = f.Seek(0, io.SeekStart); err != nil {
+		return
+	}
+
+	r := newCSVReader(f, d.dialect)
+	r.FieldsPerRecord = -1
+	r.ReuseRecord = true
+	for {
+		start := r.InputOffset()
+		switch _, err = r.Read(); {
+		case err == io.EOF:
+			// The final record was read without a terminating newline
+			return offset, nil
+		case err != nil && r.InputOffset() < size:
+			// Records before the final record are invalid, which is left to the integrity check
+			return size, nil
+		case err != nil:
+			// The final record could not be parsed
+			return start, nil
+		}
+
+		offset = start
+	}
+}
+
+func (d *DB[T]) truncate(filename string, size int64) (err error) {
+	var f file
+	if f, err = d.fs.OpenFile(filename, os.O_WRONLY, 0644); err != nil {
+		return
+	}
+
+	err = f.Truncate(size)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+
+	return
+}
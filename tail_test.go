@@ -0,0 +1,100 @@
+package csvdb
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestDB_repairTails(t *testing.T) {
+	type testcase struct {
+		name   string
+		repair TailRepair
+		file   string
+
+		wantFile        string
+		wantQuarantined []string
+	}
+
+	tests := []testcase{
+		{
+			name:     "complete",
+			repair:   TailRepairTruncate,
+			file:     "foo,bar\n1,1b\n",
+			wantFile: "foo,bar\n1,1b\n",
+		},
+		{
+			name:     "partial record",
+			repair:   TailRepairTruncate,
+			file:     "foo,bar\n1,1b\n2,2",
+			wantFile: "foo,bar\n1,1b\n",
+		},
+		{
+			name:     "partial quoted field",
+			repair:   TailRepairTruncate,
+			file:     "foo,bar\n1,1b\n2,\"2\nb",
+			wantFile: "foo,bar\n1,1b\n",
+		},
+		{
+			name:     "partial header",
+			repair:   TailRepairTruncate,
+			file:     "foo,b",
+			wantFile: "",
+		},
+		{
+			name:            "quarantine",
+			repair:          TailRepairQuarantine,
+			file:            "foo,bar\n1,1b\n2,2",
+			wantQuarantined: []string{"a"},
+		},
+		{
+			name:     "off",
+			repair:   TailRepairOff,
+			file:     "foo,bar\n1,1b\n2,2",
+			wantFile: "foo,bar\n1,1b\n2,2",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := fmt.Sprintf("test_%d", time.Now().UnixNano())
+			defer os.RemoveAll(dir)
+
+			filename := path.Join(dir, "foo", "foo.a.csv")
+			if err := os.MkdirAll(path.Dir(filename), 0744); err != nil {
+				t.Fatal(err)
+			}
+
+			if err := os.WriteFile(filename, []byte(tt.file), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			var opts Options
+			opts.Dir = dir
+			opts.Name = "foo"
+			opts.TailRepair = tt.repair
+			d, err := makeDB[testentry](opts, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if got := d.Quarantined(); len(got)+len(tt.wantQuarantined) > 0 && !reflect.DeepEqual(got, tt.wantQuarantined) {
+				t.Fatalf("DB.Quarantined() = %v, want %v", got, tt.wantQuarantined)
+			} else if len(got) > 0 {
+				return
+			}
+
+			bs, err := os.ReadFile(filename)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if string(bs) != tt.wantFile {
+				t.Errorf("file = %q, want %q", string(bs), tt.wantFile)
+			}
+		})
+	}
+}
@@ -0,0 +1,40 @@
+package csvdb
+
+import (
+	"errors"
+	"fmt"
+	"path"
+)
+
+// ErrInvalidTempDir is returned by New when files cannot be renamed from Options.TempDir into the directory of the DB
+var ErrInvalidTempDir = errors.New("invalid tempDir, must reside on the same filesystem as dir")
+
+// checkTempDir will create the temporary directory and verify files staged within it
+// can be renamed into the directory of the DB
+func checkTempDir(fsys fileSystem, tempDir, dir string) (err error) {
+	if tempDir == "" {
+		return
+	}
+
+	if err = fsys.MkdirAll(tempDir, 0744); err != nil {
+		return
+	}
+
+	var tmp file
+	if tmp, err = fsys.CreateTemp(tempDir, ".tempdir.*.tmp"); err != nil {
+		return
+	}
+
+	if err = tmp.Close(); err != nil {
+		fsys.Remove(tmp.Name())
+		return
+	}
+
+	target := path.Join(dir, path.Base(tmp.Name()))
+	if err = fsys.Rename(tmp.Name(), target); err != nil {
+		fsys.Remove(tmp.Name())
+		return fmt.Errorf("%w: %v", ErrInvalidTempDir, err)
+	}
+
+	return fsys.Remove(target)
+}
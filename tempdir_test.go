@@ -0,0 +1,140 @@
+package csvdb
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// renameFailFS is a fileSystem whose renames fail
+type renameFailFS struct {
+	fileSystem
+
+	err error
+}
+
+func (r *renameFailFS) Rename(oldpath, newpath string) error {
+	return &os.LinkError{Op: "rename", Old: oldpath, New: newpath, Err: r.err}
+}
+
+// tempDirFS is a fileSystem which records the directories temporary files are created within
+type tempDirFS struct {
+	fileSystem
+
+	dirs []string
+}
+
+func (t *tempDirFS) CreateTemp(dir, pattern string) (file, error) {
+	t.dirs = append(t.dirs, dir)
+	return t.fileSystem.CreateTemp(dir, pattern)
+}
+
+func Test_checkTempDir(t *testing.T) {
+	type testcase struct {
+		name      string
+		tempDir   bool
+		renameErr error
+
+		wantErr error
+	}
+
+	tests := []testcase{
+		{
+			name: "default",
+		},
+		{
+			name:    "same filesystem",
+			tempDir: true,
+		},
+		{
+			name:      "cross device",
+			tempDir:   true,
+			renameErr: syscall.EXDEV,
+			wantErr:   ErrInvalidTempDir,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := fmt.Sprintf("test_%d", time.Now().UnixNano())
+			defer os.RemoveAll(dir)
+			if err := os.MkdirAll(path.Join(dir, "db"), 0744); err != nil {
+				t.Fatal(err)
+			}
+
+			var fsys fileSystem = osFS{}
+			if tt.renameErr != nil {
+				fsys = &renameFailFS{fileSystem: fsys, err: tt.renameErr}
+			}
+
+			var tempDir string
+			if tt.tempDir {
+				tempDir = path.Join(dir, "tmp")
+			}
+
+			if err := checkTempDir(fsys, tempDir, path.Join(dir, "db")); !errors.Is(err, tt.wantErr) {
+				t.Fatalf("checkTempDir() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			for _, d := range []string{tempDir, path.Join(dir, "db")} {
+				if d == "" {
+					continue
+				}
+
+				if entries, err := os.ReadDir(d); err != nil {
+					t.Fatal(err)
+				} else if len(entries) != 0 {
+					t.Errorf("checkTempDir() left %d files within <%s>", len(entries), d)
+				}
+			}
+		})
+	}
+}
+
+func TestDB_TempDir(t *testing.T) {
+	dir := fmt.Sprintf("test_%d", time.Now().UnixNano())
+	defer os.RemoveAll(dir)
+
+	var opts Options
+	opts.Dir = dir
+	opts.Name = "foo"
+	opts.TempDir = path.Join(dir, "tmp")
+	opts.AtomicAppend = true
+	d, err := makeDB[testentry](opts, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fsys := &tempDirFS{fileSystem: d.fs}
+	d.fs = fsys
+	if err = d.Append("a", testentry{Foo: "1", Bar: "1b"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err = d.Upsert("a", "foo", testentry{Foo: "1", Bar: "1c"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(fsys.dirs) != 2 {
+		t.Fatalf("temporary files = %d, want 2", len(fsys.dirs))
+	}
+
+	for _, d := range fsys.dirs {
+		if d != opts.TempDir {
+			t.Errorf("temporary file created within <%s>, want <%s>", d, opts.TempDir)
+		}
+	}
+
+	bs, err := os.ReadFile(path.Join(dir, "foo", "foo.a.csv"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want := "foo,bar\n1,1c\n"; string(bs) != want {
+		t.Fatalf("file = %v, want %v", string(bs), want)
+	}
+}
@@ -0,0 +1,87 @@
+package csvdb
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+func newThrottle(rate float64) *throttle {
+	if rate <= 0 {
+		return nil
+	}
+
+	var t throttle
+	t.rate = rate
+	t.tokens = rate
+	t.last = time.Now()
+	return &t
+}
+
+// throttle is a token bucket which limits a rate per second, allowing bursts of up to one second
+type throttle struct {
+	mux sync.Mutex
+
+	rate   float64
+	tokens float64
+	last   time.Time
+}
+
+// wait will take n tokens, blocking until the bucket is no longer in debt. A nil throttle never blocks.
+func (t *throttle) wait(ctx context.Context, n int64) (err error) {
+	if t == nil || n <= 0 {
+		return
+	}
+
+	t.mux.Lock()
+	now := time.Now()
+	t.tokens += now.Sub(t.last).Seconds() * t.rate
+	if t.tokens > t.rate {
+		t.tokens = t.rate
+	}
+
+	t.last = now
+	t.tokens -= float64(n)
+	delay := time.Duration(-t.tokens / t.rate * float64(time.Second))
+	t.mux.Unlock()
+
+	if delay <= 0 {
+		return
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return
+	}
+}
+
+// throttledFile is a file whose reads are limited by a throttle
+type throttledFile struct {
+	file
+
+	ctx context.Context
+	t   *throttle
+}
+
+func (f *throttledFile) Read(bs []byte) (n int, err error) {
+	if n, err = f.file.Read(bs); err != nil {
+		return
+	}
+
+	err = f.t.wait(f.ctx, int64(n))
+	return
+}
+
+// throttleFile will limit the reads of a background job from a file to Options.BackgroundIOBytesPerSecond
+func (d *DB[T]) throttleFile(ctx context.Context, f file) file {
+	if d.ioBytes == nil {
+		return f
+	}
+
+	return &throttledFile{file: f, ctx: ctx, t: d.ioBytes}
+}
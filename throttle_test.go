@@ -0,0 +1,121 @@
+package csvdb
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+)
+
+func Test_throttle_wait(t *testing.T) {
+	type testcase struct {
+		name    string
+		rate    float64
+		takes   []int64
+		timeout time.Duration
+
+		wantMin time.Duration
+		wantMax time.Duration
+		wantErr bool
+	}
+
+	tests := []testcase{
+		{
+			name:    "unlimited",
+			takes:   []int64{1000, 1000},
+			wantMax: 20 * time.Millisecond,
+		},
+		{
+			name:    "within burst",
+			rate:    100,
+			takes:   []int64{50, 50},
+			wantMax: 20 * time.Millisecond,
+		},
+		{
+			name:    "beyond burst",
+			rate:    100,
+			takes:   []int64{100, 10},
+			wantMin: 80 * time.Millisecond,
+			wantMax: time.Second,
+		},
+		{
+			name:    "cancelled",
+			rate:    10,
+			takes:   []int64{10, 100},
+			timeout: 20 * time.Millisecond,
+			wantMax: time.Second,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			if tt.timeout > 0 {
+				var cancel func()
+				ctx, cancel = context.WithTimeout(ctx, tt.timeout)
+				defer cancel()
+			}
+
+			th := newThrottle(tt.rate)
+			start := time.Now()
+			var err error
+			for _, n := range tt.takes {
+				if err = th.wait(ctx, n); err != nil {
+					break
+				}
+			}
+
+			elapsed := time.Since(start)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("throttle.wait() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if elapsed < tt.wantMin || elapsed > tt.wantMax {
+				t.Errorf("throttle.wait() elapsed = %v, want between %v and %v", elapsed, tt.wantMin, tt.wantMax)
+			}
+		})
+	}
+}
+
+func TestDB_backgroundIOThrottle(t *testing.T) {
+	var opts Options
+	opts.Dir = fmt.Sprintf("test_%d", time.Now().UnixNano())
+	opts.Name = "foo"
+	opts.FileTTL = time.Millisecond
+	opts.BackgroundIOOpsPerSecond = 20
+	d, err := makeDB[testentry](opts, &mockBackend{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(d.o.Dir)
+
+	for i := 0; i < 22; i++ {
+		if err = d.Append(fmt.Sprint(i), testentry{Foo: "1", Bar: "1b"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	time.Sleep(10 * time.Millisecond)
+	start := time.Now()
+	if err = d.purge(""); err != nil {
+		t.Fatal(err)
+	}
+
+	if elapsed := time.Since(start); elapsed < 80*time.Millisecond {
+		t.Errorf("DB.purge() elapsed = %v, want at least %v", elapsed, 80*time.Millisecond)
+	}
+
+	var count int
+	if err = d.forEach(func(key string, info os.FileInfo) error {
+		count++
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	if count != 0 {
+		t.Errorf("DB.purge() left %d files", count)
+	}
+}
@@ -2,14 +2,166 @@ package csvdb
 
 import (
 	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
 	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
 	"time"
 )
 
 var openFile = os.OpenFile
 
-func getOrCreate(filename string) (f *os.File, err error) {
-	return openFile(filename, os.O_CREATE|os.O_RDWR|os.O_APPEND, 0644)
+func getOrCreate(fsys fileSystem, filename string) (f file, err error) {
+	return fsys.OpenFile(filename, os.O_CREATE|os.O_RDWR|os.O_APPEND, 0644)
+}
+
+// createTemp will create a temporary file within dir so it can later be renamed over
+// the provided filename. When dir is empty, the file is created alongside the filename.
+func createTemp(fsys fileSystem, dir, filename string) (f file, err error) {
+	if dir == "" {
+		dir = filepath.Dir(filename)
+	}
+
+	return fsys.CreateTemp(dir, filepath.Base(filename)+".*.tmp")
+}
+
+// rewriteFile will stream the contents of a file through the provided func into a
+// temporary file within tempDir, which then atomically replaces the original file. The
+// header will be nil when the original file is empty or does not exist.
+func rewriteFile(ctx context.Context, fsys fileSystem, tempDir string, dialect csvDialect, filename string, fn func(header []string, r *csv.Reader, w *csv.Writer) error) (err error) {
+	var src io.Reader = strings.NewReader("")
+	f, err := fsys.Open(filename)
+	switch {
+	case err == nil:
+		defer f.Close()
+		src = newContextReader(ctx, f)
+	case os.IsNotExist(err):
+		err = nil
+	default:
+		return
+	}
+
+	var tmp file
+	if tmp, err = createTemp(fsys, tempDir, filename); err != nil {
+		return
+	}
+
+	defer func() {
+		if err == nil {
+			return
+		}
+
+		tmp.Close()
+		fsys.Remove(tmp.Name())
+	}()
+
+	r := newCSVReader(src, dialect)
+	header, err := r.Read()
+	switch err {
+	case nil:
+	case io.EOF:
+		err = nil
+	default:
+		return
+	}
+
+	w := newCSVWriter(tmp, dialect)
+	if err = fn(header, r, w); err != nil {
+		return
+	}
+
+	w.Flush()
+	if err = w.Error(); err != nil {
+		return
+	}
+
+	if err = tmp.Close(); err != nil {
+		return
+	}
+
+	return fsys.Rename(tmp.Name(), filename)
+}
+
+// tryLocker is a sync.Locker which supports TryLock, such as sync.Mutex and sync.RWMutex
+type tryLocker interface {
+	sync.Locker
+	TryLock() bool
+}
+
+// lockContext will acquire the provided mutex. When the context is done before the
+// lock is acquired, an error wrapping both ErrBusy and the context error is returned.
+func lockContext(ctx context.Context, mux tryLocker) (err error) {
+	if err = ctx.Err(); err != nil {
+		return
+	}
+
+	if mux.TryLock() {
+		return
+	}
+
+	locked := make(chan struct{})
+	go func() {
+		mux.Lock()
+		close(locked)
+	}()
+
+	select {
+	case <-locked:
+		return
+	case <-ctx.Done():
+		// Release the lock once it has been acquired by the abandoned goroutine
+		go func() {
+			<-locked
+			mux.Unlock()
+		}()
+
+		return fmt.Errorf("%w: %w", ErrBusy, ctx.Err())
+	}
+}
+
+// contextReader is an io.Reader which stops reading once its context is done
+type contextReader struct {
+	ctx context.Context
+	r   io.Reader
+}
+
+func newContextReader(ctx context.Context, r io.Reader) *contextReader {
+	return &contextReader{ctx: ctx, r: r}
+}
+
+func (c *contextReader) Read(bs []byte) (n int, err error) {
+	if err = c.ctx.Err(); err != nil {
+		return
+	}
+
+	return c.r.Read(bs)
+}
+
+// rowKey will return a collision-free string representation of a row of values
+func rowKey(values []string) string {
+	var sb strings.Builder
+	for _, value := range values {
+		sb.WriteString(strconv.Itoa(len(value)))
+		sb.WriteByte(':')
+		sb.WriteString(value)
+	}
+
+	return sb.String()
+}
+
+// indexOf will return the index of a value within a slice, -1 is returned when not found
+func indexOf(values []string, value string) int {
+	for i, v := range values {
+		if v == value {
+			return i
+		}
+	}
+
+	return -1
 }
 
 func isExpiredBasic(ttl time.Duration, info os.FileInfo) (expired bool) {
@@ -27,13 +179,24 @@ func basicExpiryMonitor(fileTTL time.Duration) ExpiryMonitor {
 	}
 }
 
-func scan(ctx context.Context, fn func(), interval time.Duration) {
+// scan will call the provided func in a new goroutine on every interval until the
+// context is done. The scanner and the calls it starts are tracked by the WaitGroup,
+// which must be incremented for the scanner before it is started.
+func scan(ctx context.Context, wg *sync.WaitGroup, fn func(), interval time.Duration) {
+	defer wg.Done()
 	ticker := time.NewTicker(interval)
-	for range ticker.C {
+	defer ticker.Stop()
+	for {
 		select {
 		case <-ctx.Done():
-		default:
+			return
+		case <-ticker.C:
 		}
-		go fn()
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			fn()
+		}()
 	}
 }
@@ -1,9 +1,12 @@
 package csvdb
 
 import (
+	"context"
+	"errors"
 	"io/fs"
 	"os"
 	"testing"
+	"time"
 )
 
 func Test_getOrCreate(t *testing.T) {
@@ -39,7 +42,7 @@ func Test_getOrCreate(t *testing.T) {
 				defer os.Remove(tt.args.filename)
 			}
 
-			_, err := getOrCreate(tt.args.filename)
+			_, err := getOrCreate(osFS{}, tt.args.filename)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("getOrCreate() error = %v, wantErr %v", err, tt.wantErr)
 				return
@@ -47,3 +50,66 @@ func Test_getOrCreate(t *testing.T) {
 		})
 	}
 }
+
+func Test_lockContext(t *testing.T) {
+	type testcase struct {
+		name      string
+		held      bool
+		cancelled bool
+
+		wantErr  error
+		wantBusy bool
+	}
+
+	tests := []testcase{
+		{
+			name: "free",
+		},
+		{
+			name:     "held until deadline",
+			held:     true,
+			wantErr:  context.DeadlineExceeded,
+			wantBusy: true,
+		},
+		{
+			name:      "cancelled before acquiring",
+			cancelled: true,
+			wantErr:   context.Canceled,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var mux timedMutex
+			if tt.held {
+				mux.Lock()
+			}
+
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+			defer cancel()
+			if tt.cancelled {
+				cancel()
+			}
+
+			err := lockContext(ctx, &mux)
+			if !errors.Is(err, tt.wantErr) || (err == nil) != (tt.wantErr == nil) {
+				t.Fatalf("lockContext() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if errors.Is(err, ErrBusy) != tt.wantBusy {
+				t.Errorf("lockContext() error = %v, wantBusy %v", err, tt.wantBusy)
+			}
+
+			if tt.held || err == nil {
+				mux.Unlock()
+			}
+
+			// Ensure the lock is released by abandoned acquisitions
+			if err = lockContext(context.Background(), &mux); err != nil {
+				t.Fatal(err)
+			}
+
+			mux.Unlock()
+		})
+	}
+}
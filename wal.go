@@ -0,0 +1,181 @@
+package csvdb
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// journalExt is the extension of the journal written alongside a file while rows are appended to it
+const journalExt = ".wal"
+
+// ErrInvalidWriteAheadLog is returned when WriteAheadLog is set alongside AtomicAppend
+var ErrInvalidWriteAheadLog = errors.New("invalid writeAheadLog, cannot be set alongside atomicAppend")
+
+// RecoveryStats are the statistics of the journals recovered when the DB was opened
+type RecoveryStats struct {
+	// Replayed is the number of journals whose appends were re-applied to their files
+	Replayed int
+	// Discarded is the number of incomplete journals, whose appends were never applied
+	Discarded int
+}
+
+// journalEntry is a pending append to a file, as it is written to the journal of the file
+type journalEntry struct {
+	// Size is the size of the file prior to the append
+	Size int64  `json:"size"`
+	Data []byte `json:"data"`
+}
+
+// appender is the writer appends to a file are made through. When WriteAheadLog is set,
+// the appended bytes are buffered until they are committed.
+type appender struct {
+	f    file
+	size int64
+	// buf is nil when WriteAheadLog is unset
+	buf *bytes.Buffer
+}
+
+func (a *appender) Write(bs []byte) (n int, err error) {
+	if a.buf == nil {
+		return a.f.Write(bs)
+	}
+
+	return a.buf.Write(bs)
+}
+
+// newAppender will return an appender for a file of the provided size
+func (d *DB[T]) newAppender(f file, size int64) (a *appender) {
+	a = &appender{f: f, size: size}
+	if d.o.WriteAheadLog {
+		a.buf = &bytes.Buffer{}
+	}
+
+	return
+}
+
+// commit will write the bytes buffered by an appender to the journal of its file, apply
+// them to the file and then remove the journal. Should the process crash mid-apply, the
+// journal is replayed when the DB is next opened.
+func (d *DB[T]) commit(a *appender) (err error) {
+	if a.buf == nil || a.buf.Len() == 0 {
+		return
+	}
+
+	journal := a.f.Name() + journalExt
+	if err = d.writeJournal(journal, journalEntry{Size: a.size, Data: a.buf.Bytes()}); err != nil {
+		return
+	}
+
+	if _, err = a.f.Write(a.buf.Bytes()); err == nil {
+		err = a.f.Sync()
+	}
+
+	if err != nil {
+		// The append failed, ensure it is not replayed
+		if terr := a.f.Truncate(a.size); terr != nil {
+			// The journal is kept, so the file is repaired once the DB is next opened
+			d.o.Logger.Printf("csvdb.DB[%s].commit(): error restoring <%s>: %v\n", d.o.Name, a.f.Name(), terr)
+			return
+		}
+	}
+
+	if rerr := d.fs.Remove(journal); err == nil {
+		err = rerr
+	}
+
+	return
+}
+
+func (d *DB[T]) writeJournal(name string, e journalEntry) (err error) {
+	var bs []byte
+	if bs, err = json.Marshal(e); err != nil {
+		return
+	}
+
+	var f file
+	if f, err = d.fs.Create(name); err != nil {
+		return
+	}
+
+	if _, err = f.Write(bs); err == nil {
+		err = f.Sync()
+	}
+
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+
+	if err != nil {
+		d.fs.Remove(name)
+		return
+	}
+
+	return d.fs.SyncDir(filepath.Dir(name))
+}
+
+// recoverJournals will replay the journals left behind by appends which were interrupted
+func (d *DB[T]) recoverJournals() (err error) {
+	if d.o.ReadOnly {
+		// Journals are left to be recovered by the writer of the directory
+		return
+	}
+
+	return d.readDataDirs(func(dir string, entries []os.DirEntry) (err error) {
+		for _, entry := range entries {
+			if entry.IsDir() || filepath.Ext(entry.Name()) != journalExt {
+				continue
+			}
+
+			if err = d.recoverJournal(path.Join(dir, entry.Name())); err != nil {
+				return
+			}
+		}
+
+		return
+	})
+}
+
+func (d *DB[T]) recoverJournal(journal string) (err error) {
+	var bs []byte
+	if bs, err = readFile(d.fs, journal); err != nil {
+		return
+	}
+
+	var e journalEntry
+	if err = json.Unmarshal(bs, &e); err != nil {
+		// The journal was not completely written, the append was never applied
+		d.o.Logger.Printf("csvdb.DB[%s].recoverJournal(): discarding incomplete journal <%s>\n", d.o.Name, journal)
+		d.recovery.Discarded++
+		return d.fs.Remove(journal)
+	}
+
+	filename := strings.TrimSuffix(journal, journalExt)
+	var f file
+	if f, err = getOrCreate(d.fs, filename); err != nil {
+		return
+	}
+
+	// Discard any partially applied bytes before re-applying the append
+	if err = f.Truncate(e.Size); err == nil {
+		if _, err = f.Write(e.Data); err == nil {
+			err = f.Sync()
+		}
+	}
+
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+
+	if err != nil {
+		return
+	}
+
+	d.o.Logger.Printf("csvdb.DB[%s].recoverJournal(): replayed journal of <%s>\n", d.o.Name, filename)
+	d.recovery.Replayed++
+	return d.fs.Remove(journal)
+}
@@ -0,0 +1,143 @@
+package csvdb
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestDB_WriteAheadLog(t *testing.T) {
+	var opts Options
+	opts.Dir = fmt.Sprintf("test_%d", time.Now().UnixNano())
+	opts.Name = "foo"
+	opts.WriteAheadLog = true
+	d, err := makeDB[testentry](opts, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(d.o.Dir)
+
+	if err = d.Append("a", testentry{Foo: "1", Bar: "1b"}); err != nil {
+		t.Fatal(err)
+	}
+
+	ew, err := d.Writer("a")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err = ew.Write(testentry{Foo: "2", Bar: "2b"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err = ew.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	bs, err := os.ReadFile(path.Join(d.getFullPath(), "foo.a.csv"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want := "foo,bar\n1,1b\n2,2b\n"; string(bs) != want {
+		t.Fatalf("file = %v, want %v", string(bs), want)
+	}
+
+	if _, err = os.Stat(path.Join(d.getFullPath(), "foo.a.csv"+journalExt)); !os.IsNotExist(err) {
+		t.Fatalf("journal was not removed, error = %v", err)
+	}
+}
+
+func TestDB_recoverJournals(t *testing.T) {
+	type testcase struct {
+		name    string
+		file    *string
+		journal string
+
+		wantFile  string
+		wantStats RecoveryStats
+	}
+
+	str := func(s string) *string { return &s }
+
+	tests := []testcase{
+		{
+			name:      "partially applied",
+			file:      str("foo,bar\n1,1b\n2,2"),
+			journal:   `{"size":13,"data":"MiwyYgo="}`,
+			wantFile:  "foo,bar\n1,1b\n2,2b\n",
+			wantStats: RecoveryStats{Replayed: 1},
+		},
+		{
+			name:      "not applied",
+			file:      str("foo,bar\n1,1b\n"),
+			journal:   `{"size":13,"data":"MiwyYgo="}`,
+			wantFile:  "foo,bar\n1,1b\n2,2b\n",
+			wantStats: RecoveryStats{Replayed: 1},
+		},
+		{
+			name:      "missing file",
+			journal:   `{"size":0,"data":"Zm9vLGJhcgoxLDFiCg=="}`,
+			wantFile:  "foo,bar\n1,1b\n",
+			wantStats: RecoveryStats{Replayed: 1},
+		},
+		{
+			name:      "incomplete journal",
+			file:      str("foo,bar\n1,1b\n"),
+			journal:   `{"size":13,"da`,
+			wantFile:  "foo,bar\n1,1b\n",
+			wantStats: RecoveryStats{Discarded: 1},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := fmt.Sprintf("test_%d", time.Now().UnixNano())
+			defer os.RemoveAll(dir)
+
+			fullDir := path.Join(dir, "foo")
+			filename := path.Join(fullDir, "foo.a.csv")
+			if err := os.MkdirAll(fullDir, 0744); err != nil {
+				t.Fatal(err)
+			}
+
+			if tt.file != nil {
+				if err := os.WriteFile(filename, []byte(*tt.file), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			if err := os.WriteFile(filename+journalExt, []byte(tt.journal), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			var opts Options
+			opts.Dir = dir
+			opts.Name = "foo"
+			d, err := makeDB[testentry](opts, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			bs, err := os.ReadFile(filename)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if string(bs) != tt.wantFile {
+				t.Errorf("file = %v, want %v", string(bs), tt.wantFile)
+			}
+
+			if got := d.Stats().Recovery; !reflect.DeepEqual(got, tt.wantStats) {
+				t.Errorf("DB.Stats().Recovery = %+v, want %+v", got, tt.wantStats)
+			}
+
+			if _, err = os.Stat(filename + journalExt); !os.IsNotExist(err) {
+				t.Errorf("journal was not removed, error = %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,58 @@
+package csvdb
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"strings"
+)
+
+// ErrListerNotImplemented is returned when listing is requested from a Backend which does not implement Lister
+var ErrListerNotImplemented = errors.New("backend does not implement Lister")
+
+// warmup will preload every key exported under the prefix of the DB, when the Backend implements Lister.
+// Keys exported under other prefixes by Options.PrefixFunc are not preloaded.
+func (d *DB[T]) warmup(ctx context.Context) (err error) {
+	lister, ok := d.ib.(Lister)
+	if !ok {
+		return
+	}
+
+	var filenames []string
+	switch filenames, err = lister.List(d.request(ctx, OpList), d.o.Name); err {
+	case nil:
+	case ErrListerNotImplemented:
+		return nil
+	default:
+		return
+	}
+
+	keys := make([]string, 0, len(filenames))
+	for _, filename := range filenames {
+		if key, ok := d.getExportedKey(filename); ok {
+			keys = append(keys, key)
+		}
+	}
+
+	return d.PreloadContext(ctx, keys...)
+}
+
+// getExportedKey will return the key of an exported filename. Filenames which do not
+// belong to the DB or do not match the export naming of their key are skipped.
+func (d *DB[T]) getExportedKey(filename string) (key string, ok bool) {
+	name := strings.TrimSuffix(filename, ".ref")
+	for ext := filepath.Ext(name); ext != ".csv" && ext != ""; ext = filepath.Ext(name) {
+		// Exported compressed or encrypted
+		name = strings.TrimSuffix(name, ext)
+	}
+
+	if !strings.HasPrefix(name, d.o.Name+".") || !strings.HasSuffix(name, ".csv") {
+		return
+	}
+
+	if d.exportName(name) != filename {
+		return
+	}
+
+	return d.getKey(name), true
+}
@@ -0,0 +1,135 @@
+package csvdb
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"testing"
+	"time"
+)
+
+type mockListerBackend struct {
+	mockBackend
+
+	filenames []string
+	err       error
+}
+
+func (m *mockListerBackend) List(ctx context.Context, prefix string) (filenames []string, err error) {
+	return m.filenames, m.err
+}
+
+func TestDB_warmup(t *testing.T) {
+	type testcase struct {
+		name     string
+		lister   bool
+		listErr  error
+		warmup   bool
+		policies []Policy
+
+		wantErr  error
+		wantKeys []string
+	}
+
+	filenames := []string{"foo.a.csv", "foo.b%2F1.csv", "foo.c.csv.gz", "bar.a.csv", "foo.a.csv.exported"}
+	tests := []testcase{
+		{
+			name:     "basic",
+			lister:   true,
+			warmup:   true,
+			wantKeys: []string{"a", "b/1"},
+		},
+		{
+			name:     "compressed",
+			lister:   true,
+			warmup:   true,
+			policies: []Policy{{Prefix: "c", Compress: true}},
+			wantKeys: []string{"a", "b/1", "c"},
+		},
+		{
+			name:   "disabled",
+			lister: true,
+		},
+		{
+			name:   "backend without lister",
+			warmup: true,
+		},
+		{
+			name:    "list error",
+			lister:  true,
+			listErr: errors.New("unavailable"),
+			warmup:  true,
+			wantErr: errors.New("unavailable"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var imports []string
+			base := mockBackend{
+				importFn: func(ctx context.Context, prefix, filename string, w io.Writer) (err error) {
+					imports = append(imports, filename)
+					if filename != "foo.c.csv.gz" {
+						_, err = w.Write([]byte("foo,bar\n"))
+						return
+					}
+
+					gw := gzip.NewWriter(w)
+					if _, err = gw.Write([]byte("foo,bar\n")); err != nil {
+						return
+					}
+
+					return gw.Close()
+				},
+			}
+
+			var b Backend = &base
+			if tt.lister {
+				b = &mockListerBackend{mockBackend: base, filenames: filenames, err: tt.listErr}
+			}
+
+			var opts Options
+			opts.Dir = fmt.Sprintf("test_%d", time.Now().UnixNano())
+			opts.Name = "foo"
+			opts.WarmupOnStart = tt.warmup
+			opts.PreloadWorkers = 1
+			opts.Policies = tt.policies
+			defer os.RemoveAll(opts.Dir)
+
+			d, err := New[testentry](context.Background(), opts, b)
+			if (err == nil) != (tt.wantErr == nil) || (err != nil && err.Error() != tt.wantErr.Error()) {
+				t.Fatalf("New() error = %v, wantErr %v", err, tt.wantErr)
+			} else if err != nil {
+				return
+			}
+			defer d.Close()
+
+			var keys []string
+			d.IterKeys("")(func(key string) bool {
+				keys = append(keys, key)
+				return true
+			})
+
+			sort.Strings(keys)
+			if fmt.Sprint(keys) != fmt.Sprint(tt.wantKeys) {
+				t.Errorf("New() warmed keys = %v, want %v", keys, tt.wantKeys)
+			}
+
+			imports = nil
+			for _, key := range tt.wantKeys {
+				if err = d.Get(&bytes.Buffer{}, key); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			if len(imports) != 0 {
+				t.Errorf("DB.Get() downloaded warmed keys = %v", imports)
+			}
+		})
+	}
+}
@@ -0,0 +1,142 @@
+package csvdb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// writeQueue buffers the entries appended to each key while Options.WriteBehind is set
+type writeQueue[T Entry] struct {
+	mux     sync.Mutex
+	pending map[string][]T
+	closed  bool
+
+	// fmux is held while batches are taken and written, so the batches of a key are
+	// always written in the order they were enqueued
+	fmux sync.Mutex
+}
+
+// Flush will write every entry enqueued by Append while Options.WriteBehind is set,
+// returning once they are on disk. Entries which fail to be written remain enqueued, while
+// those which are rejected, such as by exceeding the quota of their key, are discarded.
+func (d *DB[T]) Flush() (err error) {
+	return d.FlushContext(context.Background())
+}
+
+// FlushContext is the context-aware variant of Flush
+func (d *DB[T]) FlushContext(ctx context.Context) (err error) {
+	d.queue.mux.Lock()
+	keys := make([]string, 0, len(d.queue.pending))
+	for key := range d.queue.pending {
+		keys = append(keys, key)
+	}
+	d.queue.mux.Unlock()
+
+	sort.Strings(keys)
+	var errs []error
+	for _, key := range keys {
+		if err = d.flushKey(ctx, key); err != nil {
+			errs = append(errs, fmt.Errorf("error flushing <%s>: %w", key, err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// enqueue will buffer entries for a key, writing them once the batch size is reached
+func (d *DB[T]) enqueue(ctx context.Context, key string, es []T) (err error) {
+	if err = d.checkWritable(); err != nil {
+		return
+	}
+
+	// Entries are validated as they are enqueued, so an invalid entry is returned to its caller
+	// rather than failing the batch it would be written with
+	extra := d.contextValues(ctx)
+	header := d.header(key, es[0])
+	for i, e := range es {
+		var values []string
+		if values, err = d.row(key, i, e, extra); err != nil {
+			return
+		}
+
+		if err = checkColumnCount(key, i, d.seal(values), header); err != nil {
+			return
+		}
+	}
+
+	d.queue.mux.Lock()
+	if d.queue.closed {
+		d.queue.mux.Unlock()
+		return ErrClosed
+	}
+
+	if d.queue.pending == nil {
+		d.queue.pending = make(map[string][]T)
+	}
+
+	d.queue.pending[key] = append(d.queue.pending[key], es...)
+	full := len(d.queue.pending[key]) >= d.o.WriteBehindBatchSize
+	d.queue.mux.Unlock()
+
+	if !full {
+		return
+	}
+
+	return d.flushKey(ctx, key)
+}
+
+// flushKey will write the entries enqueued for a key
+func (d *DB[T]) flushKey(ctx context.Context, key string) (err error) {
+	d.queue.fmux.Lock()
+	defer d.queue.fmux.Unlock()
+
+	d.queue.mux.Lock()
+	es := d.queue.pending[key]
+	delete(d.queue.pending, key)
+	d.queue.mux.Unlock()
+
+	if len(es) == 0 {
+		return
+	}
+
+	defer func() {
+		if err == nil || rejected(err) {
+			return
+		}
+
+		// Put the batch back ahead of anything enqueued in the meantime
+		d.queue.mux.Lock()
+		d.queue.pending[key] = append(es, d.queue.pending[key]...)
+		d.queue.mux.Unlock()
+	}()
+
+	var unlock func()
+	if unlock, err = d.lockKeys(ctx, key); err != nil {
+		return
+	}
+	defer unlock()
+	return d.append(ctx, key, es)
+}
+
+// rejected will return whether or not an error rejects the entries of a batch, rather than
+// failing to write them, so writing them again would fail the same way
+func rejected(err error) bool {
+	return errors.Is(err, ErrInvalidColumnCount) || errors.Is(err, ErrQuotaExceeded)
+}
+
+// closeQueue will stop accepting entries and flush those which are enqueued
+func (d *DB[T]) closeQueue(ctx context.Context) (err error) {
+	d.queue.mux.Lock()
+	d.queue.closed = true
+	d.queue.mux.Unlock()
+	return d.FlushContext(ctx)
+}
+
+func (d *DB[T]) asyncFlush() {
+	if err := d.Flush(); err != nil {
+		d.o.Logger.Printf("csvdb.DB[%s].asyncFlush(): error flushing: %v\n", d.o.Name, err)
+	}
+}
@@ -0,0 +1,226 @@
+package csvdb
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestDB_WriteBehind(t *testing.T) {
+	type testcase struct {
+		name string
+		// entries is the number of entries appended, one per call
+		entries int
+		// flush is called after the entries are appended
+		flush func(d *DB[testentry]) error
+		want  string
+	}
+
+	tests := []testcase{
+		{
+			name:    "buffered",
+			entries: 2,
+			want:    "",
+		},
+		{
+			name:    "batch size",
+			entries: 3,
+			want:    "foo,bar\n0,0b\n1,1b\n2,2b\n",
+		},
+		{
+			name:    "flush",
+			entries: 2,
+			flush: func(d *DB[testentry]) error {
+				return d.Flush()
+			},
+			want: "foo,bar\n0,0b\n1,1b\n",
+		},
+		{
+			name:    "barrier",
+			entries: 2,
+			flush: func(d *DB[testentry]) error {
+				return d.Barrier("foo", false)
+			},
+			want: "foo,bar\n0,0b\n1,1b\n",
+		},
+		{
+			name:    "close",
+			entries: 2,
+			flush: func(d *DB[testentry]) error {
+				return d.Close()
+			},
+			want: "foo,bar\n0,0b\n1,1b\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := fmt.Sprintf("test_%d", time.Now().UnixNano())
+			defer os.RemoveAll(dir)
+
+			var opts Options
+			opts.Dir = dir
+			opts.Name = "foo"
+			opts.WriteBehind = true
+			opts.WriteBehindBatchSize = 3
+			d, err := makeDB[testentry](opts, &mockBackend{})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			for i := 0; i < tt.entries; i++ {
+				e := testentry{Foo: fmt.Sprint(i), Bar: fmt.Sprintf("%db", i)}
+				if err = d.Append("foo", e); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			if tt.flush != nil {
+				if err = tt.flush(&d); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			_, filename := d.getFilename("foo")
+			got, err := os.ReadFile(filename)
+			if err != nil && !os.IsNotExist(err) {
+				t.Fatal(err)
+			}
+
+			if string(got) != tt.want {
+				t.Errorf("file = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDB_WriteBehind_interval(t *testing.T) {
+	dir := fmt.Sprintf("test_%d", time.Now().UnixNano())
+	defer os.RemoveAll(dir)
+
+	var opts Options
+	opts.Dir = dir
+	opts.Name = "foo"
+	opts.WriteBehind = true
+	opts.WriteBehindInterval = 10 * time.Millisecond
+	d, err := New[testentry](context.Background(), opts, &mockBackend{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer d.Close()
+
+	if err = d.Append("foo", testentry{Foo: "1", Bar: "1b"}); err != nil {
+		t.Fatal(err)
+	}
+
+	want := "foo,bar\n1,1b\n"
+	deadline := time.Now().Add(time.Second)
+	for {
+		w := &bytes.Buffer{}
+		if err = d.Get(w, "foo"); err == nil && w.String() == want {
+			break
+		}
+
+		if time.Now().After(deadline) {
+			t.Fatalf("entries were not flushed, DB.Get() = %q, %v", w.String(), err)
+		}
+
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	if err = d.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if err = d.Append("foo", testentry{Foo: "2", Bar: "2b"}); err != ErrClosed {
+		t.Errorf("DB.Append() error = %v after Close, want %v", err, ErrClosed)
+	}
+}
+
+func TestDB_WriteBehind_rejected(t *testing.T) {
+	type testcase struct {
+		name string
+		// file is written as the file of the key before appending when set
+		file     string
+		policies []Policy
+		entry    raggedentry
+
+		wantAppendErr error
+		wantFlushErr  error
+		want          string
+	}
+
+	tests := []testcase{
+		{
+			name:          "invalid entry",
+			entry:         raggedentry{testentry{Foo: "2"}},
+			wantAppendErr: ErrInvalidColumnCount,
+			want:          "foo,bar\n1,1b\n",
+		},
+		{
+			name:         "existing header",
+			file:         "foo,bar,baz\n0,0b,0c\n",
+			entry:        raggedentry{testentry{Foo: "2", Bar: "2b"}},
+			wantFlushErr: ErrInvalidColumnCount,
+			want:         "foo,bar,baz\n0,0b,0c\n",
+		},
+		{
+			name:         "quota",
+			file:         "foo,bar\n0,0b\n",
+			policies:     []Policy{{Prefix: "a", MaxBytes: 1}},
+			entry:        raggedentry{testentry{Foo: "2", Bar: "2b"}},
+			wantFlushErr: ErrQuotaExceeded,
+			want:         "foo,bar\n0,0b\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var opts Options
+			opts.Dir = fmt.Sprintf("test_%d", time.Now().UnixNano())
+			opts.Name = "foo"
+			opts.WriteBehind = true
+			opts.WriteBehindBatchSize = 10
+			opts.Policies = tt.policies
+			d, err := makeDB[raggedentry](opts, &mockBackend{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer os.RemoveAll(opts.Dir)
+
+			if tt.file != "" {
+				if err = os.WriteFile(d.getPath("foo.a.csv"), []byte(tt.file), 0644); err != nil {
+					t.Fatal(err)
+				}
+			} else if err = d.Append("a", raggedentry{testentry{Foo: "1", Bar: "1b"}}); err != nil {
+				t.Fatal(err)
+			}
+
+			if err = d.Append("a", tt.entry); !errors.Is(err, tt.wantAppendErr) {
+				t.Fatalf("DB.Append() error = %v, wantErr %v", err, tt.wantAppendErr)
+			}
+
+			if err = d.Flush(); !errors.Is(err, tt.wantFlushErr) {
+				t.Fatalf("DB.Flush() error = %v, wantErr %v", err, tt.wantFlushErr)
+			}
+
+			// Rejected entries are discarded rather than requeued, so they do not block the key
+			if err = d.Flush(); err != nil {
+				t.Fatalf("DB.Flush() error = %v after a rejected batch", err)
+			}
+
+			got, err := os.ReadFile(d.getPath("foo.a.csv"))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if string(got) != tt.want {
+				t.Errorf("file = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,12 @@
+module github.com/itsmontoya/csvdb/zstd
+
+go 1.22
+
+require (
+	github.com/itsmontoya/csvdb v0.0.0
+	github.com/klauspost/compress v1.18.0
+)
+
+require golang.org/x/text v0.14.0 // indirect
+
+replace github.com/itsmontoya/csvdb => ../
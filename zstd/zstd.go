@@ -0,0 +1,43 @@
+// Package zstd provides a csvdb.Codec storing and exporting files compressed with Zstandard.
+// It is a module of its own, so csvdb does not depend upon the zstd implementation.
+package zstd
+
+import (
+	"io"
+
+	"github.com/itsmontoya/csvdb"
+	"github.com/klauspost/compress/zstd"
+)
+
+var _ csvdb.Codec = Codec{}
+
+// Codec is a csvdb.Codec compressing files with Zstandard, files are stored and exported
+// as <Name>.<key>.csv.zst
+type Codec struct {
+	// Level is the compression level of written streams, defaults to zstd.SpeedDefault
+	Level zstd.EncoderLevel
+}
+
+// Extension returns ".zst"
+func (Codec) Extension() string { return ".zst" }
+
+// NewWriter will return a zstd.Encoder, each stream is written as a single frame
+func (c Codec) NewWriter(w io.Writer) (io.WriteCloser, error) {
+	level := c.Level
+	if level == 0 {
+		level = zstd.SpeedDefault
+	}
+
+	// Streams are small and short-lived, so they are encoded without background goroutines
+	return zstd.NewWriter(w, zstd.WithEncoderLevel(level), zstd.WithEncoderConcurrency(1))
+}
+
+// NewReader will return a reader of every frame of the provided reader
+func (Codec) NewReader(r io.Reader) (rc io.ReadCloser, err error) {
+	var d *zstd.Decoder
+	if d, err = zstd.NewReader(r, zstd.WithDecoderConcurrency(1)); err != nil {
+		return
+	}
+
+	return d.IOReadCloser(), nil
+}
@@ -0,0 +1,208 @@
+package zstd
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/itsmontoya/csvdb"
+)
+
+type testentry struct {
+	Foo string
+	Bar string
+}
+
+func (t testentry) Keys() []string {
+	return []string{"foo", "bar"}
+}
+
+func (t testentry) Values() []string {
+	return []string{t.Foo, t.Bar}
+}
+
+// memoryBackend holds exported files in memory
+type memoryBackend struct {
+	mux   sync.Mutex
+	files map[string][]byte
+}
+
+func (m *memoryBackend) Import(ctx context.Context, prefix, filename string, w io.Writer) (err error) {
+	m.mux.Lock()
+	bs, ok := m.files[filename]
+	m.mux.Unlock()
+	if !ok {
+		return os.ErrNotExist
+	}
+
+	_, err = w.Write(bs)
+	return
+}
+
+func (m *memoryBackend) Export(ctx context.Context, prefix, filename string, r io.Reader) (newFilename string, err error) {
+	var bs []byte
+	if bs, err = io.ReadAll(r); err != nil {
+		return
+	}
+
+	m.mux.Lock()
+	defer m.mux.Unlock()
+	m.files[filename] = bs
+	return filename, nil
+}
+
+func TestCodec(t *testing.T) {
+	type testcase struct {
+		name string
+		// streams are each written as a separate stream, one after another
+		streams []string
+	}
+
+	tests := []testcase{
+		{name: "single stream", streams: []string{"foo,bar\n1,1b\n"}},
+		{name: "multiple streams", streams: []string{"foo,bar\n", "1,1b\n", "2,2b\n"}},
+		{name: "large stream", streams: []string{strings.Repeat("1,1b\n", 100000)}},
+		{name: "empty", streams: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var (
+				c   Codec
+				buf bytes.Buffer
+			)
+
+			for _, stream := range tt.streams {
+				w, err := c.NewWriter(&buf)
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				if _, err = io.WriteString(w, stream); err != nil {
+					t.Fatal(err)
+				}
+
+				if err = w.Close(); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			r, err := c.NewReader(&buf)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer r.Close()
+
+			got, err := io.ReadAll(r)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if want := strings.Join(tt.streams, ""); string(got) != want {
+				t.Errorf("Codec.NewReader() = %d bytes, want %d bytes", len(got), len(want))
+			}
+		})
+	}
+}
+
+func TestCodec_DB(t *testing.T) {
+	type testcase struct {
+		name string
+		opts func(o *csvdb.Options)
+	}
+
+	tests := []testcase{
+		{name: "basic", opts: func(o *csvdb.Options) {}},
+		// Each append is stored as a frame of its own
+		{name: "sync writes", opts: func(o *csvdb.Options) { o.SyncWrites = true }},
+		{name: "write ahead log", opts: func(o *csvdb.Options) { o.WriteAheadLog = true }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := fmt.Sprintf("test_%d", time.Now().UnixNano())
+			defer os.RemoveAll(dir)
+
+			b := &memoryBackend{files: make(map[string][]byte)}
+			open := func(name string) *csvdb.DB[testentry] {
+				t.Helper()
+				var opts csvdb.Options
+				opts.Dir = filepath.Join(dir, name)
+				opts.Name = "foo"
+				opts.Codec = Codec{}
+				tt.opts(&opts)
+				d, err := csvdb.New[testentry](context.Background(), opts, b)
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				return d
+			}
+
+			d := open("a")
+			for i := 0; i < 3; i++ {
+				if err := d.Append("a", testentry{Foo: fmt.Sprint(i), Bar: fmt.Sprintf("%db", i)}); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			want := "foo,bar\n0,0b\n1,1b\n2,2b\n"
+			w := &bytes.Buffer{}
+			if err := d.Get(w, "a"); err != nil {
+				t.Fatal(err)
+			}
+
+			if w.String() != want {
+				t.Errorf("DB.Get() = %q, want %q", w.String(), want)
+			}
+
+			stored, err := os.ReadFile(filepath.Join(dir, "a", "foo", "foo.a.csv.zst"))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var c Codec
+			r, err := c.NewReader(bytes.NewReader(stored))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := io.ReadAll(r)
+			r.Close()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if string(got) != want {
+				t.Errorf("stored file = %q, want %q", got, want)
+			}
+
+			if err = d.Close(); err != nil {
+				t.Fatal(err)
+			}
+
+			// Exported files are downloaded by another DB and decompressed when read
+			if _, ok := b.files["foo.a.csv.zst"]; !ok {
+				t.Fatalf("exported files = %v, want foo.a.csv.zst", b.files)
+			}
+
+			replica := open("b")
+			defer replica.Close()
+			w.Reset()
+			if err = replica.Get(w, "a"); err != nil {
+				t.Fatal(err)
+			}
+
+			if w.String() != want {
+				t.Errorf("DB.Get() = %q, want %q", w.String(), want)
+			}
+		})
+	}
+}